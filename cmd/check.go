@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// 結果の表示に含めるレスポンスボディの最大長
+const maxCheckBodySnippet = 200
+
+// チェック結果の種類
+const (
+	checkOK    = "OK"
+	checkDrift = "DRIFT"
+	checkError = "ERROR"
+	checkSkip  = "SKIP"
+)
+
+// オペレーションのチェック結果
+type checkResult struct {
+	kind        string
+	method      string
+	path        string
+	operationID string
+	statusCode  int
+	detail      string
+}
+
+func (r checkResult) String() string {
+	s := fmt.Sprintf("%-5s %s %s", r.kind, r.method, r.path)
+	if r.operationID != "" {
+		s += " (" + r.operationID + ")"
+	}
+	if r.statusCode != 0 {
+		s += fmt.Sprintf(" %d", r.statusCode)
+	}
+	if r.detail != "" {
+		s += ": " + r.detail
+	}
+	return s
+}
+
+// "Name: value" 形式のヘッダーを受け取るフラグ（値にカンマを含められるよう分割しない）
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	var headers []string
+	for name, values := range h {
+		for _, value := range values {
+			headers = append(headers, name+": "+value)
+		}
+	}
+	return strings.Join(headers, ", ")
+}
+
+func (h headerFlag) Set(value string) error {
+	name, v, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q: must be Name: value", value)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(v))
+	return nil
+}
+
+// 実際のAPIに安全なGETのオペレーションを送り、レスポンスが仕様書と一致するか確認する
+// 仕様書と異なる（ドリフトした）オペレーションかエラーがあれば1を返す
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	var openapiPath, baseURL string
+	var timeout time.Duration
+	var operations listFlag
+	headers := headerFlag{}
+	specHeaders := headerFlag{}
+	fs.StringVar(&openapiPath, "path", "", "OpenAPI specification file path or http(s) URL")
+	fs.Var(specHeaders, "spec-header", "Header sent to download the spec from a URL, e.g. \"Authorization: Bearer $TOKEN\" (repeatable, environment variables are expanded)")
+	fs.StringVar(&baseURL, "base-url", "", "Base URL of the API (default: API_BASE_URL or the first server of the spec)")
+	fs.Var(headers, "header", "Header sent with every request, e.g. \"Authorization: Bearer xxx\" (repeatable)")
+	fs.Var(&operations, "operations", "Comma separated operationIds to check (default: every GET operation)")
+	fs.DurationVar(&timeout, "timeout", 10*time.Second, "Timeout of each request")
+	_ = fs.Parse(args)
+
+	if openapiPath == "" {
+		log.Print("OpenAPI specification file path is required")
+		return 2
+	}
+	spec, err := readSpec(openapiPath, http.Header(specHeaders), true)
+	if err != nil {
+		log.Printf("Failed to read OpenAPI spec: %v", err)
+		return 2
+	}
+	// kin-openapi は3.1の構文を読めない
+	if spec, err = downconvertOAS31(spec); err != nil {
+		log.Print(err)
+		return 2
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		log.Printf("Failed to parse OpenAPI spec: %v", err)
+		return 2
+	}
+	if baseURL == "" {
+		baseURL = os.Getenv("API_BASE_URL")
+	}
+	if baseURL == "" {
+		baseURL = defaultOpenAPI3ServerURL(doc.Servers)
+	}
+	if baseURL == "" {
+		log.Print("base URL is required: use -base-url or API_BASE_URL")
+		return 2
+	}
+
+	client := &http.Client{Timeout: timeout}
+	counts := map[string]int{}
+	for _, path := range doc.Paths.InMatchingOrder() {
+		pathItem := doc.Paths.Value(path)
+		op := pathItem.Get
+		if op == nil || (len(operations) > 0 && !slices.Contains(operations, op.OperationID)) {
+			continue
+		}
+		route := &routers.Route{Spec: doc, Path: path, PathItem: pathItem, Method: http.MethodGet, Operation: op}
+		result := checkOperation(context.Background(), client, baseURL, http.Header(headers), route)
+		counts[result.kind]++
+		fmt.Println(result)
+	}
+	fmt.Printf("%d ok, %d drift, %d errors, %d skipped\n", counts[checkOK], counts[checkDrift], counts[checkError], counts[checkSkip])
+	if counts[checkDrift] > 0 || counts[checkError] > 0 {
+		return 1
+	}
+	return 0
+}
+
+// オペレーションを呼び出してレスポンスを仕様書で検証する
+func checkOperation(ctx context.Context, client *http.Client, baseURL string, headers http.Header, route *routers.Route) checkResult {
+	result := checkResult{method: route.Method, path: route.Path, operationID: route.Operation.OperationID}
+	if route.Operation.RequestBody != nil && route.Operation.RequestBody.Value != nil && route.Operation.RequestBody.Value.Required {
+		result.kind, result.detail = checkSkip, "requires a request body"
+		return result
+	}
+	req, pathParams, err := newCheckRequest(ctx, baseURL, route)
+	if err != nil {
+		result.kind, result.detail = checkSkip, err.Error()
+		return result
+	}
+	for name, values := range headers {
+		req.Header[name] = values
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		result.kind, result.detail = checkError, err.Error()
+		return result
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		result.kind, result.detail = checkError, err.Error()
+		return result
+	}
+	result.statusCode = res.StatusCode
+	if res.StatusCode >= 400 {
+		result.kind, result.detail = checkError, bodySnippet(body)
+		return result
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status:  res.StatusCode,
+		Header:  res.Header,
+		Options: &openapi3filter.Options{IncludeResponseStatus: true, MultiError: true},
+	}
+	input.SetBodyBytes(body)
+	if err := openapi3filter.ValidateResponse(ctx, input); err != nil {
+		result.kind, result.detail = checkDrift, driftDetail(err)
+		return result
+	}
+	result.kind = checkOK
+	return result
+}
+
+// 仕様書の例（無ければ既定値か列挙値）からリクエストを組み立てる
+// 必須のパラメータの値が仕様書から得られない場合はエラー
+func newCheckRequest(ctx context.Context, baseURL string, route *routers.Route) (*http.Request, map[string]string, error) {
+	path := route.Path
+	pathParams := map[string]string{}
+	query := url.Values{}
+	header := http.Header{}
+	for _, param := range checkParameters(route) {
+		value, ok := checkParameterValue(param)
+		if !ok {
+			if param.Required {
+				return nil, nil, fmt.Errorf("no example for the required %s parameter %s", param.In, param.Name)
+			}
+			continue
+		}
+		values := parameterStrings(value)
+		switch param.In {
+		case openapi3.ParameterInPath:
+			pathParams[param.Name] = strings.Join(values, ",")
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(pathParams[param.Name]))
+		case openapi3.ParameterInQuery:
+			query[param.Name] = values
+		case openapi3.ParameterInHeader:
+			header.Set(param.Name, strings.Join(values, ","))
+		}
+	}
+	u := strings.TrimSuffix(baseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, route.Method, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header = header
+	return req, pathParams, nil
+}
+
+// パスとオペレーションのパラメータ（オペレーションのものを優先）
+func checkParameters(route *routers.Route) []*openapi3.Parameter {
+	var params []*openapi3.Parameter
+	for _, ref := range route.Operation.Parameters {
+		if ref != nil && ref.Value != nil {
+			params = append(params, ref.Value)
+		}
+	}
+	for _, ref := range route.PathItem.Parameters {
+		if ref != nil && ref.Value != nil && route.Operation.Parameters.GetByInAndName(ref.Value.In, ref.Value.Name) == nil {
+			params = append(params, ref.Value)
+		}
+	}
+	return params
+}
+
+func checkParameterValue(param *openapi3.Parameter) (any, bool) {
+	if v, ok := openAPI3ParameterExample(param); ok {
+		return v, true
+	}
+	if param.Schema == nil || param.Schema.Value == nil {
+		return nil, false
+	}
+	if schema := param.Schema.Value; schema.Default != nil {
+		return schema.Default, true
+	} else if len(schema.Enum) > 0 {
+		return schema.Enum[0], true
+	}
+	return nil, false
+}
+
+// パラメータの値を文字列にする（配列は要素ごと）
+func parameterStrings(value any) []string {
+	if items, ok := value.([]any); ok {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	}
+	return []string{fmt.Sprint(value)}
+}
+
+// 検証エラーのうち仕様書との差分を表す部分
+func driftDetail(err error) string {
+	var responseErr *openapi3filter.ResponseError
+	if !errors.As(err, &responseErr) || responseErr.Err == nil {
+		return err.Error()
+	}
+	if reasons := schemaErrorReasons(responseErr.Err); len(reasons) > 0 {
+		return responseErr.Reason + ": " + strings.Join(reasons, "; ")
+	}
+	return responseErr.Reason + ": " + responseErr.Err.Error()
+}
+
+// スキーマのエラーを「JSONポインタ: 理由」の形式で列挙する（スキーマ自体は含めない）
+func schemaErrorReasons(err error) []string {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		var reasons []string
+		for _, err := range multi {
+			reasons = append(reasons, schemaErrorReasons(err)...)
+		}
+		return reasons
+	}
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		pointer := "/" + strings.Join(schemaErr.JSONPointer(), "/")
+		return []string{pointer + ": " + schemaErr.Reason}
+	}
+	return nil
+}
+
+func bodySnippet(body []byte) string {
+	s := string(bytes.TrimSpace(body))
+	if len(s) > maxCheckBodySnippet {
+		s = strings.ToValidUTF8(s[:maxCheckBodySnippet], "") + "..."
+	}
+	return s
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/yaml"
+)
+
+// 既定の設定ファイル（-config を指定しない場合にカレントディレクトリにあれば読み込む）
+const defaultConfigFile = "oas-mcp.yaml"
+
+// 既定の上書きファイル（カレントディレクトリにあれば使う）
+const defaultOverridesFile = "overrides.yaml"
+
+// WebSocketのトランスポート（SSEとStreamable HTTPはMCPクライアントと同じ名前）
+const transportWebSocket = "websocket"
+
+// 生成したサーバーが公開できるトランスポート
+var serverTransports = []string{transportSSE, transportHTTP, transportWebSocket}
+
+// ジェネレーターの設定ファイル
+// フラグと同じ設定を指定でき、コマンドラインで指定したフラグが優先される
+type generatorConfig struct {
+	// OpenAPIの仕様書のパスまたはURL（-path、複数の場合は name=path の配列）
+	Spec stringList `yaml:"spec"`
+	// URLの仕様書を取得するリクエストのヘッダー（-spec-header、値の環境変数は展開する）
+	SpecHeaders map[string]string `yaml:"specHeaders"`
+	// 出力先のディレクトリ（-output）
+	Output string `yaml:"output"`
+	// 生成するクライアントのパッケージ名（-package）
+	Package string `yaml:"package"`
+	// クライアントのバックエンド（-client-backend）
+	Backend string `yaml:"backend"`
+	// x-descriptions から使う言語（-lang）
+	Lang string `yaml:"lang"`
+	// 生成したサーバーが公開するトランスポート（-transport、文字列または配列）
+	Transport stringList `yaml:"transport"`
+	// 生成するパスと除外するパスのglob（-include-paths、-exclude-paths）
+	IncludePaths stringList `yaml:"includePaths"`
+	ExcludePaths stringList `yaml:"excludePaths"`
+	// 生成するオペレーションと除外するオペレーションのoperationIdの正規表現（-include-operations、-exclude-operations）
+	IncludeOperations stringList `yaml:"includeOperations"`
+	ExcludeOperations stringList `yaml:"excludeOperations"`
+	MCPTest           *bool      `yaml:"mcptest"`
+	Prompts           *bool      `yaml:"prompts"`
+	FlatInput         *bool      `yaml:"flatInput"`
+	StripEmpty        *bool      `yaml:"stripEmpty"`
+	// 出力ディレクトリを独立したモジュールにするか、そのモジュールパス（-standalone、-module）
+	Standalone *bool  `yaml:"standalone"`
+	Module     string `yaml:"module"`
+	// ツール名の付け方（-naming）
+	Naming string `yaml:"naming"`
+	// cmd/server/main.go を生成するか（-with-main）
+	WithMain *bool `yaml:"withMain"`
+	// Dockerfile と compose.yaml を生成するか（-with-docker、-with-compose）
+	WithDocker  *bool `yaml:"withDocker"`
+	WithCompose *bool `yaml:"withCompose"`
+	// OpenAPI 3.1の仕様書を3.0に変換するか（-oas31-compat）
+	OAS31Compat *bool `yaml:"oas31Compat"`
+	// 上書きファイル（-overrides）
+	Overrides string `yaml:"overrides"`
+	// オペレーションごとの上書き（キーはoperationIdまたはクライアントのメソッド名）
+	Operations map[string]operationOverride `yaml:"operations"`
+}
+
+// オペレーションごとの上書き
+type operationOverride struct {
+	// ツール名
+	Name string `yaml:"name"`
+	// ツールの説明
+	Description string `yaml:"description"`
+	// false の場合はツールを生成しない（未指定は有効）
+	Enabled *bool `yaml:"enabled"`
+}
+
+// 文字列または文字列の配列で指定できる値
+type stringList []string
+
+func (l *stringList) UnmarshalYAML(unmarshal func(any) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*l = stringList{s}
+		return nil
+	}
+	var list []string
+	if err := unmarshal(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// 設定ファイルを読み込む
+// path が空の場合は既定の設定ファイルを探し、無ければ nil を返す
+func loadConfig(path string) (*generatorConfig, string, error) {
+	if path == "" {
+		if _, err := os.Stat(defaultConfigFile); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, "", nil
+			}
+			return nil, "", err
+		}
+		path = defaultConfigFile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config: %w", err)
+	}
+	var config generatorConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, "", fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+	return &config, path, nil
+}
+
+// 上書きファイルを読み込む（キーはoperationIdまたはクライアントのメソッド名）
+// path が空の場合は既定の上書きファイルを探し、無ければ nil を返す
+func loadOverrides(path string) (map[string]operationOverride, string, error) {
+	if path == "" {
+		if _, err := os.Stat(defaultOverridesFile); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, "", nil
+			}
+			return nil, "", err
+		}
+		path = defaultOverridesFile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read overrides: %w", err)
+	}
+	var overrides map[string]operationOverride
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, "", fmt.Errorf("failed to parse overrides %s: %w", path, err)
+	}
+	return overrides, path, nil
+}
+
+// 設定ファイルの値をコマンドラインで指定されなかったフラグに設定する
+// 相対パスは設定ファイルのディレクトリを基準にする
+func (c *generatorConfig) applyFlags(fs *flag.FlagSet, path string) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	dir := filepath.Dir(path)
+	relative := func(p string) string {
+		if p == "" || filepath.IsAbs(p) || isRemoteSpec(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	// 配列の値はフラグを繰り返し指定した場合と同じく1つずつ設定する
+	values := map[string][]string{
+		"output":             {relative(c.Output)},
+		"package":            {c.Package},
+		"client-backend":     {c.Backend},
+		"lang":               {c.Lang},
+		"transport":          c.Transport,
+		"include-paths":      c.IncludePaths,
+		"exclude-paths":      c.ExcludePaths,
+		"include-operations": c.IncludeOperations,
+		"exclude-operations": c.ExcludeOperations,
+		"mcptest":            {formatBool(c.MCPTest)},
+		"prompts":            {formatBool(c.Prompts)},
+		"flat-input":         {formatBool(c.FlatInput)},
+		"strip-empty":        {formatBool(c.StripEmpty)},
+		"oas31-compat":       {formatBool(c.OAS31Compat)},
+		"standalone":         {formatBool(c.Standalone)},
+		"module":             {c.Module},
+		"naming":             {c.Naming},
+		"overrides":          {relative(c.Overrides)},
+		"with-main":          {formatBool(c.WithMain)},
+		"with-docker":        {formatBool(c.WithDocker)},
+		"with-compose":       {formatBool(c.WithCompose)},
+	}
+	for _, spec := range c.Spec {
+		name, location := splitSpecSource(spec)
+		if name != "" {
+			name += "="
+		}
+		values["path"] = append(values["path"], name+relative(location))
+	}
+	for name, value := range c.SpecHeaders {
+		values["spec-header"] = append(values["spec-header"], name+": "+value)
+	}
+	for name, list := range values {
+		if set[name] {
+			continue
+		}
+		for _, value := range list {
+			if value == "" {
+				continue
+			}
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("config %s: %s: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func formatBool(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+// 公開するトランスポートを検証する（空の場合はすべて）
+func parseTransports(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return serverTransports, nil
+	}
+	var result []string
+	for _, name := range names {
+		name = strings.ToLower(name)
+		if !slices.Contains(serverTransports, name) {
+			return nil, fmt.Errorf("unknown transport %q: use %s", name, strings.Join(serverTransports, ", "))
+		}
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+// オペレーションごとの上書きを適用し、無効にしたオペレーションを除いたオペレーションを返す
+// 適用したキーは used に記録する（複数の仕様書で共有し、最後に warnUnusedOverrides で確認する）
+func applyOverrides(operations []*operation, overrides map[string]operationOverride, used map[string]bool) ([]*operation, error) {
+	names := map[string]string{}
+	result := make([]*operation, 0, len(operations))
+	for _, operation := range operations {
+		enabled := true
+		for _, key := range []string{operation.OperationID, operation.Name} {
+			override, ok := overrides[key]
+			if !ok {
+				continue
+			}
+			used[key] = true
+			if override.Name != "" {
+				operation.ToolName = override.Name
+			}
+			if override.Description != "" {
+				operation.Description = override.Description
+			}
+			if override.Enabled != nil {
+				enabled = *override.Enabled
+			}
+			break
+		}
+		if !enabled {
+			continue
+		}
+		name := operation.toolName()
+		if other, ok := names[name]; ok {
+			return nil, fmt.Errorf("operations %s and %s have the same tool name %s", other, operation.OperationID, name)
+		}
+		names[name] = operation.OperationID
+		result = append(result, operation)
+	}
+	return result, nil
+}
+
+// どのオペレーションにも適用されなかった上書きを警告する
+func warnUnusedOverrides(overrides map[string]operationOverride, used map[string]bool) {
+	for key := range overrides {
+		if !used[key] {
+			log.Printf("Override for unknown operation %s is ignored", key)
+		}
+	}
+}
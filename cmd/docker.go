@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// コンテナで待ち受けるポート
+const dockerPort = "8080"
+
+// 生成したサーバーをビルドするマルチステージの Dockerfile を出力ディレクトリに生成する
+// ビルドコンテキストは go.mod のあるディレクトリ（-standalone の場合は出力ディレクトリ、それ以外は呼び出し元のモジュール）
+// envs は API のベースURLを指定する環境変数（まとめたサーバーはサービスごと）で、ビルド引数と実行時の環境変数のどちらでも指定できる
+// withCompose の場合は docker compose の設定も生成する
+func writeDocker(outputPath, root string, envs []string, withCompose bool) error {
+	absOutput, err := filepath.Abs(outputPath)
+	if err != nil {
+		return err
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	// ビルドコンテキストから見た出力ディレクトリと、出力ディレクトリから見たビルドコンテキスト
+	output, err := filepath.Rel(absRoot, absOutput)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the build context: %w", err)
+	}
+	buildContext, err := filepath.Rel(absOutput, absRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve the build context: %w", err)
+	}
+	output, buildContext = filepath.ToSlash(output), filepath.ToSlash(buildContext)
+
+	// イメージのタグはパッチバージョンを除いたもの（例: 1.24）
+	goVersion := strings.Join(strings.SplitN(standaloneGoVersion, ".", 3)[:2], ".")
+
+	var b strings.Builder
+	b.WriteString("# Code generated by OpenAPI MCP generator.\n")
+	if buildContext != "." {
+		fmt.Fprintf(&b, "# Build from the module root: docker build -f %s .\n", pathJoin(output, "Dockerfile"))
+	}
+	fmt.Fprintf(&b, "\nFROM golang:%s AS build\n", goVersion)
+	b.WriteString("WORKDIR /src\n")
+	b.WriteString("COPY go.mod go.sum ./\n")
+	b.WriteString("RUN go mod download\n")
+	b.WriteString("COPY . .\n")
+	fmt.Fprintf(&b, "RUN CGO_ENABLED=0 go build -trimpath -ldflags=\"-s -w\" -o /out/server ./%s\n", pathJoin(output, "cmd/server"))
+	b.WriteString("\nFROM gcr.io/distroless/static-debian12:nonroot\n")
+	for _, env := range envs {
+		fmt.Fprintf(&b, "ARG %s=\"\"\n", env)
+	}
+	for _, env := range envs {
+		fmt.Fprintf(&b, "ENV %s=${%s}\n", env, env)
+	}
+	b.WriteString("COPY --from=build /out/server /server\n")
+	fmt.Fprintf(&b, "EXPOSE %s\n", dockerPort)
+	b.WriteString("USER nonroot:nonroot\n")
+	fmt.Fprintf(&b, "ENTRYPOINT [\"/server\", \"-addr=:%s\"]\n", dockerPort)
+	if err := os.WriteFile(filepath.Join(outputPath, "Dockerfile"), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write Dockerfile: %w", err)
+	}
+	if !withCompose {
+		return nil
+	}
+
+	b.Reset()
+	b.WriteString("# Code generated by OpenAPI MCP generator.\n\n")
+	b.WriteString("services:\n")
+	b.WriteString("  mcp:\n")
+	b.WriteString("    build:\n")
+	fmt.Fprintf(&b, "      context: %s\n", buildContext)
+	fmt.Fprintf(&b, "      dockerfile: %s\n", pathJoin(output, "Dockerfile"))
+	b.WriteString("    ports:\n")
+	fmt.Fprintf(&b, "      - \"%s:%s\"\n", dockerPort, dockerPort)
+	b.WriteString("    environment:\n")
+	for _, env := range envs {
+		fmt.Fprintf(&b, "      %s: ${%s:-}\n", env, env)
+	}
+	b.WriteString("      MOCK_UPSTREAM: ${MOCK_UPSTREAM:-false}\n")
+	if err := os.WriteFile(filepath.Join(outputPath, "compose.yaml"), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write compose.yaml: %w", err)
+	}
+	return nil
+}
+
+// ビルドコンテキストからの相対パスをつなげる（出力ディレクトリがビルドコンテキストの場合は "." になる）
+func pathJoin(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return dir + "/" + name
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// 出力ディレクトリに書き込まずに、生成するツールの名前、説明、入力のフィールドを出力する
+// クライアントは一時ディレクトリに生成してオペレーションを求め、入力のフィールドは生成したコードをビルドせずに仕様書から求める
+// prefix は複数の仕様書をまとめる場合のツール名の接頭辞
+func (g *generator) dryRun(w io.Writer, source *specSource, prefix string, used map[string]bool) error {
+	spec, _, err := g.readSpec(source)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp("", "oas-mcp-dry-run")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+	info, err := g.backend.generate(spec, tmp, g.packageName, g.opts)
+	if err != nil {
+		return fmt.Errorf("failed to generate client: %w", err)
+	}
+	applyNaming(info.operations, g.opts.naming)
+	if info.operations, err = applyOverrides(info.operations, g.opts.overrides, used); err != nil {
+		return err
+	}
+	snapshot, err := buildSpecSnapshot(spec, info.operations)
+	if err != nil {
+		return fmt.Errorf("failed to build schema snapshot: %w", err)
+	}
+
+	for _, operation := range info.operations {
+		name := operation.toolName()
+		fmt.Fprintf(w, "%s%s (%s %s)\n", prefix, name, strings.ToUpper(operation.HTTPMethod), operation.Path)
+		if description := strings.TrimSpace(operation.toolDescription()); description != "" {
+			for _, line := range strings.Split(description, "\n") {
+				fmt.Fprintf(w, "    %s\n", line)
+			}
+		}
+		tool := snapshot.Tools[name]
+		if tool == nil {
+			continue
+		}
+		for _, path := range sortedKeys(tool.Fields) {
+			field := tool.Fields[path]
+			typ := field.Type
+			if typ == "" {
+				typ = "any"
+			}
+			if field.Required {
+				typ += ", required"
+			}
+			fmt.Fprintf(w, "    - %s: %s\n", path, typ)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/go-faster/yaml"
+)
+
+// サーバー名に使えない文字
+var serverNameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// 仕様書の info からサーバーの既定の名前とバージョンを作る（例: Pet Store API は pet-store-api）
+func specNameVersion(spec []byte) (string, string) {
+	var doc struct {
+		Info struct {
+			Title   string `yaml:"title"`
+			Version string `yaml:"version"`
+		} `yaml:"info"`
+	}
+	_ = yaml.Unmarshal(spec, &doc)
+	return strings.Trim(serverNameInvalid.ReplaceAllString(strings.ToLower(doc.Info.Title), "-"), "-"), strings.TrimSpace(doc.Info.Version)
+}
+
+// サーバーを起動する main パッケージを出力ディレクトリの cmd/server に生成する
+// serverPath は StartServer を持つサーバーパッケージのインポートパス
+// withBaseURL の場合は WithBaseURL を指定するフラグも作る（まとめたサーバーはサービスごとの環境変数で指定する）
+func generateMain(outputPath, serverPath, name, version string, withBaseURL bool) error {
+	mainDir := filepath.Join(outputPath, "cmd", "server")
+	if err := os.MkdirAll(mainDir, 0755); err != nil {
+		return fmt.Errorf("failed to create main directory: %w", err)
+	}
+	if name == "" {
+		name = "mcp-server"
+	}
+	if version == "" {
+		version = "1.0.0"
+	}
+
+	f := jen.NewFile("main")
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+	f.ImportName(serverPath, "server")
+
+	body := []jen.Code{
+		jen.Id("name").Op(":=").Qual("flag", "String").Call(jen.Lit("name"), jen.Lit(name), jen.Lit("Name of the MCP server")),
+		jen.Id("version").Op(":=").Qual("flag", "String").Call(jen.Lit("version"), jen.Lit(version), jen.Lit("Version of the MCP server")),
+		jen.Id("addr").Op(":=").Qual("flag", "String").Call(jen.Lit("addr"), jen.Lit(":8080"), jen.Lit("Address to listen on")),
+	}
+	if withBaseURL {
+		body = append(body,
+			jen.Id("baseURL").Op(":=").Qual("flag", "String").Call(jen.Lit("base-url"), jen.Lit(""), jen.Lit("Base URL of the API (default: API_BASE_URL or the servers of the OpenAPI spec)")),
+		)
+	}
+	body = append(body, jen.Qual("flag", "Parse").Call(), jen.Line())
+	args := []jen.Code{
+		jen.Qual("context", "Background").Call(),
+		jen.Op("*").Id("name"),
+		jen.Op("*").Id("version"),
+		jen.Op("*").Id("addr"),
+	}
+	if withBaseURL {
+		body = append(body,
+			jen.Var().Id("opts").Index().Qual(serverPath, "Option"),
+			jen.If(jen.Op("*").Id("baseURL").Op("!=").Lit("")).Block(
+				jen.Id("opts").Op("=").Append(jen.Id("opts"), jen.Qual(serverPath, "WithBaseURL").Call(jen.Op("*").Id("baseURL"))),
+			),
+		)
+		args = append(args, jen.Id("opts").Op("..."))
+	}
+	body = append(body,
+		jen.If(
+			jen.Id("err").Op(":=").Qual(serverPath, "StartServer").Call(args...),
+			jen.Id("err").Op("!=").Nil(),
+		).Block(
+			jen.Qual("log", "Fatal").Call(jen.Id("err")),
+		),
+	)
+
+	f.Comment("The MCP server serves the generated tools until it is interrupted.")
+	f.Comment("Set MOCK_UPSTREAM=true to call the generated mock instead of the API.")
+	f.Func().Id("main").Params().Block(body...)
+	return f.Save(filepath.Join(mainDir, "main.go"))
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/ogen-go/ogen/gen/ir"
+	"github.com/ogen-go/ogen/jsonschema"
+	"github.com/ogen-go/ogen/openapi"
+)
+
+// オペレーションの例からツールの引数の例を作成
+func operationExample(operation *operation, flatInput bool) map[string]any {
+	example := map[string]any{}
+
+	if len(operation.ParamsExample) > 0 {
+		if flatInput {
+			for k, v := range operation.ParamsExample {
+				example[k] = v
+			}
+		} else {
+			example["requestParameter"] = operation.ParamsExample
+		}
+	}
+
+	if body := operation.BodyExample; body != nil {
+		obj, isObject := body.(map[string]any)
+		if flatInput && isObject && operation.BodyIsStruct {
+			for k, v := range obj {
+				example[k] = v
+			}
+		} else {
+			example["requestBody"] = body
+		}
+	}
+	return example
+}
+
+// オペレーションの既定値からツールの引数の既定値を作成（引数の例と同じ形）
+// 任意のリクエストボディは省略時に送らないよう、入れ子の入力では必須の場合のみ既定値を設定する
+func operationDefaults(operation *operation, flatInput bool) map[string]any {
+	defaults := map[string]any{}
+
+	if len(operation.ParamsDefaults) > 0 {
+		if flatInput {
+			maps.Copy(defaults, operation.ParamsDefaults)
+		} else {
+			defaults["requestParameter"] = operation.ParamsDefaults
+		}
+	}
+
+	if len(operation.BodyDefaults) > 0 {
+		if flatInput && operation.BodyIsStruct {
+			maps.Copy(defaults, operation.BodyDefaults)
+		} else if operation.BodyRequired {
+			defaults["requestBody"] = operation.BodyDefaults
+		}
+	}
+	return defaults
+}
+
+// パラメータの既定値を取得（キーはパラメータの型のフィールド名、x-mcp-name の場合は json タグ）
+func parameterDefaults(operation *ir.Operation) map[string]any {
+	defaults := map[string]any{}
+	for _, param := range operation.Params {
+		if def := param.Default(); def.Set && !def.IsNil() {
+			name := param.Name
+			if tag := param.Tag.ExtraTags["json"]; tag != "" {
+				name = tag
+			}
+			defaults[name] = def.Value
+		}
+	}
+	return defaults
+}
+
+// 構造体のリクエストボディのフィールドの既定値を取得
+func requestBodyDefaults(operation *ir.Operation) map[string]any {
+	defaults := map[string]any{}
+	if operation.Request == nil || operation.Request.Type == nil || !operation.Request.Type.IsStruct() {
+		return defaults
+	}
+	for _, field := range operation.Request.Type.Fields {
+		if def := field.Default(); def.Set && !def.IsNil() && field.Tag.JSON != "" {
+			defaults[field.Tag.JSON] = def.Value
+		}
+	}
+	return defaults
+}
+
+// パラメータの例を取得（キーはパラメータの型のフィールド名、x-mcp-name の場合は json タグ）
+func parameterExamples(operation *ir.Operation) map[string]any {
+	params := map[string]any{}
+	for _, param := range operation.Params {
+		if param.Spec == nil || param.Spec.Schema == nil {
+			continue
+		}
+		if v, ok := decodeExample(param.Spec.Schema.Examples); ok {
+			name := param.Name
+			if tag := param.Tag.ExtraTags["json"]; tag != "" {
+				name = tag
+			}
+			params[name] = v
+		}
+	}
+	return params
+}
+
+// リクエストボディの例を取得
+func requestBodyExample(operation *ir.Operation) (any, bool) {
+	if operation.Request == nil || operation.Request.Spec == nil {
+		return nil, false
+	}
+	contentTypes := make([]string, 0, len(operation.Request.Spec.Content))
+	for contentType := range operation.Request.Spec.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	for _, contentType := range contentTypes {
+		if v, ok := mediaExample(operation.Request.Spec.Content[contentType]); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// メディアタイプの例を取得
+func mediaExample(media *openapi.MediaType) (any, bool) {
+	if media == nil {
+		return nil, false
+	}
+	if v, ok := decodeExample([]jsonschema.Example{jsonschema.Example(media.Example)}); ok {
+		return v, true
+	}
+	names := make([]string, 0, len(media.Examples))
+	for name := range media.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ex := media.Examples[name]; ex != nil {
+			if v, ok := decodeExample([]jsonschema.Example{ex.Value}); ok {
+				return v, true
+			}
+		}
+	}
+	if media.Schema != nil {
+		if v, ok := decodeExample(media.Schema.Examples); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// 最初にデコードできた例を返す
+func decodeExample(examples []jsonschema.Example) (any, bool) {
+	for _, raw := range examples {
+		if len(raw) == 0 {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(raw, &v); err == nil && v != nil {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// JSONの値をGoのリテラルに変換
+func jsonLiteral(v any) jen.Code {
+	switch v := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		return jen.Map(jen.String()).Any().ValuesFunc(func(g *jen.Group) {
+			for _, k := range keys {
+				g.Line().Lit(k).Op(":").Add(jsonLiteral(v[k]))
+			}
+			g.Line()
+		})
+	case []any:
+		return jen.Index().Any().ValuesFunc(func(g *jen.Group) {
+			for _, item := range v {
+				g.Add(jsonLiteral(item))
+			}
+		})
+	case nil:
+		return jen.Nil()
+	default:
+		return jen.Lit(v)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"github.com/ogen-go/ogen"
+)
+
+// レスポンスを平坦化するパスを指定する拡張
+const extensionFlatten = "x-mcp-flatten"
+
+// JSON:API の data.attributes と included.attributes を持ち上げるパス
+var jsonAPIFlattenPaths = []string{"data.attributes", "included.attributes"}
+
+// x-mcp-flatten の値から平坦化するパスを取得
+// true の場合は JSON:API のパス、文字列または文字列の配列の場合はそのパスを使用する
+func flattenPaths(value any) []string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return jsonAPIFlattenPaths
+		}
+	case string:
+		if v = strings.TrimSpace(v); v != "" {
+			return []string{v}
+		}
+	case []any:
+		var paths []string
+		for _, item := range v {
+			if path, ok := item.(string); ok && strings.TrimSpace(path) != "" {
+				paths = append(paths, strings.TrimSpace(path))
+			}
+		}
+		return paths
+	case nil:
+	default:
+		log.Printf("%s: unsupported value %v", extensionFlatten, value)
+	}
+	return nil
+}
+
+// 大きなレスポンスをクライアントのLLMで要約する拡張
+const extensionSummarize = "x-mcp-summarize"
+
+// x-mcp-summarize の設定（ゼロ値は functions の既定値）
+type summarizeConfig struct {
+	MinBytes      int
+	MaxInputBytes int
+	MaxTokens     int
+	Instructions  string
+}
+
+// x-mcp-summarize の値から要約の設定を取得
+// true の場合は既定値、数値の場合は要約するサイズ、文字列の場合は要約の指示、
+// オブジェクトの場合は minBytes、maxInputBytes、maxTokens、instructions を使用する
+func summarizeOptions(value any) *summarizeConfig {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return &summarizeConfig{}
+		}
+	case int, int64, uint64, float64:
+		return &summarizeConfig{MinBytes: extensionInt(v)}
+	case string:
+		return &summarizeConfig{Instructions: strings.TrimSpace(v)}
+	case map[string]any:
+		config := &summarizeConfig{
+			MinBytes:      extensionInt(v["minBytes"]),
+			MaxInputBytes: extensionInt(v["maxInputBytes"]),
+			MaxTokens:     extensionInt(v["maxTokens"]),
+		}
+		if instructions, ok := v["instructions"].(string); ok {
+			config.Instructions = strings.TrimSpace(instructions)
+		}
+		return config
+	case nil:
+	default:
+		log.Printf("%s: unsupported value %v", extensionSummarize, value)
+	}
+	return nil
+}
+
+// 拡張の数値（YAMLは整数、JSONは浮動小数点数にデコードされる）
+func extensionInt(value any) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case uint64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// ogen の仕様書からオペレーションの拡張の値を取得
+func ogenOperationExtension(spec *ogen.Spec, path, method, name string) any {
+	pathItem := spec.Paths[path]
+	if pathItem == nil {
+		return nil
+	}
+	op := getOperations(pathItem)[strings.ToLower(method)]
+	if op == nil {
+		return nil
+	}
+	node, ok := op.Common.Extensions[name]
+	if !ok {
+		return nil
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		log.Printf("%s: %v", name, err)
+		return nil
+	}
+	return value
+}
+
+// 言語ごとの説明を指定する拡張（例: x-descriptions: {ja: ..., en: ...}）
+const extensionDescriptions = "x-descriptions"
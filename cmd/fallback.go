@@ -0,0 +1,473 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/ogen-go/ogen"
+	"github.com/ogen-go/ogen/gen"
+	"github.com/ogen-go/ogen/gen/ir"
+	"github.com/ogen-go/ogen/jsonschema"
+)
+
+// クライアントを生成できなかったオペレーションをnet/httpで呼び出すための情報
+type fallbackOperation struct {
+	Parameters []fallbackParameter
+	// リクエストボディのContent-Type
+	ContentType string
+	// ツールの入力スキーマ（JSON）
+	InputSchema string
+}
+
+type fallbackParameter struct {
+	Name string
+	In   string
+	// ツールの引数名
+	Argument string
+}
+
+// ogen がIRを構築できずにスキップしたオペレーションを取得
+func fallbackOperations(spec *ogen.Spec, generated []*ir.Operation, filters gen.Filters) []*operation {
+	done := map[string]bool{}
+	for _, op := range generated {
+		done[strings.ToLower(op.Spec.HTTPMethod)+" "+op.Spec.Path.String()] = true
+	}
+
+	paths := make([]string, 0, len(spec.Paths))
+	for p := range spec.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var operations []*operation
+	for _, p := range paths {
+		pathItem := spec.Paths[p]
+		if pathItem == nil {
+			continue
+		}
+		ops := getOperations(pathItem)
+		methods := make([]string, 0, len(ops))
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			if done[method+" "+p] || !acceptFallback(filters, method, p) {
+				continue
+			}
+			operation, err := newFallbackOperation(spec, pathItem, method, p, ops[method])
+			if err != nil {
+				log.Printf("Skipping %s %s: %v", strings.ToUpper(method), p, err)
+				continue
+			}
+			log.Printf("Generating net/http fallback tool %s for %s %s", operation.Name, strings.ToUpper(method), p)
+			operations = append(operations, operation)
+		}
+	}
+	return operations
+}
+
+// ogen のフィルタと同じ条件で対象のオペレーションか判定
+func acceptFallback(filters gen.Filters, method, path string) bool {
+	if filters.PathRegex != nil && !filters.PathRegex.MatchString(path) {
+		return false
+	}
+	if len(filters.Methods) > 0 {
+		return slices.ContainsFunc(filters.Methods, func(m string) bool { return strings.EqualFold(m, method) })
+	}
+	return true
+}
+
+// 仕様書のオペレーションからフォールバックのオペレーションを作成
+func newFallbackOperation(spec *ogen.Spec, pathItem *ogen.PathItem, method, path string, op *ogen.Operation) (*operation, error) {
+	result := &operation{
+		Name:        fallbackName(op.OperationID, method, path),
+		OperationID: op.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		HTTPMethod:  method,
+		Path:        path,
+		Tags:        op.Tags,
+		ServerURL:   operationServerURL(spec, path, method),
+		Response:    fallbackMockResponse(spec, op),
+		Fallback:    &fallbackOperation{},
+	}
+	if result.OperationID == "" {
+		result.OperationID = result.Name
+	}
+	if result.Summary == "" {
+		result.Summary = pathItem.Summary
+	}
+
+	defs := &schemaDefs{spec: spec, defs: map[string]any{}}
+	properties := map[string]any{}
+	var required []string
+
+	// パスの共通パラメータはオペレーションのパラメータで上書きされる
+	params := map[string]*ogen.Parameter{}
+	var keys []string
+	for _, param := range slices.Concat(pathItem.Parameters, op.Parameters) {
+		param, err := resolveParameter(spec, param)
+		if err != nil {
+			return nil, err
+		}
+		key := param.In + ":" + param.Name
+		if _, ok := params[key]; !ok {
+			keys = append(keys, key)
+		}
+		params[key] = param
+	}
+	if len(keys) > 0 {
+		paramProperties := map[string]any{}
+		var paramRequired []string
+		result.ParamsExample = map[string]any{}
+		for _, key := range keys {
+			param := params[key]
+			paramSchema := param.Schema
+			// contentで定義されたパラメータはメディアのスキーマを使用する
+			if contentType, ok := preferredContentType(param.Content); ok && paramSchema == nil {
+				paramSchema = param.Content[contentType].Schema
+			}
+			schema, err := defs.schema(paramSchema)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", param.Name, err)
+			}
+			if param.Description != "" {
+				schema["description"] = param.Description
+			}
+			// x-mcp-name の場合は引数名だけを変える
+			argument := param.Name
+			if name := ogenExtensionString(param.Common.Extensions, extensionMCPName); name != "" {
+				argument = name
+			}
+			paramProperties[argument] = schema
+			if param.Required || param.In == "path" {
+				paramRequired = append(paramRequired, argument)
+			}
+			if v, ok := decodeExample([]jsonschema.Example{jsonschema.Example(param.Example)}); ok {
+				result.ParamsExample[argument] = v
+			} else if param.Schema != nil {
+				if v, ok := decodeExample([]jsonschema.Example{jsonschema.Example(param.Schema.Example)}); ok {
+					result.ParamsExample[argument] = v
+				}
+			}
+			result.Fallback.Parameters = append(result.Fallback.Parameters, fallbackParameter{
+				Name:     param.Name,
+				In:       param.In,
+				Argument: argument,
+			})
+		}
+		requestParameter := map[string]any{
+			"type":       "object",
+			"properties": paramProperties,
+		}
+		if len(paramRequired) > 0 {
+			requestParameter["required"] = paramRequired
+			required = append(required, "requestParameter")
+			result.ParamsRequired = true
+		}
+		properties["requestParameter"] = requestParameter
+	}
+
+	if op.RequestBody != nil {
+		body, err := resolveRequestBody(spec, op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+		if contentType, ok := preferredContentType(body.Content); ok {
+			media := body.Content[contentType]
+			// JSONとフォーム以外のリクエストボディは文字列のまま送信する
+			raw := !strings.Contains(contentType, "json") && !strings.HasPrefix(contentType, "application/x-www-form-urlencoded")
+			schema := map[string]any{"type": "string"}
+			if !raw {
+				if schema, err = defs.schema(media.Schema); err != nil {
+					return nil, fmt.Errorf("request body: %w", err)
+				}
+			}
+			if body.Description != "" {
+				schema["description"] = body.Description
+			}
+			properties["requestBody"] = schema
+			if body.Required {
+				required = append(required, "requestBody")
+			}
+			result.Fallback.ContentType = contentType
+			result.BodyRequired = body.Required
+			if v, ok := decodeExample([]jsonschema.Example{jsonschema.Example(media.Example)}); ok && !raw {
+				result.BodyExample = v
+			}
+		}
+	}
+
+	inputSchema := map[string]any{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+	if len(defs.defs) > 0 {
+		inputSchema["$defs"] = defs.defs
+	}
+	buf, err := json.Marshal(inputSchema)
+	if err != nil {
+		return nil, err
+	}
+	result.Fallback.InputSchema = string(buf)
+	return result, nil
+}
+
+// パラメータの参照を解決
+func resolveParameter(spec *ogen.Spec, param *ogen.Parameter) (*ogen.Parameter, error) {
+	for depth := 0; param != nil && param.Ref != ""; depth++ {
+		name, ok := strings.CutPrefix(param.Ref, "#/components/parameters/")
+		if !ok || depth > 8 || spec.Components == nil {
+			return nil, fmt.Errorf("unsupported reference %q", param.Ref)
+		}
+		param = spec.Components.Parameters[name]
+	}
+	if param == nil {
+		return nil, fmt.Errorf("parameter not found")
+	}
+	return param, nil
+}
+
+// リクエストボディの参照を解決
+func resolveRequestBody(spec *ogen.Spec, body *ogen.RequestBody) (*ogen.RequestBody, error) {
+	for depth := 0; body != nil && body.Ref != ""; depth++ {
+		name, ok := strings.CutPrefix(body.Ref, "#/components/requestBodies/")
+		if !ok || depth > 8 || spec.Components == nil {
+			return nil, fmt.Errorf("unsupported reference %q", body.Ref)
+		}
+		body = spec.Components.RequestBodies[name]
+	}
+	if body == nil {
+		return nil, fmt.Errorf("request body not found")
+	}
+	return body, nil
+}
+
+// JSONを優先してContent-Typeを選択
+func preferredContentType(content map[string]ogen.Media) (string, bool) {
+	if len(content) == 0 {
+		return "", false
+	}
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	if i := slices.IndexFunc(contentTypes, func(contentType string) bool {
+		return strings.Contains(contentType, "json")
+	}); i >= 0 {
+		return contentTypes[i], true
+	}
+	return contentTypes[0], true
+}
+
+// 入力スキーマから参照されるコンポーネントのスキーマ
+// #/components/schemas/X は #/$defs/X に書き換える
+type schemaDefs struct {
+	spec *ogen.Spec
+	defs map[string]any
+}
+
+// スキーマをJSON Schemaのオブジェクトに変換
+func (d *schemaDefs) schema(schema *ogen.Schema) (map[string]any, error) {
+	if schema == nil {
+		return map[string]any{}, nil
+	}
+	buf, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, err
+	}
+	if err := d.rewrite(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// 参照を書き換え、参照先のスキーマを $defs に追加
+func (d *schemaDefs) rewrite(v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			ref, ok := value.(string)
+			if key != "$ref" || !ok {
+				if err := d.rewrite(value); err != nil {
+					return err
+				}
+				continue
+			}
+			name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+			if !ok {
+				return fmt.Errorf("unsupported reference %q", ref)
+			}
+			v[key] = "#/$defs/" + name
+			if _, ok := d.defs[name]; ok {
+				continue
+			}
+			var target *ogen.Schema
+			if d.spec.Components != nil {
+				target = d.spec.Components.Schemas[name]
+			}
+			if target == nil {
+				return fmt.Errorf("schema %q not found", name)
+			}
+			// 循環参照に備えて先に登録する
+			d.defs[name] = map[string]any{}
+			def, err := d.schema(target)
+			if err != nil {
+				return err
+			}
+			d.defs[name] = def
+		}
+	case []any:
+		for _, value := range v {
+			if err := d.rewrite(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// operationIdまたはメソッドとパスからGoの名前を作成
+func fallbackName(operationID, method, path string) string {
+	source := operationID
+	if source == "" {
+		source = method + " " + path
+	}
+	var b strings.Builder
+	for _, part := range strings.FieldsFunc(source, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "Op" + name
+	}
+	return name
+}
+
+// 成功レスポンスの例からモックのレスポンスを作成
+func fallbackMockResponse(spec *ogen.Spec, op *ogen.Operation) mockResponse {
+	res := mockResponse{statusCode: 200}
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var response *ogen.Response
+	for _, code := range codes {
+		if statusCode, err := strconv.Atoi(code); err == nil && statusCode >= 200 && statusCode < 300 {
+			res.statusCode, response = statusCode, op.Responses[code]
+			break
+		}
+	}
+	for depth := 0; response != nil && response.Ref != "" && depth <= 8; depth++ {
+		name, _ := strings.CutPrefix(response.Ref, "#/components/responses/")
+		response = nil
+		if spec.Components != nil {
+			response = spec.Components.Responses[name]
+		}
+	}
+	if response == nil {
+		return res
+	}
+	contentType, ok := preferredContentType(response.Content)
+	if !ok {
+		return res
+	}
+	res.contentType = contentType
+	if v, ok := decodeExample([]jsonschema.Example{jsonschema.Example(response.Content[contentType].Example)}); ok {
+		if buf, err := json.Marshal(v); err == nil {
+			res.body = string(buf)
+		}
+	}
+	return res
+}
+
+// net/httpでAPIを呼び出すフォールバックのツールを生成
+func generateFallbackTool(f *jen.File, operation *operation, functions, toolDescription string) *jen.Statement {
+	fallback := operation.Fallback
+	// リクエストを組み立てるための情報
+	httpOperation := jen.Qual(functions, "HTTPOperation").Values(jen.DictFunc(func(d jen.Dict) {
+		d[jen.Id("Method")] = jen.Lit(strings.ToUpper(operation.HTTPMethod))
+		d[jen.Id("Path")] = jen.Lit(operation.Path)
+		if len(fallback.Parameters) > 0 {
+			d[jen.Id("Parameters")] = jen.Index().Qual(functions, "HTTPParameter").ValuesFunc(func(g *jen.Group) {
+				for _, param := range fallback.Parameters {
+					values := jen.Dict{
+						jen.Id("Name"): jen.Lit(param.Name),
+						jen.Id("In"):   jen.Lit(param.In),
+					}
+					if param.Argument != param.Name {
+						values[jen.Id("Argument")] = jen.Lit(param.Argument)
+					}
+					g.Line().Values(values)
+				}
+			})
+		}
+		if fallback.ContentType != "" {
+			d[jen.Id("ContentType")] = jen.Lit(fallback.ContentType)
+		}
+		if operation.ServerURL != "" {
+			d[jen.Id("Server")] = jen.Lit(operation.ServerURL)
+		}
+	}))
+	operationVar := lowerFirst(operation.Name) + "Operation"
+	f.Var().Id(operationVar).Op("=").Add(httpOperation)
+	f.Line()
+
+	return jen.Qual(functions, "NewTool").Call(jen.Lit(operation.toolName())).
+		Dot("Description").Call(jen.Lit(toolDescription)).
+		Dot("InputSchema").Call(jen.Qual(functions, "MustParseSchema").Call(jen.Lit(fallback.InputSchema))).
+		Dot("Handler").Call(
+		jen.Func().Params(
+			jen.Id("ctx").Qual("context", "Context"),
+			jen.Id("input").Struct(
+				jen.Id("RequestParameter").Map(jen.String()).Any().Tag(map[string]string{"json": "requestParameter"}),
+				jen.Id("RequestBody").Any().Tag(map[string]string{"json": "requestBody"}),
+			),
+		).Params(jen.Any(), jen.Error()).Block(
+			jen.Comment("net/httpを使用してAPIを呼び出し"),
+			jen.List(jen.Id("resp"), jen.Id("err")).Op(":=").Id(operationVar).Dot("Do").Call(
+				jen.Id("ctx"),
+				jen.Id("doer"),
+				jen.Id("baseURL"),
+				jen.Id("input").Dot("RequestParameter"),
+				jen.Id("input").Dot("RequestBody"),
+			),
+			jen.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Id("err")),
+			),
+			jen.Comment("登録されたResponseProcessorでレスポンスを後処理"),
+			jen.Return(jen.Qual(functions, "ProcessResponse").Call(jen.Id("ctx"), jen.Id("resp"))),
+		),
+	)
+}
+
+// 先頭の文字を小文字にする
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return s
+	}
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-faster/yaml"
+)
+
+// 生成するパスを絞り込む条件
+type specFilter struct {
+	// いずれかに一致するパスだけを生成する（空の場合はすべて）
+	includePaths []*regexp.Regexp
+	// 一致するパスは生成しない
+	excludePaths []*regexp.Regexp
+	// いずれかに一致するoperationIdのオペレーションだけを生成する（空の場合はすべて）
+	includeOperations []*regexp.Regexp
+	// 一致するoperationIdのオペレーションは生成しない
+	excludeOperations []*regexp.Regexp
+}
+
+// パスアイテムのオペレーションのキー
+var operationMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// フラグのglobと正規表現から絞り込みの条件を作る
+func newSpecFilter(includePaths, excludePaths, includeOperations, excludeOperations []string) (*specFilter, error) {
+	filter := &specFilter{}
+	var err error
+	if filter.includePaths, err = compileGlobs(includePaths); err != nil {
+		return nil, err
+	}
+	if filter.excludePaths, err = compileGlobs(excludePaths); err != nil {
+		return nil, err
+	}
+	if filter.includeOperations, err = compileRegexps(includeOperations); err != nil {
+		return nil, err
+	}
+	if filter.excludeOperations, err = compileRegexps(excludeOperations); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// 条件が無いか
+func (f *specFilter) empty() bool {
+	return len(f.includePaths) == 0 && len(f.excludePaths) == 0 &&
+		len(f.includeOperations) == 0 && len(f.excludeOperations) == 0
+}
+
+// パスを生成するか
+func (f *specFilter) acceptPath(path string) bool {
+	if len(f.includePaths) > 0 && !matchAny(f.includePaths, path) {
+		return false
+	}
+	return !matchAny(f.excludePaths, path)
+}
+
+// オペレーションを生成するか（operationIdの無いオペレーションは空文字として判定する）
+func (f *specFilter) acceptOperation(operationID string) bool {
+	if len(f.includeOperations) > 0 && !matchAny(f.includeOperations, operationID) {
+		return false
+	}
+	return !matchAny(f.excludeOperations, operationID)
+}
+
+// 条件に一致しないパスとオペレーションを仕様書から取り除く
+// オペレーションが残らないパスも取り除く
+// クライアントも対象のオペレーションだけで生成するよう、バックエンドに渡す前の仕様書に適用する
+func filterSpec(spec []byte, filter *specFilter) ([]byte, error) {
+	if filter.empty() {
+		return spec, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return spec, nil
+	}
+	paths := mappingValue(doc.Content[0], "paths")
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return spec, nil
+	}
+	var content []*yaml.Node
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path := paths.Content[i].Value
+		if !filter.acceptPath(path) {
+			log.Printf("Excluding %s", path)
+			continue
+		}
+		if !filter.filterOperations(path, paths.Content[i+1]) {
+			continue
+		}
+		content = append(content, paths.Content[i], paths.Content[i+1])
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("no operation of the OpenAPI spec matches the filters")
+	}
+	paths.Content = content
+	filtered, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filtered OpenAPI spec: %w", err)
+	}
+	return filtered, nil
+}
+
+// 条件に一致しないオペレーションをパスアイテムから取り除き、オペレーションが残るかを返す
+// $ref のパスアイテムはそのまま残す
+func (f *specFilter) filterOperations(path string, item *yaml.Node) bool {
+	if item.Kind != yaml.MappingNode || mappingValue(item, "$ref") != nil {
+		return true
+	}
+	var content []*yaml.Node
+	operations := 0
+	for i := 0; i+1 < len(item.Content); i += 2 {
+		key, value := item.Content[i], item.Content[i+1]
+		method := strings.ToLower(key.Value)
+		if slices.Contains(operationMethods, method) {
+			var operationID string
+			if id := mappingValue(value, "operationId"); id != nil {
+				operationID = id.Value
+			}
+			if !f.acceptOperation(operationID) {
+				log.Printf("Excluding %s %s (%s)", strings.ToUpper(method), path, operationID)
+				continue
+			}
+			operations++
+		}
+		content = append(content, key, value)
+	}
+	item.Content = content
+	return operations > 0
+}
+
+// operationIdの正規表現をコンパイルする
+func compileRegexps(exprs []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, expr := range exprs {
+		pattern, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid operation pattern %q: %w", expr, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// パスのglobを正規表現に変換する
+// * は / 以外の任意の文字列、** は / を含む任意の文字列に一致し、末尾の /** はその親のパスにも一致する
+// （例: /admin/** は /admin と /admin/users/{id} に一致）
+func compileGlobs(globs []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, glob := range globs {
+		var b strings.Builder
+		b.WriteString("^")
+		rest := glob
+		for rest != "" {
+			switch {
+			case strings.HasPrefix(rest, "/**") && len(rest) == 3:
+				b.WriteString("(/.*)?")
+				rest = ""
+			case strings.HasPrefix(rest, "**"):
+				b.WriteString(".*")
+				rest = rest[2:]
+			case strings.HasPrefix(rest, "*"):
+				b.WriteString("[^/]*")
+				rest = rest[1:]
+			case strings.HasPrefix(rest, "?"):
+				b.WriteString("[^/]")
+				rest = rest[1:]
+			default:
+				_, size := utf8.DecodeRuneInString(rest)
+				b.WriteString(regexp.QuoteMeta(rest[:size]))
+				rest = rest[size:]
+			}
+		}
+		b.WriteString("$")
+		pattern, err := regexp.Compile(b.String())
+		if err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q: %w", glob, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// 繰り返し指定できるフラグ（カンマで区切らない）
+type repeatedFlag []string
+
+func (r *repeatedFlag) String() string {
+	return strings.Join(*r, " ")
+}
+
+func (r *repeatedFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+func matchAny(patterns []*regexp.Regexp, s string) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-faster/yaml"
+)
+
+// サーバーの instructions に追加する説明を指定する拡張（仕様書のルートまたは info）
+const extensionInstructions = "x-mcp-instructions"
+
+// 認証方式
+type securityScheme struct {
+	Type   string `yaml:"type"`
+	Scheme string `yaml:"scheme"`
+	In     string `yaml:"in"`
+	Name   string `yaml:"name"`
+}
+
+// 仕様書の info と x-mcp-instructions から MCP サーバーの instructions を作成
+// 接続したモデルに API の概要、認証、レート制限の扱いを伝える
+func buildInstructions(spec []byte) (string, error) {
+	var doc struct {
+		Info struct {
+			Title        string `yaml:"title"`
+			Description  string `yaml:"description"`
+			Version      string `yaml:"version"`
+			Instructions string `yaml:"x-mcp-instructions"`
+		} `yaml:"info"`
+		Instructions string                `yaml:"x-mcp-instructions"`
+		Security     []map[string][]string `yaml:"security"`
+		Components   struct {
+			SecuritySchemes map[string]securityScheme `yaml:"securitySchemes"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return "", fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+
+	var sections []string
+	if title := strings.TrimSpace(doc.Info.Title); title != "" {
+		overview := "The tools of this server call the " + title
+		if !strings.HasSuffix(strings.ToLower(title), "api") {
+			overview += " API"
+		}
+		if version := strings.TrimSpace(doc.Info.Version); version != "" {
+			overview += fmt.Sprintf(" (version %s)", version)
+		}
+		sections = append(sections, overview+".")
+	}
+	if description := strings.TrimSpace(doc.Info.Description); description != "" {
+		sections = append(sections, description)
+	}
+	if auth := authenticationInstructions(doc.Security, doc.Components.SecuritySchemes); auth != "" {
+		sections = append(sections, auth)
+	}
+	if len(sections) > 0 {
+		sections = append(sections, "A call failing with 429 Too Many Requests hit the rate limit of the API: wait before calling it again, and prefer fewer and narrower calls.")
+	}
+	for _, instructions := range []string{doc.Info.Instructions, doc.Instructions} {
+		if instructions = strings.TrimSpace(instructions); instructions != "" {
+			sections = append(sections, instructions)
+		}
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// 認証方式の説明
+// 仕様書全体の security で使う方式を、無ければ定義されたすべての方式を説明する
+func authenticationInstructions(security []map[string][]string, schemes map[string]securityScheme) string {
+	var names []string
+	for _, requirement := range security {
+		for name := range requirement {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	if len(security) == 0 {
+		for name := range schemes {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	var methods []string
+	for _, name := range names {
+		scheme, ok := schemes[name]
+		if !ok {
+			continue
+		}
+		if method := scheme.method(); method != "" && !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("The API is authenticated with %s, which the server sends with its own credentials: do not ask the user for them. A call failing with 401 or 403 means the credentials of the server are missing or lack the permission, so report it instead of retrying.", strings.Join(methods, " or "))
+}
+
+// 認証方式の説明（例: an API key in the X-API-Key header）
+func (s securityScheme) method() string {
+	switch strings.ToLower(s.Type) {
+	case "apikey":
+		if s.Name != "" && s.In != "" {
+			return fmt.Sprintf("an API key in the %s %s", s.Name, s.In)
+		}
+		return "an API key"
+	case "http":
+		switch strings.ToLower(s.Scheme) {
+		case "basic":
+			return "HTTP basic authentication"
+		case "bearer":
+			return "a bearer token"
+		}
+		return "HTTP authentication"
+	case "oauth2":
+		return "an OAuth 2.0 access token"
+	case "openidconnect":
+		return "an OpenID Connect token"
+	case "mutualtls":
+		return "a TLS client certificate"
+	}
+	return ""
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// 負荷試験のシナリオ
+//
+//	calls:
+//	  - tool: getPetById
+//	    arguments: {petId: 1}
+//	    weight: 3
+//	  - tool: findPetsByStatus
+//	    arguments: {status: available}
+type loadScenario struct {
+	Calls []loadCall `json:"calls" yaml:"calls"`
+}
+
+// シナリオのツール呼び出し（weight の比率で選ばれる。既定は1）
+type loadCall struct {
+	Tool      string         `json:"tool" yaml:"tool"`
+	Arguments map[string]any `json:"arguments" yaml:"arguments"`
+	Weight    int            `json:"weight" yaml:"weight"`
+}
+
+// ツールごとの計測結果
+type loadStats struct {
+	latencies []time.Duration
+	errors    int
+}
+
+func (s *loadStats) add(latency time.Duration, failed bool) {
+	s.latencies = append(s.latencies, latency)
+	if failed {
+		s.errors++
+	}
+}
+
+func (s *loadStats) merge(other *loadStats) {
+	s.latencies = append(s.latencies, other.latencies...)
+	s.errors += other.errors
+}
+
+// 起動中のMCPサーバーに並行してセッションを張り、シナリオのツール呼び出しを送ってレイテンシとエラー率を表示する
+// エラー率が -max-error-rate を超えれば1を返す
+func runLoadTest(args []string) int {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	var serverURL, transportName, scenarioPath string
+	var sessions, requests int
+	var duration, timeout time.Duration
+	var maxErrorRate float64
+	headers := headerFlag{}
+	fs.StringVar(&serverURL, "url", "http://localhost:8080/sse", "URL of the MCP server (the SSE endpoint or the Streamable HTTP endpoint)")
+	fs.StringVar(&transportName, "transport", transportSSE, "Transport of the MCP server: sse or http")
+	fs.StringVar(&scenarioPath, "scenario", "", "Scenario file (YAML or JSON) listing the tool calls")
+	fs.IntVar(&sessions, "sessions", 10, "Number of concurrent MCP sessions")
+	fs.IntVar(&requests, "requests", 1000, "Total number of tool calls (ignored when -duration is set)")
+	fs.DurationVar(&duration, "duration", 0, "Duration of the test, e.g. 30s")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "Timeout of each tool call")
+	fs.Float64Var(&maxErrorRate, "max-error-rate", 0, "Maximum error rate (0-1) before failing")
+	fs.Var(headers, "header", "Header sent to the MCP server, e.g. \"Authorization: Bearer xxx\" (repeatable)")
+	_ = fs.Parse(args)
+
+	if scenarioPath == "" {
+		log.Print("scenario file path is required")
+		return 2
+	}
+	calls, err := loadScenarioCalls(scenarioPath)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	if sessions < 1 || (duration <= 0 && requests < 1) {
+		log.Print("-sessions and -requests must be positive")
+		return 2
+	}
+
+	ctx := context.Background()
+	if duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, duration)
+		defer cancel()
+	}
+
+	// 呼び出しの番号を配る（-duration の場合は時間切れまで）
+	var next atomic.Int64
+	take := func() (int, bool) {
+		n := int(next.Add(1) - 1)
+		if duration > 0 {
+			return n, ctx.Err() == nil
+		}
+		return n, n < requests
+	}
+
+	var mu sync.Mutex
+	stats := map[string]*loadStats{}
+	var connectErrors []error
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range sessions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			local, err := runLoadSession(ctx, serverURL, transportName, http.Header(headers), calls, timeout, take)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				connectErrors = append(connectErrors, err)
+				return
+			}
+			for tool, s := range local {
+				if stats[tool] == nil {
+					stats[tool] = &loadStats{}
+				}
+				stats[tool].merge(s)
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	for _, err := range connectErrors {
+		log.Printf("session failed: %v", err)
+	}
+	if len(connectErrors) == sessions {
+		return 1
+	}
+	total := printLoadReport(stats, elapsed, sessions-len(connectErrors))
+	if total.errors > 0 && float64(total.errors)/float64(len(total.latencies)) > maxErrorRate {
+		return 1
+	}
+	return 0
+}
+
+// シナリオファイルを読み込み、重みの数だけ呼び出しを並べる
+func loadScenarioCalls(path string) ([]loadCall, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+	var scenario loadScenario
+	if err := yaml.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %w", err)
+	}
+	var calls []loadCall
+	for i, call := range scenario.Calls {
+		if call.Tool == "" {
+			return nil, fmt.Errorf("scenario calls[%d]: tool is required", i)
+		}
+		if call.Weight < 0 {
+			return nil, fmt.Errorf("scenario calls[%d]: weight must not be negative", i)
+		}
+		for range max(call.Weight, 1) {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return nil, errors.New("scenario has no calls")
+	}
+	return calls, nil
+}
+
+// 1つのセッションで呼び出しの番号が尽きるまでツールを呼び出す
+func runLoadSession(ctx context.Context, serverURL, transportName string, headers http.Header, calls []loadCall, timeout time.Duration, take func() (int, bool)) (map[string]*loadStats, error) {
+	c, err := connectMCP(ctx, serverURL, transportName, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	stats := map[string]*loadStats{}
+	for {
+		n, ok := take()
+		if !ok {
+			return stats, nil
+		}
+		call := calls[n%len(calls)]
+		req := mcp.CallToolRequest{}
+		req.Params.Name = call.Tool
+		req.Params.Arguments = call.Arguments
+
+		callCtx, cancel := context.WithTimeout(ctx, timeout)
+		begin := time.Now()
+		res, err := c.CallTool(callCtx, req)
+		latency := time.Since(begin)
+		cancel()
+		// -duration の時間切れで中断された呼び出しは数えない
+		if err != nil && ctx.Err() != nil {
+			return stats, nil
+		}
+		if stats[call.Tool] == nil {
+			stats[call.Tool] = &loadStats{}
+		}
+		stats[call.Tool].add(latency, err != nil || res.IsError)
+	}
+}
+
+// ツールごとと全体の結果を表示し、全体の結果を返す
+func printLoadReport(stats map[string]*loadStats, elapsed time.Duration, sessions int) *loadStats {
+	tools := make([]string, 0, len(stats))
+	for tool := range stats {
+		tools = append(tools, tool)
+	}
+	slices.Sort(tools)
+
+	total := &loadStats{}
+	fmt.Printf("%-30s %8s %7s %10s %10s %10s %10s\n", "TOOL", "CALLS", "ERRORS", "P50", "P90", "P99", "MAX")
+	for _, tool := range tools {
+		printLoadLine(tool, stats[tool])
+		total.merge(stats[tool])
+	}
+	printLoadLine("TOTAL", total)
+	throughput := float64(len(total.latencies)) / elapsed.Seconds()
+	fmt.Printf("%d calls in %s over %d sessions (%.1f calls/s)\n", len(total.latencies), elapsed.Round(time.Millisecond), sessions, throughput)
+	return total
+}
+
+func printLoadLine(name string, s *loadStats) {
+	slices.Sort(s.latencies)
+	errorRate := 0.0
+	if len(s.latencies) > 0 {
+		errorRate = float64(s.errors) / float64(len(s.latencies)) * 100
+	}
+	fmt.Printf("%-30s %8d %6.1f%% %10s %10s %10s %10s\n", name, len(s.latencies), errorRate,
+		percentile(s.latencies, 50), percentile(s.latencies, 90), percentile(s.latencies, 99), percentile(s.latencies, 100))
+}
+
+// ソート済みのレイテンシのパーセンタイル（最近傍順位法）
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1].Round(time.Microsecond)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-faster/yaml"
+)
+
+// x-descriptions の指定した言語の説明で description を置き換える
+// 仕様書のどこにあるオブジェクト（オペレーション、パラメータ、スキーマなど）でも置き換えるため、
+// バックエンドに渡す前の仕様書に適用する
+func localizeDescriptions(spec []byte, lang string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if !localizeNode(&doc, lang, 0) {
+		return spec, nil
+	}
+	localized, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode localized OpenAPI spec: %w", err)
+	}
+	return localized, nil
+}
+
+// ノード以下の説明を置き換え、置き換えたかを返す
+func localizeNode(node *yaml.Node, lang string, depth int) bool {
+	if node == nil || depth > 128 {
+		return false
+	}
+	changed := false
+	if node.Kind == yaml.MappingNode {
+		if description, ok := localizedDescription(node, lang); ok {
+			setMappingValue(node, "description", description)
+			changed = true
+		}
+	}
+	for _, child := range node.Content {
+		if localizeNode(child, lang, depth+1) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// x-descriptions から言語に合う説明を取得
+// 完全に一致する言語を優先し、無ければ地域を除いた言語（ja-JP なら ja）を使う
+func localizedDescription(node *yaml.Node, lang string) (string, bool) {
+	descriptions := mappingValue(node, extensionDescriptions)
+	if descriptions == nil || descriptions.Kind != yaml.MappingNode {
+		return "", false
+	}
+	base, _, _ := strings.Cut(lang, "-")
+	for _, candidate := range []string{lang, base} {
+		for i := 0; i+1 < len(descriptions.Content); i += 2 {
+			key, value := descriptions.Content[i], descriptions.Content[i+1]
+			if strings.EqualFold(strings.ReplaceAll(key.Value, "_", "-"), candidate) && value.Kind == yaml.ScalarNode {
+				return value.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key, value string) {
+	// JSON の仕様書のフロースタイルでも壊れないようダブルクォートで出力する
+	scalar := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value, Style: yaml.DoubleQuotedStyle}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = scalar
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		scalar,
+	)
+}
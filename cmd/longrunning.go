@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/go-faster/yaml"
+)
+
+// 完了までポーリングする長時間実行のオペレーションを指定する拡張
+const extensionLongRunning = "x-mcp-longrunning"
+
+// 202のレスポンスでステータスのURLを返すヘッダー
+var longRunningHeaders = []string{"Location", "Operation-Location", "Azure-AsyncOperation"}
+
+// x-mcp-longrunning の設定（ゼロ値は functions の既定値）
+type longRunningConfig struct {
+	Interval       time.Duration
+	Timeout        time.Duration
+	StatusURLField string
+	StatusField    string
+}
+
+// 仕様書のレスポンス
+type specResponse struct {
+	Ref     string         `yaml:"$ref"`
+	Headers map[string]any `yaml:"headers"`
+}
+
+// 仕様書のオペレーション
+type specOperation struct {
+	Responses   map[string]specResponse `yaml:"responses"`
+	LongRunning any                     `yaml:"x-mcp-longrunning"`
+}
+
+// 長時間実行のオペレーションに設定を付ける
+// 202のレスポンスでLocationなどのヘッダーを返すオペレーションと、x-mcp-longrunning を指定した
+// オペレーションが対象（x-mcp-longrunning: false で除外できる）
+func applyLongRunning(spec []byte, operations []*operation) error {
+	var doc struct {
+		Paths map[string]struct {
+			Get     *specOperation `yaml:"get"`
+			Put     *specOperation `yaml:"put"`
+			Post    *specOperation `yaml:"post"`
+			Delete  *specOperation `yaml:"delete"`
+			Options *specOperation `yaml:"options"`
+			Head    *specOperation `yaml:"head"`
+			Patch   *specOperation `yaml:"patch"`
+			Trace   *specOperation `yaml:"trace"`
+		} `yaml:"paths"`
+		Components struct {
+			Responses map[string]specResponse `yaml:"responses"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	for _, operation := range operations {
+		item, ok := doc.Paths[operation.Path]
+		if !ok {
+			continue
+		}
+		op := map[string]*specOperation{
+			"get":     item.Get,
+			"put":     item.Put,
+			"post":    item.Post,
+			"delete":  item.Delete,
+			"options": item.Options,
+			"head":    item.Head,
+			"patch":   item.Patch,
+			"trace":   item.Trace,
+		}[strings.ToLower(operation.HTTPMethod)]
+		if op == nil {
+			continue
+		}
+		if op.LongRunning != nil {
+			operation.LongRunning = longRunningOptions(op.LongRunning)
+			continue
+		}
+		accepted, ok := op.Responses["202"]
+		if !ok {
+			continue
+		}
+		// components/responses を参照するレスポンス
+		if name, ok := strings.CutPrefix(accepted.Ref, "#/components/responses/"); ok {
+			accepted = doc.Components.Responses[name]
+		}
+		for header := range accepted.Headers {
+			if slices.ContainsFunc(longRunningHeaders, func(h string) bool { return strings.EqualFold(h, header) }) {
+				operation.LongRunning = &longRunningConfig{}
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// x-mcp-longrunning の値から設定を取得
+// true の場合は既定値、false の場合は対象外、オブジェクトの場合は interval、timeout（秒数または
+// 10s などの時間）、statusUrl（202のボディのステータスのURLのパス）、status（ステータスの状態のパス）を使用する
+func longRunningOptions(value any) *longRunningConfig {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return &longRunningConfig{}
+		}
+	case map[string]any:
+		config := &longRunningConfig{
+			Interval: extensionDuration(v["interval"]),
+			Timeout:  extensionDuration(v["timeout"]),
+		}
+		if field, ok := v["statusUrl"].(string); ok {
+			config.StatusURLField = strings.TrimSpace(field)
+		}
+		if field, ok := v["status"].(string); ok {
+			config.StatusField = strings.TrimSpace(field)
+		}
+		return config
+	default:
+		log.Printf("%s: unsupported value %v", extensionLongRunning, value)
+	}
+	return nil
+}
+
+// 拡張の時間（数値は秒数、文字列は time.ParseDuration の形式）
+func extensionDuration(value any) time.Duration {
+	if s, ok := value.(string); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			log.Printf("%s: invalid duration %q", extensionLongRunning, s)
+		}
+		return d
+	}
+	return time.Duration(extensionInt(value)) * time.Second
+}
+
+// 時間のリテラル（例: 10 * time.Second）
+func durationLiteral(d time.Duration) jen.Code {
+	if d%time.Second == 0 {
+		return jen.Lit(int(d/time.Second)).Op("*").Qual("time", "Second")
+	}
+	return jen.Lit(int(d/time.Millisecond)).Op("*").Qual("time", "Millisecond")
+}
@@ -5,9 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"maps"
+	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/dave/jennifer/jen"
@@ -19,7 +23,7 @@ import (
 	"github.com/ogen-go/ogen/jsonschema"
 )
 
-//go:generate go run main.go -path=../../api/openapi.yaml -output=../../pkg/client
+//go:generate go run . -path=../../api/openapi.yaml -output=../../pkg/client
 
 func main() {
 	/*
@@ -34,53 +38,417 @@ func main() {
 		- mcp tool, mcp server のコード生成を行う際は github.com/dave/jennifer を使用してください。
 	*/
 
-	var openapiPath string
+	// サブコマンド
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			os.Exit(runCheck(os.Args[2:]))
+		case "loadtest":
+			os.Exit(runLoadTest(os.Args[2:]))
+		case "smoke":
+			os.Exit(runSmoke(os.Args[2:]))
+		case "validate":
+			os.Exit(runValidate(os.Args[2:]))
+		}
+	}
+
+	var configPath string
+	var overridesPath string
+	var specPaths repeatedFlag
 	var outputPath string
-	var packageName string
+	var backendName string
+	var checkCompat bool
+	var dryRun bool
+	var standalone bool
+	var withMain bool
+	var withDocker, withCompose bool
+	var transportNames listFlag
+	var includePaths listFlag
+	var excludePaths listFlag
+	var includeOperations repeatedFlag
+	var excludeOperations repeatedFlag
+	g := &generator{specHeaders: headerFlag{}}
+	opts := &g.opts
 
-	flag.StringVar(&openapiPath, "path", "", "OpenAPI specification file path")
+	flag.StringVar(&configPath, "config", "", "Config file (YAML) with the settings of the flags and per-operation overrides; defaults to "+defaultConfigFile+" when present. The flags given on the command line take precedence")
+	flag.StringVar(&overridesPath, "overrides", "", "Overrides file (YAML) mapping operationId to the tool name, description and enabled flag; defaults to "+defaultOverridesFile+" when present. It takes precedence over the operations of the config file")
+	flag.Var(&specPaths, "path", "OpenAPI specification file path or http(s) URL, e.g. https://api.example.com/openapi.json; repeat the flag as name=path to merge several specs into one server")
+	flag.Var(g.specHeaders, "spec-header", "Header sent to download the spec from a URL, e.g. \"Authorization: Bearer $TOKEN\" (repeatable, environment variables are expanded)")
+	flag.BoolVar(&g.specCache, "spec-cache", true, "Cache the spec downloaded from a URL and revalidate it with ETag and Last-Modified")
 	flag.StringVar(&outputPath, "output", "pkg/client", "Output directory for generated client")
-	flag.StringVar(&packageName, "package", "client", "Package name for generated client")
+	flag.BoolVar(&standalone, "standalone", false, "Generate a go.mod in the output directory so that the output compiles as its own module, pinning mcp-go, ogen and the other dependencies to the versions of the generator")
+	flag.StringVar(&g.module, "module", "", "Module path of the -standalone output (default: the name of the output directory)")
+	flag.StringVar(&g.packageName, "package", "client", "Package name for generated client")
+	flag.StringVar(&backendName, "client-backend", backendOgen, "Client generator backend: ogen or oapi-codegen")
+	flag.StringVar(&opts.naming, "naming", namingClient, "Naming strategy of the tools: "+strings.Join(namingStrategies, ", ")+" (the names of the config file take precedence)")
+	flag.StringVar(&g.lang, "lang", "", "Language of the descriptions taken from x-descriptions, e.g. ja or en")
+	flag.Var(&transportNames, "transport", "Comma separated transports served by the generated handler: sse, http (streamable HTTP) and websocket (default all)")
+	flag.Var(&includePaths, "include-paths", "Comma separated globs of the paths to generate, e.g. /pets/**; * matches a path segment and ** any number of segments")
+	flag.Var(&excludePaths, "exclude-paths", "Comma separated globs of the paths not to generate, e.g. /admin/**")
+	flag.Var(&includeOperations, "include-operations", "Regular expression of the operationIds to generate, e.g. ^(listPets|getPet)$; repeat the flag for several expressions")
+	flag.Var(&excludeOperations, "exclude-operations", "Regular expression of the operationIds not to generate, e.g. ^admin; repeat the flag for several expressions")
+	flag.BoolVar(&g.oas31Compat, "oas31-compat", true, "Convert OpenAPI 3.1 specs (type arrays, const, examples, numeric exclusiveMinimum, webhooks...) to 3.0 before generating; the embedded spec is kept as is")
+	flag.BoolVar(&g.withMCPTest, "mcptest", true, "Generate the mcptest package calling every tool in process against the mock")
+	flag.BoolVar(&g.withPrompts, "prompts", false, "Generate the prompts package with a prompt per tag built from the operation summaries and examples")
+	flag.BoolVar(&withMain, "with-main", false, "Generate cmd/server/main.go in the output directory starting the server with -name, -version and -addr flags, so that the output can be run with go run")
+	flag.BoolVar(&withDocker, "with-docker", false, "Generate a multi-stage Dockerfile in the output directory building the server of -with-main (implies -with-main)")
+	flag.BoolVar(&withCompose, "with-compose", false, "Generate a compose.yaml running the container of -with-docker (implies -with-docker)")
+	flag.BoolVar(&checkCompat, "check-compat", false, "Compare the tool schemas with the snapshot in the output directory and fail on breaking changes without generating")
+	flag.BoolVar(&dryRun, "dry-run", false, "Print the names, descriptions and input fields of the tools that would be generated without writing the output directory")
+	flag.BoolVar(&opts.flatInput, "flat-input", false, "Expose parameters and request body fields as top-level tool arguments")
+	flag.BoolVar(&opts.stripEmpty, "strip-empty", false, "Remove null, empty string and empty array fields from the tool results")
+	flag.Var(&opts.ogenFeatures, "ogen-features", "Comma separated ogen features to enable in addition to paths/client and ogen/otel")
+	flag.Var(&opts.ogenDisableFeatures, "ogen-disable-features", "Comma separated ogen features to disable (paths/client cannot be disabled)")
+	flag.Var(&opts.ogen.ConvenientErrors, "ogen-convenient-errors", "ogen convenient errors: auto, on or off")
+	flag.Var(&opts.ogen.ContentTypeAliases, "ogen-content-type-aliases", "ogen content type aliases, e.g. text/x-markdown=text/plain")
+	flag.Var(&opts.ogenIgnoreNotImplemented, "ogen-ignore-not-implemented", "Comma separated ogen ErrNotImplemented messages to ignore, or all")
 	flag.Parse()
 
-	if openapiPath == "" {
+	// 設定ファイルの値はコマンドラインで指定しなかったフラグに使う
+	config, configFile, err := loadConfig(configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if config != nil {
+		log.Printf("Using config %s", configFile)
+		if err := config.applyFlags(flag.CommandLine, configFile); err != nil {
+			log.Fatal(err)
+		}
+		opts.overrides = config.Operations
+	}
+	// 上書きファイルは設定ファイルの operations より優先する
+	overrides, overridesFile, err := loadOverrides(overridesPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if overrides != nil {
+		log.Printf("Using overrides %s", overridesFile)
+		if opts.overrides == nil {
+			opts.overrides = map[string]operationOverride{}
+		}
+		maps.Copy(opts.overrides, overrides)
+	}
+	opts.ogen.IgnoreNotImplemented = opts.ogenIgnoreNotImplemented
+
+	if len(specPaths) == 0 {
 		log.Fatal("OpenAPI specification file path is required")
 	}
+	sources, err := parseSpecSources(specPaths)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if g.backend, err = newClientBackend(backendName); err != nil {
+		log.Fatal(err)
+	}
+	if g.transports, err = parseTransports(transportNames); err != nil {
+		log.Fatal(err)
+	}
+	if err := validateNaming(opts.naming); err != nil {
+		log.Fatal(err)
+	}
+	if g.filter, err = newSpecFilter(includePaths, excludePaths, includeOperations, excludeOperations); err != nil {
+		log.Fatal(err)
+	}
+
+	// 複数の仕様書は出力ディレクトリのサービス名のディレクトリにそれぞれ生成する
+	merged := len(sources) > 1
+	for _, source := range sources {
+		if merged {
+			source.output = filepath.Join(outputPath, source.name)
+		} else {
+			source.output = outputPath
+		}
+	}
+	// 出力ディレクトリを独立したモジュールにする
+	if standalone {
+		if g.module == "" {
+			abs, err := filepath.Abs(outputPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			g.module = filepath.Base(abs)
+		}
+		g.root = outputPath
+	}
+	// コンテナは生成した main パッケージをビルドする
+	withDocker = withDocker || withCompose
+	withMain = withMain || withDocker
+	used := map[string]bool{}
+
+	// 生成するツールを出力するだけで出力ディレクトリには書き込まない
+	if dryRun {
+		for _, source := range sources {
+			var prefix string
+			if merged {
+				prefix = source.name + "_"
+			}
+			if err := g.dryRun(os.Stdout, source, prefix, used); err != nil {
+				log.Fatal(err)
+			}
+		}
+		warnUnusedOverrides(opts.overrides, used)
+		return
+	}
+
+	// 前回のスナップショットと比較するだけで生成はしない
+	if checkCompat {
+		code := 0
+		for _, source := range sources {
+			code = max(code, g.checkCompat(source, used))
+		}
+		warnUnusedOverrides(opts.overrides, used)
+		os.Exit(code)
+	}
+
+	for _, source := range sources {
+		if err := g.generate(source, used); err != nil {
+			log.Fatal(err)
+		}
+	}
+	warnUnusedOverrides(opts.overrides, used)
+	// 各仕様書のサーバーを1つのMCPサーバーにまとめる
+	if merged {
+		if err := generateMergedServer(sources, g.transports, outputPath); err != nil {
+			log.Fatalf("Failed to generate merged MCP server: %v", err)
+		}
+	}
+	// go run で起動できるよう main パッケージを生成する
+	if withMain {
+		serverPath := g.importPath(outputPath) + "/server"
+		name, version := sources[0].title, sources[0].version
+		if merged {
+			// まとめたサーバーの名前は出力ディレクトリ名にする
+			abs, err := filepath.Abs(outputPath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			name, version = filepath.Base(abs), ""
+		}
+		if err := generateMain(outputPath, serverPath, name, version, !merged); err != nil {
+			log.Fatalf("Failed to generate main: %v", err)
+		}
+	}
+	if withDocker {
+		// ビルドコンテキストは go.mod のあるディレクトリ
+		root := moduleDir()
+		envs := []string{"API_BASE_URL"}
+		if standalone {
+			root = outputPath
+		}
+		if merged {
+			envs = nil
+			for _, source := range sources {
+				envs = append(envs, baseURLEnv(source.name))
+			}
+		}
+		if err := writeDocker(outputPath, root, envs, withCompose); err != nil {
+			log.Fatalf("Failed to generate Dockerfile: %v", err)
+		}
+	}
+	if standalone {
+		if err := writeGoMod(outputPath, g.module); err != nil {
+			log.Fatal(err)
+		}
+	}
+	// 生成したツールのスキーマのスナップショットと、コンパイルせずにツールを知れるマニフェストを出力する
+	// 生成したサーバーをビルドするため、go.mod を含むすべてを生成した後に行う
+	for _, source := range sources {
+		if err := writeServedTools(source); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if merged {
+		if err := mergeToolsManifests(sources, outputPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Printf("Successfully generated OpenAPI client, MCP tools, server and mock in %s", outputPath)
+}
+
+// 仕様書ごとの生成の設定
+type generator struct {
+	backend     clientBackend
+	packageName string
+	// URLの仕様書を取得するリクエストのヘッダー
+	specHeaders headerFlag
+	// URLの仕様書をキャッシュするか
+	specCache bool
+	filter    *specFilter
+	// x-descriptions から使う言語
+	lang string
+	// OpenAPI 3.1の仕様書を3.0に変換するか
+	oas31Compat bool
+	// 生成したサーバーが公開するトランスポート
+	transports  []string
+	withMCPTest bool
+	withPrompts bool
+	opts        generateOptions
+	// -standalone の場合のモジュールパスと、そのモジュールのディレクトリ（出力ディレクトリ）
+	module string
+	root   string
+}
 
+// 仕様書を読み込み、バックエンドに渡す仕様書と生成したサーバーに埋め込む元の仕様書を返す
+func (g *generator) readSpec(source *specSource) (spec, original []byte, err error) {
 	// OpenAPIファイルを読み込む
-	spec, err := os.ReadFile(openapiPath)
+	spec, err = readSpec(source.location, http.Header(g.specHeaders), g.specCache)
 	if err != nil {
-		log.Fatalf("Failed to read OpenAPI spec: %v", err)
+		return nil, nil, fmt.Errorf("failed to read OpenAPI spec: %w", err)
+	}
+	// 対象外のパスはクライアントも生成しない
+	if spec, err = filterSpec(spec, g.filter); err != nil {
+		return nil, nil, err
+	}
+	// x-mcp-ignore のオペレーションとパラメータも同じく取り除く
+	if spec, err = ignoreMCPExtension(spec); err != nil {
+		return nil, nil, err
+	}
+	// 生成したサーバーに埋め込む元の仕様書（対象外のパスは公開しない）
+	original = spec
+	// ツールの説明とスキーマの言語を揃える
+	if g.lang != "" {
+		if spec, err = localizeDescriptions(spec, g.lang); err != nil {
+			return nil, nil, err
+		}
+	}
+	// 仕様書の x-mcp-description はツールの説明として言語ごとの説明より優先する
+	if spec, err = describeMCPExtension(spec); err != nil {
+		return nil, nil, err
+	}
+	// バックエンドが読めない3.1の構文を3.0に変換する
+	if g.oas31Compat {
+		if spec, err = downconvertOAS31(spec); err != nil {
+			return nil, nil, err
+		}
 	}
+	return spec, original, nil
+}
 
-	// OpenAPIパーサーでパース
-	parsedSpec, err := ogen.Parse(spec)
+// 仕様書からクライアント、ツール、サーバー、モックなどを生成する
+func (g *generator) generate(source *specSource, used map[string]bool) error {
+	spec, original, err := g.readSpec(source)
 	if err != nil {
-		log.Fatalf("Failed to parse OpenAPI spec: %v", err)
+		return err
 	}
-	setDescriptionTag(parsedSpec)
+	outputPath := source.output
+
 	// 出力ディレクトリを作成
 	if err := os.MkdirAll(outputPath, 0755); err != nil {
-		log.Fatalf("Failed to create output directory: %v", err)
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// ogen を使ってクライアントコードを生成
-	g, err := generateClient(parsedSpec, outputPath, packageName)
+	// バックエンドを使ってクライアントコードを生成
+	info, err := g.backend.generate(spec, outputPath, g.packageName, g.opts)
 	if err != nil {
-		log.Fatalf("Failed to generate client: %v", err)
+		return fmt.Errorf("failed to generate client: %w", err)
+	}
+	applyNaming(info.operations, g.opts.naming)
+	if info.operations, err = applyOverrides(info.operations, g.opts.overrides, used); err != nil {
+		return err
+	}
+	info.transports = g.transports
+	info.importPath = g.importPath(outputPath)
+	source.importPath = info.importPath
+	source.title, source.version = specNameVersion(original)
+	source.info = info
+	// 接続したモデルにAPIの概要を伝える
+	if info.instructions, err = buildInstructions(spec); err != nil {
+		return err
+	}
+	// 202を返すオペレーションのツールは完了までポーリングする
+	if err := applyLongRunning(spec, info.operations); err != nil {
+		return err
 	}
 
 	// MCP Tools を生成
-	if err := generateMCPTools(g, outputPath); err != nil {
-		log.Fatalf("Failed to generate MCP tools: %v", err)
+	if err := generateMCPTools(info, outputPath, g.opts); err != nil {
+		return fmt.Errorf("failed to generate MCP tools: %w", err)
 	}
-	hasSecuritySource := len(parsedSpec.Security) > 0 || len(parsedSpec.Components.SecuritySchemes) > 0
 	// MCP Server ファイルを生成
-	if err := generateMCPServer(g, hasSecuritySource, outputPath); err != nil {
-		log.Fatalf("Failed to generate MCP server: %v", err)
+	if err := generateMCPServer(info, original, outputPath); err != nil {
+		return fmt.Errorf("failed to generate MCP server: %w", err)
+	}
+
+	// 上流APIのモックサーバーを生成
+	if err := generateMock(info.operations, outputPath); err != nil {
+		return fmt.Errorf("failed to generate mock server: %w", err)
+	}
+	// モックに対してツールを呼び出す統合テストのハーネスを生成
+	if g.withMCPTest {
+		if err := generateMCPTest(info, outputPath, g.opts); err != nil {
+			return fmt.Errorf("failed to generate MCP test harness: %w", err)
+		}
+	}
+	// タグごとのプロンプトを生成
+	if g.withPrompts {
+		if err := generatePrompts(info, spec, outputPath, g.opts); err != nil {
+			return fmt.Errorf("failed to generate prompts: %w", err)
+		}
+	}
+	return nil
+}
+
+// コード生成のオプション
+type generateOptions struct {
+	// パラメータとリクエストボディのフィールドをトップレベルの引数として公開する
+	flatInput bool
+	// ツールの結果から null、空文字、空配列のフィールドを取り除く
+	stripEmpty bool
+	// ogen に渡すオプション
+	ogen gen.GenerateOptions
+	// 追加で有効にする ogen の機能
+	ogenFeatures listFlag
+	// 無効にする ogen の機能
+	ogenDisableFeatures listFlag
+	// 無視する ogen の未実装エラー
+	ogenIgnoreNotImplemented listFlag
+	// 設定ファイルのオペレーションごとの上書き
+	overrides map[string]operationOverride
+	// ツール名の付け方
+	naming string
+}
+
+// カンマ区切りのリストを受け取るフラグ
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
 	}
+	return nil
+}
 
-	log.Printf("Successfully generated OpenAPI client, MCP tools and server in %s", outputPath)
+// ogen の機能を組み立てる
+// クライアントの生成に必要な paths/client は常に有効にする
+func (opts generateOptions) ogenFeatureOptions() (*gen.FeatureOptions, error) {
+	features := &gen.FeatureOptions{
+		Enable:     gen.FeatureSet{},
+		Disable:    gen.FeatureSet{},
+		DisableAll: true,
+	}
+	for _, name := range append([]string{gen.PathsClient.Name, gen.OgenOtel.Name}, opts.ogenFeatures...) {
+		if err := features.Enable.Enable(name); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range opts.ogenDisableFeatures {
+		if name == gen.PathsClient.Name {
+			return nil, fmt.Errorf("feature %q cannot be disabled", name)
+		}
+		if err := features.Disable.Enable(name); err != nil {
+			return nil, err
+		}
+		features.Enable.Disable(name)
+	}
+	return features, nil
 }
 
 func setDescriptionTag(parsedSpec *ogen.Spec) {
@@ -136,6 +504,17 @@ func setDescriptionTag(parsedSpec *ogen.Spec) {
 			}
 		}
 
+		// マップの値のスキーマを処理
+		if schema.AdditionalProperties != nil && schema.AdditionalProperties.Bool == nil {
+			additional := &schema.AdditionalProperties.Schema
+			setSchemaRecursive(additional, additional.Description)
+		}
+		for _, prop := range schema.PatternProperties {
+			if prop.Schema != nil {
+				setSchemaRecursive(prop.Schema, prop.Schema.Description)
+			}
+		}
+
 		// allOf, oneOf, anyOfを処理
 		for _, s := range schema.AllOf {
 			setSchemaRecursive(s, s.Description)
@@ -149,11 +528,15 @@ func setDescriptionTag(parsedSpec *ogen.Spec) {
 	}
 
 	// パラメータを処理
+	// x-mcp-name を指定したパラメータは json タグで引数名を変える
 	setParameter := func(parameters []*ogen.Parameter) {
 		for _, param := range parameters {
 			if param.Description != "" && param.Schema != nil {
 				setSchemaRecursive(param.Schema, param.Description)
 			}
+			if name := ogenExtensionString(param.Common.Extensions, extensionMCPName); name != "" && param.Schema != nil {
+				addExtraTag(param.Schema, "json", name)
+			}
 		}
 	}
 
@@ -202,8 +585,24 @@ func setDescriptionTag(parsedSpec *ogen.Spec) {
 	}
 }
 
+// スキーマの x-oapi-codegen-extra-tags にタグを追加する
+func addExtraTag(schema *ogen.Schema, key, value string) {
+	if schema.Common.Extensions == nil {
+		schema.Common.Extensions = make(jsonschema.Extensions)
+	}
+	tags := schema.Common.Extensions["x-oapi-codegen-extra-tags"]
+	if tags.Kind != yaml.MappingNode {
+		tags = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+	tags.Content = append(tags.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
+	schema.Common.Extensions["x-oapi-codegen-extra-tags"] = tags
+}
+
 // OpenAPI仕様からogenクライアントを生成
-func generateClient(spec *ogen.Spec, basePath, packageName string) (*gen.Generator, error) {
+func generateClient(spec *ogen.Spec, basePath, packageName string, opts generateOptions) (*gen.Generator, error) {
 	outputPath := path.Join(basePath, "client")
 	// 中間ステップを省略して、オリジナルのYAMLファイルを直接使用
 	// 出力ディレクトリを絶対パスに変換
@@ -211,16 +610,19 @@ func generateClient(spec *ogen.Spec, basePath, packageName string) (*gen.Generat
 	if err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
+	features, err := opts.ogenFeatureOptions()
+	if err != nil {
+		return nil, fmt.Errorf("invalid ogen features: %w", err)
+	}
+	generateOpts := opts.ogen
+	generateOpts.Features = features
+	// 未対応の機能を使うオペレーションはスキップし、net/httpのフォールバックで補う
+	generateOpts.IgnoreNotImplemented = append(slices.Clone(generateOpts.IgnoreNotImplemented), "all")
+	generateOpts.NotImplementedHook = func(name string, err error) {
+		log.Printf("ogen: %s is not implemented: %v", name, err)
+	}
 	g, err := gen.NewGenerator(spec, gen.Options{
-		Generator: gen.GenerateOptions{
-			Features: &gen.FeatureOptions{
-				Enable: gen.FeatureSet{
-					"paths/client": struct{}{},
-					"ogen/otel":    struct{}{},
-				},
-				DisableAll: true,
-			},
-		},
+		Generator: generateOpts,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("build IR: %w", err)
@@ -249,7 +651,7 @@ func generateClient(spec *ogen.Spec, basePath, packageName string) (*gen.Generat
 }
 
 // MCP Toolsを生成
-func generateMCPTools(g *gen.Generator, outputPath string) error {
+func generateMCPTools(info *clientInfo, outputPath string, opts generateOptions) error {
 	// 各エンドポイントに対応するMCP Toolを生成
 	toolsDir := filepath.Join(outputPath, "tools")
 
@@ -258,15 +660,17 @@ func generateMCPTools(g *gen.Generator, outputPath string) error {
 		return fmt.Errorf("failed to create tools directory: %w", err)
 	}
 
-	for _, operation := range g.Operations() {
+	for _, operation := range info.operations {
 		// MCPツールファイルを生成
-		toolFilename := strings.ToLower(operation.Spec.OperationID) + "_tool.go"
+		toolFilename := strings.ToLower(operation.OperationID) + "_tool.go"
 		toolFilePath := filepath.Join(toolsDir, toolFilename)
 
 		// Jenniferを使ってコードを生成
 		if err := generateMCPToolWithJennifer(
 			operation,
+			info,
 			toolFilePath,
+			opts,
 		); err != nil {
 			return fmt.Errorf("failed to generate tool for %s: %w", operation.Name, err)
 		}
@@ -276,25 +680,13 @@ func generateMCPTools(g *gen.Generator, outputPath string) error {
 }
 
 // Jenniferを使用してMCPツールコードを生成
-func generateMCPToolWithJennifer(operation *ir.Operation, outputPath string) error {
-	// パッケージパスを準備
-	outputDir := filepath.Dir(outputPath)
-	basePath := strings.TrimSuffix(outputDir, "/tools")
-	modName := getModuleName()
+func generateMCPToolWithJennifer(operation *operation, info *clientInfo, outputPath string, opts generateOptions) error {
 	// クライアントパッケージへの参照
-	oasClient := modName + "/" + basePath + "/client"
+	oasClient := info.importPath + "/client"
 	// function
 	functions := "github.com/nonchan7720/oas-mcp/functions"
 
-	toolDescription := ""
-	switch {
-	case operation.Description != "":
-		toolDescription = operation.Description
-	case operation.Summary != "":
-		toolDescription = operation.Summary
-	case operation.Spec.Summary != "":
-		toolDescription = operation.Spec.Summary
-	}
+	toolDescription := operation.toolDescription()
 
 	// ファイル作成
 	f := jen.NewFile("tools")
@@ -308,211 +700,363 @@ func generateMCPToolWithJennifer(operation *ir.Operation, outputPath string) err
 	f.ImportName(functions, "functions")
 	f.ImportName(oasClient, "client")
 
-	// 関数コメント
-	f.Comment(fmt.Sprintf("%s is a MCP tool for %s", operation.Spec.OperationID, toolDescription))
-	// パスパラメータ、クエリパラメータ、リクエストボディの処理
-	hasPathParams := len(operation.PathParams()) > 0
-	hasQueryParams := len(operation.QueryParams()) > 0
-	hasParams := hasPathParams || hasQueryParams
-	hasRequestBody := operation.Request != nil
-	const (
-		reqParams = "RequestParameter"
-		reqBody   = "RequestBody"
-		input     = "input"
-	)
-	inputFields := []jen.Code{}
-	if hasParams {
-		inputFields = append(inputFields,
-			jen.Id(reqParams).Qual(oasClient, operation.Name+"Params").Op(
-				fmt.Sprintf("`json:\"requestParameter\" mcpdescription:\"%s\"`", operation.Description),
-			),
-		)
+	var tool *jen.Statement
+	// 関数の引数
+	toolParams := []jen.Code{
+		jen.Id("oasClient").Op("*").Qual(oasClient, info.clientType),
+	}
+	// フォールバックのツールと引数名が衝突するオペレーションは常にrequestParameterとrequestBodyで受け取る
+	flatInput := operation.flatInput(opts.flatInput)
+	if conflicts := operation.flatInputConflicts(); opts.flatInput && operation.Fallback == nil && len(conflicts) > 0 {
+		log.Printf("Using requestParameter and requestBody for %s: the parameters and the request body share the names %s", operation.toolName(), strings.Join(conflicts, ", "))
 	}
-	if hasRequestBody {
-		ope := ""
-		if operation.Request.DoTakePtr() {
-			ope = "*"
+	if operation.Fallback != nil {
+		tool = generateFallbackTool(f, operation, functions, toolDescription)
+		toolParams = []jen.Code{
+			jen.Id("doer").Qual(functions, "HTTPDoer"),
+			jen.Id("baseURL").String(),
 		}
-		inputFields = append(inputFields,
-			jen.Id(reqBody).Op(ope).Qual(oasClient, operation.Request.Type.Name).Op("`json:\"requestBody\"`"),
-		)
+	} else {
+		tool = generateClientTool(operation, info, oasClient, functions, toolDescription, flatInput)
+	}
+	// 引数の例を設定
+	if example := operationExample(operation, flatInput); len(example) > 0 {
+		tool = tool.Dot("WithExamples").Call(jsonLiteral(example))
+	}
+	// OpenAPIの既定値を入力スキーマに設定
+	if defaults := operationDefaults(operation, flatInput); len(defaults) > 0 {
+		tool = tool.Dot("WithPropertyDefaults").Call(jsonLiteral(defaults))
+	}
+	if title := operation.Summary; title != "" {
+		tool = tool.Dot("WithTitle").Call(jen.Lit(title))
+	}
+	// HTTPメソッドから安全性のアノテーションを設定
+	for _, hint := range annotationHints(operation.HTTPMethod) {
+		tool = tool.Dot(hint.method).Call(jen.Lit(hint.value))
+	}
+	// 大きな結果をクライアントのLLMで要約する（整形した後の結果を要約するため最も外側に置く）
+	if config := operation.Summarize; config != nil {
+		tool = tool.Dot("Use").Call(jen.Qual(functions, "Summarize").Call(jen.Qual(functions, "SummarizeOptions").ValuesFunc(func(g *jen.Group) {
+			if config.MinBytes > 0 {
+				g.Id("MinBytes").Op(":").Lit(config.MinBytes)
+			}
+			if config.MaxInputBytes > 0 {
+				g.Id("MaxInputBytes").Op(":").Lit(config.MaxInputBytes)
+			}
+			if config.MaxTokens > 0 {
+				g.Id("MaxTokens").Op(":").Lit(config.MaxTokens)
+			}
+			if config.Instructions != "" {
+				g.Id("Instructions").Op(":").Lit(config.Instructions)
+			}
+		})))
+	}
+	// 結果から空のフィールドを取り除く（レジストリの切り詰めやマスクより前に整形するため内側に置く）
+	if opts.stripEmpty {
+		tool = tool.Dot("UseInner").Call(jen.Qual(functions, "StripEmpty").Call())
+	}
+	// ネストしたオブジェクトを持ち上げる
+	if len(operation.Flatten) > 0 {
+		tool = tool.Dot("UseInner").Call(jen.Qual(functions, "Flatten").CallFunc(func(g *jen.Group) {
+			for _, path := range operation.Flatten {
+				g.Lit(path)
+			}
+		}))
+	}
+	// 202の後に完了までポーリングする（完了後の結果を整形するため最も内側に置く）
+	if config := operation.LongRunning; config != nil {
+		tool = tool.Dot("UseInner").Call(jen.Qual(functions, "LongRunning").Call(jen.Qual(functions, "LongRunningOptions").ValuesFunc(func(g *jen.Group) {
+			if config.Interval > 0 {
+				g.Id("Interval").Op(":").Add(durationLiteral(config.Interval))
+			}
+			if config.Timeout > 0 {
+				g.Id("Timeout").Op(":").Add(durationLiteral(config.Timeout))
+			}
+			if config.StatusURLField != "" {
+				g.Id("StatusURLField").Op(":").Lit(config.StatusURLField)
+			}
+			if config.StatusField != "" {
+				g.Id("StatusField").Op(":").Lit(config.StatusField)
+			}
+		})))
+	}
+	// 日時の正規化で使うフィールド
+	if len(operation.TimeFields) > 0 {
+		tool = tool.Dot("WithTimeFields").CallFunc(func(g *jen.Group) {
+			for _, path := range operation.TimeFields {
+				g.Lit(path)
+			}
+		})
+	}
+	// トレースでツールとオペレーションを対応付ける
+	if operation.OperationID != "" {
+		tool = tool.Dot("WithOperationID").Call(jen.Lit(operation.OperationID))
+	}
+	// タグでツールをグループ化
+	if tags := operation.Tags; len(tags) > 0 {
+		tool = tool.Dot("WithTags").CallFunc(func(g *jen.Group) {
+			for _, tag := range tags {
+				g.Lit(tag)
+			}
+		})
 	}
+	// 関数コメント
+	f.Comment(fmt.Sprintf("%s is a MCP tool for %s", operation.OperationID, toolDescription))
 	// 関数定義
 	f.Func().Id("New"+operation.Name+"Tool").Params(
-		jen.Id("oasClient").Op("*").Qual(oasClient, "Client"),
+		toolParams...,
 	).Op("*").Qual(functions, "Tool").Block(
-		jen.Return(
-			jen.Qual(functions, "NewFunctionTool").Call(
-				jen.Lit(operation.Name),
-				jen.Lit(toolDescription),
-				jen.Func().Params(
-					jen.Id("ctx").Qual("context", "Context"),
-					jen.Id(input).Struct(
-						inputFields...,
-					),
-				).Params(
-					jen.Any(),
-					jen.Error(),
-				).BlockFunc(func(g *jen.Group) {
-					g.Line()
-					requestArgs := []jen.Code{
-						jen.Id("ctx"),
-					}
-					if hasRequestBody {
-						requestArgs = append(requestArgs, jen.Id(input).Dot(reqBody))
-					}
-					if hasParams {
-						requestArgs = append(requestArgs, jen.Id(input).Dot(reqParams))
-					}
-					// クライアントを呼び出す（リクエストボディ + パラメータ）
-					g.Line()
-					g.Comment("クライアントを使用してAPIを呼び出し")
-					g.List(jen.Id("resp"), jen.Id("err")).Op(":=").Id("oasClient").Dot(operation.Name).Call(
-						requestArgs...,
-					)
-
-					g.If(jen.Id("err").Op("!=").Nil()).Block(
-						jen.Return(jen.Lit(""), jen.Id("err")),
-					)
-					g.Line()
-
-					// レスポンスをJSON文字列に変換
-					g.Comment("レスポンスをJSON文字列に変換")
-					g.List(jen.Id("resultBytes"), jen.Id("err")).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("resp"))
-					g.If(jen.Id("err").Op("!=").Nil()).Block(
-						jen.Return(jen.Lit(""), jen.Id("err")),
-					)
-					g.Line()
-					g.Return(jen.String().Call(jen.Id("resultBytes")), jen.Nil())
-				}),
-			),
-		),
+		jen.Return(tool),
 	)
 
 	// ファイルに保存
 	return f.Save(outputPath)
 }
 
-// MCP Serverを生成
-func generateMCPServer(g *gen.Generator, hasSecuritySchemes bool, outputPath string) error {
-	// サーバーディレクトリ
-	serverDir := filepath.Join(outputPath, "server")
-
-	// ディレクトリを作成
-	if err := os.MkdirAll(serverDir, 0755); err != nil {
-		return fmt.Errorf("failed to create server directory: %w", err)
+// クライアントを呼び出すツールを生成
+func generateClientTool(operation *operation, info *clientInfo, oasClient, functions, toolDescription string, flatInput bool) *jen.Statement {
+	// パラメータ、リクエストボディの処理
+	hasParams := operation.ParamsType != ""
+	hasRequestBody := operation.BodyType != ""
+	const (
+		reqParams = "RequestParameter"
+		reqBody   = "RequestBody"
+		input     = "input"
+	)
+	// 関数の引数とクライアント呼び出し時の引数
+	funcParams := []jen.Code{
+		jen.Id("ctx").Qual("context", "Context"),
 	}
-
-	// ツール名を収集
-	var toolNames []string
-	for _, operation := range g.Operations() {
-		toolNames = append(toolNames, operation.Name)
+	var paramsArg, bodyArg jen.Code
+	// フラット入力時の引数名 (空文字はトップレベルに展開)
+	var parameterNames []jen.Code
+	if flatInput {
+		if hasParams {
+			funcParams = append(funcParams, jen.Id("params").Qual(oasClient, operation.ParamsType))
+			paramsArg = jen.Id("params")
+			parameterNames = append(parameterNames, jen.Lit(""))
+		}
+		if hasRequestBody {
+			funcParams = append(funcParams, jen.Id("body").Add(bodyType(operation, oasClient)))
+			bodyArg = jen.Id("body")
+			// 構造体以外のリクエストボディは展開できないため名前付きの引数とする
+			name := ""
+			if !operation.BodyIsStruct {
+				name = "requestBody"
+			}
+			parameterNames = append(parameterNames, jen.Lit(name))
+		}
+	} else {
+		inputFields := []jen.Code{}
+		if hasParams {
+			inputFields = append(inputFields,
+				jen.Id(reqParams).Qual(oasClient, operation.ParamsType).Op(
+					fmt.Sprintf("`json:\"requestParameter\" mcpdescription:\"%s\" mcprequired:\"%t\"`", operation.Description, operation.ParamsRequired),
+				),
+			)
+			paramsArg = jen.Id(input).Dot(reqParams)
+		}
+		if hasRequestBody {
+			inputFields = append(inputFields,
+				jen.Id(reqBody).Add(bodyType(operation, oasClient)).Op(
+					fmt.Sprintf("`json:\"requestBody\" mcprequired:\"%t\"`", operation.BodyRequired),
+				),
+			)
+			bodyArg = jen.Id(input).Dot(reqBody)
+		}
+		funcParams = append(funcParams, jen.Id(input).Struct(
+			inputFields...,
+		))
 	}
-	// サーバーファイルパス
-	serverFilePath := filepath.Join(serverDir, "server.go")
-	// Jenniferを使ってサーバーコードを生成
-	return generateMCPServerWithJennifer(hasSecuritySchemes, toolNames, serverFilePath)
-}
+	tool := jen.Qual(functions, "NewFunctionTool").Call(
+		jen.Lit(operation.toolName()),
+		jen.Lit(toolDescription),
+		jen.Func().Params(
+			funcParams...,
+		).Params(
+			jen.Any(),
+			jen.Error(),
+		).BlockFunc(func(g *jen.Group) {
+			g.Line()
+			requestArgs := []jen.Code{
+				jen.Id("ctx"),
+			}
+			if hasRequestBody {
+				requestArgs = append(requestArgs, bodyArg)
+			}
+			if hasParams {
+				requestArgs = append(requestArgs, paramsArg)
+			}
+			// オペレーションのserversが指定されていればそのURLに送信する
+			if operation.ServerURL != "" && info.serverURLContext != "" {
+				g.Line()
+				g.Comment("オペレーションのserversで指定されたURLに送信")
+				g.Id("ctx").Op("=").Qual(oasClient, info.serverURLContext).Call(jen.Id("ctx"), urlLiteral(operation.ServerURL))
+			}
+			// クライアントを呼び出す（リクエストボディ + パラメータ）
+			g.Line()
+			g.Comment("クライアントを使用してAPIを呼び出し")
+			method := strings.ToUpper(operation.HTTPMethod)
+			if operation.NoResponseStatus != 0 {
+				// レスポンスの値が無いクライアントはエラーのみを返す
+				g.Id("err").Op(":=").Id("oasClient").Dot(operation.Name).Call(
+					requestArgs...,
+				)
+			} else {
+				g.List(jen.Id("resp"), jen.Id("err")).Op(":=").Id("oasClient").Dot(operation.Name).Call(
+					requestArgs...,
+				)
+			}
 
-// Jenniferを使用してMCPサーバーコードを生成
-func generateMCPServerWithJennifer(hasSecuritySource bool, toolNames []string, outputPath string) error {
-	// パッケージパスを準備
-	outputDir := filepath.Dir(outputPath)
-	basePath := strings.TrimSuffix(outputDir, "/server")
-	modName := getModuleName()
-	// クライアントパッケージへの参照
-	oasClient := modName + "/" + basePath + "/client"
-	// toolsパッケージへの参照
-	toolsPath := modName + "/" + basePath + "/tools"
+			g.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Return(jen.Lit(""), jen.Qual(functions, "NewUpstreamError").Call(jen.Id("err"))),
+			)
+			g.Line()
 
-	// ファイル作成
-	f := jen.NewFile("server")
+			// ボディの無いレスポンスは {} ではなく成功したことを伝える
+			response := jen.Id("resp")
+			switch {
+			case operation.NoResponseStatus != 0:
+				g.Comment("ボディの無いレスポンスは成功したことをテキストで返す")
+				g.Id("response").Op(":=").Qual(functions, "EmptyResponse").Values(jen.Dict{
+					jen.Id("Method"):     jen.Lit(method),
+					jen.Id("StatusCode"): jen.Lit(operation.NoResponseStatus),
+				})
+				response = jen.Id("response")
+				g.Line()
+			case len(operation.EmptyResponses) > 0:
+				g.Comment("ボディの無いレスポンスは成功したことをテキストで返す")
+				g.Var().Id("response").Any().Op("=").Id("resp")
+				g.Switch(jen.Any().Call(jen.Id("resp")).Assert(jen.Type())).BlockFunc(func(g *jen.Group) {
+					for _, name := range sortedKeys(operation.EmptyResponses) {
+						g.Case(jen.Op("*").Qual(oasClient, name), jen.Qual(oasClient, name)).Block(
+							jen.Id("response").Op("=").Qual(functions, "EmptyResponse").Values(jen.Dict{
+								jen.Id("Method"):     jen.Lit(method),
+								jen.Id("StatusCode"): jen.Lit(operation.EmptyResponses[name]),
+							}),
+						)
+					}
+				})
+				response = jen.Id("response")
+				g.Line()
+			}
 
-	// ファイルコメント
-	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+			// レスポンスを後処理
+			g.Comment("登録されたResponseProcessorでレスポンスを後処理")
+			g.List(jen.Id("result"), jen.Id("err")).Op(":=").Qual(functions, "ProcessResponse").Call(jen.Id("ctx"), response)
+			g.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Return(jen.Lit(""), jen.Id("err")),
+			)
+			// テキストのレスポンス（HTML、CSVなど）はそのまま返す
+			g.If(jen.List(jen.Id("text"), jen.Id("ok")).Op(":=").Id("result").Assert(jen.String()), jen.Id("ok")).Block(
+				jen.Return(jen.Id("text"), jen.Nil()),
+			)
+			g.Line()
 
-	// インポート
-	f.ImportName("context", "context")
-	f.ImportName("log/slog", "slog")
-	f.ImportName("net/http", "http")
-	f.ImportName("os", "os")
-	f.ImportName("os/signal", "signal")
-	f.ImportName("syscall", "syscall")
-	f.ImportName("github.com/mark3labs/mcp-go/server", "server")
-	// 生成されたOpenAPIクライアントとツールのパスを指定
-	f.ImportName(oasClient, "client")
-	f.ImportName(toolsPath, "tools")
-
-	funcBody := []jen.Code{
-		jen.Comment("シャットダウンハンドリング"),
-		jen.List(jen.Id("ctx"), jen.Id("stop")).Op(":=").Qual("os/signal", "NotifyContext").Call(
-			jen.Id("ctx"),
-			jen.Qual("syscall", "SIGINT"),
-			jen.Qual("syscall", "SIGTERM"),
-		),
-		jen.Defer().Id("stop").Call(),
-		// クライアント初期化
-		jen.Comment("クライアント初期化"),
-		jen.List(jen.Id("client"), jen.Id("err")).Op(":=").Qual(oasClient, "NewClient").CallFunc(func(g *jen.Group) {
-			g.Qual("os", "Getenv").Call(jen.Lit("API_BASE_URL"))
-			if hasSecuritySource {
-				g.Id("securitySource")
-			}
+			// レスポンスをJSON文字列に変換
+			g.Comment("レスポンスをJSON文字列に変換")
+			g.List(jen.Id("resultBytes"), jen.Id("err")).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("result"))
+			g.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Return(jen.Lit(""), jen.Qual(functions, "NewInternalError").Call(jen.Id("err"))),
+			)
+			g.Line()
+			g.Return(jen.String().Call(jen.Id("resultBytes")), jen.Nil())
 		}),
-		jen.If(jen.Id("err").Op("!=").Nil()).Block(
-			jen.Return(jen.Id("err")),
-		),
-		jen.Line(),
-		// MCPサーバー初期化
-		jen.Comment("MCPサーバー初期化"),
-		jen.Id("mcpServer").Op(":=").Qual("github.com/mark3labs/mcp-go/server", "NewMCPServer").Call(
-			jen.Id("name"),
-			jen.Id("version"),
-			jen.Id("opts").Op("..."),
-		),
-		jen.Comment("全ツールを登録"),
-	}
-
-	funcBody = append(funcBody,
-		jen.Id("mcpServer").Dot("AddTools").Call(jen.ListFunc(func(g *jen.Group) {
-			for _, toolName := range toolNames {
-				g.Qual(toolsPath, "New"+toolName+"Tool").Call(jen.Id("client")).Dot("ServerTool").Call()
-			}
-		})),
-		jen.Id("sse").Op(":=").Qual("github.com/mark3labs/mcp-go/server", "NewSSEServer").Call(
-			jen.Id("mcpServer"),
-		),
-		jen.Line(),
-		jen.Go().Func().Params().Block(
-			jen.Qual("log/slog", "InfoContext").Call(jen.Id("ctx"), jen.Lit("Start mcp server")),
-			jen.If(
-				jen.Id("err").Op(":=").Id("sse").Dot("Start").Params(jen.Id("addr")),
-				jen.Id("err").Op("!=").Nil().Op("&&").Id("err").Op("!=").Qual("net/http", "ErrServerClosed"),
-			).Block(
-				jen.Qual("log/slog", "Error").Call(jen.Lit("MCP server Shutdown.")),
-			),
-		).Call(),
-		jen.Op("<-").Id("ctx").Dot("Done").Call(),
-		jen.Id("stop").Call(),
-		jen.Qual("log/slog", "InfoContext").Params(jen.Id("ctx"), jen.Lit("Shutdown mcp server")),
-		jen.Return(jen.Nil()),
 	)
+	if len(parameterNames) > 0 {
+		tool = tool.Dot("WithParameterNames").Call(parameterNames...)
+	}
+	return tool
+}
 
-	// StartServer関数を追加
-	f.Comment("StartServer starts the MCP server with all generated tools")
-	f.Func().Id("StartServer").ParamsFunc(func(g *jen.Group) {
-		g.Id("ctx").Qual("context", "Context")
-		g.Id("name")
-		g.Id("version")
-		g.Id("addr").String()
-		if hasSecuritySource {
-			g.Id("securitySource").Qual(oasClient, "SecuritySource")
+// URLを *url.URL のリテラルに変換
+func urlLiteral(rawURL string) jen.Code {
+	u, _ := url.Parse(rawURL)
+	return jen.Op("&").Qual("net/url", "URL").Values(jen.DictFunc(func(d jen.Dict) {
+		d[jen.Id("Scheme")] = jen.Lit(u.Scheme)
+		d[jen.Id("Host")] = jen.Lit(u.Host)
+		if u.Path != "" {
+			d[jen.Id("Path")] = jen.Lit(u.Path)
 		}
-		g.List(jen.Id("opts").Op("...").Qual("github.com/mark3labs/mcp-go/server", "ServerOption"))
-	}).Error().Block(funcBody...)
+	}))
+}
 
-	// ファイルに保存
-	return f.Save(outputPath)
+// アノテーションのヒント
+type annotationHint struct {
+	method string
+	value  bool
+}
+
+// HTTPメソッドの意味からアノテーションのヒントを決定
+func annotationHints(httpMethod string) []annotationHint {
+	hints := []annotationHint{
+		{method: "WithOpenWorldHint", value: true},
+	}
+	switch strings.ToLower(httpMethod) {
+	case "get", "head", "options", "trace":
+		hints = append(hints,
+			annotationHint{method: "WithReadOnlyHint", value: true},
+			annotationHint{method: "WithIdempotentHint", value: true},
+		)
+	case "put":
+		hints = append(hints,
+			annotationHint{method: "WithReadOnlyHint", value: false},
+			annotationHint{method: "WithDestructiveHint", value: true},
+			annotationHint{method: "WithIdempotentHint", value: true},
+		)
+	case "delete":
+		hints = append(hints,
+			annotationHint{method: "WithReadOnlyHint", value: false},
+			annotationHint{method: "WithDestructiveHint", value: true},
+			annotationHint{method: "WithIdempotentHint", value: true},
+		)
+	case "post", "patch":
+		hints = append(hints,
+			annotationHint{method: "WithReadOnlyHint", value: false},
+			annotationHint{method: "WithIdempotentHint", value: false},
+		)
+	}
+	return hints
+}
+
+// 必須のパラメータが存在するか判定
+func hasRequiredParams(operation *ir.Operation) bool {
+	for _, param := range operation.Params {
+		if param.Spec != nil && param.Spec.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// リクエストボディの型
+func bodyType(operation *operation, oasClient string) jen.Code {
+	ope := ""
+	if operation.BodyPointer {
+		ope = "*"
+	}
+	// 組み込み型はクライアントパッケージに属さない
+	if operation.BodyType == "string" {
+		return jen.Op(ope).String()
+	}
+	return jen.Op(ope).Qual(oasClient, operation.BodyType)
+}
+
+// 仕様書のserversから既定のベースURLを取得
+// 変数は既定値で置き換え、相対URLは使用しない
+func defaultServerURL(servers []ogen.Server) string {
+	for _, server := range servers {
+		serverURL := server.URL
+		for name, variable := range server.Variables {
+			serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", variable.Default)
+		}
+		u, err := url.Parse(serverURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		return serverURL
+	}
+	return ""
 }
 
 // PathItemから操作を取得するヘルパー関数
@@ -537,6 +1081,12 @@ func getOperations(pathItem *ogen.PathItem) map[string]*ogen.Operation {
 	if pathItem.Options != nil {
 		operations["options"] = pathItem.Options
 	}
+	if pathItem.Head != nil {
+		operations["head"] = pathItem.Head
+	}
+	if pathItem.Trace != nil {
+		operations["trace"] = pathItem.Trace
+	}
 
 	return operations
 }
@@ -593,7 +1143,7 @@ func cleanDir(targetDir string, files []os.DirEntry) (rerr error) {
 		if !strings.HasSuffix(name, "_gen.go") && !strings.HasSuffix(name, "_gen_test.go") {
 			continue
 		}
-		if !strings.HasPrefix(name, "openapi") && !strings.HasPrefix(name, "oas") {
+		if !strings.HasPrefix(name, "openapi") && !strings.HasPrefix(name, "oas") && !strings.HasPrefix(name, "oapi") {
 			continue
 		}
 		// Do not return error if file does not exist.
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ツールのマニフェストのファイル名
+const manifestFileName = "tools.json"
+
+// 生成したツールのマニフェスト
+// ゲートウェイやカタログ、監査などがパッケージをコンパイルせずにツールを知るために出力する
+type toolsManifest struct {
+	Tools []manifestTool `json:"tools"`
+}
+
+// マニフェストのツール
+type manifestTool struct {
+	Name         string             `json:"name"`
+	Description  string             `json:"description,omitempty"`
+	InputSchema  map[string]any     `json:"inputSchema"`
+	OutputSchema map[string]any     `json:"outputSchema,omitempty"`
+	Operation    *manifestOperation `json:"operation,omitempty"`
+}
+
+// ツールの元のオペレーション
+type manifestOperation struct {
+	OperationID string   `json:"operationId"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// 生成したサーバーが公開するツールとオペレーションからマニフェストを作る
+// 説明と入力・出力のスキーマは tools/list で返すものをそのまま使う
+func buildToolsManifest(tools []servedTool, operations []*operation) *toolsManifest {
+	byName := make(map[string]*operation, len(operations))
+	for _, op := range operations {
+		byName[op.toolName()] = op
+	}
+	manifest := &toolsManifest{Tools: []manifestTool{}}
+	for _, tool := range tools {
+		t := manifestTool{
+			Name:         tool.Name,
+			Description:  tool.Description,
+			InputSchema:  tool.InputSchema,
+			OutputSchema: tool.OutputSchema,
+		}
+		if op := byName[tool.Name]; op != nil {
+			t.Operation = &manifestOperation{
+				OperationID: op.OperationID,
+				Method:      strings.ToUpper(op.HTTPMethod),
+				Path:        op.Path,
+				Tags:        op.Tags,
+			}
+		}
+		manifest.Tools = append(manifest.Tools, t)
+	}
+	return manifest
+}
+
+// 複数の仕様書をまとめる場合に、各サービスのマニフェストを1つにまとめる
+// ツール名はまとめたサーバーと同じくサービス名を接頭辞にする（既定の区切り文字 _ の場合）
+func mergeToolsManifests(sources []*specSource, outputPath string) error {
+	merged := &toolsManifest{Tools: []manifestTool{}}
+	for _, source := range sources {
+		data, err := os.ReadFile(filepath.Join(source.output, manifestFileName))
+		if err != nil {
+			return fmt.Errorf("failed to read tools manifest: %w", err)
+		}
+		var manifest toolsManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("failed to parse tools manifest of %s: %w", source.name, err)
+		}
+		for _, tool := range manifest.Tools {
+			tool.Name = source.name + "_" + tool.Name
+			merged.Tools = append(merged.Tools, tool)
+		}
+	}
+	return writeToolsManifest(merged, outputPath)
+}
+
+// マニフェストを出力ディレクトリに書き込む
+func writeToolsManifest(manifest *toolsManifest, outputPath string) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode tools manifest: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputPath, manifestFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write tools manifest: %w", err)
+	}
+	return nil
+}
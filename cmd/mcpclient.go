@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// MCPクライアントのトランスポート
+const (
+	transportSSE  = "sse"
+	transportHTTP = "http"
+)
+
+// 起動中のMCPサーバーに接続して初期化する
+// serverURL は SSE ではエンドポイント（例: http://localhost:8080/sse）、Streamable HTTP では /mcp のURL
+func connectMCP(ctx context.Context, serverURL, transportName string, headers http.Header) (*client.Client, error) {
+	hdr := make(map[string]string, len(headers))
+	for name := range headers {
+		hdr[name] = headers.Get(name)
+	}
+	var c *client.Client
+	var err error
+	switch transportName {
+	case transportSSE:
+		c, err = client.NewSSEMCPClient(serverURL, transport.WithHeaders(hdr))
+	case transportHTTP:
+		c, err = client.NewStreamableHttpClient(serverURL, transport.WithHTTPHeaders(hdr))
+	default:
+		return nil, fmt.Errorf("unknown transport %q: must be %s or %s", transportName, transportSSE, transportHTTP)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MCP client: %w", err)
+	}
+	if err := c.Start(ctx); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", serverURL, err)
+	}
+	req := mcp.InitializeRequest{}
+	req.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	req.Params.ClientInfo = mcp.Implementation{Name: "oas-mcp", Version: "0.0.0"}
+	if _, err := c.Initialize(ctx, req); err != nil {
+		_ = c.Close()
+		return nil, fmt.Errorf("failed to initialize the MCP session: %w", err)
+	}
+	return c, nil
+}
+
+// ツール呼び出しの結果のテキスト
+func resultText(res *mcp.CallToolResult) string {
+	var text string
+	for _, content := range res.Content {
+		if c, ok := mcp.AsTextContent(content); ok {
+			text += c.Text
+		}
+	}
+	return text
+}
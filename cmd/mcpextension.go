@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-faster/yaml"
+	"github.com/ogen-go/ogen/jsonschema"
+)
+
+// 仕様書でMCPへの公開を制御する拡張
+const (
+	// true のオペレーションはツールを生成せず、パラメータはツールの引数にしない
+	extensionMCPIgnore = "x-mcp-ignore"
+	// オペレーションのツール名、パラメータの引数名
+	extensionMCPName = "x-mcp-name"
+	// ツールと引数の説明（description を置き換える）
+	extensionMCPDescription = "x-mcp-description"
+)
+
+// x-mcp-ignore: true のオペレーションとパラメータを仕様書から取り除く
+// クライアントも生成せず、生成したサーバーに埋め込む仕様書にも含めない
+// 必須のパラメータは取り除くとAPIを呼び出せないため、警告を出して残す
+func ignoreMCPExtension(spec []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return spec, nil
+	}
+	root := doc.Content[0]
+	paths := mappingValue(root, "paths")
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return spec, nil
+	}
+	var components *yaml.Node
+	if c := mappingValue(root, "components"); c != nil {
+		components = mappingValue(c, "parameters")
+	}
+
+	changed := false
+	// パラメータの一覧から x-mcp-ignore のパラメータを取り除く
+	filterParameters := func(node *yaml.Node, where string) {
+		params := mappingValue(node, "parameters")
+		if params == nil || params.Kind != yaml.SequenceNode {
+			return
+		}
+		var content []*yaml.Node
+		for _, param := range params.Content {
+			resolved := param
+			if ref := mappingValue(param, "$ref"); ref != nil {
+				name, ok := strings.CutPrefix(ref.Value, "#/components/parameters/")
+				if ok && components != nil {
+					resolved = mappingValue(components, name)
+				}
+			}
+			if resolved == nil || !extensionTrue(resolved, extensionMCPIgnore) {
+				content = append(content, param)
+				continue
+			}
+			var name string
+			if n := mappingValue(resolved, "name"); n != nil {
+				name = n.Value
+			}
+			in := mappingValue(resolved, "in")
+			if (in != nil && in.Value == "path") || extensionTrue(resolved, "required") {
+				log.Printf("%s on the required parameter %s of %s is ignored", extensionMCPIgnore, name, where)
+				content = append(content, param)
+				continue
+			}
+			log.Printf("Ignoring parameter %s of %s (%s)", name, where, extensionMCPIgnore)
+			changed = true
+		}
+		params.Content = content
+	}
+
+	var content []*yaml.Node
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path, item := paths.Content[i].Value, paths.Content[i+1]
+		if item.Kind != yaml.MappingNode || mappingValue(item, "$ref") != nil {
+			content = append(content, paths.Content[i], item)
+			continue
+		}
+		filterParameters(item, path)
+		var itemContent []*yaml.Node
+		operations, ignored := 0, 0
+		for j := 0; j+1 < len(item.Content); j += 2 {
+			key, value := item.Content[j], item.Content[j+1]
+			method := strings.ToLower(key.Value)
+			if slices.Contains(operationMethods, method) && value.Kind == yaml.MappingNode {
+				if extensionTrue(value, extensionMCPIgnore) {
+					log.Printf("Ignoring %s %s (%s)", strings.ToUpper(method), path, extensionMCPIgnore)
+					ignored++
+					continue
+				}
+				filterParameters(value, strings.ToUpper(method)+" "+path)
+				operations++
+			}
+			itemContent = append(itemContent, key, value)
+		}
+		if ignored > 0 {
+			changed = true
+			item.Content = itemContent
+			if operations == 0 {
+				continue
+			}
+		}
+		content = append(content, paths.Content[i], item)
+	}
+	if !changed {
+		return spec, nil
+	}
+	if len(content) == 0 {
+		return nil, fmt.Errorf("every operation of the OpenAPI spec is ignored by %s", extensionMCPIgnore)
+	}
+	paths.Content = content
+	ignoredSpec, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	return ignoredSpec, nil
+}
+
+// x-mcp-description でオペレーション、パラメータ、スキーマなどの description を置き換える
+// ツールと引数の説明だけを変えるため、生成したサーバーに埋め込む仕様書には適用しない
+func describeMCPExtension(spec []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if !describeNode(&doc, 0) {
+		return spec, nil
+	}
+	described, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	return described, nil
+}
+
+// ノード以下の説明を置き換え、置き換えたかを返す
+func describeNode(node *yaml.Node, depth int) bool {
+	if node == nil || depth > 128 {
+		return false
+	}
+	changed := false
+	if node.Kind == yaml.MappingNode {
+		if description := mappingValue(node, extensionMCPDescription); description != nil && description.Kind == yaml.ScalarNode {
+			setMappingValue(node, "description", description.Value)
+			changed = true
+		}
+	}
+	for _, child := range node.Content {
+		if describeNode(child, depth+1) {
+			changed = true
+		}
+	}
+	return changed
+}
+
+// 真偽値の拡張が true か
+func extensionTrue(node *yaml.Node, key string) bool {
+	value := mappingValue(node, key)
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return false
+	}
+	b, _ := strconv.ParseBool(value.Value)
+	return b
+}
+
+// 名前を指定する拡張の値（文字列以外は警告を出して無視する）
+func extensionString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+	default:
+		log.Printf("%s: unsupported value %v", extensionMCPName, value)
+	}
+	return ""
+}
+
+// ogen の仕様書の拡張の文字列
+func ogenExtensionString(extensions jsonschema.Extensions, name string) string {
+	node, ok := extensions[name]
+	if !ok {
+		return ""
+	}
+	var value any
+	if err := node.Decode(&value); err != nil {
+		log.Printf("%s: %v", name, err)
+		return ""
+	}
+	return extensionString(value)
+}
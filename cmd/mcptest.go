@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// 生成したサーバーをインメモリのMCPクライアントで呼び出す統合テストのハーネスを生成
+// モックの上流APIに対して全ツールを呼び出すテストも合わせて生成する
+func generateMCPTest(info *clientInfo, outputPath string, opts generateOptions) error {
+	testDir := filepath.Join(outputPath, "mcptest")
+
+	// ディレクトリを作成
+	if err := os.MkdirAll(testDir, 0755); err != nil {
+		return fmt.Errorf("failed to create mcptest directory: %w", err)
+	}
+
+	// パッケージパスを準備
+	basePath := info.importPath
+	serverPath := basePath + "/server"
+	mockPath := basePath + "/mock"
+	functions := "github.com/nonchan7720/oas-mcp/functions"
+	mcpClientPkg := "github.com/mark3labs/mcp-go/client"
+	mcpPkg := "github.com/mark3labs/mcp-go/mcp"
+
+	f := jen.NewFile("mcptest")
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+	f.PackageComment("Package mcptest runs the generated MCP server in process against the mock upstream API.")
+	f.ImportName(serverPath, "server")
+	f.ImportName(mockPath, "mock")
+	f.ImportName(mcpClientPkg, "client")
+	f.ImportName(mcpPkg, "mcp")
+
+	// ツール呼び出し
+	f.Comment("Call is a tool call exercised by the generated test.")
+	f.Type().Id("Call").Struct(
+		jen.Id("Tool").String(),
+		jen.Comment("Arguments are the example arguments of the operation, or nil to sample them from the input schema"),
+		jen.Id("Arguments").Map(jen.String()).Any(),
+		jen.Comment("Defaults are the defaults of the OpenAPI spec the input schema declares, in the shape of the arguments"),
+		jen.Id("Defaults").Map(jen.String()).Any(),
+	)
+	f.Line()
+	f.Comment("Calls are the calls of every generated tool.")
+	f.Var().Id("Calls").Op("=").Index().Id("Call").ValuesFunc(func(g *jen.Group) {
+		for _, operation := range info.operations {
+			flatInput := operation.flatInput(opts.flatInput)
+			values := jen.Dict{jen.Id("Tool"): jen.Lit(operation.toolName())}
+			if example := operationExample(operation, flatInput); len(example) > 0 {
+				values[jen.Id("Arguments")] = jsonLiteral(example)
+			}
+			if defaults := operationDefaults(operation, flatInput); len(defaults) > 0 {
+				values[jen.Id("Defaults")] = jsonLiteral(defaults)
+			}
+			g.Line().Values(values)
+		}
+		g.Line()
+	})
+	f.Line()
+	f.Comment("Options are applied to the server of the generated test, e.g. WithSecurity.")
+	f.Comment("Set them from an init function in a test file of this package.")
+	f.Var().Id("Options").Index().Qual(serverPath, "Option")
+	f.Line()
+
+	// ハーネス
+	f.Comment("Harness is the generated MCP server connected to an in-memory MCP client.")
+	f.Comment("The tools call the mock upstream API, whose responses can be replaced with Mock.")
+	f.Type().Id("Harness").Struct(
+		jen.Id("Client").Op("*").Qual(mcpClientPkg, "Client"),
+		jen.Id("Mock").Op("*").Qual(mockPath, "Server"),
+		jen.Id("Upstream").Op("*").Qual("net/http/httptest", "Server"),
+	)
+	f.Line()
+	f.Comment("New starts the mock upstream API and the MCP server, and initializes an in-process client.")
+	f.Comment("opts are applied after the base URL of the mock. Everything is closed at the end of the test.")
+	f.Func().Id("New").Params(
+		jen.Id("t").Qual("testing", "TB"),
+		jen.Id("opts").Op("...").Qual(serverPath, "Option"),
+	).Op("*").Id("Harness").Block(
+		jen.Id("t").Dot("Helper").Call(),
+		jen.Id("m").Op(":=").Qual(mockPath, "NewServer").Call(),
+		jen.Id("upstream").Op(":=").Qual("net/http/httptest", "NewServer").Call(jen.Id("m")),
+		jen.Id("t").Dot("Cleanup").Call(jen.Id("upstream").Dot("Close")),
+		jen.Line(),
+		jen.List(jen.Id("mcpServer"), jen.Id("err")).Op(":=").Qual(serverPath, "NewMCPServer").Call(
+			jen.Lit("mcptest"),
+			jen.Lit("0.0.0"),
+			jen.Append(
+				jen.Index().Qual(serverPath, "Option").Values(jen.Qual(serverPath, "WithBaseURL").Call(jen.Id("upstream").Dot("URL"))),
+				jen.Id("opts").Op("..."),
+			).Op("..."),
+		),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Id("t").Dot("Fatalf").Call(jen.Lit("create MCP server: %v"), jen.Id("err")),
+		),
+		jen.List(jen.Id("c"), jen.Id("err")).Op(":=").Qual(mcpClientPkg, "NewInProcessClient").Call(jen.Id("mcpServer")),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Id("t").Dot("Fatalf").Call(jen.Lit("create MCP client: %v"), jen.Id("err")),
+		),
+		jen.Id("t").Dot("Cleanup").Call(jen.Func().Params().Block(
+			jen.Id("_").Op("=").Id("c").Dot("Close").Call(),
+		)),
+		jen.Line(),
+		jen.Id("ctx").Op(":=").Qual("context", "Background").Call(),
+		jen.If(jen.Id("err").Op(":=").Id("c").Dot("Start").Call(jen.Id("ctx")), jen.Id("err").Op("!=").Nil()).Block(
+			jen.Id("t").Dot("Fatalf").Call(jen.Lit("start MCP client: %v"), jen.Id("err")),
+		),
+		jen.Id("req").Op(":=").Qual(mcpPkg, "InitializeRequest").Values(),
+		jen.Id("req").Dot("Params").Dot("ProtocolVersion").Op("=").Qual(mcpPkg, "LATEST_PROTOCOL_VERSION"),
+		jen.Id("req").Dot("Params").Dot("ClientInfo").Op("=").Qual(mcpPkg, "Implementation").Values(jen.Dict{
+			jen.Id("Name"):    jen.Lit("mcptest"),
+			jen.Id("Version"): jen.Lit("0.0.0"),
+		}),
+		jen.If(
+			jen.List(jen.Id("_"), jen.Id("err")).Op(":=").Id("c").Dot("Initialize").Call(jen.Id("ctx"), jen.Id("req")),
+			jen.Id("err").Op("!=").Nil(),
+		).Block(
+			jen.Id("t").Dot("Fatalf").Call(jen.Lit("initialize MCP client: %v"), jen.Id("err")),
+		),
+		jen.Return(jen.Op("&").Id("Harness").Values(jen.Dict{
+			jen.Id("Client"):   jen.Id("c"),
+			jen.Id("Mock"):     jen.Id("m"),
+			jen.Id("Upstream"): jen.Id("upstream"),
+		})),
+	)
+	f.Line()
+	f.Comment("ListTools returns the listed tools by name.")
+	f.Func().Params(jen.Id("h").Op("*").Id("Harness")).Id("ListTools").Params(
+		jen.Id("t").Qual("testing", "TB"),
+	).Map(jen.String()).Qual(mcpPkg, "Tool").Block(
+		jen.Id("t").Dot("Helper").Call(),
+		jen.List(jen.Id("res"), jen.Id("err")).Op(":=").Id("h").Dot("Client").Dot("ListTools").Call(
+			jen.Qual("context", "Background").Call(),
+			jen.Qual(mcpPkg, "ListToolsRequest").Values(),
+		),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Id("t").Dot("Fatalf").Call(jen.Lit("list tools: %v"), jen.Id("err")),
+		),
+		jen.Id("tools").Op(":=").Make(jen.Map(jen.String()).Qual(mcpPkg, "Tool"), jen.Len(jen.Id("res").Dot("Tools"))),
+		jen.For(jen.List(jen.Id("_"), jen.Id("tool")).Op(":=").Range().Id("res").Dot("Tools")).Block(
+			jen.Id("tools").Index(jen.Id("tool").Dot("Name")).Op("=").Id("tool"),
+		),
+		jen.Return(jen.Id("tools")),
+	)
+	f.Line()
+	f.Comment("CallTool calls the tool and fails the test on a protocol error.")
+	f.Comment("Errors of the tool are returned in the result.")
+	f.Func().Params(jen.Id("h").Op("*").Id("Harness")).Id("CallTool").Params(
+		jen.Id("t").Qual("testing", "TB"),
+		jen.Id("name").String(),
+		jen.Id("args").Map(jen.String()).Any(),
+	).Op("*").Qual(mcpPkg, "CallToolResult").Block(
+		jen.Id("t").Dot("Helper").Call(),
+		jen.Id("req").Op(":=").Qual(mcpPkg, "CallToolRequest").Values(),
+		jen.Id("req").Dot("Params").Dot("Name").Op("=").Id("name"),
+		jen.Id("req").Dot("Params").Dot("Arguments").Op("=").Id("args"),
+		jen.List(jen.Id("res"), jen.Id("err")).Op(":=").Id("h").Dot("Client").Dot("CallTool").Call(jen.Qual("context", "Background").Call(), jen.Id("req")),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Id("t").Dot("Fatalf").Call(jen.Lit("call %s: %v"), jen.Id("name"), jen.Id("err")),
+		),
+		jen.Return(jen.Id("res")),
+	)
+	f.Line()
+	f.Comment("ResultText returns the text contents of the result.")
+	f.Func().Id("ResultText").Params(jen.Id("res").Op("*").Qual(mcpPkg, "CallToolResult")).String().Block(
+		jen.Var().Id("text").Qual("strings", "Builder"),
+		jen.For(jen.List(jen.Id("_"), jen.Id("content")).Op(":=").Range().Id("res").Dot("Content")).Block(
+			jen.If(jen.List(jen.Id("c"), jen.Id("ok")).Op(":=").Qual(mcpPkg, "AsTextContent").Call(jen.Id("content")), jen.Id("ok")).Block(
+				jen.Id("text").Dot("WriteString").Call(jen.Id("c").Dot("Text")),
+			),
+		),
+		jen.Return(jen.Id("text").Dot("String").Call()),
+	)
+	if err := f.Save(filepath.Join(testDir, "mcptest.go")); err != nil {
+		return err
+	}
+
+	// 全ツールを呼び出すテスト
+	t := jen.NewFile("mcptest")
+	t.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+	t.ImportName(functions, "functions")
+	t.ImportName(mcpPkg, "mcp")
+	skipWithoutSecurity := func(g *jen.Group) {
+		if info.hasSecuritySource {
+			g.If(jen.Len(jen.Id("Options")).Op("==").Lit(0)).Block(
+				jen.Id("t").Dot("Skip").Call(jen.Lit("the API requires a security source: set Options with server.WithSecurity")),
+			)
+		}
+	}
+	t.Func().Id("TestListTools").Params(jen.Id("t").Op("*").Qual("testing", "T")).BlockFunc(func(g *jen.Group) {
+		skipWithoutSecurity(g)
+		g.Id("tools").Op(":=").Id("New").Call(jen.Id("t"), jen.Id("Options").Op("...")).Dot("ListTools").Call(jen.Id("t"))
+		g.For(jen.List(jen.Id("_"), jen.Id("call")).Op(":=").Range().Id("Calls")).Block(
+			jen.List(jen.Id("tool"), jen.Id("ok")).Op(":=").Id("tools").Index(jen.Id("call").Dot("Tool")),
+			jen.If(jen.Op("!").Id("ok")).Block(
+				jen.Id("t").Dot("Errorf").Call(jen.Lit("tool %s is not listed"), jen.Id("call").Dot("Tool")),
+				jen.Continue(),
+			),
+			jen.If(
+				jen.Id("missing").Op(":=").Id("missingDefaults").Call(jen.Id("tool").Dot("InputSchema").Dot("Properties"), jen.Id("call").Dot("Defaults"), jen.Lit("")),
+				jen.Len(jen.Id("missing")).Op(">").Lit(0),
+			).Block(
+				jen.Id("t").Dot("Errorf").Call(jen.Lit("tool %s does not declare the defaults of %v"), jen.Id("call").Dot("Tool"), jen.Id("missing")),
+			),
+		)
+	})
+	t.Line()
+	t.Comment("missingDefaults returns the paths of the defaults the schema properties do not declare.")
+	t.Func().Id("missingDefaults").Params(
+		jen.Id("properties").Map(jen.String()).Any(),
+		jen.Id("defaults").Map(jen.String()).Any(),
+		jen.Id("path").String(),
+	).Index().String().Block(
+		jen.Var().Id("missing").Index().String(),
+		jen.For(jen.List(jen.Id("name"), jen.Id("def")).Op(":=").Range().Id("defaults")).Block(
+			jen.List(jen.Id("property"), jen.Id("_")).Op(":=").Id("properties").Index(jen.Id("name")).Assert(jen.Map(jen.String()).Any()),
+			jen.List(jen.Id("nested"), jen.Id("_")).Op(":=").Id("property").Index(jen.Lit("properties")).Assert(jen.Map(jen.String()).Any()),
+			jen.If(jen.List(jen.Id("values"), jen.Id("ok")).Op(":=").Id("def").Assert(jen.Map(jen.String()).Any()), jen.Id("ok").Op("&&").Id("nested").Op("!=").Nil()).Block(
+				jen.Id("missing").Op("=").Append(jen.Id("missing"), jen.Id("missingDefaults").Call(jen.Id("nested"), jen.Id("values"), jen.Id("path").Op("+").Id("name").Op("+").Lit(".")).Op("...")),
+				jen.Continue(),
+			),
+			jen.List(jen.Id("got"), jen.Id("_")).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("property").Index(jen.Lit("default"))),
+			jen.List(jen.Id("want"), jen.Id("_")).Op(":=").Qual("encoding/json", "Marshal").Call(jen.Id("def")),
+			jen.If(jen.Op("!").Qual("bytes", "Equal").Call(jen.Id("got"), jen.Id("want"))).Block(
+				jen.Id("missing").Op("=").Append(jen.Id("missing"), jen.Id("path").Op("+").Id("name")),
+			),
+		),
+		jen.Return(jen.Id("missing")),
+	)
+	t.Line()
+	t.Func().Id("TestCallTools").Params(jen.Id("t").Op("*").Qual("testing", "T")).BlockFunc(func(g *jen.Group) {
+		skipWithoutSecurity(g)
+		g.Id("h").Op(":=").Id("New").Call(jen.Id("t"), jen.Id("Options").Op("..."))
+		g.Id("tools").Op(":=").Id("h").Dot("ListTools").Call(jen.Id("t"))
+		g.For(jen.List(jen.Id("_"), jen.Id("call")).Op(":=").Range().Id("Calls")).Block(
+			jen.Id("t").Dot("Run").Call(jen.Id("call").Dot("Tool"), jen.Func().Params(jen.Id("t").Op("*").Qual("testing", "T")).Block(
+				jen.List(jen.Id("tool"), jen.Id("ok")).Op(":=").Id("tools").Index(jen.Id("call").Dot("Tool")),
+				jen.If(jen.Op("!").Id("ok")).Block(
+					jen.Id("t").Dot("Skipf").Call(jen.Lit("tool %s is not listed"), jen.Id("call").Dot("Tool")),
+				),
+				jen.Id("args").Op(":=").Id("call").Dot("Arguments"),
+				jen.If(jen.Id("args").Op("==").Nil()).Block(
+					jen.Id("args").Op("=").Qual(functions, "SampleArguments").Call(jen.Id("tool").Dot("InputSchema")),
+				),
+				jen.Id("res").Op(":=").Id("h").Dot("CallTool").Call(jen.Id("t"), jen.Id("call").Dot("Tool"), jen.Id("args")),
+				jen.If(jen.Id("res").Dot("IsError")).Block(
+					jen.Id("t").Dot("Errorf").Call(jen.Lit("%s(%v): %s"), jen.Id("call").Dot("Tool"), jen.Id("args"), jen.Id("ResultText").Call(jen.Id("res"))),
+				),
+			)),
+		)
+	})
+	return t.Save(filepath.Join(testDir, "mcptest_test.go"))
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// 生成する仕様書
+type specSource struct {
+	// サービス名（複数の仕様書をまとめる場合のツール名の接頭辞と出力先のディレクトリ名）
+	name string
+	// 仕様書のパスまたはURL
+	location string
+	// 出力先のディレクトリ
+	output string
+	// 出力先のディレクトリのインポートパス
+	importPath string
+	// 仕様書の info から作ったサーバーの既定の名前とバージョン
+	title   string
+	version string
+	// 生成したクライアントの情報
+	info *clientInfo
+}
+
+var (
+	// name=path の name として扱う文字列
+	specSourceName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+	// サービス名（Goのパッケージ名とツール名の接頭辞に使える名前）
+	serviceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+	// サービス名に使えない文字
+	serviceNameInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+)
+
+// name=path の形式からサービス名と仕様書を分ける（名前が無い場合は空）
+func splitSpecSource(value string) (name, location string) {
+	if name, location, ok := strings.Cut(value, "="); ok && specSourceName.MatchString(name) {
+		return name, location
+	}
+	return "", value
+}
+
+// -path の値から生成する仕様書を作る
+// 名前を省略した場合は仕様書のファイル名をサービス名にする
+func parseSpecSources(values []string) ([]*specSource, error) {
+	var sources []*specSource
+	for _, value := range values {
+		name, location := splitSpecSource(value)
+		if name == "" {
+			name = defaultServiceName(location)
+		}
+		sources = append(sources, &specSource{name: name, location: location})
+	}
+	if len(sources) == 1 {
+		return sources, nil
+	}
+	names := map[string]string{}
+	for _, source := range sources {
+		if !serviceNamePattern.MatchString(source.name) {
+			return nil, fmt.Errorf("invalid service name %q of %s: use lower case letters, digits and _, e.g. -path billing=%s", source.name, source.location, source.location)
+		}
+		// 出力ディレクトリの server はまとめたサーバーに使う
+		if source.name == "server" {
+			return nil, fmt.Errorf("service name %q of %s is reserved: name the spec with name=path", source.name, source.location)
+		}
+		if other, ok := names[source.name]; ok {
+			return nil, fmt.Errorf("specs %s and %s have the same service name %q: name them with name=path", other, source.location, source.name)
+		}
+		names[source.name] = source.location
+	}
+	return sources, nil
+}
+
+// 仕様書のファイル名（拡張子を除く）からサービス名を作る
+func defaultServiceName(location string) string {
+	base := filepath.Base(location)
+	if isRemoteSpec(location) {
+		if u, err := url.Parse(location); err == nil {
+			base = path.Base(u.Path)
+		}
+	}
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return strings.Trim(serviceNameInvalid.ReplaceAllString(strings.ToLower(base), "_"), "_")
+}
+
+// サービス名から関数名に使う名前を作る（例: billing_v2 は BillingV2）
+func serviceIdentifier(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// 各仕様書のサーバーを1つのMCPサーバーにまとめるパッケージを出力ディレクトリの server に生成する
+// ツールとプロンプトの名前の重複は functions.Aggregator がサービス名の接頭辞で避ける
+func generateMergedServer(sources []*specSource, transports []string, outputPath string) error {
+	serverDir := filepath.Join(outputPath, "server")
+	if err := os.MkdirAll(serverDir, 0755); err != nil {
+		return fmt.Errorf("failed to create server directory: %w", err)
+	}
+
+	functions := "github.com/nonchan7720/oas-mcp/functions"
+	mcpServerPkg := "github.com/mark3labs/mcp-go/server"
+
+	f := jen.NewFile("server")
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+	f.ImportName("context", "context")
+	f.ImportName("log/slog", "slog")
+	f.ImportName("net/http", "http")
+	f.ImportName(mcpServerPkg, "server")
+	f.ImportName(functions, "functions")
+	// 各サービスのサーバーパッケージ（ローカル変数と衝突しないよう接尾辞を付ける）
+	servicePkgs := map[string]string{}
+	for _, source := range sources {
+		servicePkgs[source.name] = source.importPath + "/server"
+		f.ImportAlias(servicePkgs[source.name], source.name+"server")
+	}
+
+	f.Comment("Services are the names of the merged services. Their tools and prompts are prefixed")
+	f.Comment("with the name and the separator, e.g. the tools of " + sources[0].name + " are named " + sources[0].name + "_<tool>.")
+	f.Var().Id("Services").Op("=").Index().String().ValuesFunc(func(g *jen.Group) {
+		for _, source := range sources {
+			g.Lit(source.name)
+		}
+	})
+	f.Line()
+
+	f.Comment("Instructions are sent to the clients on initialization, joining those of the services.")
+	f.Comment("WithServerOptions(server.WithInstructions(...)) replaces them.")
+	f.Var().Id("Instructions").Op("=").Qual("strings", "Join").Call(
+		jen.Index().String().ValuesFunc(func(g *jen.Group) {
+			for _, source := range sources {
+				g.Line().Lit("## "+source.name+"\n\n").Op("+").Qual(servicePkgs[source.name], "Instructions")
+			}
+			g.Line()
+		}),
+		jen.Lit("\n\n"),
+	)
+	f.Line()
+
+	// options 構造体のフィールド
+	fields := []serverOptionField{
+		{name: "serverOptions", typ: jen.Index().Qual(mcpServerPkg, "ServerOption")},
+		{name: "separator", typ: jen.String()},
+		{name: "logger", typ: jen.Op("*").Qual("log/slog", "Logger")},
+		{name: "basePath", typ: jen.String()},
+		{name: "webSocketOrigins", typ: jen.Index().String()},
+	}
+	options := []serverOption{
+		{
+			name:    "WithServerOptions",
+			comment: []string{"WithServerOptions adds options of the MCP server hosting the services, e.g. server.WithLogging()."},
+			params:  []jen.Code{jen.Id("opts").Op("...").Qual(mcpServerPkg, "ServerOption")},
+			body: []jen.Code{
+				jen.Id("o").Dot("serverOptions").Op("=").Append(jen.Id("o").Dot("serverOptions"), jen.Id("opts").Op("...")),
+			},
+		},
+		{
+			name: "WithSeparator",
+			comment: []string{
+				"WithSeparator sets the separator between the service name and the names of its tools",
+				"and prompts. It defaults to \"_\".",
+			},
+			params: []jen.Code{jen.Id("separator").String()},
+			body: []jen.Code{
+				jen.Id("o").Dot("separator").Op("=").Id("separator"),
+			},
+		},
+		{
+			name:    "WithLogger",
+			comment: []string{"WithLogger sets the logger of StartServer. It defaults to slog.Default()."},
+			params:  []jen.Code{jen.Id("logger").Op("*").Qual("log/slog", "Logger")},
+			body: []jen.Code{
+				jen.Id("o").Dot("logger").Op("=").Id("logger"),
+			},
+		},
+		{
+			name:    "WithBasePath",
+			comment: []string{"WithBasePath serves the endpoints of NewHandler under the path, e.g. /api."},
+			params:  []jen.Code{jen.Id("basePath").String()},
+			body: []jen.Code{
+				jen.Id("o").Dot("basePath").Op("=").Id("basePath"),
+			},
+		},
+		{
+			name:    "WithWebSocketOrigins",
+			comment: []string{"WithWebSocketOrigins sets the origins allowed to open the WebSocket transport."},
+			params:  []jen.Code{jen.Id("origins").Op("...").String()},
+			body: []jen.Code{
+				jen.Id("o").Dot("webSocketOrigins").Op("=").Append(jen.Id("o").Dot("webSocketOrigins"), jen.Id("origins").Op("...")),
+			},
+		},
+	}
+	for _, source := range sources {
+		fields = append(fields, serverOptionField{name: source.name + "Options", typ: jen.Index().Qual(servicePkgs[source.name], "Option")})
+		options = append(options, serverOption{
+			name: "With" + serviceIdentifier(source.name) + "Options",
+			comment: []string{
+				fmt.Sprintf("With%sOptions adds options of the %s service, e.g. WithBaseURL or WithSecurity.", serviceIdentifier(source.name), source.name),
+				fmt.Sprintf("Its base URL defaults to the %s environment variable.", baseURLEnv(source.name)),
+			},
+			params: []jen.Code{jen.Id("opts").Op("...").Qual(servicePkgs[source.name], "Option")},
+			body: []jen.Code{
+				jen.Id("o").Dot(source.name+"Options").Op("=").Append(jen.Id("o").Dot(source.name+"Options"), jen.Id("opts").Op("...")),
+			},
+		})
+	}
+
+	f.Comment("Option configures NewAggregator, NewHandler and StartServer.")
+	f.Type().Id("Option").Func().Params(jen.Op("*").Id("options"))
+	f.Line()
+	f.Type().Id("options").StructFunc(func(g *jen.Group) {
+		for _, field := range fields {
+			g.Id(field.name).Add(field.typ)
+		}
+	})
+	f.Line()
+	for _, option := range options {
+		for _, comment := range option.comment {
+			f.Comment(comment)
+		}
+		f.Func().Id(option.name).Params(option.params...).Id("Option").Block(
+			jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("options")).Block(option.body...)),
+		)
+		f.Line()
+	}
+	f.Func().Id("newOptions").Params(jen.Id("opts").Index().Id("Option")).Op("*").Id("options").Block(
+		jen.Id("o").Op(":=").Op("&").Id("options").Values(jen.Dict{
+			jen.Id("logger"): jen.Qual("log/slog", "Default").Call(),
+		}),
+		jen.For(jen.List(jen.Id("_"), jen.Id("opt")).Op(":=").Range().Id("opts")).Block(
+			jen.Id("opt").Call(jen.Id("o")),
+		),
+		jen.Return(jen.Id("o")),
+	)
+	f.Line()
+
+	// サービスごとにサーバーを作成して追加する
+	aggregatorBody := []jen.Code{
+		jen.Id("serverOptions").Op(":=").Append(
+			jen.Index().Qual(mcpServerPkg, "ServerOption").Values(jen.Qual(mcpServerPkg, "WithInstructions").Call(jen.Id("Instructions"))),
+			jen.Id("o").Dot("serverOptions").Op("..."),
+		),
+		jen.Id("aggregator").Op(":=").Qual(functions, "NewAggregator").Call(
+			jen.Qual(mcpServerPkg, "NewMCPServer").Call(jen.Id("name"), jen.Id("version"), jen.Id("serverOptions").Op("...")),
+		),
+		jen.If(jen.Id("o").Dot("separator").Op("!=").Lit("")).Block(
+			jen.Id("aggregator").Dot("WithSeparator").Call(jen.Id("o").Dot("separator")),
+		),
+		jen.Comment("API_BASE_URL は全サービスで共通のため、サービスごとの環境変数を優先する"),
+	}
+	for _, source := range sources {
+		pkg := servicePkgs[source.name]
+		opts := source.name + "Options"
+		srv := source.name + "Server"
+		aggregatorBody = append(aggregatorBody,
+			jen.Id(opts).Op(":=").Id("o").Dot(opts),
+			jen.If(jen.Id("baseURL").Op(":=").Qual("os", "Getenv").Call(jen.Lit(baseURLEnv(source.name))), jen.Id("baseURL").Op("!=").Lit("")).Block(
+				jen.Id(opts).Op("=").Append(jen.Index().Qual(pkg, "Option").Values(jen.Qual(pkg, "WithBaseURL").Call(jen.Id("baseURL"))), jen.Id(opts).Op("...")),
+			),
+			jen.List(jen.Id(srv), jen.Id("err")).Op(":=").Qual(pkg, "NewMCPServer").Call(jen.Id("name"), jen.Id("version"), jen.Id(opts).Op("...")),
+			jen.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Id("aggregator").Dot("Close").Call(),
+				jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("service "+source.name+": %w"), jen.Id("err"))),
+			),
+			jen.If(jen.Id("err").Op(":=").Id("aggregator").Dot("AddServer").Call(jen.Id("ctx"), jen.Lit(source.name), jen.Id(srv)), jen.Id("err").Op("!=").Nil()).Block(
+				jen.Id("aggregator").Dot("Close").Call(),
+				jen.Return(jen.Nil(), jen.Id("err")),
+			),
+		)
+	}
+	aggregatorBody = append(aggregatorBody, jen.Return(jen.Id("aggregator"), jen.Nil()))
+
+	f.Comment("NewAggregator returns the aggregator hosting the tools, resources and prompts of all")
+	f.Comment("services in one MCP server, without serving it. Close it when it is no longer used.")
+	f.Func().Id("NewAggregator").Params(
+		jen.Id("ctx").Qual("context", "Context"),
+		jen.List(jen.Id("name"), jen.Id("version")).String(),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Params(jen.Op("*").Qual(functions, "Aggregator"), jen.Error()).Block(
+		jen.Return(jen.Id("newAggregator").Call(jen.Id("ctx"), jen.Id("name"), jen.Id("version"), jen.Id("newOptions").Call(jen.Id("opts")))),
+	)
+	f.Line()
+	f.Func().Id("newAggregator").Params(
+		jen.Id("ctx").Qual("context", "Context"),
+		jen.List(jen.Id("name"), jen.Id("version")).String(),
+		jen.Id("o").Op("*").Id("options"),
+	).Params(jen.Op("*").Qual(functions, "Aggregator"), jen.Error()).Block(aggregatorBody...)
+	f.Line()
+
+	// 生成時に指定したトランスポートと各サービスの仕様書のエンドポイント
+	handlerBody := []jen.Code{
+		jen.Id("basePath").Op(":=").Qual("path", "Join").Call(jen.Lit("/"), jen.Id("o").Dot("basePath")),
+	}
+	if slices.Contains(transports, transportSSE) {
+		handlerBody = append(handlerBody, jen.Id("sseOptions").Op(":=").Index().Qual(mcpServerPkg, "SSEOption").Values(
+			jen.Qual(mcpServerPkg, "WithStaticBasePath").Call(jen.Id("basePath")),
+		))
+	}
+	if slices.Contains(transports, transportHTTP) {
+		handlerBody = append(handlerBody, jen.Var().Id("streamableOptions").Index().Qual(mcpServerPkg, "StreamableHTTPOption"))
+	}
+	handlerBody = append(handlerBody, jen.Id("mux").Op(":=").Qual("net/http", "NewServeMux").Call())
+	handlerBody = append(handlerBody, mountTransports(transports, mcpServerPkg, functions)...)
+	for _, source := range sources {
+		handlerBody = append(handlerBody, jen.Id("mux").Dot("Handle").Call(
+			jen.Qual("path", "Join").Call(jen.Id("basePath"), jen.Lit(source.name), jen.Qual(functions, "SpecPath")),
+			jen.Qual(functions, "SpecHandler").Call(jen.Qual(servicePkgs[source.name], "OpenAPISpec")),
+		))
+	}
+	handlerBody = append(handlerBody, jen.Return(jen.Id("mux")))
+
+	f.Comment("NewHandler returns the HTTP handler of the aggregated MCP server. It serves the transports")
+	f.Comment("given to the generator with -transport as the handler of a single service does, and the")
+	f.Comment("OpenAPI document of each service at /<service>/openapi.json, under the path set by WithBasePath.")
+	f.Func().Id("NewHandler").Params(
+		jen.Id("aggregator").Op("*").Qual(functions, "Aggregator"),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Qual("net/http", "Handler").Block(
+		jen.Return(jen.Id("newHandler").Call(jen.Id("aggregator").Dot("Server").Call(), jen.Id("newOptions").Call(jen.Id("opts")))),
+	)
+	f.Line()
+	f.Func().Id("newHandler").Params(
+		jen.Id("mcpServer").Op("*").Qual(mcpServerPkg, "MCPServer"),
+		jen.Id("o").Op("*").Id("options"),
+	).Qual("net/http", "Handler").Block(handlerBody...)
+	f.Line()
+
+	f.Comment("StartServer starts the aggregated MCP server, listening on addr until ctx is done or the")
+	f.Comment("process is interrupted. The endpoints are those of NewHandler.")
+	f.Func().Id("StartServer").Params(
+		jen.Id("ctx").Qual("context", "Context"),
+		jen.List(jen.Id("name"), jen.Id("version"), jen.Id("addr")).String(),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Error().Block(
+		jen.Id("o").Op(":=").Id("newOptions").Call(jen.Id("opts")),
+		jen.Line(),
+		jen.Comment("シャットダウンハンドリング"),
+		jen.List(jen.Id("ctx"), jen.Id("stop")).Op(":=").Qual("os/signal", "NotifyContext").Call(
+			jen.Id("ctx"),
+			jen.Qual("syscall", "SIGINT"),
+			jen.Qual("syscall", "SIGTERM"),
+		),
+		jen.Defer().Id("stop").Call(),
+		jen.List(jen.Id("aggregator"), jen.Id("err")).Op(":=").Id("newAggregator").Call(jen.Id("ctx"), jen.Id("name"), jen.Id("version"), jen.Id("o")),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Return(jen.Id("err")),
+		),
+		jen.Defer().Id("aggregator").Dot("Close").Call(),
+		jen.Id("httpServer").Op(":=").Op("&").Qual("net/http", "Server").Values(jen.Dict{
+			jen.Id("Addr"):    jen.Id("addr"),
+			jen.Id("Handler"): jen.Id("newHandler").Call(jen.Id("aggregator").Dot("Server").Call(), jen.Id("o")),
+		}),
+		jen.Line(),
+		jen.Go().Func().Params().Block(
+			jen.Id("o").Dot("logger").Dot("InfoContext").Call(jen.Id("ctx"), jen.Lit("Start mcp server"), jen.Lit("services"), jen.Id("Services")),
+			jen.If(
+				jen.Id("err").Op(":=").Id("httpServer").Dot("ListenAndServe").Call(),
+				jen.Id("err").Op("!=").Nil().Op("&&").Id("err").Op("!=").Qual("net/http", "ErrServerClosed"),
+			).Block(
+				jen.Id("o").Dot("logger").Dot("Error").Call(jen.Lit("MCP server Shutdown."), jen.Lit("error"), jen.Id("err")),
+			),
+		).Call(),
+		jen.Op("<-").Id("ctx").Dot("Done").Call(),
+		jen.Id("stop").Call(),
+		jen.Id("o").Dot("logger").Dot("InfoContext").Call(jen.Id("ctx"), jen.Lit("Shutdown mcp server")),
+		jen.Comment("SSEの接続は終了しないため、完了を待たずに閉じる"),
+		jen.Return(jen.Id("httpServer").Dot("Close").Call()),
+	)
+
+	return f.Save(filepath.Join(serverDir, "server.go"))
+}
+
+// サービスのベースURLの環境変数（例: BILLING_API_BASE_URL）
+func baseURLEnv(name string) string {
+	return strings.ToUpper(name) + "_API_BASE_URL"
+}
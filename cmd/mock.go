@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/ogen-go/ogen/gen/ir"
+	"github.com/ogen-go/ogen/jsonschema"
+	"github.com/ogen-go/ogen/openapi"
+)
+
+// モックのレスポンス
+type mockResponse struct {
+	statusCode  int
+	contentType string
+	body        string
+}
+
+// 上流APIのモックサーバーを生成
+func generateMock(operations []*operation, outputPath string) error {
+	mockDir := filepath.Join(outputPath, "mock")
+
+	// ディレクトリを作成
+	if err := os.MkdirAll(mockDir, 0755); err != nil {
+		return fmt.Errorf("failed to create mock directory: %w", err)
+	}
+
+	f := jen.NewFile("mock")
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+
+	// レスポンス
+	f.Comment("Response is a response returned by the mock server.")
+	f.Type().Id("Response").Struct(
+		jen.Id("StatusCode").Int(),
+		jen.Id("ContentType").String(),
+		jen.Id("Header").Qual("net/http", "Header"),
+		jen.Id("Body").Index().Byte(),
+	)
+	f.Line()
+	f.Comment("ServeHTTP writes the response.")
+	f.Func().Params(jen.Id("res").Id("Response")).Id("ServeHTTP").Params(
+		jen.Id("w").Qual("net/http", "ResponseWriter"),
+		jen.Id("_").Op("*").Qual("net/http", "Request"),
+	).Block(
+		jen.For(jen.List(jen.Id("name"), jen.Id("values")).Op(":=").Range().Id("res").Dot("Header")).Block(
+			jen.Id("w").Dot("Header").Call().Index(jen.Id("name")).Op("=").Id("values"),
+		),
+		jen.If(jen.Id("res").Dot("ContentType").Op("!=").Lit("")).Block(
+			jen.Id("w").Dot("Header").Call().Dot("Set").Call(jen.Lit("Content-Type"), jen.Id("res").Dot("ContentType")),
+		),
+		jen.Id("w").Dot("WriteHeader").Call(jen.Id("res").Dot("StatusCode")),
+		jen.List(jen.Id("_"), jen.Id("_")).Op("=").Id("w").Dot("Write").Call(jen.Id("res").Dot("Body")),
+	)
+	f.Line()
+
+	// サーバー
+	f.Comment("Server is a mock of the upstream API responding with the examples of the OpenAPI spec.")
+	f.Comment("The responses can be replaced per operation with Override and SetResponse.")
+	f.Comment("The long-running operations respond with the status URL /mock/operations/<operation>, whose")
+	f.Comment("operation is <operation>Status, reporting that they succeeded.")
+	f.Type().Id("Server").Struct(
+		jen.Id("mux").Op("*").Qual("net/http", "ServeMux"),
+		jen.Id("mu").Qual("sync", "RWMutex"),
+		jen.Id("overrides").Map(jen.String()).Qual("net/http", "Handler"),
+	)
+	f.Line()
+
+	f.Comment("NewServer returns a mock server serving every operation of the spec.")
+	f.Func().Id("NewServer").Params().Op("*").Id("Server").BlockFunc(func(body *jen.Group) {
+		body.Id("s").Op(":=").Op("&").Id("Server").Values(jen.Dict{
+			jen.Id("mux"):       jen.Qual("net/http", "NewServeMux").Call(),
+			jen.Id("overrides"): jen.Map(jen.String()).Qual("net/http", "Handler").Values(),
+		})
+		for _, operation := range operations {
+			res := operation.Response
+			values := jen.Dict{
+				jen.Id("StatusCode"):  jen.Lit(res.statusCode),
+				jen.Id("ContentType"): jen.Lit(res.contentType),
+				jen.Id("Body"):        jen.Index().Byte().Call(jen.Lit(res.body)),
+			}
+			// 長時間実行のオペレーションは完了したステータスのURLを返す
+			longRunning := operation.LongRunning != nil && res.statusCode == http.StatusAccepted
+			statusPath := "/mock/operations/" + operation.Name
+			if longRunning {
+				values[jen.Id("Header")] = jen.Qual("net/http", "Header").Values(jen.Dict{
+					jen.Lit("Location"): jen.Index().String().Values(jen.Lit(statusPath)),
+				})
+			}
+			body.Id("s").Dot("route").Call(
+				jen.Lit(mockPattern(operation)),
+				jen.Lit(operation.Name),
+				jen.Id("Response").Values(values),
+			)
+			if longRunning {
+				body.Id("s").Dot("route").Call(
+					jen.Lit("GET "+statusPath),
+					jen.Lit(operation.Name+"Status"),
+					jen.Id("Response").Values(jen.Dict{
+						jen.Id("StatusCode"):  jen.Lit(http.StatusOK),
+						jen.Id("ContentType"): jen.Lit("application/json"),
+						jen.Id("Body"):        jen.Index().Byte().Call(jen.Lit(`{"status":"succeeded"}`)),
+					}),
+				)
+			}
+		}
+		body.Return(jen.Id("s"))
+	})
+	f.Line()
+
+	f.Comment("Override replaces the handler of the operation.")
+	f.Func().Params(jen.Id("s").Op("*").Id("Server")).Id("Override").Params(
+		jen.Id("operation").String(),
+		jen.Id("handler").Qual("net/http", "Handler"),
+	).Block(
+		jen.Id("s").Dot("mu").Dot("Lock").Call(),
+		jen.Defer().Id("s").Dot("mu").Dot("Unlock").Call(),
+		jen.Id("s").Dot("overrides").Index(jen.Id("operation")).Op("=").Id("handler"),
+	)
+	f.Line()
+
+	f.Comment("SetResponse replaces the response of the operation.")
+	f.Func().Params(jen.Id("s").Op("*").Id("Server")).Id("SetResponse").Params(
+		jen.Id("operation").String(),
+		jen.Id("res").Id("Response"),
+	).Block(
+		jen.Id("s").Dot("Override").Call(jen.Id("operation"), jen.Id("res")),
+	)
+	f.Line()
+
+	f.Comment("Reset removes every override.")
+	f.Func().Params(jen.Id("s").Op("*").Id("Server")).Id("Reset").Params().Block(
+		jen.Id("s").Dot("mu").Dot("Lock").Call(),
+		jen.Defer().Id("s").Dot("mu").Dot("Unlock").Call(),
+		jen.Id("s").Dot("overrides").Op("=").Map(jen.String()).Qual("net/http", "Handler").Values(),
+	)
+	f.Line()
+
+	f.Func().Params(jen.Id("s").Op("*").Id("Server")).Id("ServeHTTP").Params(
+		jen.Id("w").Qual("net/http", "ResponseWriter"),
+		jen.Id("r").Op("*").Qual("net/http", "Request"),
+	).Block(
+		jen.Id("s").Dot("mux").Dot("ServeHTTP").Call(jen.Id("w"), jen.Id("r")),
+	)
+	f.Line()
+
+	f.Func().Params(jen.Id("s").Op("*").Id("Server")).Id("route").Params(
+		jen.List(jen.Id("pattern"), jen.Id("operation")).String(),
+		jen.Id("res").Id("Response"),
+	).Block(
+		jen.Id("s").Dot("mux").Dot("HandleFunc").Call(
+			jen.Id("pattern"),
+			jen.Func().Params(
+				jen.Id("w").Qual("net/http", "ResponseWriter"),
+				jen.Id("r").Op("*").Qual("net/http", "Request"),
+			).Block(
+				jen.Id("s").Dot("mu").Dot("RLock").Call(),
+				jen.List(jen.Id("handler"), jen.Id("ok")).Op(":=").Id("s").Dot("overrides").Index(jen.Id("operation")),
+				jen.Id("s").Dot("mu").Dot("RUnlock").Call(),
+				jen.If(jen.Id("ok")).Block(
+					jen.Id("handler").Dot("ServeHTTP").Call(jen.Id("w"), jen.Id("r")),
+					jen.Return(),
+				),
+				jen.Id("res").Dot("ServeHTTP").Call(jen.Id("w"), jen.Id("r")),
+			),
+		),
+	)
+
+	return f.Save(filepath.Join(mockDir, "mock.go"))
+}
+
+// net/http.ServeMux のパターンに変換
+// パスパラメータはセグメント単位のワイルドカードに置き換える
+func mockPattern(operation *operation) string {
+	segments := strings.Split(operation.Path, "/")
+	for i, segment := range segments {
+		if strings.Contains(segment, "{") {
+			segments[i] = fmt.Sprintf("{p%d}", i)
+		}
+	}
+	p := strings.Join(segments, "/")
+	if strings.HasSuffix(p, "/") {
+		p += "{$}"
+	}
+	return strings.ToUpper(operation.HTTPMethod) + " " + p
+}
+
+// 成功レスポンスの例からモックのレスポンスを作成
+func operationMockResponse(operation *ir.Operation) mockResponse {
+	statusCode, contentType, media := successMedia(operation)
+	res := mockResponse{statusCode: statusCode, contentType: contentType}
+	if contentType == "" {
+		return res
+	}
+	example, ok := mediaExample(media)
+	if !ok && media != nil {
+		example = sampleFromSchema(media.Schema, 0)
+	}
+	if example != nil {
+		if buf, err := json.Marshal(example); err == nil {
+			res.body = string(buf)
+		}
+	}
+	return res
+}
+
+// 成功レスポンスのステータスコード、コンテントタイプ（JSONを優先）とメディアを取得
+// ボディが無い場合のコンテントタイプは空
+func successMedia(operation *ir.Operation) (int, string, *openapi.MediaType) {
+	responses := operation.Spec.Responses
+	statusCode, response := 200, responses.Default
+	codes := make([]int, 0, len(responses.StatusCode))
+	for code := range responses.StatusCode {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	if i := slices.IndexFunc(codes, func(code int) bool { return code >= 200 && code < 300 }); i >= 0 {
+		statusCode, response = codes[i], responses.StatusCode[codes[i]]
+	} else if responses.Pattern[1] != nil {
+		response = responses.Pattern[1]
+	}
+	if response == nil || len(response.Content) == 0 {
+		return statusCode, "", nil
+	}
+	contentTypes := make([]string, 0, len(response.Content))
+	for contentType := range response.Content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+	// JSONを優先する
+	contentType := contentTypes[0]
+	if i := slices.IndexFunc(contentTypes, func(contentType string) bool {
+		return strings.HasPrefix(contentType, "application/json")
+	}); i >= 0 {
+		contentType = contentTypes[i]
+	}
+	return statusCode, contentType, response.Content[contentType]
+}
+
+// 例が無い場合にスキーマから最小限のサンプルを作成
+func sampleFromSchema(schema *jsonschema.Schema, depth int) any {
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	if v, ok := decodeExample(schema.Examples); ok {
+		return v
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	for _, schemas := range [][]*jsonschema.Schema{schema.OneOf, schema.AnyOf} {
+		if len(schemas) > 0 {
+			return sampleFromSchema(schemas[0], depth+1)
+		}
+	}
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, s := range schema.AllOf {
+			if obj, ok := sampleFromSchema(s, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	switch schema.Type {
+	case jsonschema.Object:
+		obj := map[string]any{}
+		for _, prop := range schema.Properties {
+			if prop.Required {
+				obj[prop.Name] = sampleFromSchema(prop.Schema, depth+1)
+			}
+		}
+		return obj
+	case jsonschema.Array:
+		return []any{}
+	case jsonschema.String:
+		return sampleString(schema.Format)
+	case jsonschema.Integer, jsonschema.Number:
+		return 0
+	case jsonschema.Boolean:
+		return false
+	}
+	return nil
+}
+
+// フォーマットに合う文字列のサンプル
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "1970-01-01T00:00:00Z"
+	case "date":
+		return "1970-01-01"
+	case "time":
+		return "00:00:00"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "ipv4":
+		return "127.0.0.1"
+	case "ipv6":
+		return "::1"
+	}
+	return ""
+}
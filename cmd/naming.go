@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ツール名の付け方
+const (
+	// クライアントのメソッド名（例: GetPetById）
+	namingClient = "client"
+	// operationId（例: getPetById）
+	namingOperationID = "operation-id"
+	// HTTPメソッドとパス（例: GET /pets/{petId} は get_pets_by_pet_id）
+	namingMethodPath = "method-path"
+	// operationId のスネークケース（例: get_pet_by_id）
+	namingSnakeCase = "snake_case"
+)
+
+var namingStrategies = []string{namingClient, namingOperationID, namingMethodPath, namingSnakeCase}
+
+// ツール名の付け方を確認する
+func validateNaming(naming string) error {
+	if slices.Contains(namingStrategies, naming) {
+		return nil
+	}
+	return fmt.Errorf("unknown naming strategy %q: use %s", naming, strings.Join(namingStrategies, ", "))
+}
+
+// ツール名の付け方に従ってツール名を付ける
+// 仕様書の x-mcp-name で指定したツール名はそのまま使い、設定ファイルの上書きはこの後に適用するため優先される
+func applyNaming(operations []*operation, naming string) {
+	for _, operation := range operations {
+		if operation.ToolName != "" {
+			continue
+		}
+		source := operation.OperationID
+		if source == "" {
+			source = operation.Name
+		}
+		switch naming {
+		case namingOperationID:
+			// MCPのクライアントが受け付けない文字は _ にする
+			operation.ToolName = strings.Map(func(r rune) rune {
+				if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-') {
+					return r
+				}
+				return '_'
+			}, source)
+		case namingMethodPath:
+			words := []string{strings.ToLower(operation.HTTPMethod)}
+			for _, segment := range strings.Split(operation.Path, "/") {
+				if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+					words = append(words, "by")
+					segment = strings.Trim(segment, "{}")
+				}
+				words = append(words, snakeWords(segment)...)
+			}
+			operation.ToolName = strings.Join(words, "_")
+		case namingSnakeCase:
+			operation.ToolName = strings.Join(snakeWords(source), "_")
+		}
+	}
+}
+
+// 名前を小文字の単語に分ける
+// 英数字以外と、小文字・数字から大文字、連続する大文字の最後（HTTPStatus の S）で区切る
+func snakeWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if !unicode.IsUpper(prev) || nextLower {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
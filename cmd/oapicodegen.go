@@ -0,0 +1,646 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oapi-codegen/oapi-codegen/v2/pkg/codegen"
+)
+
+// oapi-codegen の生成コードが利用する型のパッケージ
+const oapiRuntimeTypes = "github.com/oapi-codegen/runtime/types"
+
+// oapi-codegen でクライアントを生成するバックエンド
+// ツールから ogen と同じ呼び出し方ができるよう、クライアントのアダプタも生成する
+type oapiCodegenBackend struct{}
+
+func (oapiCodegenBackend) generate(spec []byte, outputPath, packageName string, opts generateOptions) (*clientInfo, error) {
+	loader := openapi3.NewLoader()
+	swagger, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	setOpenAPI3DescriptionTag(swagger)
+
+	code, err := codegen.Generate(swagger, codegen.Configuration{
+		PackageName: packageName,
+		Generate: codegen.GenerateOptions{
+			Client: true,
+			Models: true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client: %w", err)
+	}
+	definitions, err := codegen.OperationDefinitions(swagger, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe operations: %w", err)
+	}
+
+	clientDir := filepath.Join(outputPath, "client")
+	switch files, err := os.ReadDir(clientDir); {
+	case os.IsNotExist(err):
+		if err := os.MkdirAll(clientDir, 0o750); err != nil {
+			return nil, err
+		}
+	default:
+		// 別のバックエンドで生成したファイルを削除
+		if err := cleanDir(clientDir, files); err != nil {
+			return nil, fmt.Errorf("failed cleanDir: %w", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(clientDir, "oapi_client_gen.go"), []byte(code), 0o644); err != nil {
+		return nil, fmt.Errorf("failed write: %w", err)
+	}
+
+	info := &clientInfo{
+		baseURL:          defaultOpenAPI3ServerURL(swagger.Servers),
+		clientType:       "MCPClient",
+		constructor:      "NewMCPClient",
+		httpClientOption: "WithHTTPClient",
+	}
+	// オペレーションのserversで指定されたURL（キーはオペレーションID）
+	servers := map[string]string{}
+	for i := range definitions {
+		operation := oapiCodegenOperation(&definitions[i])
+		operation.ServerURL = openAPI3OperationServerURL(swagger, &definitions[i])
+		operation.Flatten = flattenPaths(definitions[i].Spec.Extensions[extensionFlatten])
+		operation.Summarize = summarizeOptions(definitions[i].Spec.Extensions[extensionSummarize])
+		operation.ToolName = extensionString(definitions[i].Spec.Extensions[extensionMCPName])
+		if operation.ServerURL != "" {
+			servers[definitions[i].OperationId] = operation.ServerURL
+		}
+		info.operations = append(info.operations, operation)
+	}
+	if err := generateOapiCodegenAdapter(definitions, servers, packageName, filepath.Join(clientDir, "oapi_mcp_gen.go")); err != nil {
+		return nil, fmt.Errorf("failed to generate client adapter: %w", err)
+	}
+	return info, nil
+}
+
+// oapi-codegen のオペレーション定義をバックエンドに依存しない情報に変換
+func oapiCodegenOperation(definition *codegen.OperationDefinition) *operation {
+	spec := definition.Spec
+	op := &operation{
+		Name:        definition.OperationId,
+		OperationID: spec.OperationID,
+		Summary:     spec.Summary,
+		Description: spec.Description,
+		HTTPMethod:  strings.ToLower(definition.Method),
+		Path:        definition.Path,
+		Tags:        spec.Tags,
+		Response:    openAPI3MockResponse(spec.Responses),
+		TimeFields:  openAPI3TimeFields(spec.Responses),
+	}
+	if op.OperationID == "" {
+		op.OperationID = definition.OperationId
+	}
+
+	if params := oapiParams(definition); len(params) > 0 {
+		op.ParamsType = definition.OperationId + "Parameters"
+		op.ParamsExample = map[string]any{}
+		op.ParamsDefaults = map[string]any{}
+		op.ParamArguments = map[string]string{}
+		for _, param := range params {
+			if param.Required {
+				op.ParamsRequired = true
+			}
+			if v, ok := openAPI3ParameterExample(param.Spec); ok {
+				op.ParamsExample[oapiArgumentName(param)] = v
+			}
+			if param.Spec != nil && param.Spec.Schema != nil && param.Spec.Schema.Value != nil {
+				if v, ok := normalizeExample(param.Spec.Schema.Value.Default); ok {
+					op.ParamsDefaults[oapiArgumentName(param)] = v
+				}
+			}
+			op.ParamFields = append(op.ParamFields, oapiArgumentName(param))
+			if argument := oapiArgumentName(param); argument != param.ParamName {
+				op.ParamArguments[param.In+":"+param.ParamName] = argument
+			}
+		}
+	}
+
+	if definition.HasBody() {
+		op.BodyRequired = definition.BodyRequired
+		if body, ok := oapiClientBody(definition); ok {
+			op.BodyType = definition.OperationId + body.NameTag + "RequestBody"
+			if schema := body.Schema.OAPISchema; schema != nil {
+				op.BodyIsStruct = schema.Type.Is(openapi3.TypeObject) && len(schema.Properties) > 0
+				op.BodyDefaults = openAPI3PropertyDefaults(schema)
+				if op.BodyIsStruct {
+					op.BodyFields = sortedKeys(schema.Properties)
+				}
+			}
+		} else {
+			// クライアントが対応していないリクエストボディは文字列で受け取る
+			op.BodyType = "string"
+		}
+		if requestBody := spec.RequestBody; requestBody != nil && requestBody.Value != nil {
+			op.BodyExample, _ = openAPI3ContentExample(requestBody.Value.Content)
+		}
+	}
+	return op
+}
+
+// パス、クエリ、ヘッダー、クッキーのパラメータ
+func oapiParams(definition *codegen.OperationDefinition) []codegen.ParameterDefinition {
+	params := slices.Clone(definition.PathParams)
+	return append(params, definition.Params()...)
+}
+
+// パラメータのツールの引数名（x-mcp-name が無ければパラメータ名）
+func oapiArgumentName(param codegen.ParameterDefinition) string {
+	if param.Spec != nil {
+		if name := extensionString(param.Spec.Extensions[extensionMCPName]); name != "" {
+			return name
+		}
+	}
+	return param.ParamName
+}
+
+// 型付きのメソッドで送信できるリクエストボディ（JSONを優先）
+func oapiClientBody(definition *codegen.OperationDefinition) (codegen.RequestBodyDefinition, bool) {
+	var supported []codegen.RequestBodyDefinition
+	for _, body := range definition.Bodies {
+		if body.IsSupportedByClient() {
+			supported = append(supported, body)
+		}
+	}
+	if len(supported) == 0 {
+		return codegen.RequestBodyDefinition{}, false
+	}
+	if i := slices.IndexFunc(supported, codegen.RequestBodyDefinition.IsJSON); i >= 0 {
+		return supported[i], true
+	}
+	return supported[0], true
+}
+
+// ツールから ogen と同じ呼び出し方ができるクライアントのアダプタを生成
+func generateOapiCodegenAdapter(definitions []codegen.OperationDefinition, servers map[string]string, packageName, outputPath string) error {
+	f := jen.NewFile(packageName)
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+
+	f.Comment("MCPClient adapts the client to the calling convention of the generated MCP tools.")
+	f.Type().Id("MCPClient").Struct(
+		jen.Op("*").Id("Client"),
+	)
+	f.Line()
+	f.Comment("NewMCPClient creates a new MCPClient for the server.")
+	f.Func().Id("NewMCPClient").Params(
+		jen.Id("server").String(),
+		jen.Id("opts").Op("...").Id("ClientOption"),
+	).Params(jen.Op("*").Id("MCPClient"), jen.Error()).Block(
+		jen.List(jen.Id("c"), jen.Err()).Op(":=").Id("NewClient").Call(jen.Id("server"), jen.Id("opts").Op("...")),
+		jen.If(jen.Err().Op("!=").Nil()).Block(
+			jen.Return(jen.Nil(), jen.Err()),
+		),
+		jen.Return(jen.Op("&").Id("MCPClient").Values(jen.Dict{
+			jen.Id("Client"): jen.Id("c"),
+		}), jen.Nil()),
+	)
+	f.Line()
+
+	for i := range definitions {
+		definition := &definitions[i]
+		opID := definition.OperationId
+		params := oapiParams(definition)
+
+		// パラメータの型
+		if len(params) > 0 {
+			f.Comment(fmt.Sprintf("%sParameters are the parameters of %s.", opID, opID))
+			f.Type().Id(opID + "Parameters").StructFunc(func(g *jen.Group) {
+				for _, param := range params {
+					typ := oapiGoType(param.TypeDef())
+					if param.In != "path" && param.IndirectOptional() {
+						typ = jen.Op("*").Add(typ)
+					}
+					tag := fmt.Sprintf("`json:\"%s\" mcprequired:\"%t\"", oapiArgumentName(param), param.Required)
+					if param.Spec != nil && param.Spec.Description != "" {
+						tag += " mcpdescription:" + strconv.Quote(strings.ReplaceAll(param.Spec.Description, "`", "'"))
+					}
+					g.Id(param.GoName()).Add(typ).Op(tag + "`")
+				}
+			})
+			f.Line()
+		}
+
+		// ツールから呼び出すメソッド
+		body, hasClientBody := oapiClientBody(definition)
+		f.Comment(fmt.Sprintf("%s calls %s with the arguments of the MCP tool.", opID, opID))
+		f.Func().Params(jen.Id("c").Op("*").Id("MCPClient")).Id(opID).ParamsFunc(func(g *jen.Group) {
+			g.Id("ctx").Qual("context", "Context")
+			if definition.HasBody() {
+				if hasClientBody {
+					g.Id("body").Id(opID + body.NameTag + "RequestBody")
+				} else {
+					g.Id("body").String()
+				}
+			}
+			if len(params) > 0 {
+				g.Id("params").Id(opID + "Parameters")
+			}
+		}).Params(jen.Any(), jen.Error()).Block(
+			jen.Return(jen.Qual("github.com/nonchan7720/oas-mcp/functions", "DecodeHTTPResponse").Call(
+				jen.Id("c").Dot("Client").Dot(oapiMethodName(definition, body, hasClientBody)).CallFunc(func(g *jen.Group) {
+					g.Id("ctx")
+					for _, param := range definition.PathParams {
+						g.Id("params").Dot(param.GoName())
+					}
+					if definition.RequiresParamObject() {
+						g.Op("&").Id(opID + "Params").Values(jen.DictFunc(func(d jen.Dict) {
+							for _, param := range definition.Params() {
+								d[jen.Id(param.GoName())] = jen.Id("params").Dot(param.GoName())
+							}
+						}))
+					}
+					if definition.HasBody() {
+						if hasClientBody {
+							g.Id("body")
+						} else {
+							g.Lit(oapiContentType(definition))
+							g.Qual("strings", "NewReader").Call(jen.Id("body"))
+						}
+					}
+					if server := servers[opID]; server != "" {
+						g.Id("c").Dot("withOperationServer").Call(jen.Lit(server))
+					}
+				}),
+			)),
+		)
+		f.Line()
+	}
+
+	// オペレーションのserversで指定されたURLに送信するエディタ
+	if len(servers) > 0 {
+		f.Comment("withOperationServer sends the request to the server declared on the operation instead of the server of the client.")
+		f.Func().Params(jen.Id("c").Op("*").Id("MCPClient")).Id("withOperationServer").Params(
+			jen.Id("server").String(),
+		).Id("RequestEditorFn").Block(
+			jen.Return(jen.Func().Params(
+				jen.Id("_").Qual("context", "Context"),
+				jen.Id("req").Op("*").Qual("net/http", "Request"),
+			).Error().Block(
+				jen.Return(jen.Qual("github.com/nonchan7720/oas-mcp/functions", "RebaseURL").Call(
+					jen.Id("req"),
+					jen.Id("c").Dot("Client").Dot("Server"),
+					jen.Id("server"),
+				)),
+			)),
+		)
+	}
+
+	return f.Save(outputPath)
+}
+
+// 呼び出すクライアントのメソッド名
+func oapiMethodName(definition *codegen.OperationDefinition, body codegen.RequestBodyDefinition, hasClientBody bool) string {
+	switch {
+	case !definition.HasBody():
+		return definition.OperationId
+	case hasClientBody:
+		return definition.OperationId + body.Suffix()
+	}
+	return definition.OperationId + "WithBody"
+}
+
+// 型付きのメソッドが無いリクエストボディのContent-Type
+func oapiContentType(definition *codegen.OperationDefinition) string {
+	if len(definition.Bodies) > 0 {
+		return definition.Bodies[0].ContentType
+	}
+	if requestBody := definition.Spec.RequestBody; requestBody != nil && requestBody.Value != nil {
+		contentTypes := make([]string, 0, len(requestBody.Value.Content))
+		for contentType := range requestBody.Value.Content {
+			contentTypes = append(contentTypes, contentType)
+		}
+		sort.Strings(contentTypes)
+		if len(contentTypes) > 0 {
+			return contentTypes[0]
+		}
+	}
+	return "application/octet-stream"
+}
+
+// oapi-codegen の型の表記をJenniferのコードに変換
+// openapi_types の型はインポートを伴う参照にする
+func oapiGoType(typeDef string) jen.Code {
+	switch {
+	case strings.HasPrefix(typeDef, "*"):
+		return jen.Op("*").Add(oapiGoType(typeDef[1:]))
+	case strings.HasPrefix(typeDef, "[]"):
+		return jen.Index().Add(oapiGoType(typeDef[2:]))
+	case strings.HasPrefix(typeDef, "map[string]"):
+		return jen.Map(jen.String()).Add(oapiGoType(strings.TrimPrefix(typeDef, "map[string]")))
+	case strings.HasPrefix(typeDef, "openapi_types."):
+		return jen.Qual(oapiRuntimeTypes, strings.TrimPrefix(typeDef, "openapi_types."))
+	}
+	return jen.Id(typeDef)
+}
+
+// パスまたはオペレーションのserversからURLを取得
+// オペレーションのserversはパスのserversより優先される
+func openAPI3OperationServerURL(swagger *openapi3.T, definition *codegen.OperationDefinition) string {
+	if servers := definition.Spec.Servers; servers != nil && len(*servers) > 0 {
+		return defaultOpenAPI3ServerURL(*servers)
+	}
+	if swagger.Paths == nil {
+		return ""
+	}
+	if pathItem := swagger.Paths.Value(definition.Path); pathItem != nil {
+		return defaultOpenAPI3ServerURL(pathItem.Servers)
+	}
+	return ""
+}
+
+// 仕様書のserversから既定のベースURLを取得
+func defaultOpenAPI3ServerURL(servers openapi3.Servers) string {
+	for _, server := range servers {
+		serverURL := server.URL
+		for name, variable := range server.Variables {
+			serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", variable.Default)
+		}
+		u, err := url.Parse(serverURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			continue
+		}
+		return serverURL
+	}
+	return ""
+}
+
+// プロパティの説明を mcpdescription タグとして生成コードに渡す
+func setOpenAPI3DescriptionTag(swagger *openapi3.T) {
+	visited := map[*openapi3.Schema]struct{}{}
+	var setSchemaRecursive func(schema *openapi3.Schema)
+	setSchemaRecursive = func(schema *openapi3.Schema) {
+		if schema == nil {
+			return
+		}
+		if _, ok := visited[schema]; ok {
+			return
+		}
+		visited[schema] = struct{}{}
+
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := schema.Properties[name]
+			if prop == nil || prop.Value == nil {
+				continue
+			}
+			description := prop.Value.Description
+			if description == "" {
+				description = prop.Value.Title
+			}
+			if description == "" {
+				description = name
+			}
+			// $ref のプロパティは参照先のスキーマを書き換えないよう拡張を付けない
+			if prop.Ref == "" {
+				if prop.Value.Extensions == nil {
+					prop.Value.Extensions = map[string]any{}
+				}
+				prop.Value.Extensions["x-oapi-codegen-extra-tags"] = map[string]any{
+					"mcpdescription": description,
+				}
+			}
+			setSchemaRecursive(prop.Value)
+		}
+		if schema.Items != nil {
+			setSchemaRecursive(schema.Items.Value)
+		}
+		for _, refs := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+			for _, ref := range refs {
+				if ref != nil {
+					setSchemaRecursive(ref.Value)
+				}
+			}
+		}
+	}
+
+	if swagger.Components != nil {
+		for _, schema := range swagger.Components.Schemas {
+			if schema != nil {
+				setSchemaRecursive(schema.Value)
+			}
+		}
+	}
+	if swagger.Paths == nil {
+		return
+	}
+	for _, pathItem := range swagger.Paths.Map() {
+		for _, op := range pathItem.Operations() {
+			if op.RequestBody != nil && op.RequestBody.Value != nil {
+				for _, media := range op.RequestBody.Value.Content {
+					if media.Schema != nil {
+						setSchemaRecursive(media.Schema.Value)
+					}
+				}
+			}
+		}
+	}
+}
+
+// パラメータの例を取得
+func openAPI3ParameterExample(param *openapi3.Parameter) (any, bool) {
+	if param == nil {
+		return nil, false
+	}
+	if param.Example != nil {
+		return normalizeExample(param.Example)
+	}
+	for _, name := range sortedKeys(param.Examples) {
+		if ex := param.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+			return normalizeExample(ex.Value.Value)
+		}
+	}
+	if param.Schema != nil && param.Schema.Value != nil && param.Schema.Value.Example != nil {
+		return normalizeExample(param.Schema.Value.Example)
+	}
+	return nil, false
+}
+
+// コンテンツの例を取得（JSONを優先）
+func openAPI3ContentExample(content openapi3.Content) (any, bool) {
+	contentTypes := sortedKeys(content)
+	slices.SortStableFunc(contentTypes, func(a, b string) int {
+		return boolOrder(strings.HasPrefix(b, "application/json")) - boolOrder(strings.HasPrefix(a, "application/json"))
+	})
+	for _, contentType := range contentTypes {
+		media := content[contentType]
+		if media == nil {
+			continue
+		}
+		if media.Example != nil {
+			return normalizeExample(media.Example)
+		}
+		for _, name := range sortedKeys(media.Examples) {
+			if ex := media.Examples[name]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+				return normalizeExample(ex.Value.Value)
+			}
+		}
+		if media.Schema != nil && media.Schema.Value != nil && media.Schema.Value.Example != nil {
+			return normalizeExample(media.Schema.Value.Example)
+		}
+	}
+	return nil, false
+}
+
+// 成功レスポンスの例からモックのレスポンスを作成
+func openAPI3MockResponse(responses *openapi3.Responses) mockResponse {
+	statusCode, contentType, media := openAPI3SuccessMedia(responses)
+	res := mockResponse{statusCode: statusCode, contentType: contentType}
+	if contentType == "" {
+		return res
+	}
+	example, ok := openAPI3ContentExample(openapi3.Content{contentType: media})
+	if !ok {
+		if media != nil && media.Schema != nil {
+			example = sampleFromOpenAPI3Schema(media.Schema.Value, 0)
+		}
+	}
+	if example != nil {
+		if buf, err := json.Marshal(example); err == nil {
+			res.body = string(buf)
+		}
+	}
+	return res
+}
+
+// 成功レスポンスのステータスコード、コンテントタイプ（JSONを優先）とメディアを取得
+// ボディが無い場合のコンテントタイプは空
+func openAPI3SuccessMedia(responses *openapi3.Responses) (int, string, *openapi3.MediaType) {
+	statusCode := 200
+	if responses == nil {
+		return statusCode, "", nil
+	}
+	var response *openapi3.ResponseRef
+	codes := sortedKeys(responses.Map())
+	if i := slices.IndexFunc(codes, func(code string) bool { return strings.HasPrefix(code, "2") }); i >= 0 {
+		response = responses.Value(codes[i])
+		if code, err := strconv.Atoi(codes[i]); err == nil {
+			statusCode = code
+		}
+	} else {
+		response = responses.Default()
+	}
+	if response == nil || response.Value == nil || len(response.Value.Content) == 0 {
+		return statusCode, "", nil
+	}
+	content := response.Value.Content
+	contentTypes := sortedKeys(content)
+	contentType := contentTypes[0]
+	if i := slices.IndexFunc(contentTypes, func(contentType string) bool {
+		return strings.HasPrefix(contentType, "application/json")
+	}); i >= 0 {
+		contentType = contentTypes[i]
+	}
+	return statusCode, contentType, content[contentType]
+}
+
+// 例が無い場合にスキーマから最小限のサンプルを作成
+func sampleFromOpenAPI3Schema(schema *openapi3.Schema, depth int) any {
+	if schema == nil || depth > 8 {
+		return nil
+	}
+	if schema.Example != nil {
+		v, _ := normalizeExample(schema.Example)
+		return v
+	}
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	for _, refs := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf} {
+		if len(refs) > 0 && refs[0] != nil {
+			return sampleFromOpenAPI3Schema(refs[0].Value, depth+1)
+		}
+	}
+	if len(schema.AllOf) > 0 {
+		merged := map[string]any{}
+		for _, ref := range schema.AllOf {
+			if ref == nil {
+				continue
+			}
+			if obj, ok := sampleFromOpenAPI3Schema(ref.Value, depth+1).(map[string]any); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	switch {
+	case schema.Type.Is(openapi3.TypeObject):
+		obj := map[string]any{}
+		for _, name := range schema.Required {
+			if prop := schema.Properties[name]; prop != nil {
+				obj[name] = sampleFromOpenAPI3Schema(prop.Value, depth+1)
+			}
+		}
+		return obj
+	case schema.Type.Is(openapi3.TypeArray):
+		return []any{}
+	case schema.Type.Is(openapi3.TypeString):
+		return sampleString(schema.Format)
+	case schema.Type.Is(openapi3.TypeInteger), schema.Type.Is(openapi3.TypeNumber):
+		return 0
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return false
+	}
+	return nil
+}
+
+// YAMLから読み込んだ例をJSONの値に揃える
+// オブジェクトのスキーマのプロパティの既定値を取得
+func openAPI3PropertyDefaults(schema *openapi3.Schema) map[string]any {
+	defaults := map[string]any{}
+	for name, property := range schema.Properties {
+		if property == nil || property.Value == nil {
+			continue
+		}
+		if v, ok := normalizeExample(property.Value.Default); ok {
+			defaults[name] = v
+		}
+	}
+	return defaults
+}
+
+func normalizeExample(v any) (any, bool) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, false
+	}
+	var example any
+	if err := json.Unmarshal(buf, &example); err != nil || example == nil {
+		return nil, false
+	}
+	return example, true
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func boolOrder(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/go-faster/yaml"
+)
+
+// バックエンドに渡すOpenAPIのバージョン
+const downconvertedVersion = "3.0.3"
+
+// スキーマを値に持つキーワード
+var (
+	// 値がスキーマのキーワード
+	schemaKeywords = []string{"items", "not", "additionalProperties", "contains", "propertyNames", "if", "then", "else", "unevaluatedItems", "unevaluatedProperties"}
+	// 値がスキーマの配列のキーワード
+	schemaListKeywords = []string{"allOf", "oneOf", "anyOf", "prefixItems"}
+	// 値がスキーマのマップのキーワード
+	schemaMapKeywords = []string{"properties", "patternProperties", "$defs", "dependentSchemas"}
+	// const の値のタグと型
+	constTypes = map[string]string{"!!str": "string", "!!int": "integer", "!!float": "number", "!!bool": "boolean"}
+)
+
+// OpenAPI 3.1の仕様書を3.0の構文に変換する
+// ogen と oapi-codegen（kin-openapi）は3.0のスキーマしか読めないため、バックエンドに渡す前の仕様書に適用する
+//   - type の配列（[string, "null"]）は type と nullable、複数の型は anyOf にする
+//   - const は値が1つの enum にする
+//   - スキーマの examples は最初の値を example にする
+//   - 数値の exclusiveMinimum / exclusiveMaximum は minimum / maximum と真偽値にする
+//   - prefixItems はいずれかの要素の items にする
+//   - contentEncoding: base64 は format: byte、バイナリの contentMediaType は format: binary にする
+//   - $ref と並んだ description などは allOf で包んで残す
+//   - webhooks、jsonSchemaDialect、components/pathItems など3.0に無いものは取り除く
+//
+// 3.1でない仕様書はそのまま返す
+func downconvertOAS31(spec []byte) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return spec, nil
+	}
+	root := doc.Content[0]
+	version := mappingValue(root, "openapi")
+	if version == nil || !strings.HasPrefix(version.Value, "3.1") {
+		return spec, nil
+	}
+	log.Printf("Converting the OpenAPI %s spec to %s", version.Value, downconvertedVersion)
+	version.Value = downconvertedVersion
+	version.Style = yaml.DoubleQuotedStyle
+	deleteMappingKeys(root, "webhooks", "jsonSchemaDialect")
+	if mappingValue(root, "paths") == nil {
+		// 3.1では paths を省略できる
+		appendMapping(root, "paths", &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+	if info := mappingValue(root, "info"); info != nil {
+		deleteMappingKeys(info, "summary")
+		if license := mappingValue(info, "license"); license != nil {
+			deleteMappingKeys(license, "identifier")
+		}
+	}
+	if components := mappingValue(root, "components"); components != nil {
+		deleteMappingKeys(components, "pathItems")
+		eachMappingValue(mappingValue(components, "schemas"), downconvertSchema)
+		eachMappingValue(mappingValue(components, "parameters"), downconvertParameter)
+		eachMappingValue(mappingValue(components, "headers"), downconvertParameter)
+		eachMappingValue(mappingValue(components, "requestBodies"), downconvertContent)
+		eachMappingValue(mappingValue(components, "responses"), downconvertResponse)
+	}
+	eachMappingValue(mappingValue(root, "paths"), func(item *yaml.Node) {
+		eachSequenceItem(mappingValue(item, "parameters"), downconvertParameter)
+		for _, method := range operationMethods {
+			operation := mappingValue(item, method)
+			if operation == nil {
+				continue
+			}
+			eachSequenceItem(mappingValue(operation, "parameters"), downconvertParameter)
+			downconvertContent(mappingValue(operation, "requestBody"))
+			eachMappingValue(mappingValue(operation, "responses"), downconvertResponse)
+		}
+	})
+	converted, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode converted OpenAPI spec: %w", err)
+	}
+	return converted, nil
+}
+
+// パラメータとヘッダーのスキーマ
+func downconvertParameter(parameter *yaml.Node) {
+	if parameter == nil {
+		return
+	}
+	downconvertSchema(mappingValue(parameter, "schema"))
+	downconvertContent(parameter)
+}
+
+// レスポンスのヘッダーとボディのスキーマ
+func downconvertResponse(response *yaml.Node) {
+	if response == nil {
+		return
+	}
+	eachMappingValue(mappingValue(response, "headers"), downconvertParameter)
+	downconvertContent(response)
+}
+
+// content のメディアタイプのスキーマ
+func downconvertContent(node *yaml.Node) {
+	if node == nil {
+		return
+	}
+	eachMappingValue(mappingValue(node, "content"), func(media *yaml.Node) {
+		downconvertSchema(mappingValue(media, "schema"))
+	})
+}
+
+// スキーマとその中のスキーマを3.0の構文に変換する
+func downconvertSchema(schema *yaml.Node) {
+	if schema == nil || schema.Kind != yaml.MappingNode {
+		return
+	}
+	for _, keyword := range schemaKeywords {
+		downconvertSchema(mappingValue(schema, keyword))
+	}
+	for _, keyword := range schemaListKeywords {
+		eachSequenceItem(mappingValue(schema, keyword), downconvertSchema)
+	}
+	for _, keyword := range schemaMapKeywords {
+		eachMappingValue(mappingValue(schema, keyword), downconvertSchema)
+	}
+
+	if ref := mappingValue(schema, "$ref"); ref != nil && len(schema.Content) > 2 {
+		// 3.0では $ref と並んだキーワードは無視されるため、参照を allOf に移す
+		deleteMappingKeys(schema, "$ref")
+		appendMapping(schema, "allOf", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{
+			{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{scalarNode("$ref"), ref}},
+		}})
+	}
+	if typ := mappingValue(schema, "type"); typ != nil && typ.Kind == yaml.SequenceNode {
+		var types []*yaml.Node
+		nullable := false
+		for _, t := range typ.Content {
+			if t.Value == "null" {
+				nullable = true
+				continue
+			}
+			types = append(types, t)
+		}
+		deleteMappingKeys(schema, "type")
+		switch len(types) {
+		case 0:
+		case 1:
+			appendMapping(schema, "type", types[0])
+		default:
+			anyOf := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			for _, t := range types {
+				anyOf.Content = append(anyOf.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{scalarNode("type"), t}})
+			}
+			appendMapping(schema, "anyOf", anyOf)
+		}
+		if nullable {
+			deleteMappingKeys(schema, "nullable")
+			appendMapping(schema, "nullable", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+		}
+	}
+	if value := mappingValue(schema, "const"); value != nil {
+		deleteMappingKeys(schema, "const")
+		if mappingValue(schema, "enum") == nil {
+			appendMapping(schema, "enum", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}})
+		}
+		// 3.0のジェネレーターは型の無い enum を扱えないため、値から型を補う
+		if typ, ok := constTypes[value.Tag]; ok && value.Kind == yaml.ScalarNode && mappingValue(schema, "type") == nil {
+			appendMapping(schema, "type", scalarNode(typ))
+		}
+	}
+	if examples := mappingValue(schema, "examples"); examples != nil && examples.Kind == yaml.SequenceNode {
+		deleteMappingKeys(schema, "examples")
+		if mappingValue(schema, "example") == nil && len(examples.Content) > 0 {
+			appendMapping(schema, "example", examples.Content[0])
+		}
+	}
+	for keyword, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		if value := mappingValue(schema, keyword); value != nil && value.Kind == yaml.ScalarNode && value.Tag != "!!bool" {
+			deleteMappingKeys(schema, keyword, bound)
+			appendMapping(schema, bound, value)
+			appendMapping(schema, keyword, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"})
+		}
+	}
+	if prefixItems := mappingValue(schema, "prefixItems"); prefixItems != nil {
+		deleteMappingKeys(schema, "prefixItems")
+		if mappingValue(schema, "items") == nil && len(prefixItems.Content) > 0 {
+			items := prefixItems.Content[0]
+			if len(prefixItems.Content) > 1 {
+				items = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{scalarNode("anyOf"), prefixItems}}
+			}
+			appendMapping(schema, "items", items)
+		}
+	}
+	if mappingValue(schema, "format") == nil {
+		if encoding := mappingValue(schema, "contentEncoding"); encoding != nil && strings.EqualFold(encoding.Value, "base64") {
+			appendMapping(schema, "format", scalarNode("byte"))
+		} else if mediaType := mappingValue(schema, "contentMediaType"); mediaType != nil && !strings.HasPrefix(mediaType.Value, "text/") && !strings.HasSuffix(mediaType.Value, "json") {
+			appendMapping(schema, "format", scalarNode("binary"))
+		}
+	}
+	deleteMappingKeys(schema, "contentEncoding", "contentMediaType", "$defs", "$schema", "$id", "$anchor", "$comment")
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func appendMapping(node *yaml.Node, key string, value *yaml.Node) {
+	node.Content = append(node.Content, scalarNode(key), value)
+}
+
+func deleteMappingKeys(node *yaml.Node, keys ...string) {
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if !slices.Contains(keys, node.Content[i].Value) {
+			content = append(content, node.Content[i], node.Content[i+1])
+		}
+	}
+	node.Content = content
+}
+
+func eachMappingValue(node *yaml.Node, fn func(*yaml.Node)) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fn(node.Content[i+1])
+	}
+}
+
+func eachSequenceItem(node *yaml.Node, fn func(*yaml.Node)) {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return
+	}
+	for _, item := range node.Content {
+		fn(item)
+	}
+}
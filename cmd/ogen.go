@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/ogen-go/ogen"
+	"github.com/ogen-go/ogen/gen"
+	"github.com/ogen-go/ogen/gen/ir"
+)
+
+// ogen でクライアントを生成するバックエンド
+type ogenBackend struct{}
+
+func (ogenBackend) generate(spec []byte, outputPath, packageName string, opts generateOptions) (*clientInfo, error) {
+	// OpenAPIパーサーでパース
+	parsedSpec, err := ogen.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	setDescriptionTag(parsedSpec)
+
+	// ogen を使ってクライアントコードを生成
+	g, err := generateClient(parsedSpec, outputPath, packageName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &clientInfo{
+		baseURL:           defaultServerURL(parsedSpec.Servers),
+		clientType:        "Client",
+		constructor:       "NewClient",
+		httpClientOption:  "WithClient",
+		serverURLContext:  "WithServerURL",
+		hasSecuritySource: len(parsedSpec.Security) > 0 || len(parsedSpec.Components.SecuritySchemes) > 0,
+	}
+	// ogen/otel が有効な場合はクライアントのスパンも同じTracerProviderで記録する
+	if !slices.Contains(opts.ogenDisableFeatures, gen.OgenOtel.Name) {
+		info.tracerProviderOption = "WithTracerProvider"
+	}
+	for _, op := range g.Operations() {
+		operation := ogenOperation(op)
+		operation.ServerURL = operationServerURL(parsedSpec, operation.Path, operation.HTTPMethod)
+		info.operations = append(info.operations, operation)
+	}
+	// IRを構築できなかったオペレーションはnet/httpで呼び出す
+	info.operations = append(info.operations, fallbackOperations(parsedSpec, g.Operations(), opts.ogen.Filters)...)
+	for _, operation := range info.operations {
+		operation.Flatten = flattenPaths(ogenOperationExtension(parsedSpec, operation.Path, operation.HTTPMethod, extensionFlatten))
+		operation.Summarize = summarizeOptions(ogenOperationExtension(parsedSpec, operation.Path, operation.HTTPMethod, extensionSummarize))
+		operation.ToolName = extensionString(ogenOperationExtension(parsedSpec, operation.Path, operation.HTTPMethod, extensionMCPName))
+	}
+	return info, nil
+}
+
+// ogen のオペレーションをバックエンドに依存しない情報に変換
+func ogenOperation(op *ir.Operation) *operation {
+	result := &operation{
+		Name:        op.Name,
+		OperationID: op.Spec.OperationID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		HTTPMethod:  op.Spec.HTTPMethod,
+		Path:        op.Spec.Path.String(),
+		Tags:        op.Spec.Tags,
+		Response:    operationMockResponse(op),
+		TimeFields:  ogenTimeFields(op),
+	}
+	if result.OperationID == "" {
+		result.OperationID = op.Name
+	}
+	result.NoResponseStatus, result.EmptyResponses = emptyResponses(op)
+	if result.Summary == "" {
+		result.Summary = op.Spec.Summary
+	}
+
+	// ogen のパラメータの型にはパス、クエリ、ヘッダー、クッキーのすべてが含まれる
+	if len(op.Params) > 0 {
+		result.ParamsType = op.Name + "Params"
+		result.ParamsRequired = hasRequiredParams(op)
+		result.ParamsExample = parameterExamples(op)
+		result.ParamsDefaults = parameterDefaults(op)
+		result.ParamArguments = map[string]string{}
+		for _, param := range op.Params {
+			argument := param.Name
+			if tag := param.Tag.ExtraTags["json"]; tag != "" {
+				argument = tag
+			}
+			result.ParamFields = append(result.ParamFields, argument)
+			if param.Spec != nil && argument != param.Spec.Name {
+				result.ParamArguments[string(param.Spec.In)+":"+param.Spec.Name] = argument
+			}
+		}
+	}
+	if op.Request != nil {
+		result.BodyType = op.Request.Type.Name
+		result.BodyPointer = op.Request.DoTakePtr()
+		result.BodyIsStruct = op.Request.Type.IsStruct()
+		result.BodyRequired = op.Request.Spec != nil && op.Request.Spec.Required
+		result.BodyExample, _ = requestBodyExample(op)
+		result.BodyDefaults = requestBodyDefaults(op)
+		if result.BodyIsStruct {
+			for _, field := range op.Request.Type.Fields {
+				if field.Inline != ir.InlineNone {
+					continue
+				}
+				name := field.Tag.JSON
+				if name == "" {
+					name = field.Name
+				}
+				result.BodyFields = append(result.BodyFields, name)
+			}
+		}
+	}
+	return result
+}
+
+// パスまたはオペレーションのserversからURLを取得
+// オペレーションのserversはパスのserversより優先される
+func operationServerURL(spec *ogen.Spec, path, method string) string {
+	pathItem := spec.Paths[path]
+	if pathItem == nil {
+		return ""
+	}
+	if op := getOperations(pathItem)[strings.ToLower(method)]; op != nil && len(op.Servers) > 0 {
+		return defaultServerURL(op.Servers)
+	}
+	return defaultServerURL(pathItem.Servers)
+}
+
+// ボディの無い成功レスポンスを取得
+// レスポンスが空の構造体1つだけの場合、ogen のクライアントはエラーのみを返すためそのステータスコードを返す
+func emptyResponses(op *ir.Operation) (int, map[string]int) {
+	codes := make([]int, 0, len(op.Responses.StatusCode))
+	for code := range op.Responses.StatusCode {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	if !op.Responses.DoPass() {
+		if len(codes) == 1 {
+			return codes[0], nil
+		}
+		return http.StatusOK, nil
+	}
+	var types map[string]int
+	for _, code := range codes {
+		if response := op.Responses.StatusCode[code]; code >= 200 && code < 300 && response.NoContent != nil {
+			if types == nil {
+				types = map[string]int{}
+			}
+			types[response.NoContent.Name] = code
+		}
+	}
+	return 0, types
+}
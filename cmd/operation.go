@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// クライアントのバックエンドに依存しないオペレーションの情報
+// ツール、サーバー、モックの生成はこの情報だけを使う
+type operation struct {
+	// クライアントのメソッド名（ToolNameが無い場合はツール名としても使用）
+	Name string
+	// 設定ファイルで上書きしたツール名
+	ToolName    string
+	OperationID string
+	Summary     string
+	Description string
+	HTTPMethod  string
+	// OpenAPIのパス（例: /pets/{petId}）
+	Path string
+	Tags []string
+	// パスまたはオペレーションのserversで指定されたURL（既定のベースURLを使う場合は空）
+	ServerURL string
+
+	// パラメータの型名（パラメータが無い場合は空）
+	ParamsType string
+	// 必須のパラメータが存在するか
+	ParamsRequired bool
+	// パラメータの例（キーはパラメータの型のフィールド名）
+	ParamsExample map[string]any
+	// パラメータの既定値（キーはParamsExampleと同じ）
+	ParamsDefaults map[string]any
+	// パラメータのツールの引数名（キーは in:name、パラメータ名と同じ場合は省略）
+	ParamArguments map[string]string
+	// パラメータの型のフィールドのJSONでの名前（フラット入力での名前の衝突の検出に使用）
+	ParamFields []string
+
+	// リクエストボディの型名（リクエストボディが無い場合は空）
+	BodyType string
+	// リクエストボディをポインタで受け取るか
+	BodyPointer bool
+	// リクエストボディが構造体か（フラット入力で展開できるか）
+	BodyIsStruct bool
+	BodyRequired bool
+	// 構造体のリクエストボディのフィールドのJSONでの名前
+	BodyFields []string
+	// リクエストボディの例
+	BodyExample any
+	// 構造体のリクエストボディのフィールドの既定値（キーはJSONのフィールド名）
+	BodyDefaults map[string]any
+
+	// ツールの結果で親のオブジェクトに持ち上げるパス（x-mcp-flatten）
+	Flatten []string
+	// 大きな結果をクライアントのLLMで要約する設定（x-mcp-summarize、無ければ nil）
+	Summarize *summarizeConfig
+	// 202の後に完了までポーリングする設定（x-mcp-longrunning、対象外なら nil）
+	LongRunning *longRunningConfig
+	// 成功時のレスポンスの日時のフィールドのパス
+	TimeFields []string
+	// クライアントがレスポンスの値を返さない場合の成功時のステータスコード（値を返す場合は0）
+	NoResponseStatus int
+	// ボディの無い成功レスポンスの型名とステータスコード
+	EmptyResponses map[string]int
+
+	// 成功時のレスポンス（モックで使用）
+	Response mockResponse
+
+	// クライアントを生成できずnet/httpで呼び出す場合の情報
+	Fallback *fallbackOperation
+}
+
+// ツール名
+func (o *operation) toolName() string {
+	if o.ToolName != "" {
+		return o.ToolName
+	}
+	return o.Name
+}
+
+// パラメータとリクエストボディをトップレベルの引数に展開するか
+// フォールバックのツールと、展開すると引数名が衝突するオペレーションは入れ子の入力とする
+func (o *operation) flatInput(enabled bool) bool {
+	return enabled && o.Fallback == nil && len(o.flatInputConflicts()) == 0
+}
+
+// フラット入力でパラメータとリクエストボディのフィールドが衝突する引数名
+func (o *operation) flatInputConflicts() []string {
+	if o.BodyType == "" {
+		return nil
+	}
+	// 構造体以外のリクエストボディは requestBody という引数になる
+	bodyFields := []string{"requestBody"}
+	if o.BodyIsStruct {
+		bodyFields = o.BodyFields
+	}
+	var conflicts []string
+	for _, name := range o.ParamFields {
+		if slices.Contains(bodyFields, name) {
+			conflicts = append(conflicts, name)
+		}
+	}
+	return conflicts
+}
+
+// ツールの説明（説明が無い場合は概要）
+func (o *operation) toolDescription() string {
+	if o.Description != "" {
+		return o.Description
+	}
+	return o.Summary
+}
+
+// 生成したクライアントの情報
+type clientInfo struct {
+	operations []*operation
+	// 仕様書のserversから得た既定のベースURL
+	baseURL string
+	// ツールが受け取るクライアントの型名
+	clientType string
+	// クライアントを作成する関数名
+	constructor string
+	// クライアントの作成時にHTTPクライアントを指定するオプションの関数名
+	httpClientOption string
+	// リクエスト先のURLをcontextで指定する関数名（クライアント側で対応する場合は空）
+	serverURLContext string
+	// クライアントの作成時にTracerProviderを指定するオプションの関数名（対応しない場合は空）
+	tracerProviderOption string
+	// クライアントの作成時にSecuritySourceを受け取るか
+	hasSecuritySource bool
+	// MCPサーバーのinstructions（仕様書のinfoとx-mcp-instructionsから作成）
+	instructions string
+	// 生成したサーバーが公開するトランスポート
+	transports []string
+	// 出力ディレクトリのインポートパス
+	importPath string
+}
+
+// クライアントコードを生成するバックエンド
+type clientBackend interface {
+	// クライアントを生成し、オペレーションの情報を返す
+	generate(spec []byte, outputPath, packageName string, opts generateOptions) (*clientInfo, error)
+}
+
+// バックエンドの名前
+const (
+	backendOgen        = "ogen"
+	backendOapiCodegen = "oapi-codegen"
+)
+
+// 名前からバックエンドを取得
+func newClientBackend(name string) (clientBackend, error) {
+	switch name {
+	case backendOgen:
+		return ogenBackend{}, nil
+	case backendOapiCodegen:
+		return oapiCodegenBackend{}, nil
+	}
+	return nil, fmt.Errorf("unknown client backend %q", name)
+}
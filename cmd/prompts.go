@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/go-faster/yaml"
+)
+
+// タグのプロンプト
+type tagPrompt struct {
+	tag         string
+	description string
+	operations  []*operation
+}
+
+// プロンプト名に使えない文字
+var promptNameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// タグごとのプロンプトを組み立てる
+// 仕様書のtagsの順に並べ、tagsに無いタグはオペレーションの順に続ける
+func buildTagPrompts(spec []byte, operations []*operation) ([]*tagPrompt, error) {
+	var doc struct {
+		Tags []struct {
+			Name        string `yaml:"name"`
+			Description string `yaml:"description"`
+		} `yaml:"tags"`
+	}
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	var prompts []*tagPrompt
+	byTag := map[string]*tagPrompt{}
+	for _, tag := range doc.Tags {
+		if _, ok := byTag[tag.Name]; ok || tag.Name == "" {
+			continue
+		}
+		prompt := &tagPrompt{tag: tag.Name, description: tag.Description}
+		byTag[tag.Name] = prompt
+		prompts = append(prompts, prompt)
+	}
+	for _, operation := range operations {
+		for _, tag := range operation.Tags {
+			prompt, ok := byTag[tag]
+			if !ok {
+				prompt = &tagPrompt{tag: tag}
+				byTag[tag] = prompt
+				prompts = append(prompts, prompt)
+			}
+			prompt.operations = append(prompt.operations, operation)
+		}
+	}
+	// オペレーションの無いタグは除く
+	var result []*tagPrompt
+	for _, prompt := range prompts {
+		if len(prompt.operations) > 0 {
+			result = append(result, prompt)
+		}
+	}
+	return result, nil
+}
+
+// プロンプト名（例: manage_invoices）
+func (p *tagPrompt) name() string {
+	name := strings.Trim(promptNameInvalid.ReplaceAllString(strings.ToLower(p.tag), "_"), "_")
+	if name == "" {
+		name = "operations"
+	}
+	return "manage_" + name
+}
+
+// プロンプトの説明（タグの説明が無い場合はタグ名から作る）
+func (p *tagPrompt) promptDescription() string {
+	if description := strings.TrimSpace(p.description); description != "" {
+		return description
+	}
+	return fmt.Sprintf("Manage %s with the API.", p.tag)
+}
+
+// タグごとのプロンプトを提供するpromptsパッケージを生成
+func generatePrompts(info *clientInfo, spec []byte, outputPath string, opts generateOptions) error {
+	prompts, err := buildTagPrompts(spec, info.operations)
+	if err != nil {
+		return err
+	}
+	promptsDir := filepath.Join(outputPath, "prompts")
+
+	// ディレクトリを作成
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create prompts directory: %w", err)
+	}
+
+	functions := "github.com/nonchan7720/oas-mcp/functions"
+
+	f := jen.NewFile("prompts")
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+	f.PackageComment("Package prompts provides an MCP prompt per tag of the API, introducing the tools of the tag")
+	f.PackageComment("with the summaries and examples of the operations. Add them to the server with")
+	f.PackageComment("server.WithPrompts(prompts.Prompts...).")
+
+	f.Comment("Prompts are the prompts of the tags, in the order of the tags of the spec.")
+	f.Var().Id("Prompts").Op("=").Index().Qual(functions, "ToolPrompt").ValuesFunc(func(g *jen.Group) {
+		for _, prompt := range prompts {
+			g.Line().Values(jen.Dict{
+				jen.Id("Name"):        jen.Lit(prompt.name()),
+				jen.Id("Description"): jen.Lit(prompt.promptDescription()),
+				jen.Id("Tools"): jen.Index().Qual(functions, "PromptTool").ValuesFunc(func(g *jen.Group) {
+					for _, operation := range prompt.operations {
+						summary := operation.Summary
+						if summary == "" {
+							summary, _, _ = strings.Cut(strings.TrimSpace(operation.Description), "\n")
+						}
+						values := jen.Dict{jen.Id("Name"): jen.Lit(operation.toolName())}
+						if summary != "" {
+							values[jen.Id("Summary")] = jen.Lit(summary)
+						}
+						flatInput := operation.flatInput(opts.flatInput)
+						if example := operationExample(operation, flatInput); len(example) > 0 {
+							values[jen.Id("Example")] = jsonLiteral(example)
+						}
+						g.Line().Values(values)
+					}
+					g.Line()
+				}),
+			})
+		}
+		g.Line()
+	})
+
+	return f.Save(filepath.Join(promptsDir, "prompts.go"))
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 仕様書を取得するタイムアウト
+const specFetchTimeout = 30 * time.Second
+
+// URLの仕様書か
+func isRemoteSpec(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// 仕様書を読み込む
+// location がURLの場合はダウンロードし、headers（値の環境変数は展開する）をリクエストに付ける
+func readSpec(location string, headers http.Header, cache bool) ([]byte, error) {
+	if !isRemoteSpec(location) {
+		return os.ReadFile(location)
+	}
+	var dir string
+	if cache {
+		if base, err := os.UserCacheDir(); err == nil {
+			dir = filepath.Join(base, "oas-mcp", "specs")
+		}
+	}
+	return fetchSpec(location, headers, dir)
+}
+
+// URLの仕様書をダウンロードする
+// dir が空でなければETagとLast-Modifiedでキャッシュし、変更が無ければ（304）キャッシュを使う
+// 接続できない場合やサーバーのエラーの場合は、キャッシュがあれば警告を出して使う
+func fetchSpec(location string, headers http.Header, dir string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, application/yaml;q=0.9, */*;q=0.8")
+	for name, values := range headers {
+		for _, value := range values {
+			req.Header.Add(name, os.ExpandEnv(value))
+		}
+	}
+
+	var cached *cachedSpec
+	if dir != "" {
+		key := sha256.Sum256([]byte(location))
+		cached = &cachedSpec{path: filepath.Join(dir, hex.EncodeToString(key[:]))}
+		if cached.load() {
+			if cached.etag != "" {
+				req.Header.Set("If-None-Match", cached.etag)
+			}
+			if cached.lastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.lastModified)
+			}
+		}
+	}
+
+	client := &http.Client{Timeout: specFetchTimeout}
+	res, err := client.Do(req)
+	if err != nil {
+		return cached.fallback(location, err)
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode == http.StatusNotModified && cached != nil && cached.body != nil:
+		log.Printf("Using cached OpenAPI spec of %s (not modified)", location)
+		return cached.body, nil
+	case res.StatusCode >= 500:
+		return cached.fallback(location, fmt.Errorf("unexpected status %s", res.Status))
+	case res.StatusCode != http.StatusOK:
+		// 認証の誤りなどはキャッシュで隠さない
+		return nil, fmt.Errorf("failed to download %s: unexpected status %s", location, res.Status)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return cached.fallback(location, err)
+	}
+	if cached != nil {
+		cached.body = body
+		cached.etag = res.Header.Get("ETag")
+		cached.lastModified = res.Header.Get("Last-Modified")
+		if err := cached.save(); err != nil {
+			log.Printf("Failed to cache OpenAPI spec: %v", err)
+		}
+	}
+	log.Printf("Downloaded OpenAPI spec from %s", location)
+	return body, nil
+}
+
+// キャッシュした仕様書
+// path に本文、path.meta にETagとLast-Modifiedを1行ずつ保存する
+type cachedSpec struct {
+	path         string
+	body         []byte
+	etag         string
+	lastModified string
+}
+
+func (c *cachedSpec) load() bool {
+	body, err := os.ReadFile(c.path)
+	if err != nil {
+		return false
+	}
+	meta, err := os.ReadFile(c.path + ".meta")
+	if err != nil {
+		return false
+	}
+	c.body = body
+	c.etag, c.lastModified, _ = strings.Cut(strings.TrimRight(string(meta), "\n"), "\n")
+	return true
+}
+
+func (c *cachedSpec) save() error {
+	if c.etag == "" && c.lastModified == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, c.body, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path+".meta", []byte(c.etag+"\n"+c.lastModified+"\n"), 0644)
+}
+
+// ダウンロードに失敗した場合にキャッシュを返す
+func (c *cachedSpec) fallback(location string, err error) ([]byte, error) {
+	if c == nil || c.body == nil {
+		return nil, fmt.Errorf("failed to download %s: %w", location, err)
+	}
+	log.Printf("Failed to download %s: %v: using the cached OpenAPI spec", location, err)
+	return c.body, nil
+}
@@ -0,0 +1,1094 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/dave/jennifer/jen"
+	"github.com/goccy/go-yaml"
+)
+
+// MCP Serverを生成
+// spec は仕様書で、JSONに変換してサーバーのバイナリに埋め込む
+func generateMCPServer(info *clientInfo, spec []byte, outputPath string) error {
+	// サーバーディレクトリ
+	serverDir := filepath.Join(outputPath, "server")
+
+	// ディレクトリを作成
+	if err := os.MkdirAll(serverDir, 0755); err != nil {
+		return fmt.Errorf("failed to create server directory: %w", err)
+	}
+
+	// 埋め込む仕様書
+	specJSON, err := specToJSON(spec)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(serverDir, specFileName), specJSON, 0644); err != nil {
+		return fmt.Errorf("failed to write embedded OpenAPI spec: %w", err)
+	}
+
+	// サーバーファイルパス
+	serverFilePath := filepath.Join(serverDir, "server.go")
+	// Jenniferを使ってサーバーコードを生成
+	return generateMCPServerWithJennifer(info, serverFilePath)
+}
+
+// サーバーに埋め込む仕様書のファイル名
+const specFileName = "openapi.json"
+
+// 仕様書をキーの順序を保ってインデントしたJSONにする
+func specToJSON(spec []byte) ([]byte, error) {
+	data := spec
+	if !json.Valid(spec) {
+		var err error
+		if data, err = yaml.YAMLToJSON(spec); err != nil {
+			return nil, fmt.Errorf("failed to convert OpenAPI spec to JSON: %w", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, fmt.Errorf("failed to format OpenAPI spec: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// StartServerのオプション
+type serverOption struct {
+	// オプションの関数名
+	name string
+	// ドキュメントコメント
+	comment []string
+	// オプションの引数
+	params []jen.Code
+	// options に値を設定する処理
+	body []jen.Code
+}
+
+// options 構造体のフィールド
+type serverOptionField struct {
+	name string
+	typ  jen.Code
+}
+
+// Jenniferを使用してMCPサーバーコードを生成
+func generateMCPServerWithJennifer(info *clientInfo, outputPath string) error {
+	hasSecuritySource := info.hasSecuritySource
+	baseURL := info.baseURL
+	// クライアントパッケージへの参照
+	oasClient := info.importPath + "/client"
+	// toolsパッケージへの参照
+	toolsPath := info.importPath + "/tools"
+	// 上流APIのモックへの参照
+	mockPath := info.importPath + "/mock"
+	functions := "github.com/nonchan7720/oas-mcp/functions"
+	mcpServerPkg := "github.com/mark3labs/mcp-go/server"
+	tracePkg := "go.opentelemetry.io/otel/trace"
+
+	// ファイル作成
+	f := jen.NewFile("server")
+
+	// ファイルコメント
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+
+	// インポート
+	f.ImportName("context", "context")
+	f.ImportName("log/slog", "slog")
+	f.ImportName("net/http", "http")
+	f.ImportName("os", "os")
+	f.ImportName("os/signal", "signal")
+	f.ImportName("syscall", "syscall")
+	f.ImportName(mcpServerPkg, "server")
+	f.ImportName(functions, "functions")
+	// 生成されたOpenAPIクライアントとツールのパスを指定
+	f.ImportName(oasClient, "client")
+	f.ImportName(toolsPath, "tools")
+	f.ImportName(mockPath, "mock")
+	f.Anon("embed")
+
+	// options 構造体のフィールド
+	fields := []serverOptionField{
+		{name: "serverOptions", typ: jen.Index().Qual(mcpServerPkg, "ServerOption")},
+		{name: "requestEditors", typ: jen.Index().Qual(functions, "RequestEditor")},
+		{name: "client", typ: jen.Op("*").Qual(oasClient, info.clientType)},
+		{name: "baseURL", typ: jen.String()},
+		{name: "httpClient", typ: jen.Qual(functions, "HTTPDoer")},
+		{name: "mockUpstream", typ: jen.Bool()},
+		{name: "transportOptions", typ: jen.Op("*").Qual(functions, "TransportOptions")},
+		{name: "connMetrics", typ: jen.Op("*").Qual(functions, "ConnMetrics")},
+		{name: "streamOptions", typ: jen.Op("*").Qual(functions, "StreamOptions")},
+		{name: "pageSize", typ: jen.Int()},
+		{name: "batch", typ: jen.Op("*").Qual(functions, "BatchOptions")},
+		{name: "maxResultSize", typ: jen.Int()},
+		{name: "resourceLinks", typ: jen.Bool()},
+		{name: "blobStore", typ: jen.Qual(functions, "BlobStore")},
+		{name: "blobMaxBytes", typ: jen.Int()},
+		{name: "stripEmpty", typ: jen.Bool()},
+		{name: "outputFormat", typ: jen.Qual(functions, "OutputFormat")},
+		{name: "redactPatterns", typ: jen.Index().String()},
+		{name: "normalizeTimes", typ: jen.Bool()},
+		{name: "timeLayouts", typ: jen.Index().String()},
+		{name: "flatten", typ: jen.Map(jen.String()).Index().String()},
+		{name: "logger", typ: jen.Op("*").Qual("log/slog", "Logger")},
+		{name: "basePath", typ: jen.String()},
+		{name: "sessionStore", typ: jen.Qual(functions, "SessionStore")},
+		{name: "webSocketOrigins", typ: jen.Index().String()},
+		{name: "keepAlive", typ: jen.Qual("time", "Duration")},
+		{name: "idleTimeout", typ: jen.Qual("time", "Duration")},
+		{name: "resumableStreams", typ: jen.Bool()},
+		{name: "streamHistory", typ: jen.Int()},
+		{name: "elicitMissing", typ: jen.Bool()},
+		{name: "mcpLogging", typ: jen.Bool()},
+		{name: "webhooks", typ: jen.Bool()},
+		{name: "webhookVerifier", typ: jen.Qual(functions, "WebhookVerifier")},
+		{name: "operationResources", typ: jen.Bool()},
+		{name: "pollInterval", typ: jen.Qual("time", "Duration")},
+		{name: "resources", typ: jen.Op("*").Qual(functions, "OperationResources")},
+		{name: "prompts", typ: jen.Index().Qual(functions, "ToolPrompt")},
+		{name: "instructions", typ: jen.String()},
+		{name: "capabilities", typ: jen.Op("*").Qual(functions, "Capabilities")},
+		{name: "tracerProvider", typ: jen.Qual(tracePkg, "TracerProvider")},
+		{name: "registryOptions", typ: jen.Index().Func().Params(jen.Op("*").Qual(functions, "Registry"))},
+		{name: "userAgent", typ: jen.String()},
+		{name: "requestIDHeader", typ: jen.String()},
+		{name: "gzip", typ: jen.Bool()},
+		{name: "gzipMinRequestSize", typ: jen.Int()},
+		{name: "htmlFormat", typ: jen.Qual(functions, "HTMLFormat")},
+		{name: "csv", typ: jen.Bool()},
+		{name: "csvMaxRows", typ: jen.Int()},
+	}
+	if hasSecuritySource {
+		fields = append(fields, serverOptionField{name: "securitySource", typ: jen.Qual(oasClient, "SecuritySource")})
+	}
+
+	options := []serverOption{
+		{
+			name:    "WithServerOptions",
+			comment: []string{"WithServerOptions adds options of the MCP server."},
+			params:  []jen.Code{jen.Id("opts").Op("...").Qual(mcpServerPkg, "ServerOption")},
+			body: []jen.Code{
+				jen.Id("o").Dot("serverOptions").Op("=").Append(jen.Id("o").Dot("serverOptions"), jen.Id("opts").Op("...")),
+			},
+		},
+		{
+			name: "WithRequestEditors",
+			comment: []string{
+				"WithRequestEditors adds editors applied in order to every request sent to the upstream API,",
+				"e.g. to add headers, sign or log the request.",
+			},
+			params: []jen.Code{jen.Id("editors").Op("...").Qual(functions, "RequestEditor")},
+			body: []jen.Code{
+				jen.Id("o").Dot("requestEditors").Op("=").Append(jen.Id("o").Dot("requestEditors"), jen.Id("editors").Op("...")),
+			},
+		},
+		{
+			name: "WithClient",
+			comment: []string{
+				"WithClient uses the pre-built client instead of creating one.",
+				"The base URL, HTTP client, request editors and security source are not applied to it.",
+			},
+			params: []jen.Code{jen.Id("c").Op("*").Qual(oasClient, info.clientType)},
+			body:   []jen.Code{jen.Id("o").Dot("client").Op("=").Id("c")},
+		},
+		{
+			name:    "WithBaseURL",
+			comment: []string{"WithBaseURL sets the base URL of the API. It takes precedence over the API_BASE_URL environment variable."},
+			params:  []jen.Code{jen.Id("baseURL").String()},
+			body:    []jen.Code{jen.Id("o").Dot("baseURL").Op("=").Id("baseURL")},
+		},
+	}
+	if hasSecuritySource {
+		options = append(options, serverOption{
+			name:    "WithSecurity",
+			comment: []string{"WithSecurity sets the source of the credentials sent to the API."},
+			params:  []jen.Code{jen.Id("source").Qual(oasClient, "SecuritySource")},
+			body:    []jen.Code{jen.Id("o").Dot("securitySource").Op("=").Id("source")},
+		})
+	}
+	options = append(options,
+		serverOption{
+			name:    "WithHTTPClient",
+			comment: []string{"WithHTTPClient sets the HTTP client sending the requests to the API. It defaults to http.DefaultClient."},
+			params:  []jen.Code{jen.Id("doer").Qual(functions, "HTTPDoer")},
+			body:    []jen.Code{jen.Id("o").Dot("httpClient").Op("=").Id("doer")},
+		},
+		serverOption{
+			name:    "WithTransport",
+			comment: []string{"WithTransport sends the requests to the API with a HTTP client using the transport."},
+			params:  []jen.Code{jen.Id("transport").Qual("net/http", "RoundTripper")},
+			body: []jen.Code{
+				jen.Id("o").Dot("httpClient").Op("=").Op("&").Qual("net/http", "Client").Values(jen.Dict{
+					jen.Id("Transport"): jen.Id("transport"),
+				}),
+			},
+		},
+		serverOption{
+			name: "WithUserAgent",
+			comment: []string{
+				"WithUserAgent sets the product sent as User-Agent to the API, followed by the tool name,",
+				"e.g. \"my-agent/1.0 (GetPet)\". It defaults to functions.DefaultUserAgent(); an empty product disables the header.",
+			},
+			params: []jen.Code{jen.Id("product").String()},
+			body:   []jen.Code{jen.Id("o").Dot("userAgent").Op("=").Id("product")},
+		},
+		serverOption{
+			name: "WithRequestIDHeader",
+			comment: []string{
+				"WithRequestIDHeader sets the header sending the request id of every tool call to the API.",
+				"The id is taken from the requestId of the _meta of the request or generated, logged and",
+				"returned in the _meta of the result. It defaults to functions.RequestIDHeader; an empty header disables it.",
+			},
+			params: []jen.Code{jen.Id("header").String()},
+			body:   []jen.Code{jen.Id("o").Dot("requestIDHeader").Op("=").Id("header")},
+		},
+		serverOption{
+			name: "WithGzip",
+			comment: []string{
+				"WithGzip compresses the request bodies of at least minRequestSize bytes with gzip and",
+				"transparently decompresses gzip encoded responses. A negative minRequestSize only compresses the responses.",
+			},
+			params: []jen.Code{jen.Id("minRequestSize").Int()},
+			body: []jen.Code{
+				jen.Id("o").Dot("gzip").Op("=").True(),
+				jen.Id("o").Dot("gzipMinRequestSize").Op("=").Id("minRequestSize"),
+			},
+		},
+		serverOption{
+			name: "WithHTMLConversion",
+			comment: []string{
+				"WithHTMLConversion converts the text/html responses of the API to markdown or plain text",
+				"before returning them, since raw HTML wastes tokens and confuses models.",
+			},
+			params: []jen.Code{jen.Id("format").Qual(functions, "HTMLFormat")},
+			body:   []jen.Code{jen.Id("o").Dot("htmlFormat").Op("=").Id("format")},
+		},
+		serverOption{
+			name: "WithCSVConversion",
+			comment: []string{
+				"WithCSVConversion converts the text/csv and text/tab-separated-values responses of the API",
+				"to JSON arrays of objects keyed by the header row, keeping at most maxRows rows (0 for no limit).",
+			},
+			params: []jen.Code{jen.Id("maxRows").Int()},
+			body: []jen.Code{
+				jen.Id("o").Dot("csv").Op("=").True(),
+				jen.Id("o").Dot("csvMaxRows").Op("=").Id("maxRows"),
+			},
+		},
+		serverOption{
+			name: "WithTransportOptions",
+			comment: []string{
+				"WithTransportOptions tunes the connection pool of the HTTP client sending the requests to the API.",
+				"It is ignored when WithHTTPClient or WithTransport is used.",
+			},
+			params: []jen.Code{jen.Id("transportOptions").Qual(functions, "TransportOptions")},
+			body:   []jen.Code{jen.Id("o").Dot("transportOptions").Op("=").Op("&").Id("transportOptions")},
+		},
+		serverOption{
+			name:    "WithConnMetrics",
+			comment: []string{"WithConnMetrics records whether the requests to the API reuse connections."},
+			params:  []jen.Code{jen.Id("metrics").Op("*").Qual(functions, "ConnMetrics")},
+			body:    []jen.Code{jen.Id("o").Dot("connMetrics").Op("=").Id("metrics")},
+		},
+		serverOption{
+			name: "WithStreaming",
+			comment: []string{
+				"WithStreaming decodes the responses of the API incrementally, keeping only the items and fields",
+				"allowed by the options, so that very large responses do not exhaust the memory.",
+				"Clients reading the whole body before decoding it, such as the ogen client, fail the call",
+				"when the response exceeds MaxBytes instead of returning the part read so far.",
+			},
+			params: []jen.Code{jen.Id("streamOptions").Qual(functions, "StreamOptions")},
+			body:   []jen.Code{jen.Id("o").Dot("streamOptions").Op("=").Op("&").Id("streamOptions")},
+		},
+		serverOption{
+			name: "WithResponseProcessor",
+			comment: []string{
+				"WithResponseProcessor post-processes the responses of the tools having one of the tags,",
+				"or of every tool when no tag is given, before they are returned to the client.",
+			},
+			params: []jen.Code{
+				jen.Id("processor").Qual(functions, "ResponseProcessor"),
+				jen.Id("tags").Op("...").String(),
+			},
+			body: []jen.Code{
+				jen.Id("o").Dot("registryOptions").Op("=").Append(jen.Id("o").Dot("registryOptions"), jen.Func().Params(jen.Id("r").Op("*").Qual(functions, "Registry")).Block(
+					jen.Id("r").Dot("WithResponseProcessor").Call(jen.Id("processor"), jen.Id("tags").Op("...")),
+				)),
+			},
+		},
+		serverOption{
+			name: "WithTracerProvider",
+			comment: []string{
+				"WithTracerProvider sets the tracer provider recording a span for every tool call.",
+				"The spans of the tools and of the requests to the API have the tool name, operation id,",
+				"tags and MCP session id as attributes. It defaults to the global tracer provider.",
+			},
+			params: []jen.Code{jen.Id("tp").Qual(tracePkg, "TracerProvider")},
+			body:   []jen.Code{jen.Id("o").Dot("tracerProvider").Op("=").Id("tp")},
+		},
+		serverOption{
+			name: "WithMockUpstream",
+			comment: []string{
+				"WithMockUpstream answers every tool call with the example response of the operation in the",
+				"OpenAPI spec, served in process by the mock package, instead of calling the API. It is also",
+				"enabled by MOCK_UPSTREAM=true, e.g. to demo and test agents before the API is deployed.",
+				"It is ignored with WithClient.",
+			},
+			body: []jen.Code{jen.Id("o").Dot("mockUpstream").Op("=").True()},
+		},
+		serverOption{
+			name: "WithMaxResultSize",
+			comment: []string{
+				"WithMaxResultSize truncates the results larger than maxBytes bytes at a JSON-safe boundary and",
+				"returns them with a notice telling the original size and how to narrow the query.",
+				"It defaults to functions.DefaultMaxResultSize; 0 disables the limit.",
+			},
+			params: []jen.Code{jen.Id("maxBytes").Int()},
+			body:   []jen.Code{jen.Id("o").Dot("maxResultSize").Op("=").Id("maxBytes")},
+		},
+		serverOption{
+			name: "WithResourceLinks",
+			comment: []string{
+				"WithResourceLinks stores the binary results and the results larger than maxBytes bytes",
+				"(binary results only when 0) in the store and returns MCP resource links to them instead.",
+				"A store writing to a temporary directory is used when store is nil.",
+			},
+			params: []jen.Code{
+				jen.Id("store").Qual(functions, "BlobStore"),
+				jen.Id("maxBytes").Int(),
+			},
+			body: []jen.Code{
+				jen.Id("o").Dot("resourceLinks").Op("=").True(),
+				jen.Id("o").Dot("blobStore").Op("=").Id("store"),
+				jen.Id("o").Dot("blobMaxBytes").Op("=").Id("maxBytes"),
+			},
+		},
+		serverOption{
+			name: "WithRedaction",
+			comment: []string{
+				"WithRedaction masks the fields matching the JSONPath patterns, e.g. \"$.users[*].email\" or \"$..token\",",
+				"in every tool result and error body before it reaches the model. functions.DefaultRedactPatterns",
+				"covers common personal data and credentials.",
+			},
+			params: []jen.Code{jen.Id("patterns").Op("...").String()},
+			body: []jen.Code{
+				jen.Id("o").Dot("redactPatterns").Op("=").Append(jen.Id("o").Dot("redactPatterns"), jen.Id("patterns").Op("...")),
+			},
+		},
+		serverOption{
+			name: "WithStripEmpty",
+			comment: []string{
+				"WithStripEmpty removes the fields whose value is null, an empty string or an empty array",
+				"from the results of the tools, since verbose payloads burn the context of the model.",
+			},
+			body: []jen.Code{jen.Id("o").Dot("stripEmpty").Op("=").True()},
+		},
+		serverOption{
+			name: "WithOutputFormat",
+			comment: []string{
+				"WithOutputFormat renders the JSON results of the tools as YAML (functions.OutputYAML) or as",
+				"\"path: value\" lines (functions.OutputCompact) instead of JSON, which takes fewer tokens",
+				"for large results. The structuredContent stays JSON.",
+			},
+			params: []jen.Code{jen.Id("format").Qual(functions, "OutputFormat")},
+			body:   []jen.Code{jen.Id("o").Dot("outputFormat").Op("=").Id("format")},
+		},
+		serverOption{
+			name: "WithTimeNormalization",
+			comment: []string{
+				"WithTimeNormalization converts the date-time fields of the results, known from the format of the",
+				"response schemas, to RFC 3339 strings in UTC. Epoch numbers and common date-time strings are",
+				"recognized; layouts adds custom layouts of time.Parse.",
+			},
+			params: []jen.Code{jen.Id("layouts").Op("...").String()},
+			body: []jen.Code{
+				jen.Id("o").Dot("normalizeTimes").Op("=").True(),
+				jen.Id("o").Dot("timeLayouts").Op("=").Append(jen.Id("o").Dot("timeLayouts"), jen.Id("layouts").Op("...")),
+			},
+		},
+		serverOption{
+			name: "WithFlatten",
+			comment: []string{
+				"WithFlatten lifts the nested objects at the paths into their parent in the results of the operation,",
+				"e.g. functions.JSONAPIFlatten for JSON:API documents. It is applied in addition to x-mcp-flatten.",
+			},
+			params: []jen.Code{
+				jen.Id("operationID").String(),
+				jen.Id("paths").Op("...").String(),
+			},
+			body: []jen.Code{
+				jen.If(jen.Id("o").Dot("flatten").Op("==").Nil()).Block(
+					jen.Id("o").Dot("flatten").Op("=").Map(jen.String()).Index().String().Values(),
+				),
+				jen.Id("o").Dot("flatten").Index(jen.Id("operationID")).Op("=").Append(jen.Id("o").Dot("flatten").Index(jen.Id("operationID")), jen.Id("paths").Op("...")),
+			},
+		},
+		serverOption{
+			name: "WithPagination",
+			comment: []string{
+				"WithPagination returns the list results having more than pageSize items page by page.",
+				"The first page is returned with a cursor and a MCP resource URI to read the next pages,",
+				"and the items are kept per MCP session in the meantime.",
+			},
+			params: []jen.Code{jen.Id("pageSize").Int()},
+			body:   []jen.Code{jen.Id("o").Dot("pageSize").Op("=").Id("pageSize")},
+		},
+		serverOption{
+			name: "WithBatch",
+			comment: []string{
+				"WithBatch adds the batch tool calling several tools in one request, in order, with",
+				"references to the results of the previous steps, e.g. creating a resource and reading it",
+				"without a round-trip per call. The consecutive read-only calls run concurrently unless",
+				"opts.Sequential is set.",
+			},
+			params: []jen.Code{jen.Id("opts").Qual(functions, "BatchOptions")},
+			body:   []jen.Code{jen.Id("o").Dot("batch").Op("=").Op("&").Id("opts")},
+		},
+		serverOption{
+			name: "WithListPageSize",
+			comment: []string{
+				"WithListPageSize returns the tools/list responses, and the lists of resources and prompts,",
+				"in pages of at most size items with a cursor to the next page, keeping the responses",
+				"manageable for clients when the spec generates hundreds of tools.",
+			},
+			params: []jen.Code{jen.Id("size").Int()},
+			body: []jen.Code{
+				jen.If(jen.Id("size").Op(">").Lit(0)).Block(
+					jen.Id("o").Dot("serverOptions").Op("=").Append(jen.Id("o").Dot("serverOptions"), jen.Qual(mcpServerPkg, "WithPaginationLimit").Call(jen.Id("size"))),
+				),
+			},
+		},
+		serverOption{
+			name:    "WithLogger",
+			comment: []string{"WithLogger sets the logger of the server. It defaults to slog.Default()."},
+			params:  []jen.Code{jen.Id("logger").Op("*").Qual("log/slog", "Logger")},
+			body:    []jen.Code{jen.Id("o").Dot("logger").Op("=").Id("logger")},
+		},
+		serverOption{
+			name: "WithBasePath",
+			comment: []string{
+				"WithBasePath sets the path the endpoints are served under, e.g. /ai for /ai/sse,",
+				"/ai/message, /ai/mcp, /ai/ws and /ai/openapi.json, when the handler is mounted into another mux.",
+			},
+			params: []jen.Code{jen.Id("basePath").String()},
+			body:   []jen.Code{jen.Id("o").Dot("basePath").Op("=").Id("basePath")},
+		},
+		serverOption{
+			name: "WithSessionStore",
+			comment: []string{
+				"WithSessionStore keeps the sessions of the streamable HTTP transport in the store, e.g. Redis",
+				"with the functions/redisstore package, so that several replicas can serve the same sessions.",
+				"The SSE transport keeps a connection per session and needs sticky sessions instead.",
+			},
+			params: []jen.Code{jen.Id("store").Qual(functions, "SessionStore")},
+			body:   []jen.Code{jen.Id("o").Dot("sessionStore").Op("=").Id("store")},
+		},
+		serverOption{
+			name: "WithKeepAlive",
+			comment: []string{
+				"WithKeepAlive sends a ping every interval on the streams of the SSE and streamable HTTP",
+				"transports, so that the proxies do not close them as idle.",
+			},
+			params: []jen.Code{jen.Id("interval").Qual("time", "Duration")},
+			body:   []jen.Code{jen.Id("o").Dot("keepAlive").Op("=").Id("interval")},
+		},
+		serverOption{
+			name: "WithIdleTimeout",
+			comment: []string{
+				"WithIdleTimeout terminates the sessions of the streamable HTTP transport without requests nor",
+				"open streams for the timeout, e.g. the ones of the clients which lost their connection.",
+				"Their clients initialize a new session on their next request.",
+			},
+			params: []jen.Code{jen.Id("timeout").Qual("time", "Duration")},
+			body:   []jen.Code{jen.Id("o").Dot("idleTimeout").Op("=").Id("timeout")},
+		},
+		serverOption{
+			name: "WithResumableStreams",
+			comment: []string{
+				"WithResumableStreams numbers the events of the streams of the streamable HTTP transport and",
+				"keeps the last history events of each session (100 when 0), so that a client reconnecting",
+				"with the Last-Event-ID header receives the events it missed.",
+			},
+			params: []jen.Code{jen.Id("history").Int()},
+			body: []jen.Code{
+				jen.Id("o").Dot("resumableStreams").Op("=").True(),
+				jen.Id("o").Dot("streamHistory").Op("=").Id("history"),
+			},
+		},
+		serverOption{
+			name: "WithWebSocketOrigins",
+			comment: []string{
+				"WithWebSocketOrigins accepts the WebSocket connections from browsers of the origins matching",
+				"the patterns, e.g. \"*.example.com\", in addition to the same host.",
+			},
+			params: []jen.Code{jen.Id("patterns").Op("...").String()},
+			body: []jen.Code{
+				jen.Id("o").Dot("webSocketOrigins").Op("=").Append(jen.Id("o").Dot("webSocketOrigins"), jen.Id("patterns").Op("...")),
+			},
+		},
+		serverOption{
+			name: "WithElicitation",
+			comment: []string{
+				"WithElicitation asks the user, through MCP elicitation, for the required arguments the model",
+				"omitted, then completes the call. Clients without elicitation get the calls executed as is.",
+			},
+			body: []jen.Code{jen.Id("o").Dot("elicitMissing").Op("=").True()},
+		},
+		serverOption{
+			name: "WithMCPLogging",
+			comment: []string{
+				"WithMCPLogging sends the logs of the tool calls to the client as MCP logging notifications,",
+				"filtered by the level the client set with logging/setLevel, in addition to the logger.",
+			},
+			body: []jen.Code{jen.Id("o").Dot("mcpLogging").Op("=").True()},
+		},
+		serverOption{
+			name: "WithWebhooks",
+			comment: []string{
+				"WithWebhooks accepts the webhooks of the upstream API at /webhooks/<event> and forwards them",
+				"to the connected sessions as notifications and updates of the oas-mcp://webhooks/events",
+				"resource. The requests are checked with verify, e.g. functions.HMACVerifier, unless it is nil.",
+			},
+			params: []jen.Code{jen.Id("verify").Qual(functions, "WebhookVerifier")},
+			body: []jen.Code{
+				jen.Id("o").Dot("webhooks").Op("=").True(),
+				jen.Id("o").Dot("webhookVerifier").Op("=").Id("verify"),
+			},
+		},
+		serverOption{
+			name: "WithOperationResources",
+			comment: []string{
+				"WithOperationResources exposes the GET operations without required parameters as the MCP",
+				"resources oas-mcp://operations/<tool>, and supports resources/subscribe for them over the SSE,",
+				"streamable HTTP and WebSocket transports: the API is polled every interval (a minute when 0)",
+				"while a session is subscribed, and the session is notified when the payload changes.",
+			},
+			params: []jen.Code{jen.Id("interval").Qual("time", "Duration")},
+			body: []jen.Code{
+				jen.Id("o").Dot("operationResources").Op("=").True(),
+				jen.Id("o").Dot("pollInterval").Op("=").Id("interval"),
+			},
+		},
+		serverOption{
+			name: "WithInstructions",
+			comment: []string{
+				"WithInstructions sets the instructions sent to the clients on initialization, telling the",
+				"models what the API does. It defaults to Instructions; an empty string sends none.",
+			},
+			params: []jen.Code{jen.Id("instructions").String()},
+			body:   []jen.Code{jen.Id("o").Dot("instructions").Op("=").Id("instructions")},
+		},
+		serverOption{
+			name: "WithPrompts",
+			comment: []string{
+				"WithPrompts adds the MCP prompts introducing groups of tools, e.g. prompts.Prompts generated",
+				"with -prompts. The tools which are not registered are left out of the prompts.",
+			},
+			params: []jen.Code{jen.Id("prompts").Op("...").Qual(functions, "ToolPrompt")},
+			body: []jen.Code{
+				jen.Id("o").Dot("prompts").Op("=").Append(jen.Id("o").Dot("prompts"), jen.Id("prompts").Op("...")),
+			},
+		},
+		serverOption{
+			name: "WithCapabilities",
+			comment: []string{
+				"WithCapabilities sets the MCP capabilities declared to the clients, replacing the ones derived",
+				"from the other options. The features of the capabilities left out are turned off: the prompts",
+				"of WithPrompts, the logging notifications of WithMCPLogging and the subscriptions of",
+				"WithOperationResources, whose resources can still be read.",
+			},
+			params: []jen.Code{jen.Id("capabilities").Qual(functions, "Capabilities")},
+			body:   []jen.Code{jen.Id("o").Dot("capabilities").Op("=").Op("&").Id("capabilities")},
+		},
+	)
+
+	// 既定値を設定してオプションを適用
+	optionsBody := []jen.Code{
+		jen.Id("o").Op(":=").Op("&").Id("options").Values(jen.Dict{
+			jen.Id("logger"):          jen.Qual("log/slog", "Default").Call(),
+			jen.Id("userAgent"):       jen.Qual(functions, "DefaultUserAgent").Call(),
+			jen.Id("requestIDHeader"): jen.Qual(functions, "RequestIDHeader"),
+			jen.Id("tracerProvider"):  jen.Qual("go.opentelemetry.io/otel", "GetTracerProvider").Call(),
+			jen.Id("maxResultSize"):   jen.Qual(functions, "DefaultMaxResultSize"),
+			jen.Id("instructions"):    jen.Id("Instructions"),
+		}),
+		jen.For(jen.List(jen.Id("_"), jen.Id("opt")).Op(":=").Range().Id("opts")).Block(
+			jen.Id("opt").Call(jen.Id("o")),
+		),
+		jen.Return(jen.Id("o")),
+	}
+
+	// MCPサーバーを組み立てる
+	funcBody := []jen.Code{
+		// ベースURL
+		jen.Comment("ベースURLは WithBaseURL、API_BASE_URL の順に優先する"),
+		jen.Id("baseURL").Op(":=").Id("o").Dot("baseURL"),
+		jen.If(jen.Id("baseURL").Op("==").Lit("")).Block(
+			jen.Id("baseURL").Op("=").Qual("os", "Getenv").Call(jen.Lit("API_BASE_URL")),
+		),
+		jen.If(jen.List(jen.Id("mockUpstream"), jen.Id("_")).Op(":=").Qual("strconv", "ParseBool").Call(jen.Qual("os", "Getenv").Call(jen.Lit("MOCK_UPSTREAM"))), jen.Id("mockUpstream")).Block(
+			jen.Id("o").Dot("mockUpstream").Op("=").True(),
+		),
+		jen.If(jen.Id("o").Dot("mockUpstream")).Block(
+			jen.Comment("仕様書の例を返すモックをプロセス内で呼び出す（モックのルートはベースURLのパスを含まない）"),
+			jen.Id("baseURL").Op("=").Lit("http://mock.invalid"),
+			jen.Id("o").Dot("httpClient").Op("=").Qual(functions, "HandlerDoer").Call(jen.Qual(mockPath, "NewServer").Call()),
+		),
+		func() jen.Code {
+			if baseURL != "" {
+				return jen.If(jen.Id("baseURL").Op("==").Lit("")).Block(
+					jen.Id("baseURL").Op("=").Id("DefaultBaseURL"),
+				)
+			}
+			// 構築済みのクライアントを使う場合はベースURLが無くてもよい
+			return jen.If(jen.Id("baseURL").Op("==").Lit("").Op("&&").Id("o").Dot("client").Op("==").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Qual("errors", "New").Call(jen.Lit("base URL is required: set API_BASE_URL or use WithBaseURL"))),
+			)
+		}(),
+		// 上流APIへのリクエストに適用するエディタ
+		jen.Comment("上流APIへのリクエストに使うHTTPクライアント"),
+		jen.If(jen.Id("o").Dot("httpClient").Op("==").Nil()).Block(
+			jen.Id("o").Dot("httpClient").Op("=").Qual("net/http", "DefaultClient"),
+			jen.If(jen.Id("o").Dot("transportOptions").Op("!=").Nil()).Block(
+				jen.Id("o").Dot("httpClient").Op("=").Qual(functions, "NewHTTPClient").Call(jen.Op("*").Id("o").Dot("transportOptions")),
+			),
+		),
+		jen.If(jen.Id("o").Dot("connMetrics").Op("!=").Nil()).Block(
+			jen.Id("o").Dot("httpClient").Op("=").Qual(functions, "TraceConnections").Call(jen.Id("o").Dot("httpClient"), jen.Id("o").Dot("connMetrics")),
+		),
+		jen.Comment("すべての上流APIへのリクエストにエディタを適用"),
+		jen.Id("editors").Op(":=").Append(
+			jen.Index().Qual(functions, "RequestEditor").Values(jen.Qual(functions, "TraceRequests").Call()),
+			jen.Id("o").Dot("requestEditors").Op("..."),
+		),
+		jen.If(jen.Id("o").Dot("requestIDHeader").Op("!=").Lit("")).Block(
+			jen.Id("editors").Op("=").Append(jen.Id("editors"), jen.Qual(functions, "PropagateRequestID").Call(jen.Id("o").Dot("requestIDHeader"))),
+		),
+		jen.If(jen.Id("o").Dot("userAgent").Op("!=").Lit("")).Block(
+			jen.Comment("User-Agent は利用者のエディタで上書きできるよう先に設定する"),
+			jen.Id("editors").Op("=").Append(
+				jen.Index().Qual(functions, "RequestEditor").Values(jen.Qual(functions, "UserAgent").Call(jen.Id("o").Dot("userAgent"))),
+				jen.Id("editors").Op("..."),
+			),
+		),
+		jen.Id("doer").Op(":=").Qual(functions, "EditRequests").Call(
+			jen.Id("o").Dot("httpClient"),
+			jen.Id("editors").Op("..."),
+		),
+		jen.If(jen.Id("o").Dot("gzip")).Block(
+			jen.Comment("エディタが圧縮後のボディに署名できるよう、エディタより先に圧縮する"),
+			jen.Id("doer").Op("=").Qual(functions, "Gzip").Call(jen.Id("doer"), jen.Id("o").Dot("gzipMinRequestSize")),
+		),
+		jen.Comment("WithStreamingのMaxBytesをクライアントによらずレスポンスのボディに適用する（ogenのクライアントはボディをすべて読み込むため）"),
+		jen.Id("doer").Op("=").Qual(functions, "LimitResponses").Call(jen.Id("doer")),
+		jen.If(jen.Id("o").Dot("csv")).Block(
+			jen.Id("doer").Op("=").Qual(functions, "ConvertCSV").Call(jen.Id("doer"), jen.Id("o").Dot("csvMaxRows")),
+		),
+		jen.If(jen.Id("o").Dot("htmlFormat").Op("!=").Lit(0)).Block(
+			jen.Id("doer").Op("=").Qual(functions, "ConvertHTML").Call(jen.Id("doer"), jen.Id("o").Dot("htmlFormat")),
+		),
+		jen.Comment("400/422 のエラーボディから修正すべき引数をモデルに伝えるため、展開後のボディを保持する"),
+		jen.Id("doer").Op("=").Qual(functions, "CaptureErrorBodies").Call(jen.Id("doer")),
+		jen.Comment("202を返す長時間実行のオペレーションのステータスを同じクライアントでポーリングする"),
+		jen.Id("doer").Op("=").Qual(functions, "CaptureAccepted").Call(jen.Id("doer")),
+		// クライアント初期化
+		jen.Comment("クライアント初期化"),
+		jen.Id("apiClient").Op(":=").Id("o").Dot("client"),
+		jen.If(jen.Id("apiClient").Op("==").Nil()).BlockFunc(func(g *jen.Group) {
+			if hasSecuritySource {
+				g.If(jen.Id("o").Dot("securitySource").Op("==").Nil()).Block(
+					jen.Return(jen.Nil(), jen.Qual("errors", "New").Call(jen.Lit("security source is required: use WithSecurity"))),
+				)
+			}
+			g.List(jen.Id("c"), jen.Id("err")).Op(":=").Qual(oasClient, info.constructor).CallFunc(func(g *jen.Group) {
+				g.Id("baseURL")
+				if hasSecuritySource {
+					g.Id("o").Dot("securitySource")
+				}
+				g.Qual(oasClient, info.httpClientOption).Call(jen.Id("doer"))
+				if info.tracerProviderOption != "" {
+					g.Qual(oasClient, info.tracerProviderOption).Call(jen.Id("o").Dot("tracerProvider"))
+				}
+			})
+			g.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Id("err")),
+			)
+			g.Id("apiClient").Op("=").Id("c")
+		}),
+		jen.Line(),
+		// MCPサーバー初期化
+		jen.Comment("MCPサーバー初期化"),
+		jen.Comment("宣言する機能（WithCapabilitiesが無ければ他のオプションから決める）"),
+		jen.Id("capabilities").Op(":=").Qual(functions, "Capabilities").Values(jen.Dict{
+			jen.Id("ToolsListChanged"):   jen.True(),
+			jen.Id("ResourcesSubscribe"): jen.Id("o").Dot("operationResources"),
+			jen.Id("Prompts"):            jen.Len(jen.Id("o").Dot("prompts")).Op(">").Lit(0),
+			jen.Id("Logging"):            jen.Id("o").Dot("mcpLogging"),
+		}),
+		jen.If(jen.Id("o").Dot("capabilities").Op("!=").Nil()).Block(
+			jen.Id("capabilities").Op("=").Op("*").Id("o").Dot("capabilities"),
+		),
+		jen.Comment("WithServerOptionsで指定した機能を優先する"),
+		jen.Id("serverOptions").Op(":=").Append(jen.Id("capabilities").Dot("ServerOptions").Call(), jen.Id("o").Dot("serverOptions").Op("...")),
+		jen.If(jen.Id("o").Dot("instructions").Op("!=").Lit("")).Block(
+			jen.Comment("WithServerOptionsで指定したinstructionsを優先する"),
+			jen.Id("serverOptions").Op("=").Append(
+				jen.Index().Qual(mcpServerPkg, "ServerOption").Values(jen.Qual(mcpServerPkg, "WithInstructions").Call(jen.Id("o").Dot("instructions"))),
+				jen.Id("serverOptions").Op("..."),
+			),
+		),
+		jen.Id("mcpServer").Op(":=").Qual(mcpServerPkg, "NewMCPServer").Call(
+			jen.Id("name"),
+			jen.Id("version"),
+			jen.Id("serverOptions").Op("..."),
+		),
+		jen.If(jen.Id("o").Dot("mcpLogging").Op("&&").Id("capabilities").Dot("Logging")).Block(
+			jen.Comment("ツール呼び出しのログをクライアントにも送る"),
+			jen.Id("o").Dot("logger").Op("=").Qual("log/slog", "New").Call(
+				jen.Qual(functions, "MCPLogHandler").Call(jen.Id("mcpServer"), jen.Id("o").Dot("logger").Dot("Handler").Call()),
+			),
+		),
+		jen.Comment("全ツールを登録"),
+	}
+
+	funcBody = append(funcBody,
+		jen.Comment("リクエストIDはスパンの属性にも付与するため、トレースより先に割り当てる"),
+		jen.Id("registry").Op(":=").Qual(functions, "NewRegistry").Call().Dot("Use").Call(
+			jen.Qual(functions, "RequestID").Call(jen.Id("o").Dot("logger")),
+			jen.Qual(functions, "Trace").Call(jen.Id("o").Dot("tracerProvider")),
+		),
+		jen.If(jen.Id("o").Dot("maxResultSize").Op(">").Lit(0)).Block(
+			jen.Comment("他のミドルウェアで整形した後の結果に適用する"),
+			jen.Id("registry").Dot("Use").Call(jen.Qual(functions, "MaxResultSize").Call(jen.Id("o").Dot("maxResultSize"))),
+		),
+		jen.Var().Id("blobs").Op("*").Qual(functions, "BlobLinker"),
+		jen.If(jen.Id("o").Dot("resourceLinks")).Block(
+			jen.If(jen.Id("o").Dot("blobStore").Op("==").Nil()).Block(
+				jen.List(jen.Id("store"), jen.Id("err")).Op(":=").Qual(functions, "NewFileBlobStore").Call(jen.Lit("")),
+				jen.If(jen.Id("err").Op("!=").Nil()).Block(
+					jen.Return(jen.Nil(), jen.Id("err")),
+				),
+				jen.Id("o").Dot("blobStore").Op("=").Id("store"),
+			),
+			jen.Comment("切り詰める前の結果をリソースとして保存する"),
+			jen.Id("blobs").Op("=").Qual(functions, "NewBlobLinker").Call(jen.Id("o").Dot("blobStore"), jen.Id("o").Dot("blobMaxBytes")),
+			jen.Id("registry").Dot("Use").Call(jen.Id("blobs").Dot("Link").Call()),
+		),
+		jen.For(jen.List(jen.Id("_"), jen.Id("apply")).Op(":=").Range().Id("o").Dot("registryOptions")).Block(
+			jen.Id("apply").Call(jen.Id("registry")),
+		),
+		jen.If(jen.Id("o").Dot("streamOptions").Op("!=").Nil()).Block(
+			jen.Id("registry").Dot("Use").Call(jen.Qual(functions, "StreamResponses").Call(jen.Op("*").Id("o").Dot("streamOptions"))),
+		),
+		jen.If(jen.Id("o").Dot("stripEmpty")).Block(
+			jen.Id("registry").Dot("Use").Call(jen.Qual(functions, "StripEmpty").Call()),
+		),
+		jen.If(jen.Id("o").Dot("elicitMissing")).Block(
+			jen.Id("registry").Dot("Use").Call(jen.Qual(functions, "ElicitMissing").Call()),
+		),
+		jen.If(jen.Id("o").Dot("normalizeTimes")).Block(
+			jen.Id("registry").Dot("Use").Call(jen.Qual(functions, "NormalizeTimes").Call(jen.Id("o").Dot("timeLayouts").Op("..."))),
+		),
+		jen.Var().Id("pages").Op("*").Qual(functions, "PageStore"),
+		jen.If(jen.Id("o").Dot("pageSize").Op(">").Lit(0)).Block(
+			jen.Id("pages").Op("=").Qual(functions, "NewPageStore").Call(jen.Id("o").Dot("pageSize")),
+			jen.Id("registry").Dot("Use").Call(jen.Id("pages").Dot("Paginate").Call()),
+		),
+		jen.If(jen.Len(jen.Id("o").Dot("redactPatterns")).Op(">").Lit(0)).Block(
+			jen.List(jen.Id("redact"), jen.Id("err")).Op(":=").Qual(functions, "Redact").Call(jen.Id("o").Dot("redactPatterns").Op("...")),
+			jen.If(jen.Id("err").Op("!=").Nil()).Block(
+				jen.Return(jen.Nil(), jen.Id("err")),
+			),
+			jen.Comment("ページやリソースとして保存する前にマスクするため、内側で適用する"),
+			jen.Id("registry").Dot("Use").Call(jen.Id("redact")),
+		),
+		jen.If(jen.Len(jen.Id("o").Dot("flatten")).Op(">").Lit(0)).Block(
+			jen.Comment("マスクや切り詰めの前に結果を持ち上げるため、マスクより内側で適用する"),
+			jen.Id("registry").Dot("Use").Call(jen.Qual(functions, "FlattenOperations").Call(jen.Id("o").Dot("flatten"))),
+		),
+		jen.If(jen.Id("o").Dot("outputFormat").Op("!=").Lit("")).Block(
+			jen.Id("registry").Dot("WithOutputFormat").Call(jen.Id("o").Dot("outputFormat")),
+		),
+		jen.If(
+			jen.Id("err").Op(":=").Id("registry").Dot("Add").Call(jen.ListFunc(func(g *jen.Group) {
+				for _, operation := range info.operations {
+					// フォールバックのツールはnet/httpで直接APIを呼び出す
+					if operation.Fallback != nil {
+						g.Qual(toolsPath, "New"+operation.Name+"Tool").Call(
+							jen.Id("doer"),
+							jen.Id("baseURL"),
+						)
+						continue
+					}
+					g.Qual(toolsPath, "New"+operation.Name+"Tool").Call(jen.Id("apiClient"))
+				}
+			})),
+			jen.Id("err").Op("!=").Nil(),
+		).Block(
+			jen.Return(jen.Nil(), jen.Id("err")),
+		),
+		jen.Id("registry").Dot("Bind").Call(jen.Id("mcpServer")),
+		jen.Qual(functions, "BindSpec").Call(jen.Id("mcpServer"), jen.Id("OpenAPISpec")),
+		jen.If(jen.Id("blobs").Op("!=").Nil()).Block(
+			jen.Id("blobs").Dot("Bind").Call(jen.Id("mcpServer")),
+		),
+		jen.If(jen.Id("pages").Op("!=").Nil()).Block(
+			jen.Comment("続きのページを読むツールとリソースを登録"),
+			jen.Id("pages").Dot("Bind").Call(jen.Id("mcpServer")),
+		),
+		jen.If(jen.Id("o").Dot("batch").Op("!=").Nil()).Block(
+			jen.Comment("複数のツールを1回で呼び出すツールを登録（レジストリのツールだけを呼び出す）"),
+			jen.Id("mcpServer").Dot("AddTools").Call(jen.Qual(functions, "BatchTool").Call(jen.Id("registry"), jen.Op("*").Id("o").Dot("batch")).Dot("ServerTool").Call()),
+		),
+		jen.If(jen.Id("capabilities").Dot("Prompts")).Block(
+			jen.For(jen.List(jen.Id("_"), jen.Id("prompt")).Op(":=").Range().Id("o").Dot("prompts")).Block(
+				jen.If(jen.Id("prompt").Op("=").Id("prompt").Dot("Registered").Call(jen.Id("registry")), jen.Len(jen.Id("prompt").Dot("Tools")).Op(">").Lit(0)).Block(
+					jen.Id("mcpServer").Dot("AddPrompts").Call(jen.Id("prompt").Dot("ServerPrompt").Call()),
+				),
+			),
+		),
+		jen.If(jen.Id("o").Dot("operationResources")).Block(
+			jen.Comment("購読はnewHandlerのトランスポートで受け付ける"),
+			jen.Id("o").Dot("resources").Op("=").Qual(functions, "NewOperationResources").Call(jen.Id("mcpServer")).
+				Dot("WithPollInterval").Call(jen.Id("o").Dot("pollInterval")).
+				Dot("WithSubscribe").Call(jen.Id("capabilities").Dot("ResourcesSubscribe")),
+			jen.Id("o").Dot("resources").Dot("Add").Call(jen.Id("registry").Dot("List").Call().Op("...")),
+		),
+		jen.Return(jen.Id("mcpServer"), jen.Nil()),
+	)
+
+	// MCPサーバーのエンドポイントを公開する
+	startBody := []jen.Code{
+		jen.Id("o").Op(":=").Id("newOptions").Call(jen.Id("opts")),
+		jen.Line(),
+		jen.Comment("シャットダウンハンドリング"),
+		jen.List(jen.Id("ctx"), jen.Id("stop")).Op(":=").Qual("os/signal", "NotifyContext").Call(
+			jen.Id("ctx"),
+			jen.Qual("syscall", "SIGINT"),
+			jen.Qual("syscall", "SIGTERM"),
+		),
+		jen.Defer().Id("stop").Call(),
+		jen.List(jen.Id("mcpServer"), jen.Id("err")).Op(":=").Id("newMCPServer").Call(jen.Id("name"), jen.Id("version"), jen.Id("o")),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Return(jen.Id("err")),
+		),
+		jen.Id("httpServer").Op(":=").Op("&").Qual("net/http", "Server").Values(jen.Dict{
+			jen.Id("Addr"):    jen.Id("addr"),
+			jen.Id("Handler"): jen.Id("newHandler").Call(jen.Id("mcpServer"), jen.Id("o")),
+		}),
+		jen.Line(),
+		jen.Go().Func().Params().Block(
+			jen.Id("o").Dot("logger").Dot("InfoContext").Call(jen.Id("ctx"), jen.Lit("Start mcp server")),
+			jen.If(
+				jen.Id("err").Op(":=").Id("httpServer").Dot("ListenAndServe").Call(),
+				jen.Id("err").Op("!=").Nil().Op("&&").Id("err").Op("!=").Qual("net/http", "ErrServerClosed"),
+			).Block(
+				jen.Id("o").Dot("logger").Dot("Error").Call(jen.Lit("MCP server Shutdown."), jen.Lit("error"), jen.Id("err")),
+			),
+		).Call(),
+		jen.Op("<-").Id("ctx").Dot("Done").Call(),
+		jen.Id("stop").Call(),
+		jen.Id("o").Dot("logger").Dot("InfoContext").Params(jen.Id("ctx"), jen.Lit("Shutdown mcp server")),
+		jen.Comment("SSEの接続は終了しないため、完了を待たずに閉じる"),
+		jen.Return(jen.Id("httpServer").Dot("Close").Call()),
+	}
+
+	// SSE、Streamable HTTP、仕様書のエンドポイント
+	handlerBody := []jen.Code{
+		jen.Id("basePath").Op(":=").Qual("path", "Join").Call(jen.Lit("/"), jen.Id("o").Dot("basePath")),
+		jen.Id("sseOptions").Op(":=").Index().Qual(mcpServerPkg, "SSEOption").Values(
+			jen.Qual(mcpServerPkg, "WithStaticBasePath").Call(jen.Id("basePath")),
+		),
+		jen.Var().Id("streamableOptions").Index().Qual(mcpServerPkg, "StreamableHTTPOption"),
+		jen.If(jen.Id("o").Dot("keepAlive").Op(">").Lit(0)).Block(
+			jen.Id("sseOptions").Op("=").Append(jen.Id("sseOptions"), jen.Qual(mcpServerPkg, "WithKeepAliveInterval").Call(jen.Id("o").Dot("keepAlive"))),
+			jen.Id("streamableOptions").Op("=").Append(jen.Id("streamableOptions"), jen.Qual(mcpServerPkg, "WithHeartbeatInterval").Call(jen.Id("o").Dot("keepAlive"))),
+		),
+		jen.Id("sessionStore").Op(":=").Id("o").Dot("sessionStore"),
+		jen.If(jen.Id("sessionStore").Op("==").Nil().Op("&&").Id("o").Dot("idleTimeout").Op(">").Lit(0)).Block(
+			jen.Comment("終了したセッションのリクエストを拒否するため、セッションを記録する"),
+			jen.Id("sessionStore").Op("=").Qual(functions, "NewMemorySessionStore").Call(jen.Lit(0)),
+		),
+		jen.If(jen.Id("sessionStore").Op("!=").Nil()).Block(
+			jen.Id("streamableOptions").Op("=").Append(
+				jen.Id("streamableOptions"),
+				jen.Qual(mcpServerPkg, "WithSessionIdManager").Call(jen.Qual(functions, "SessionIDManager").Call(jen.Id("sessionStore"))),
+			),
+		),
+		jen.Id("mux").Op(":=").Qual("net/http", "NewServeMux").Call(),
+	}
+	// 生成時に指定したトランスポートだけを公開する
+	handlerBody = append(handlerBody, mountTransports(info.transports, mcpServerPkg, functions)...)
+	handlerBody = append(handlerBody,
+		jen.Id("mux").Dot("Handle").Call(
+			jen.Qual("path", "Join").Call(jen.Id("basePath"), jen.Qual(functions, "SpecPath")),
+			jen.Qual(functions, "SpecHandler").Call(jen.Id("OpenAPISpec")),
+		),
+		jen.If(jen.Id("o").Dot("webhooks")).Block(
+			jen.Id("webhooks").Op(":=").Qual(functions, "NewWebhookReceiver").Call(jen.Id("mcpServer")).Dot("WithVerifier").Call(jen.Id("o").Dot("webhookVerifier")),
+			jen.Id("prefix").Op(":=").Qual("path", "Join").Call(jen.Id("basePath"), jen.Lit("webhooks")),
+			jen.Comment("イベント名をパスで受け取る（例: /webhooks/invoice.paid）"),
+			jen.Id("mux").Dot("Handle").Call(jen.Id("prefix").Op("+").Lit("/"), jen.Qual("net/http", "StripPrefix").Call(jen.Id("prefix"), jen.Id("webhooks"))),
+		),
+		jen.Var().Id("handler").Qual("net/http", "Handler").Op("=").Id("mux"),
+		jen.If(jen.Id("o").Dot("resources").Op("!=").Nil()).Block(
+			jen.Comment("MCPサーバーが実装していないresources/subscribeに応答する"),
+			jen.Id("handler").Op("=").Id("o").Dot("resources").Dot("Handler").Call(jen.Id("handler")),
+		),
+		jen.If(jen.Id("o").Dot("resumableStreams")).Block(
+			jen.Id("handler").Op("=").Qual(functions, "ResumableStreams").Call(jen.Id("handler"), jen.Id("o").Dot("streamHistory")),
+		),
+		jen.If(jen.Id("o").Dot("idleTimeout").Op(">").Lit(0)).Block(
+			jen.Comment("セッションの削除を内側のハンドラーにも伝えるため、最も外側で適用する"),
+			jen.Id("handler").Op("=").Qual(functions, "IdleSessions").Call(jen.Id("handler"), jen.Id("o").Dot("idleTimeout")),
+		),
+		jen.Return(jen.Id("handler")),
+	)
+
+	if baseURL != "" {
+		f.Comment("DefaultBaseURL is the base URL of the API taken from the servers of the OpenAPI spec.")
+		f.Comment("It can be overridden with WithBaseURL or the API_BASE_URL environment variable.")
+		f.Const().Id("DefaultBaseURL").Op("=").Lit(baseURL)
+		f.Line()
+	}
+
+	f.Comment("Instructions are sent to the clients on initialization, built from the info of the OpenAPI")
+	f.Comment("spec, its security schemes and x-mcp-instructions. WithInstructions replaces them.")
+	f.Const().Id("Instructions").Op("=").Lit(info.instructions)
+	f.Line()
+
+	f.Comment("OpenAPISpec is the OpenAPI document the server was generated from, encoded as JSON.")
+	f.Comment("NewHandler serves it at /openapi.json, and it is the oas-mcp://openapi.json MCP resource.")
+	f.Comment("//go:embed " + specFileName)
+	f.Var().Id("OpenAPISpec").Index().Byte()
+	f.Line()
+
+	// StartServerのオプション
+	f.Comment("Option configures NewMCPServer and StartServer.")
+	f.Type().Id("Option").Func().Params(jen.Op("*").Id("options"))
+	f.Line()
+	f.Type().Id("options").StructFunc(func(g *jen.Group) {
+		for _, field := range fields {
+			g.Id(field.name).Add(field.typ)
+		}
+	})
+	f.Line()
+	for _, option := range options {
+		for _, comment := range option.comment {
+			f.Comment(comment)
+		}
+		f.Func().Id(option.name).Params(option.params...).Id("Option").Block(
+			jen.Return(jen.Func().Params(jen.Id("o").Op("*").Id("options")).Block(option.body...)),
+		)
+		f.Line()
+	}
+
+	f.Func().Id("newOptions").Params(jen.Id("opts").Index().Id("Option")).Op("*").Id("options").Block(optionsBody...)
+	f.Line()
+
+	// NewMCPServer関数を追加
+	f.Comment("NewMCPServer returns the MCP server with all generated tools, without serving it.")
+	f.Comment("It is used to serve the tools over another transport or to call them in process in tests.")
+	f.Func().Id("NewMCPServer").Params(
+		jen.List(jen.Id("name"), jen.Id("version")).String(),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Params(jen.Op("*").Qual(mcpServerPkg, "MCPServer"), jen.Error()).Block(
+		jen.Return(jen.Id("newMCPServer").Call(jen.Id("name"), jen.Id("version"), jen.Id("newOptions").Call(jen.Id("opts")))),
+	)
+	f.Line()
+	f.Func().Id("newMCPServer").Params(
+		jen.List(jen.Id("name"), jen.Id("version")).String(),
+		jen.Id("o").Op("*").Id("options"),
+	).Params(jen.Op("*").Qual(mcpServerPkg, "MCPServer"), jen.Error()).Block(funcBody...)
+	f.Line()
+
+	// NewHandler関数を追加
+	f.Comment("NewHandler returns the HTTP handler of the MCP server with all generated tools, to be")
+	f.Comment("mounted into an existing mux or server instead of letting StartServer bind its own address.")
+	f.Comment("It serves the SSE transport at /sse and /message, the streamable HTTP transport at /mcp,")
+	f.Comment("the WebSocket transport at /ws and the OpenAPI document at /openapi.json, under the path")
+	f.Comment("set by WithBasePath. Only the transports given to the generator with -transport are served.")
+	f.Func().Id("NewHandler").Params(
+		jen.List(jen.Id("name"), jen.Id("version")).String(),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Params(jen.Qual("net/http", "Handler"), jen.Error()).Block(
+		jen.Id("o").Op(":=").Id("newOptions").Call(jen.Id("opts")),
+		jen.List(jen.Id("mcpServer"), jen.Id("err")).Op(":=").Id("newMCPServer").Call(jen.Id("name"), jen.Id("version"), jen.Id("o")),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Return(jen.Nil(), jen.Id("err")),
+		),
+		jen.Return(jen.Id("newHandler").Call(jen.Id("mcpServer"), jen.Id("o")), jen.Nil()),
+	)
+	f.Line()
+	f.Func().Id("newHandler").Params(
+		jen.Id("mcpServer").Op("*").Qual(mcpServerPkg, "MCPServer"),
+		jen.Id("o").Op("*").Id("options"),
+	).Qual("net/http", "Handler").Block(handlerBody...)
+	f.Line()
+
+	// StartServer関数を追加
+	f.Comment("StartServer starts the MCP server with all generated tools, listening on addr")
+	f.Comment("until ctx is done or the process is interrupted. The endpoints are those of NewHandler.")
+	f.Func().Id("StartServer").Params(
+		jen.Id("ctx").Qual("context", "Context"),
+		jen.List(jen.Id("name"), jen.Id("version"), jen.Id("addr")).String(),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Error().Block(startBody...)
+	f.Line()
+
+	// NewLambdaHandler関数を追加
+	f.Comment("NewLambdaHandler returns an AWS Lambda handler serving the MCP server over the")
+	f.Comment("streamable HTTP transport from API Gateway or function URL events, e.g.")
+	f.Comment("lambda.Start(handler) of github.com/aws/aws-lambda-go/lambda.")
+	f.Comment("The server is stateless since consecutive requests may reach different instances.")
+	f.Func().Id("NewLambdaHandler").Params(
+		jen.List(jen.Id("name"), jen.Id("version")).String(),
+		jen.Id("opts").Op("...").Id("Option"),
+	).Params(
+		jen.Func().Params(jen.Qual("context", "Context"), jen.Qual(functions, "LambdaRequest")).Params(jen.Qual(functions, "LambdaResponse"), jen.Error()),
+		jen.Error(),
+	).Block(
+		jen.List(jen.Id("mcpServer"), jen.Id("err")).Op(":=").Id("newMCPServer").Call(jen.Id("name"), jen.Id("version"), jen.Id("newOptions").Call(jen.Id("opts"))),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Return(jen.Nil(), jen.Id("err")),
+		),
+		jen.Id("handler").Op(":=").Qual(mcpServerPkg, "NewStreamableHTTPServer").Call(
+			jen.Id("mcpServer"),
+			jen.Qual(mcpServerPkg, "WithStateLess").Call(jen.True()),
+		),
+		jen.Return(jen.Qual(functions, "LambdaHandler").Call(jen.Id("handler")), jen.Nil()),
+	)
+
+	// ファイルに保存
+	return f.Save(outputPath)
+}
+
+// 生成時に指定したトランスポートのエンドポイントをmuxに登録するコード
+func mountTransports(names []string, mcpServerPkg, functions string) []jen.Code {
+	var code []jen.Code
+	if slices.Contains(names, transportSSE) {
+		code = append(code,
+			jen.Id("sse").Op(":=").Qual(mcpServerPkg, "NewSSEServer").Call(jen.Id("mcpServer"), jen.Id("sseOptions").Op("...")),
+			jen.Id("mux").Dot("Handle").Call(jen.Id("sse").Dot("CompleteSsePath").Call(), jen.Id("sse")),
+			jen.Id("mux").Dot("Handle").Call(jen.Id("sse").Dot("CompleteMessagePath").Call(), jen.Id("sse")),
+		)
+	}
+	if slices.Contains(names, transportHTTP) {
+		code = append(code, jen.Id("mux").Dot("Handle").Call(
+			jen.Qual("path", "Join").Call(jen.Id("basePath"), jen.Lit("mcp")),
+			jen.Qual(mcpServerPkg, "NewStreamableHTTPServer").Call(jen.Id("mcpServer"), jen.Id("streamableOptions").Op("...")),
+		))
+	}
+	if slices.Contains(names, transportWebSocket) {
+		code = append(code, jen.Id("mux").Dot("Handle").Call(
+			jen.Qual("path", "Join").Call(jen.Id("basePath"), jen.Lit("ws")),
+			jen.Qual(functions, "WebSocketHandler").Call(jen.Id("mcpServer"), jen.Id("o").Dot("webSocketOrigins").Op("...")),
+		))
+	}
+	return code
+}
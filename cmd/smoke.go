@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nonchan7720/oas-mcp/functions"
+)
+
+// スモークテストの結果の種類
+const (
+	smokePass = "PASS"
+	smokeFail = "FAIL"
+)
+
+// 起動中のMCPサーバーのツールを一覧し、読み取り専用（readOnlyHint）のツールを
+// 入力スキーマの既定値や例から作った引数で呼び出して結果を表示する
+// 失敗したツールがあれば1を返す
+func runSmoke(args []string) int {
+	fs := flag.NewFlagSet("smoke", flag.ExitOnError)
+	var serverURL, transportName string
+	var timeout time.Duration
+	var tools listFlag
+	headers := headerFlag{}
+	fs.StringVar(&serverURL, "url", "http://localhost:8080/sse", "URL of the MCP server (the SSE endpoint or the Streamable HTTP endpoint)")
+	fs.StringVar(&transportName, "transport", transportSSE, "Transport of the MCP server: sse or http")
+	fs.Var(&tools, "tools", "Comma separated read-only tools to call (default: every read-only tool)")
+	fs.DurationVar(&timeout, "timeout", 30*time.Second, "Timeout of each tool call")
+	fs.Var(headers, "header", "Header sent to the MCP server, e.g. \"Authorization: Bearer xxx\" (repeatable)")
+	_ = fs.Parse(args)
+
+	ctx := context.Background()
+	c, err := connectMCP(ctx, serverURL, transportName, http.Header(headers))
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	defer c.Close()
+
+	list, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		log.Printf("Failed to list tools: %v", err)
+		return 1
+	}
+	counts := map[string]int{}
+	for _, tool := range list.Tools {
+		if tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint {
+			continue
+		}
+		if len(tools) > 0 && !slices.Contains(tools, tool.Name) {
+			continue
+		}
+		kind, detail := smokeCall(ctx, c, tool, timeout)
+		counts[kind]++
+		if detail != "" {
+			fmt.Printf("%s %s: %s\n", kind, tool.Name, detail)
+		} else {
+			fmt.Printf("%s %s\n", kind, tool.Name)
+		}
+	}
+	fmt.Printf("%d passed, %d failed (%d tools listed)\n", counts[smokePass], counts[smokeFail], len(list.Tools))
+	if counts[smokeFail] > 0 {
+		return 1
+	}
+	return 0
+}
+
+// ツールを入力スキーマから作った引数で呼び出す
+func smokeCall(ctx context.Context, c *client.Client, tool mcp.Tool, timeout time.Duration) (string, string) {
+	var schema any = tool.InputSchema
+	if tool.RawInputSchema != nil {
+		schema = tool.RawInputSchema
+	}
+	req := mcp.CallToolRequest{}
+	req.Params.Name = tool.Name
+	req.Params.Arguments = functions.SampleArguments(schema)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	begin := time.Now()
+	res, err := c.CallTool(ctx, req)
+	latency := time.Since(begin).Round(time.Millisecond)
+	if err != nil {
+		return smokeFail, err.Error()
+	}
+	if res.IsError {
+		return smokeFail, bodySnippet([]byte(resultText(res)))
+	}
+	return smokePass, latency.String()
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// 生成したツールのスキーマのスナップショットのファイル名
+const snapshotFileName = "schema.snapshot.json"
+
+// スナップショットに含めるスキーマの最大の深さ（再帰するスキーマ用）
+const maxSnapshotDepth = 10
+
+// スナップショットの形式のバージョン（形式の異なる前回のスナップショットとは比較しない）
+const snapshotVersion = 2
+
+// ツールのスキーマのスナップショット
+// 生成ごとに出力先に書き出し、-check-compat で前回のものと比較する
+type schemaSnapshot struct {
+	Version int                      `json:"version"`
+	Tools   map[string]*toolSnapshot `json:"tools"`
+}
+
+// ツールの入力と出力のスナップショット
+type toolSnapshot struct {
+	OperationID string `json:"operationId,omitempty"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	// 入力のフィールド（キーはツールの引数のパス、例: requestParameter.petId、配列の要素は []）
+	Fields map[string]fieldSnapshot `json:"fields"`
+	// 構造化された結果のフィールド（出力のスキーマが無いツールは空）
+	Output map[string]fieldSnapshot `json:"output,omitempty"`
+}
+
+// 入力または出力のフィールドのスナップショット
+type fieldSnapshot struct {
+	Type string `json:"type,omitempty"`
+	// フィールドと親のフィールドがすべて必須か（入力は呼び出し側が必ず指定し、出力は必ず含まれるか）
+	Required bool `json:"required,omitempty"`
+}
+
+// 生成したサーバーが公開するツールの入力と出力のスキーマからスナップショットを作る
+func buildSchemaSnapshot(tools []servedTool, operations []*operation) *schemaSnapshot {
+	byName := make(map[string]*operation, len(operations))
+	for _, op := range operations {
+		byName[op.toolName()] = op
+	}
+	snapshot := &schemaSnapshot{Version: snapshotVersion, Tools: map[string]*toolSnapshot{}}
+	for _, tool := range tools {
+		t := &toolSnapshot{Fields: map[string]fieldSnapshot{}}
+		if op := byName[tool.Name]; op != nil {
+			t.OperationID = op.OperationID
+			t.Method = strings.ToUpper(op.HTTPMethod)
+			t.Path = op.Path
+		}
+		snapshotFields(t.Fields, "", tool.InputSchema, tool.InputSchema, true, 0)
+		if tool.OutputSchema != nil {
+			t.Output = map[string]fieldSnapshot{}
+			snapshotFields(t.Output, "", tool.OutputSchema, tool.OutputSchema, true, 0)
+		}
+		snapshot.Tools[tool.Name] = t
+	}
+	return snapshot
+}
+
+// スキーマのプロパティと配列の要素を再帰的に記録する（required は親まで含めて必須か）
+func snapshotFields(fields map[string]fieldSnapshot, path string, schema, root map[string]any, required bool, depth int) {
+	schema = resolveSnapshotRef(schema, root)
+	if schema == nil || depth > maxSnapshotDepth {
+		return
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	requiredNames := stringValues(schema["required"])
+	for name, value := range properties {
+		property := resolveSnapshotRef(schemaObject(value), root)
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		fieldRequired := required && slices.Contains(requiredNames, name)
+		fields[fieldPath] = fieldSnapshot{Type: snapshotType(property, root), Required: fieldRequired}
+		snapshotFields(fields, fieldPath, property, root, fieldRequired, depth+1)
+	}
+	if items := resolveSnapshotRef(schemaObject(schema["items"]), root); items != nil {
+		minItems, _ := schema["minItems"].(float64)
+		itemsRequired := required && minItems > 0
+		fields[path+"[]"] = fieldSnapshot{Type: snapshotType(items, root), Required: itemsRequired}
+		snapshotFields(fields, path+"[]", items, root, itemsRequired, depth+1)
+	}
+	// allOf はプロパティを同じパスに合成する
+	for _, value := range asSlice(schema["allOf"]) {
+		snapshotFields(fields, path, schemaObject(value), root, required, depth+1)
+	}
+}
+
+// スキーマの型（oneOf/anyOf は候補の型を | で繋ぐ）
+func snapshotType(schema, root map[string]any) string {
+	if schema == nil {
+		return ""
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		return strings.Join(stringValues(t), "|")
+	}
+	var types []string
+	for _, value := range append(asSlice(schema["oneOf"]), asSlice(schema["anyOf"])...) {
+		if t := snapshotType(resolveSnapshotRef(schemaObject(value), root), root); t != "" && !slices.Contains(types, t) {
+			types = append(types, t)
+		}
+	}
+	if _, ok := schema["properties"]; ok && len(types) == 0 {
+		return "object"
+	}
+	return strings.Join(types, "|")
+}
+
+// $defs への参照を解決する
+func resolveSnapshotRef(schema, root map[string]any) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	defs, _ := root["$defs"].(map[string]any)
+	return schemaObject(defs[strings.TrimPrefix(ref, "#/$defs/")])
+}
+
+func schemaObject(v any) map[string]any {
+	schema, _ := v.(map[string]any)
+	return schema
+}
+
+func asSlice(v any) []any {
+	values, _ := v.([]any)
+	return values
+}
+
+// 文字列の配列（JSONから読んだ []any）
+func stringValues(v any) []string {
+	var values []string
+	for _, item := range asSlice(v) {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// 仕様書からオペレーションの入力のフィールドを求める
+// 生成したサーバーをビルドしない -dry-run と validate で使う（キーは parameters.<名前> または body.<パス>）
+func buildSpecSnapshot(spec []byte, operations []*operation) (*schemaSnapshot, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
+	}
+	snapshot := &schemaSnapshot{Tools: map[string]*toolSnapshot{}}
+	for _, op := range operations {
+		method := strings.ToUpper(op.HTTPMethod)
+		tool := &toolSnapshot{
+			OperationID: op.OperationID,
+			Method:      method,
+			Path:        op.Path,
+			Fields:      map[string]fieldSnapshot{},
+		}
+		snapshot.Tools[op.toolName()] = tool
+		pathItem := doc.Paths.Value(op.Path)
+		if pathItem == nil {
+			continue
+		}
+		specOp := pathItem.Operations()[method]
+		if specOp == nil {
+			continue
+		}
+		route := &routers.Route{Spec: doc, Path: op.Path, PathItem: pathItem, Method: method, Operation: specOp}
+		for _, param := range checkParameters(route) {
+			name := param.Name
+			if argument := extensionString(param.Extensions[extensionMCPName]); argument != "" {
+				name = argument
+			}
+			specSnapshotFields(tool.Fields, "parameters."+name, param.Schema, param.Required, 0)
+		}
+		if body := specOp.RequestBody; body != nil && body.Value != nil {
+			if media := requestBodyMedia(body.Value); media != nil {
+				specSnapshotFields(tool.Fields, "body", media.Schema, body.Value.Required, 0)
+			}
+		}
+	}
+	return snapshot, nil
+}
+
+// JSONのリクエストボディ（無ければ最初のメディアタイプ）
+func requestBodyMedia(body *openapi3.RequestBody) *openapi3.MediaType {
+	if media := body.Content.Get("application/json"); media != nil {
+		return media
+	}
+	keys := sortedKeys(body.Content)
+	if len(keys) == 0 {
+		return nil
+	}
+	return body.Content[keys[0]]
+}
+
+// 仕様書のスキーマのフィールドを再帰的に記録する（required は親まで含めて必須か）
+func specSnapshotFields(fields map[string]fieldSnapshot, path string, ref *openapi3.SchemaRef, required bool, depth int) {
+	field := fieldSnapshot{Required: required}
+	if ref == nil || ref.Value == nil || depth > maxSnapshotDepth {
+		fields[path] = field
+		return
+	}
+	schema := ref.Value
+	field.Type = specSnapshotType(schema)
+	fields[path] = field
+
+	// allOf はプロパティを同じパスに合成する
+	schemas := append([]*openapi3.SchemaRef{ref}, schema.AllOf...)
+	for _, s := range schemas {
+		if s == nil || s.Value == nil {
+			continue
+		}
+		for name, prop := range s.Value.Properties {
+			specSnapshotFields(fields, path+"."+name, prop, required && slices.Contains(s.Value.Required, name), depth+1)
+		}
+		if s.Value.Items != nil {
+			specSnapshotFields(fields, path+"[]", s.Value.Items, required && s.Value.MinItems > 0, depth+1)
+		}
+	}
+}
+
+// 仕様書のスキーマの型（oneOf/anyOf は候補の型を | で繋ぐ）
+func specSnapshotType(schema *openapi3.Schema) string {
+	if schema.Type != nil && len(*schema.Type) > 0 {
+		return strings.Join(schema.Type.Slice(), "|")
+	}
+	var types []string
+	for _, s := range append(slices.Clone(schema.OneOf), schema.AnyOf...) {
+		if s != nil && s.Value != nil {
+			if t := specSnapshotType(s.Value); t != "" && !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+	}
+	if len(types) == 0 && len(schema.Properties) > 0 {
+		return openapi3.TypeObject
+	}
+	return strings.Join(types, "|")
+}
+
+// 出力先のスナップショットを読み込む（無ければ nil）
+func readSchemaSnapshot(outputPath string) (*schemaSnapshot, error) {
+	data, err := os.ReadFile(filepath.Join(outputPath, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read schema snapshot: %w", err)
+	}
+	var snapshot schemaSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse schema snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+// スナップショットを出力先に書き出す
+func writeSchemaSnapshot(snapshot *schemaSnapshot, outputPath string) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(outputPath, snapshotFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write schema snapshot: %w", err)
+	}
+	return nil
+}
+
+// 前回のスナップショットから互換性の無い変更を列挙する
+// ツールの削除、必須の入力のフィールドの追加（任意から必須への変更を含む）、フィールドの型の変更、
+// 必ず含まれていた出力のフィールドの削除（任意への変更を含む）
+func breakingChanges(prev, next *schemaSnapshot) []string {
+	var changes []string
+	for _, name := range sortedKeys(prev.Tools) {
+		prevTool := prev.Tools[name]
+		nextTool, ok := next.Tools[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("%s: tool removed", name))
+			continue
+		}
+		for _, path := range sortedKeys(nextTool.Fields) {
+			field := nextTool.Fields[path]
+			prevField, existed := prevTool.Fields[path]
+			switch {
+			case field.Required && !existed:
+				changes = append(changes, fmt.Sprintf("%s: required field %s added", name, path))
+			case field.Required && !prevField.Required:
+				changes = append(changes, fmt.Sprintf("%s: field %s became required", name, path))
+			case existed && prevField.Type != "" && field.Type != prevField.Type:
+				changes = append(changes, fmt.Sprintf("%s: type of field %s changed from %s to %s", name, path, prevField.Type, field.Type))
+			}
+		}
+		for _, path := range sortedKeys(prevTool.Output) {
+			prevField := prevTool.Output[path]
+			field, exists := nextTool.Output[path]
+			switch {
+			case prevField.Required && !exists:
+				changes = append(changes, fmt.Sprintf("%s: output field %s removed", name, path))
+			case prevField.Required && !field.Required:
+				changes = append(changes, fmt.Sprintf("%s: output field %s became optional", name, path))
+			case exists && prevField.Type != "" && field.Type != prevField.Type:
+				changes = append(changes, fmt.Sprintf("%s: type of output field %s changed from %s to %s", name, path, prevField.Type, field.Type))
+			}
+		}
+	}
+	return changes
+}
+
+// 出力先の中の一時ディレクトリに生成し直し、出力先のスナップショットと比較する
+// 生成したサーバーをビルドするため、一時ディレクトリは出力先と同じモジュールに置く
+// 互換性の無い変更があれば1を返す
+func (g *generator) checkCompat(source *specSource, used map[string]bool) int {
+	prev, err := readSchemaSnapshot(source.output)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	if prev == nil {
+		log.Printf("No schema snapshot in %s: nothing to compare", source.output)
+		return 0
+	}
+	if prev.Version != snapshotVersion {
+		log.Printf("The schema snapshot in %s has an older format: generate once to update it", source.output)
+		return 0
+	}
+	tmp, err := os.MkdirTemp(source.output, "_compat")
+	if err != nil {
+		log.Printf("Failed to create temporary directory: %v", err)
+		return 2
+	}
+	defer os.RemoveAll(tmp)
+	compat := *source
+	compat.output = tmp
+	if err := g.generate(&compat, used); err != nil {
+		log.Print(err)
+		return 2
+	}
+	tools, err := listServedTools(compat.output, compat.info)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	next := buildSchemaSnapshot(tools, compat.info.operations)
+	changes := breakingChanges(prev, next)
+	for _, change := range changes {
+		fmt.Println(change)
+	}
+	if len(changes) > 0 {
+		log.Printf("%d breaking changes against %s", len(changes), filepath.Join(source.output, snapshotFileName))
+		return 1
+	}
+	log.Printf("No breaking changes against %s", filepath.Join(source.output, snapshotFileName))
+	return 0
+}
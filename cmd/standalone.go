@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+)
+
+// 独立したモジュールの go ディレクティブ（oas-mcp の go.mod と同じ。依存の要求が高ければ go mod tidy が上げる）
+const standaloneGoVersion = "1.24.3"
+
+// oas-mcp のモジュールパス（生成したコードは functions パッケージを使う）
+const oasMCPModule = "github.com/nonchan7720/oas-mcp"
+
+// 出力ディレクトリのインポートパス
+// -standalone の場合は出力ディレクトリを module のモジュールとし、それ以外は呼び出し元のモジュールの中とする
+func (g *generator) importPath(outputPath string) string {
+	if g.module == "" {
+		return getModuleName() + "/" + outputPath
+	}
+	rel, err := filepath.Rel(g.root, outputPath)
+	if err != nil || rel == "." {
+		return g.module
+	}
+	return g.module + "/" + filepath.ToSlash(rel)
+}
+
+// 出力ディレクトリに go.mod を生成し、go mod tidy で go.sum を作る
+// 依存のバージョンはジェネレーターのビルドに使ったもの（mcp-go、ogen など）に固定する
+func writeGoMod(outputPath, modulePath string) error {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return fmt.Errorf("failed to read the build info of the generator")
+	}
+	var require, replace []string
+	for _, dep := range info.Deps {
+		require = append(require, dep.Path+" "+dep.Version)
+		if dep.Replace != nil {
+			replace = append(replace, dep.Path+" => "+replaceTarget(dep.Replace))
+		}
+	}
+	// ジェネレーターは oas-mcp のモジュールのため、置き換えやリポジトリでの実行も引き継ぐ
+	if main := info.Main; main.Path == oasMCPModule {
+		version := main.Version
+		if version == "" || version == "(devel)" {
+			version = "v0.0.0"
+		}
+		require = append(require, oasMCPModule+" "+version)
+		switch {
+		case main.Replace != nil:
+			replace = append(replace, oasMCPModule+" => "+replaceTarget(main.Replace))
+		case main.Version == "" || main.Version == "(devel)":
+			replace = append(replace, oasMCPModule+" => "+moduleDir())
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by OpenAPI MCP generator.\n\n")
+	fmt.Fprintf(&b, "module %s\n\ngo %s\n", modulePath, standaloneGoVersion)
+	if len(require) > 0 {
+		b.WriteString("\nrequire (\n")
+		for _, r := range require {
+			b.WriteString("\t" + r + "\n")
+		}
+		b.WriteString(")\n")
+	}
+	for _, r := range replace {
+		b.WriteString("\nreplace " + r + "\n")
+	}
+	if err := os.WriteFile(filepath.Join(outputPath, "go.mod"), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write go.mod: %w", err)
+	}
+
+	// 使わない依存を取り除き、go.sum を作る
+	cmd := exec.Command("go", "mod", "tidy")
+	cmd.Dir = outputPath
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("Failed to run go mod tidy in %s: %v: run it once the modules can be downloaded", outputPath, err)
+	}
+	return nil
+}
+
+// replace ディレクティブの置き換え先
+// ローカルのディレクトリは呼び出し元のモジュールからの相対パスのため、絶対パスにする
+func replaceTarget(m *debug.Module) string {
+	if m.Version != "" && m.Version != "(devel)" {
+		return m.Path + " " + m.Version
+	}
+	if filepath.IsAbs(m.Path) {
+		return m.Path
+	}
+	return filepath.Join(moduleDir(), m.Path)
+}
+
+// カレントディレクトリから親ディレクトリに向かって探した go.mod のディレクトリ
+func moduleDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/ogen-go/ogen/gen/ir"
+	"github.com/ogen-go/ogen/jsonschema"
+)
+
+// 日時として扱うスキーマのフォーマット（unix系はogenの拡張フォーマット）
+var timeFormats = []string{"date-time", "unix", "unix-seconds", "unix-milli", "unix-micro", "unix-nano"}
+
+// 成功レスポンスの日時のフィールドのパス（配列は経由してドット区切り）
+func ogenTimeFields(op *ir.Operation) []string {
+	_, _, media := successMedia(op)
+	if media == nil {
+		return nil
+	}
+	var paths []string
+	var collect func(schema *jsonschema.Schema, path string, depth int)
+	collect = func(schema *jsonschema.Schema, path string, depth int) {
+		if schema == nil || depth > 8 {
+			return
+		}
+		if path != "" && slices.Contains(timeFormats, schema.Format) {
+			paths = append(paths, path)
+		}
+		for _, schemas := range [][]*jsonschema.Schema{schema.OneOf, schema.AnyOf, schema.AllOf} {
+			for _, s := range schemas {
+				collect(s, path, depth+1)
+			}
+		}
+		if schema.Type == jsonschema.Array {
+			collect(schema.Item, path, depth+1)
+		}
+		for _, prop := range schema.Properties {
+			// ドットを含むフィールド名はパスで区別できない
+			if !strings.Contains(prop.Name, ".") {
+				collect(prop.Schema, joinFieldPath(path, prop.Name), depth+1)
+			}
+		}
+	}
+	collect(media.Schema, "", 0)
+	return compactPaths(paths)
+}
+
+// oapi-codegen のオペレーションの成功レスポンスの日時のフィールドのパス
+func openAPI3TimeFields(responses *openapi3.Responses) []string {
+	_, _, media := openAPI3SuccessMedia(responses)
+	if media == nil || media.Schema == nil {
+		return nil
+	}
+	var paths []string
+	var collect func(schema *openapi3.Schema, path string, depth int)
+	collect = func(schema *openapi3.Schema, path string, depth int) {
+		if schema == nil || depth > 8 {
+			return
+		}
+		if path != "" && slices.Contains(timeFormats, schema.Format) {
+			paths = append(paths, path)
+		}
+		for _, refs := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf, schema.AllOf} {
+			for _, ref := range refs {
+				collect(ref.Value, path, depth+1)
+			}
+		}
+		if schema.Items != nil && schema.Type.Is(openapi3.TypeArray) {
+			collect(schema.Items.Value, path, depth+1)
+		}
+		for _, name := range sortedKeys(schema.Properties) {
+			if ref := schema.Properties[name]; ref != nil && !strings.Contains(name, ".") {
+				collect(ref.Value, joinFieldPath(path, name), depth+1)
+			}
+		}
+	}
+	collect(media.Schema.Value, "", 0)
+	return compactPaths(paths)
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// oneOf などで重複したパスを取り除く
+func compactPaths(paths []string) []string {
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
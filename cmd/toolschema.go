@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/dave/jennifer/jen"
+)
+
+// 生成したサーバーのツールを一覧するプログラムを置くディレクトリ（_ で始まるため ./... の対象外）
+const toolSchemaDir = "_toolschemas"
+
+// 生成したサーバーが tools/list で公開するツール
+type servedTool struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description,omitempty"`
+	InputSchema  map[string]any `json:"inputSchema"`
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
+}
+
+// 生成したサーバーをビルドして実行し、公開するツールを取得する
+// スキーマを仕様書から作り直さず、各ツールの functions.Schema をそのまま使うため
+func listServedTools(outputPath string, info *clientInfo) ([]servedTool, error) {
+	dir := filepath.Join(outputPath, toolSchemaDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	defer os.RemoveAll(dir)
+
+	serverPath := info.importPath + "/server"
+	clientPath := info.importPath + "/client"
+	f := jen.NewFile("main")
+	f.HeaderComment("Code generated by OpenAPI MCP generator. DO NOT EDIT.")
+	f.ImportName(serverPath, "server")
+	opts := []jen.Code{
+		jen.Lit("toolschemas"),
+		jen.Lit("0.0.0"),
+		jen.Qual(serverPath, "WithBaseURL").Call(jen.Lit("http://localhost")),
+	}
+	// ツールを一覧するだけで呼び出さないため、認証情報は空のまま渡す
+	if info.hasSecuritySource {
+		f.Type().Id("noSecurity").Struct(jen.Qual(clientPath, "SecuritySource"))
+		opts = append(opts, jen.Qual(serverPath, "WithSecurity").Call(jen.Id("noSecurity").Values()))
+	}
+	f.Func().Id("main").Params().Block(
+		jen.List(jen.Id("mcpServer"), jen.Id("err")).Op(":=").Qual(serverPath, "NewMCPServer").Call(opts...),
+		jen.If(jen.Id("err").Op("!=").Nil()).Block(
+			jen.Qual("log", "Fatal").Call(jen.Id("err")),
+		),
+		jen.Id("tools").Op(":=").Make(jen.Index().Qual("github.com/mark3labs/mcp-go/mcp", "Tool"), jen.Lit(0)),
+		jen.For(jen.List(jen.Id("_"), jen.Id("tool")).Op(":=").Range().Id("mcpServer").Dot("ListTools").Call()).Block(
+			jen.Id("tools").Op("=").Append(jen.Id("tools"), jen.Id("tool").Dot("Tool")),
+		),
+		jen.Qual("sort", "Slice").Call(jen.Id("tools"), jen.Func().Params(jen.List(jen.Id("i"), jen.Id("j")).Int()).Bool().Block(
+			jen.Return(jen.Id("tools").Index(jen.Id("i")).Dot("Name").Op("<").Id("tools").Index(jen.Id("j")).Dot("Name")),
+		)),
+		jen.If(jen.Id("err").Op(":=").Qual("encoding/json", "NewEncoder").Call(jen.Qual("os", "Stdout")).Dot("Encode").Call(jen.Id("tools")), jen.Id("err").Op("!=").Nil()).Block(
+			jen.Qual("log", "Fatal").Call(jen.Id("err")),
+		),
+	)
+	if err := f.Save(filepath.Join(dir, "main.go")); err != nil {
+		return nil, err
+	}
+
+	var stdout bytes.Buffer
+	cmd := exec.Command("go", "run", "./"+toolSchemaDir)
+	cmd.Dir = outputPath
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run the generated server in %s to list the tools: %w", outputPath, err)
+	}
+	var tools []servedTool
+	if err := json.Unmarshal(stdout.Bytes(), &tools); err != nil {
+		return nil, fmt.Errorf("failed to parse the tools of the generated server: %w", err)
+	}
+	return tools, nil
+}
+
+// 生成したサーバーが公開するツールから、スキーマのスナップショットとマニフェストを出力先に書き出す
+func writeServedTools(source *specSource) error {
+	tools, err := listServedTools(source.output, source.info)
+	if err != nil {
+		return err
+	}
+	if err := writeSchemaSnapshot(buildSchemaSnapshot(tools, source.info.operations), source.output); err != nil {
+		return err
+	}
+	return writeToolsManifest(buildToolsManifest(tools, source.info.operations), source.output)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// 検証のルール
+const (
+	ruleOperationID = "operation-id"
+	ruleDescription = "description"
+	ruleToolName    = "duplicate-name"
+	ruleContentType = "content-type"
+	ruleDepth       = "schema-depth"
+	ruleSize        = "schema-size"
+)
+
+var validateRules = []string{ruleOperationID, ruleDescription, ruleToolName, ruleContentType, ruleDepth, ruleSize}
+
+// ツールの引数として渡せるリクエストボディのContent-Type（JSONを含むものも対応する）
+var supportedContentTypes = []string{"application/x-www-form-urlencoded", "multipart/form-data", "text/plain", "application/octet-stream"}
+
+// 検証で見つかった問題
+type validateFinding struct {
+	rule   string
+	method string
+	path   string
+	detail string
+}
+
+func (f validateFinding) String() string {
+	return fmt.Sprintf("%-14s %s %s: %s", f.rule, f.method, f.path, f.detail)
+}
+
+// 検証の上限
+type validateLimits struct {
+	// ツールの引数のネストの深さの上限
+	maxDepth int
+	// ツールの引数のフィールド数の上限
+	maxFields int
+}
+
+// 仕様書がMCPのツールに向いているかを検証する
+// operationIdと説明の欠落、ツール名の重複、引数にできないContent-Type、LLMが扱うには深すぎる・大きすぎるスキーマを報告し、
+// 問題があれば1を返す（再生成の前に実行して止めるため）
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	var openapiPath, backendName string
+	var skip listFlag
+	var limits validateLimits
+	g := &generator{specHeaders: headerFlag{}, specCache: true, packageName: "client", filter: &specFilter{}, oas31Compat: true}
+	fs.StringVar(&openapiPath, "path", "", "OpenAPI specification file path or http(s) URL")
+	fs.Var(g.specHeaders, "spec-header", "Header sent to download the spec from a URL, e.g. \"Authorization: Bearer $TOKEN\" (repeatable, environment variables are expanded)")
+	fs.StringVar(&backendName, "client-backend", backendOgen, "Client generator backend used to name the tools: ogen or oapi-codegen")
+	fs.StringVar(&g.opts.naming, "naming", namingClient, "Naming strategy of the tools: "+strings.Join(namingStrategies, ", "))
+	fs.IntVar(&limits.maxDepth, "max-depth", 5, "Maximum nesting depth of the tool arguments")
+	fs.IntVar(&limits.maxFields, "max-fields", 100, "Maximum number of fields of the tool arguments")
+	fs.Var(&skip, "skip", "Comma separated rules not to check: "+strings.Join(validateRules, ", "))
+	_ = fs.Parse(args)
+
+	if openapiPath == "" {
+		log.Print("OpenAPI specification file path is required")
+		return 2
+	}
+	for _, rule := range skip {
+		if !slices.Contains(validateRules, rule) {
+			log.Printf("Unknown rule %q: use %s", rule, strings.Join(validateRules, ", "))
+			return 2
+		}
+	}
+	var err error
+	if g.backend, err = newClientBackend(backendName); err != nil {
+		log.Print(err)
+		return 2
+	}
+	if err := validateNaming(g.opts.naming); err != nil {
+		log.Print(err)
+		return 2
+	}
+	spec, _, err := g.readSpec(&specSource{location: openapiPath})
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		log.Printf("Failed to parse OpenAPI spec: %v", err)
+		return 2
+	}
+	// ツール名と引数はジェネレーターと同じく求める
+	tmp, err := os.MkdirTemp("", "oas-mcp-validate")
+	if err != nil {
+		log.Printf("Failed to create temporary directory: %v", err)
+		return 2
+	}
+	defer os.RemoveAll(tmp)
+	info, err := g.backend.generate(spec, tmp, g.packageName, g.opts)
+	if err != nil {
+		log.Printf("Failed to generate client: %v", err)
+		return 2
+	}
+	applyNaming(info.operations, g.opts.naming)
+	snapshot, err := buildSpecSnapshot(spec, info.operations)
+	if err != nil {
+		log.Printf("Failed to build schema snapshot: %v", err)
+		return 2
+	}
+
+	var findings []validateFinding
+	for _, finding := range validateSpec(doc, info.operations, snapshot, limits) {
+		if !slices.Contains(skip, finding.rule) {
+			findings = append(findings, finding)
+		}
+	}
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	fmt.Printf("%d problems in %d tools\n", len(findings), len(info.operations))
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// 仕様書と生成するオペレーションを検証する
+func validateSpec(doc *openapi3.T, operations []*operation, snapshot *schemaSnapshot, limits validateLimits) []validateFinding {
+	var findings []validateFinding
+	for _, path := range sortedKeys(doc.Paths.Map()) {
+		pathItem := doc.Paths.Value(path)
+		for _, method := range sortedKeys(pathItem.Operations()) {
+			op := pathItem.Operations()[method]
+			add := func(rule, format string, args ...any) {
+				findings = append(findings, validateFinding{rule: rule, method: method, path: path, detail: fmt.Sprintf(format, args...)})
+			}
+			if op.OperationID == "" {
+				add(ruleOperationID, "no operationId: the tool is named after the method and path")
+			}
+			if op.Description == "" && op.Summary == "" {
+				add(ruleDescription, "no description or summary")
+			}
+			for _, param := range checkParameters(&routers.Route{PathItem: pathItem, Operation: op}) {
+				if param.Description == "" {
+					add(ruleDescription, "parameter %s has no description", param.Name)
+				}
+			}
+			if body := op.RequestBody; body != nil && body.Value != nil && len(body.Value.Content) > 0 {
+				if !slices.ContainsFunc(sortedKeys(body.Value.Content), supportedContentType) {
+					add(ruleContentType, "request body %s cannot be given as tool arguments", strings.Join(sortedKeys(body.Value.Content), ", "))
+				}
+			}
+		}
+	}
+
+	names := map[string]*operation{}
+	for _, op := range operations {
+		method, path := strings.ToUpper(op.HTTPMethod), op.Path
+		name := op.toolName()
+		if other, ok := names[name]; ok {
+			findings = append(findings, validateFinding{
+				rule: ruleToolName, method: method, path: path,
+				detail: fmt.Sprintf("tool name %s is also used by %s %s", name, strings.ToUpper(other.HTTPMethod), other.Path),
+			})
+			continue
+		}
+		names[name] = op
+
+		tool := snapshot.Tools[name]
+		if tool == nil {
+			continue
+		}
+		depth := 0
+		for field := range tool.Fields {
+			depth = max(depth, strings.Count(field, ".")+strings.Count(field, "[]"))
+		}
+		if depth > limits.maxDepth {
+			findings = append(findings, validateFinding{
+				rule: ruleDepth, method: method, path: path,
+				detail: fmt.Sprintf("arguments of %s are nested %d levels deep (max %d)", name, depth, limits.maxDepth),
+			})
+		}
+		if len(tool.Fields) > limits.maxFields {
+			findings = append(findings, validateFinding{
+				rule: ruleSize, method: method, path: path,
+				detail: fmt.Sprintf("arguments of %s have %d fields (max %d)", name, len(tool.Fields), limits.maxFields),
+			})
+		}
+	}
+	return findings
+}
+
+// ツールの引数として渡せるContent-Typeか
+func supportedContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(mediaType, "json") || slices.Contains(supportedContentTypes, mediaType)
+}
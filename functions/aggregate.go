@@ -0,0 +1,314 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ErrDuplicateService is returned when a service prefix is already used by the aggregator.
+var ErrDuplicateService = errors.New("duplicate service prefix")
+
+// servicePrefixPattern matches the prefixes allowed in tool names.
+var servicePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Aggregator hosts the tools, resources and prompts of several MCP servers in one MCP
+// server, e.g. the servers generated from the specs of every service of a platform or
+// remote MCP servers, so that clients connect to a single endpoint. The names of the
+// tools and prompts are prefixed with the prefix of their service, e.g. billing_GetInvoice.
+type Aggregator struct {
+	srv       *server.MCPServer
+	separator string
+	mu        sync.Mutex
+	services  map[string]*aggregatedService
+	// resources holds the URIs of the registered resources
+	resources map[string]struct{}
+	// templates holds the services serving the resource templates, by URI template
+	templates map[string][]*aggregatedService
+}
+
+// aggregatedService is an MCP server whose features are forwarded by the aggregator.
+type aggregatedService struct {
+	prefix string
+	client *client.Client
+	// local is the MCP server of the in-process services
+	local *server.MCPServer
+	// tools holds the names of the registered tools
+	tools []string
+}
+
+// NewAggregator returns an aggregator registering the features of the services to srv.
+// srv declares the tools, resources and prompts capabilities as they are added; the
+// other capabilities, e.g. logging, are set by its options.
+func NewAggregator(srv *server.MCPServer) *Aggregator {
+	return &Aggregator{
+		srv:       srv,
+		separator: "_",
+		services:  map[string]*aggregatedService{},
+		resources: map[string]struct{}{},
+		templates: map[string][]*aggregatedService{},
+	}
+}
+
+// WithSeparator sets the separator between the prefix and the names of the tools and
+// prompts. It defaults to "_".
+func (a *Aggregator) WithSeparator(separator string) *Aggregator {
+	a.separator = separator
+	return a
+}
+
+// Server returns the MCP server hosting the services.
+func (a *Aggregator) Server() *server.MCPServer {
+	return a.srv
+}
+
+// AddServer hosts the features of an MCP server of the same process, e.g. the one
+// returned by NewMCPServer of a generated server package. Its tools are called with the
+// session of the client, so that progress, logging, sampling, elicitation and roots
+// work as when the server is served directly.
+func (a *Aggregator) AddServer(ctx context.Context, prefix string, srv *server.MCPServer) error {
+	c, err := client.NewInProcessClient(srv)
+	if err != nil {
+		return fmt.Errorf("service %s: %w", prefix, err)
+	}
+	return a.add(ctx, &aggregatedService{prefix: prefix, client: c, local: srv})
+}
+
+// AddClient proxies the MCP server of the client, e.g. a remote server over streamable
+// HTTP. The client is started and initialized by the aggregator, and closed by Close.
+// The tools are updated when the server notifies that they changed.
+func (a *Aggregator) AddClient(ctx context.Context, prefix string, c *client.Client) error {
+	return a.add(ctx, &aggregatedService{prefix: prefix, client: c})
+}
+
+// Close closes the clients of the services.
+func (a *Aggregator) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for _, service := range a.services {
+		if err := service.client.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("service %s: %w", service.prefix, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Aggregator) add(ctx context.Context, service *aggregatedService) error {
+	if !servicePrefixPattern.MatchString(service.prefix) {
+		return fmt.Errorf("invalid service prefix %q: use letters, digits, _ and -", service.prefix)
+	}
+	a.mu.Lock()
+	_, ok := a.services[service.prefix]
+	a.mu.Unlock()
+	if ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateService, service.prefix)
+	}
+	if err := service.client.Start(ctx); err != nil {
+		return fmt.Errorf("service %s: start: %w", service.prefix, err)
+	}
+	initialize := mcp.InitializeRequest{}
+	initialize.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initialize.Params.ClientInfo = mcp.Implementation{Name: "oas-mcp-aggregator", Version: "1.0.0"}
+	res, err := service.client.Initialize(ctx, initialize)
+	if err != nil {
+		return fmt.Errorf("service %s: initialize: %w", service.prefix, err)
+	}
+	a.mu.Lock()
+	a.services[service.prefix] = service
+	a.mu.Unlock()
+
+	if res.Capabilities.Tools != nil {
+		if err := a.syncTools(ctx, service); err != nil {
+			return err
+		}
+	}
+	if res.Capabilities.Resources != nil {
+		if err := a.addResources(ctx, service); err != nil {
+			return err
+		}
+	}
+	if res.Capabilities.Prompts != nil {
+		if err := a.addPrompts(ctx, service); err != nil {
+			return err
+		}
+	}
+	service.client.OnNotification(func(notification mcp.JSONRPCNotification) {
+		switch notification.Method {
+		case string(mcp.MethodNotificationToolsListChanged):
+			// The error is reported on the next call of the removed tools
+			_ = a.syncTools(context.Background(), service)
+		case string(mcp.MethodNotificationResourceUpdated):
+			a.srv.SendNotificationToAllClients(notification.Method, notification.Params.AdditionalFields)
+		}
+	})
+	return nil
+}
+
+// name returns the name of a tool or prompt of the service.
+func (a *Aggregator) name(service *aggregatedService, name string) string {
+	return service.prefix + a.separator + name
+}
+
+// syncTools registers the tools of the service and removes the ones it no longer has.
+func (a *Aggregator) syncTools(ctx context.Context, service *aggregatedService) error {
+	res, err := service.client.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return fmt.Errorf("service %s: list tools: %w", service.prefix, err)
+	}
+	tools := make([]server.ServerTool, 0, len(res.Tools))
+	names := make([]string, 0, len(res.Tools))
+	for _, tool := range res.Tools {
+		original := tool.Name
+		// The schemas of the local tools are kept as they are, with every keyword
+		if service.local != nil {
+			if local := service.local.GetTool(original); local != nil {
+				tool = local.Tool
+			}
+		}
+		tool.Name = a.name(service, original)
+		names = append(names, tool.Name)
+		tools = append(tools, server.ServerTool{
+			Tool: tool,
+			Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				request.Params.Name = original
+				return service.client.CallTool(ctx, request)
+			},
+		})
+	}
+	a.mu.Lock()
+	var removed []string
+	for _, name := range service.tools {
+		if !slices.Contains(names, name) {
+			removed = append(removed, name)
+		}
+	}
+	service.tools = names
+	a.mu.Unlock()
+	if len(removed) > 0 {
+		a.srv.DeleteTools(removed...)
+	}
+	a.srv.AddTools(tools...)
+	return nil
+}
+
+// addResources registers the resources and resource templates of the service. The
+// resources keep their URIs, so that the links in the tool results can be read. The
+// URI of a resource already registered by another service is prefixed with the
+// prefix of the service, e.g. oas-mcp://billing/openapi.json.
+func (a *Aggregator) addResources(ctx context.Context, service *aggregatedService) error {
+	resources, err := service.client.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil {
+		return fmt.Errorf("service %s: list resources: %w", service.prefix, err)
+	}
+	for _, resource := range resources.Resources {
+		original := resource.URI
+		a.mu.Lock()
+		if _, ok := a.resources[original]; ok {
+			resource.URI = prefixedURI(service.prefix, original)
+		}
+		a.resources[resource.URI] = struct{}{}
+		a.mu.Unlock()
+		uri := resource.URI
+		a.srv.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			request.Params.URI = original
+			res, err := service.client.ReadResource(ctx, request)
+			if err != nil {
+				return nil, err
+			}
+			return renameContents(res.Contents, original, uri), nil
+		})
+	}
+	templates, err := service.client.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+	if err != nil {
+		return fmt.Errorf("service %s: list resource templates: %w", service.prefix, err)
+	}
+	for _, template := range templates.ResourceTemplates {
+		if template.URITemplate == nil {
+			continue
+		}
+		raw := template.URITemplate.Raw()
+		a.mu.Lock()
+		services := a.templates[raw]
+		a.templates[raw] = append(services, service)
+		a.mu.Unlock()
+		if len(services) > 0 {
+			// The template is served by trying the services in order
+			continue
+		}
+		a.srv.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			a.mu.Lock()
+			services := a.templates[raw]
+			a.mu.Unlock()
+			var err error
+			for _, service := range services {
+				var res *mcp.ReadResourceResult
+				if res, err = service.client.ReadResource(ctx, request); err == nil {
+					return res.Contents, nil
+				}
+			}
+			return nil, err
+		})
+	}
+	return nil
+}
+
+// addPrompts registers the prompts of the service.
+func (a *Aggregator) addPrompts(ctx context.Context, service *aggregatedService) error {
+	res, err := service.client.ListPrompts(ctx, mcp.ListPromptsRequest{})
+	if err != nil {
+		return fmt.Errorf("service %s: list prompts: %w", service.prefix, err)
+	}
+	prompts := make([]server.ServerPrompt, 0, len(res.Prompts))
+	for _, prompt := range res.Prompts {
+		original := prompt.Name
+		prompt.Name = a.name(service, original)
+		prompts = append(prompts, server.ServerPrompt{
+			Prompt: prompt,
+			Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				request.Params.Name = original
+				return service.client.GetPrompt(ctx, request)
+			},
+		})
+	}
+	a.srv.AddPrompts(prompts...)
+	return nil
+}
+
+// prefixedURI inserts the prefix after the scheme of the URI.
+func prefixedURI(prefix, uri string) string {
+	if scheme, rest, ok := strings.Cut(uri, "://"); ok {
+		return scheme + "://" + prefix + "/" + rest
+	}
+	return prefix + "/" + uri
+}
+
+// renameContents replaces the URI of the contents read from the original resource.
+func renameContents(contents []mcp.ResourceContents, original, uri string) []mcp.ResourceContents {
+	if original == uri {
+		return contents
+	}
+	for i, content := range contents {
+		switch v := content.(type) {
+		case mcp.TextResourceContents:
+			if v.URI == original {
+				v.URI = uri
+			}
+			contents[i] = v
+		case mcp.BlobResourceContents:
+			if v.URI == original {
+				v.URI = uri
+			}
+			contents[i] = v
+		}
+	}
+	return contents
+}
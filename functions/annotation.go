@@ -0,0 +1,50 @@
+package functions
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Annotation returns the MCP annotations of the tool.
+func (tool *Tool) Annotation() mcp.ToolAnnotation {
+	if tool.annotation == nil {
+		return mcp.ToolAnnotation{}
+	}
+	return *tool.annotation
+}
+
+// WithTitle sets the human-readable title of the tool.
+func (tool *Tool) WithTitle(title string) *Tool {
+	tool.annotate(func(a *mcp.ToolAnnotation) { a.Title = title })
+	return tool
+}
+
+// WithReadOnlyHint declares whether the tool does not modify its environment.
+func (tool *Tool) WithReadOnlyHint(readOnly bool) *Tool {
+	tool.annotate(func(a *mcp.ToolAnnotation) { a.ReadOnlyHint = &readOnly })
+	return tool
+}
+
+// WithDestructiveHint declares whether the tool may perform destructive updates.
+func (tool *Tool) WithDestructiveHint(destructive bool) *Tool {
+	tool.annotate(func(a *mcp.ToolAnnotation) { a.DestructiveHint = &destructive })
+	return tool
+}
+
+// WithIdempotentHint declares whether repeated calls with the same arguments have no additional effect.
+func (tool *Tool) WithIdempotentHint(idempotent bool) *Tool {
+	tool.annotate(func(a *mcp.ToolAnnotation) { a.IdempotentHint = &idempotent })
+	return tool
+}
+
+// WithOpenWorldHint declares whether the tool interacts with external entities.
+func (tool *Tool) WithOpenWorldHint(openWorld bool) *Tool {
+	tool.annotate(func(a *mcp.ToolAnnotation) { a.OpenWorldHint = &openWorld })
+	return tool
+}
+
+func (tool *Tool) annotate(fn func(a *mcp.ToolAnnotation)) {
+	if tool.annotation == nil {
+		tool.annotation = &mcp.ToolAnnotation{}
+	}
+	fn(tool.annotation)
+}
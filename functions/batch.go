@@ -0,0 +1,425 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// BatchToolName is the name of the batch tool.
+const BatchToolName = "batch"
+
+// DefaultBatchMaxSteps is the number of steps a batch accepts by default.
+const DefaultBatchMaxSteps = 20
+
+// batchReference matches the references to the results of the previous steps, e.g.
+// ${0.id} or ${pet.tags.0.name}.
+var batchReference = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// BatchOptions configures BatchTool. The zero value uses the defaults.
+type BatchOptions struct {
+	// MaxSteps is the number of steps a batch accepts. It defaults to DefaultBatchMaxSteps.
+	MaxSteps int
+	// Sequential runs every step after the previous one. By default, the consecutive
+	// steps of read-only tools not referring to each other run concurrently.
+	Sequential bool
+}
+
+// BatchStep is a tool call of a batch.
+type BatchStep struct {
+	// ID names the step for the references of the next steps, besides its index
+	ID        string         `json:"id,omitempty"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+}
+
+// batchInputSchema is the input schema of the batch tool; the arguments of the steps
+// take any JSON value.
+const batchInputSchema = `{
+	"type": "object",
+	"properties": {
+		"steps": {
+			"type": "array",
+			"description": "The tool calls, run in order",
+			"minItems": 1,
+			"items": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string", "description": "Optional name of the step, to refer to its result as ${id.path} in the next steps"},
+					"tool": {"type": "string", "description": "The name of the tool to call"},
+					"arguments": {"type": "object", "description": "The arguments of the tool. Strings may refer to the results of the previous steps with ${step.path}"}
+				},
+				"required": ["tool"]
+			}
+		},
+		"continueOnError": {"type": "boolean", "description": "Run the next steps after a failing step; the steps referring to its result fail"}
+	},
+	"required": ["steps"]
+}`
+
+// BatchStepResult is the outcome of a step of a batch.
+type BatchStepResult struct {
+	ID     string `json:"id,omitempty"`
+	Tool   string `json:"tool"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Skipped is set for the steps not run after a failed step
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// BatchResult is the result of the batch tool, with the outcome of every step in order.
+type BatchResult struct {
+	Steps []BatchStepResult `json:"steps"`
+}
+
+// BatchTool returns a tool calling several tools of the registry in one call, so that
+// the model does not spend a round-trip per call of an obvious sequence, e.g. creating
+// a resource and reading it. The string arguments of a step may refer to the result of
+// a previous step with ${step.path}, where step is the index or the id of the step and
+// path the dot separated fields and indexes of its result, e.g. ${0.id}: an argument
+// made only of a reference gets the referred value as is, and the references within a
+// longer string are replaced with their text. The steps run in order and the batch
+// stops at the first failure, unless continueOnError is set; the consecutive steps of
+// read-only tools not referring to each other run concurrently, and complete even when
+// one of them fails. The tools run with their middlewares and observers as when they
+// are called directly.
+func BatchTool(registry *Registry, opts BatchOptions) *Tool {
+	if opts.MaxSteps <= 0 {
+		opts.MaxSteps = DefaultBatchMaxSteps
+	}
+	batch := &batchRunner{registry: registry, opts: opts}
+	return NewTool(BatchToolName).
+		Description("Call several tools in one request, in order, and return all their results. " +
+			"The string arguments of a step can use the result of a previous step with ${step.path}, " +
+			"where step is the index (from 0) or the id of the step and path the dot separated fields and array indexes of its result, " +
+			`e.g. {"petId": "${0.id}"} or {"name": "${created.name} copy"}. ` +
+			"The batch stops at the first failing step unless continueOnError is true.").
+		InputSchema(MustParseSchema(batchInputSchema)).
+		Handler(func(ctx context.Context, input struct {
+			Steps           []BatchStep `json:"steps"`
+			ContinueOnError bool        `json:"continueOnError,omitempty"`
+		}) (*BatchResult, error) {
+			return batch.run(ctx, input.Steps, input.ContinueOnError)
+		})
+}
+
+type batchRunner struct {
+	registry *Registry
+	opts     BatchOptions
+}
+
+// batchState holds the outcome of the steps of a batch.
+type batchState struct {
+	steps   []BatchStep
+	results []BatchStepResult
+	// values holds the results of the steps as JSON values, for the references
+	values []any
+	// done tells which steps succeeded
+	done []bool
+}
+
+func (b *batchRunner) run(ctx context.Context, steps []BatchStep, continueOnError bool) (*BatchResult, error) {
+	tools, err := b.validate(steps)
+	if err != nil {
+		return nil, err
+	}
+	state := &batchState{
+		steps:   steps,
+		results: make([]BatchStepResult, len(steps)),
+		values:  make([]any, len(steps)),
+		done:    make([]bool, len(steps)),
+	}
+	for i, step := range steps {
+		state.results[i] = BatchStepResult{ID: step.ID, Tool: step.Tool}
+	}
+	progress := ProgressFromContext(ctx)
+	failed := false
+	for start := 0; start < len(steps); {
+		if failed && !continueOnError {
+			for i := start; i < len(steps); i++ {
+				state.results[i].Skipped = true
+			}
+			break
+		}
+		end := b.group(steps, tools, start)
+		var wg sync.WaitGroup
+		for i := start; i < end; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				state.call(ctx, i, tools[i])
+			}()
+		}
+		wg.Wait()
+		for i := start; i < end; i++ {
+			failed = failed || !state.done[i]
+		}
+		_ = progress.Report(float64(end), float64(len(steps)), fmt.Sprintf("Ran %d of %d steps", end, len(steps)))
+		start = end
+	}
+	return &BatchResult{Steps: state.results}, nil
+}
+
+// validate returns the tools of the steps, or a ValidationError for the unknown tools,
+// the batch tool itself, the duplicated ids and the references to the steps which do
+// not run before.
+func (b *batchRunner) validate(steps []BatchStep) ([]*Tool, error) {
+	var errs []FieldError
+	if len(steps) == 0 {
+		errs = append(errs, FieldError{Field: "steps", Message: "at least one step is required"})
+	}
+	if len(steps) > b.opts.MaxSteps {
+		errs = append(errs, FieldError{Field: "steps", Message: fmt.Sprintf("a batch accepts up to %d steps", b.opts.MaxSteps)})
+	}
+	tools := make([]*Tool, len(steps))
+	ids := map[string]int{}
+	for i, step := range steps {
+		field := fmt.Sprintf("steps[%d]", i)
+		tool, ok := b.registry.Get(step.Tool)
+		switch {
+		case step.Tool == BatchToolName:
+			// A batch calling itself would recurse without bound
+			errs = append(errs, FieldError{Field: field + ".tool", Message: "a batch cannot call the batch tool"})
+		case !ok:
+			errs = append(errs, FieldError{Field: field + ".tool", Message: fmt.Sprintf("unknown tool %q", step.Tool)})
+		}
+		tools[i] = tool
+		for _, ref := range references(step.Arguments) {
+			if index, ok := stepIndex(ref, ids); !ok || index >= i {
+				errs = append(errs, FieldError{Field: field + ".arguments", Message: fmt.Sprintf("${%s} does not refer to a previous step", ref)})
+			}
+		}
+		if step.ID != "" {
+			if _, err := strconv.Atoi(step.ID); err == nil || strings.Contains(step.ID, ".") {
+				errs = append(errs, FieldError{Field: field + ".id", Message: "the id must not be a number nor contain dots"})
+			} else if _, ok := ids[step.ID]; ok {
+				errs = append(errs, FieldError{Field: field + ".id", Message: fmt.Sprintf("duplicate id %q", step.ID)})
+			}
+			ids[step.ID] = i
+		}
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	return tools, nil
+}
+
+// group returns the end of the steps running concurrently from start: the following
+// steps of read-only tools which do not refer to each other.
+func (b *batchRunner) group(steps []BatchStep, tools []*Tool, start int) int {
+	end := start + 1
+	if b.opts.Sequential || !readOnly(tools[start]) {
+		return end
+	}
+	ids := stepIDs(steps)
+	for ; end < len(steps) && readOnly(tools[end]); end++ {
+		for _, ref := range references(steps[end].Arguments) {
+			if index, _ := stepIndex(ref, ids); index >= start {
+				return end
+			}
+		}
+	}
+	return end
+}
+
+// call runs the step i and records its outcome.
+func (s *batchState) call(ctx context.Context, i int, tool *Tool) {
+	args, err := s.resolve(s.steps[i].Arguments)
+	if err != nil {
+		s.results[i].Error = err.Error()
+		return
+	}
+	params, _ := args.(map[string]any)
+	if params == nil {
+		params = map[string]any{}
+	}
+	res, err := tool.Execute(ctx, params)
+	if err != nil {
+		s.results[i].Error = err.Error()
+		return
+	}
+	value, err := jsonValue(res)
+	if err != nil {
+		s.results[i].Error = err.Error()
+		return
+	}
+	s.results[i].Result = value
+	s.values[i] = value
+	s.done[i] = true
+}
+
+// resolve returns v with the references replaced by the results of the steps.
+func (s *batchState) resolve(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return s.resolveString(v)
+	case map[string]any:
+		resolved := make(map[string]any, len(v))
+		for key, value := range v {
+			value, err := s.resolve(value)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = value
+		}
+		return resolved, nil
+	case []any:
+		resolved := make([]any, len(v))
+		for i, value := range v {
+			value, err := s.resolve(value)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = value
+		}
+		return resolved, nil
+	}
+	return v, nil
+}
+
+func (s *batchState) resolveString(v string) (any, error) {
+	matches := batchReference.FindAllStringSubmatchIndex(v, -1)
+	if len(matches) == 0 {
+		return v, nil
+	}
+	// A string made of a single reference keeps the type of the value, e.g. a number
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(v) {
+		return s.value(v[matches[0][2]:matches[0][3]])
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		value, err := s.value(v[m[2]:m[3]])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(v[last:m[0]])
+		if text, ok := value.(string); ok {
+			b.WriteString(text)
+		} else {
+			buf, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			b.Write(buf)
+		}
+		last = m[1]
+	}
+	b.WriteString(v[last:])
+	return b.String(), nil
+}
+
+// value returns the value of the reference, e.g. 0.items.1.id.
+func (s *batchState) value(ref string) (any, error) {
+	index, _ := stepIndex(ref, stepIDs(s.steps))
+	if !s.done[index] {
+		return nil, fmt.Errorf("${%s}: step %d did not succeed", ref, index)
+	}
+	v := s.values[index]
+	_, path, _ := strings.Cut(strings.TrimSpace(ref), ".")
+	if path == "" {
+		return v, nil
+	}
+	for _, name := range strings.Split(path, ".") {
+		switch current := v.(type) {
+		case map[string]any:
+			value, ok := current[name]
+			if !ok {
+				return nil, fmt.Errorf("${%s}: the result of step %d has no field %q", ref, index, name)
+			}
+			v = value
+		case []any:
+			i, err := strconv.Atoi(name)
+			if err != nil || i < 0 || i >= len(current) {
+				return nil, fmt.Errorf("${%s}: the result of step %d has no item %q", ref, index, name)
+			}
+			v = current[i]
+		default:
+			return nil, fmt.Errorf("${%s}: the result of step %d has no field %q", ref, index, name)
+		}
+	}
+	return v, nil
+}
+
+// references returns the references within the arguments.
+func references(v any) []string {
+	var refs []string
+	switch v := v.(type) {
+	case string:
+		for _, m := range batchReference.FindAllStringSubmatch(v, -1) {
+			refs = append(refs, m[1])
+		}
+	case map[string]any:
+		for _, value := range v {
+			refs = append(refs, references(value)...)
+		}
+	case []any:
+		for _, value := range v {
+			refs = append(refs, references(value)...)
+		}
+	}
+	return refs
+}
+
+// stepIDs returns the indexes of the steps by id.
+func stepIDs(steps []BatchStep) map[string]int {
+	ids := map[string]int{}
+	for i, step := range steps {
+		if step.ID != "" {
+			ids[step.ID] = i
+		}
+	}
+	return ids
+}
+
+// stepIndex returns the index of the step of the reference, given by index or id.
+func stepIndex(ref string, ids map[string]int) (int, bool) {
+	step, _, _ := strings.Cut(strings.TrimSpace(ref), ".")
+	if index, err := strconv.Atoi(step); err == nil {
+		return index, index >= 0
+	}
+	index, ok := ids[step]
+	return index, ok
+}
+
+// readOnly tells whether the tool declares not to modify its environment.
+func readOnly(tool *Tool) bool {
+	hint := tool.Annotation().ReadOnlyHint
+	return hint != nil && *hint
+}
+
+// jsonValue returns the JSON value of the result, keeping the numbers as json.Number
+// as the arguments of the tool calls. The results already encoded, e.g. the JSON text
+// returned by the generated tools, are decoded.
+func jsonValue(res any) (any, error) {
+	var buf []byte
+	switch res := res.(type) {
+	case string:
+		if !json.Valid([]byte(res)) {
+			return res, nil
+		}
+		buf = []byte(res)
+	case []byte:
+		if !json.Valid(res) {
+			return string(res), nil
+		}
+		buf = res
+	default:
+		var err error
+		if buf, err = json.Marshal(res); err != nil {
+			return nil, err
+		}
+	}
+	var v any
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
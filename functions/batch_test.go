@@ -0,0 +1,32 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestBatchRejectsBatchStep(t *testing.T) {
+	registry := NewRegistry()
+	echo := NewFunctionTool("echo", "Echo the input", func(ctx context.Context, params map[string]any) (any, error) {
+		return params, nil
+	})
+	if err := registry.Add(echo, BatchTool(registry, BatchOptions{})); err != nil {
+		t.Fatal(err)
+	}
+	batch, _ := registry.Get(BatchToolName)
+
+	_, err := batch.Execute(context.Background(), map[string]any{
+		"steps": []any{
+			map[string]any{"tool": "echo"},
+			map[string]any{"tool": BatchToolName, "arguments": map[string]any{"steps": []any{}}},
+		},
+	})
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Execute() error = %v, want a ValidationError", err)
+	}
+	if len(validationErr.Errors) != 1 || validationErr.Errors[0].Field != "steps[1].tool" {
+		t.Errorf("unexpected errors: %+v", validationErr.Errors)
+	}
+}
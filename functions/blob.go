@@ -0,0 +1,163 @@
+package functions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// BlobResourcePrefix is the prefix of the URIs of the stored blobs.
+const BlobResourcePrefix = "oas-mcp://blobs/"
+
+// ErrBlobNotFound is returned when a blob is not stored.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore stores the payloads returned as MCP resource links.
+type BlobStore interface {
+	// Put stores the data and returns its id.
+	Put(ctx context.Context, data []byte, mimeType string) (string, error)
+	// Get returns the data and MIME type of the blob.
+	Get(ctx context.Context, id string) ([]byte, string, error)
+}
+
+// FileBlobStore stores the blobs as files of a directory.
+type FileBlobStore struct {
+	dir       string
+	mu        sync.RWMutex
+	mimeTypes map[string]string
+}
+
+// NewFileBlobStore returns a store writing the blobs to dir. A temporary directory
+// is created when dir is empty.
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if dir == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "oas-mcp-blobs-"); err != nil {
+			return nil, fmt.Errorf("create blob directory: %w", err)
+		}
+	} else if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create blob directory: %w", err)
+	}
+	return &FileBlobStore{dir: dir, mimeTypes: map[string]string{}}, nil
+}
+
+func (s *FileBlobStore) Put(_ context.Context, data []byte, mimeType string) (string, error) {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf[:])
+	if err := os.WriteFile(filepath.Join(s.dir, id), data, 0o600); err != nil {
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	s.mu.Lock()
+	s.mimeTypes[id] = mimeType
+	s.mu.Unlock()
+	return id, nil
+}
+
+func (s *FileBlobStore) Get(_ context.Context, id string) ([]byte, string, error) {
+	s.mu.RLock()
+	mimeType, ok := s.mimeTypes[id]
+	s.mu.RUnlock()
+	// The id is checked against the stored ones, so it cannot escape the directory
+	if !ok {
+		return nil, "", ErrBlobNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, id))
+	if err != nil {
+		return nil, "", fmt.Errorf("read blob: %w", err)
+	}
+	return data, mimeType, nil
+}
+
+// BlobLinker stores the large or binary results of the tools in a BlobStore and
+// returns MCP resource links instead, so that clients fetch them on demand.
+type BlobLinker struct {
+	store BlobStore
+	// maxBytes is the size above which results are linked. 0 links binary results only.
+	maxBytes int
+}
+
+// NewBlobLinker returns a linker storing the binary results and the results larger
+// than maxBytes bytes in the store. 0 links binary results only.
+func NewBlobLinker(store BlobStore, maxBytes int) *BlobLinker {
+	return &BlobLinker{store: store, maxBytes: maxBytes}
+}
+
+// Link returns a middleware replacing the large or binary results with a resource link.
+func (l *BlobLinker) Link() Middleware {
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil {
+				return res, err
+			}
+			var data []byte
+			switch v := res.(type) {
+			case nil, *ToolResult, ToolResult, *mcp.CallToolResult, mcp.CallToolResult, []mcp.Content, mcp.Content:
+				return res, nil
+			case string:
+				data = []byte(v)
+			default:
+				if data, err = json.Marshal(res); err != nil {
+					return res, nil
+				}
+			}
+			binary := !utf8.Valid(data) || strings.ContainsRune(string(data), 0)
+			if !binary && (l.maxBytes <= 0 || len(data) <= l.maxBytes) {
+				return res, nil
+			}
+			mimeType := "text/plain"
+			switch {
+			case binary:
+				mimeType = http.DetectContentType(data)
+			case json.Valid(data):
+				mimeType = "application/json"
+			}
+			id, err := l.store.Put(ctx, data, mimeType)
+			if err != nil {
+				return nil, NewInternalError(err)
+			}
+			description := fmt.Sprintf("The %d bytes result of %s", len(data), tool.Name())
+			return NewToolResult(
+				mcp.NewTextContent(fmt.Sprintf("The result of %d bytes (%s) is too large or binary to be returned inline. Read the resource %s to get it.", len(data), mimeType, BlobResourcePrefix+id)),
+				mcp.NewResourceLink(BlobResourcePrefix+id, tool.Name()+" result", description, mimeType),
+			), nil
+		}
+	}
+}
+
+// Bind registers the resource template of the stored blobs to the MCP server.
+func (l *BlobLinker) Bind(srv *server.MCPServer) {
+	template := mcp.NewResourceTemplate(BlobResourcePrefix+"{id}", "Tool result",
+		mcp.WithTemplateDescription("A large or binary tool result"),
+	)
+	srv.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, mimeType, err := l.store.Get(ctx, strings.TrimPrefix(request.Params.URI, BlobResourcePrefix))
+		if err != nil {
+			return nil, err
+		}
+		if utf8.Valid(data) && (strings.HasPrefix(mimeType, "text/") || mimeType == "application/json") {
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: request.Params.URI, MIMEType: mimeType, Text: string(data)},
+			}, nil
+		}
+		return []mcp.ResourceContents{
+			mcp.BlobResourceContents{URI: request.Params.URI, MIMEType: mimeType, Blob: base64.StdEncoding.EncodeToString(data)},
+		}, nil
+	})
+}
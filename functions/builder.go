@@ -0,0 +1,78 @@
+package functions
+
+import (
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ToolBuilder builds a Tool with a fluent API, e.g.
+//
+//	functions.NewTool("get_user").
+//		Description("Get a user by id").
+//		Annotation(mcp.ToolAnnotation{ReadOnlyHint: mcp.ToBoolPtr(true)}).
+//		Handler(func(ctx context.Context, input GetUserInput) (any, error) { ... })
+type ToolBuilder struct {
+	name         string
+	description  string
+	annotation   *mcp.ToolAnnotation
+	inputSchema  *Schema
+	outputSchema *Schema
+	tags         []string
+	examples     []map[string]any
+}
+
+// NewTool starts building a tool with the given name.
+func NewTool(name string) *ToolBuilder {
+	return &ToolBuilder{
+		name: name,
+	}
+}
+
+// Description sets the description of the tool.
+func (b *ToolBuilder) Description(description string) *ToolBuilder {
+	b.description = description
+	return b
+}
+
+// Annotation sets the MCP annotations describing the behavior of the tool.
+func (b *ToolBuilder) Annotation(annotation mcp.ToolAnnotation) *ToolBuilder {
+	b.annotation = &annotation
+	return b
+}
+
+// InputSchema overrides the input schema derived from the handler.
+func (b *ToolBuilder) InputSchema(schema *Schema) *ToolBuilder {
+	b.inputSchema = schema
+	return b
+}
+
+// OutputSchema sets the schema of the tool result.
+func (b *ToolBuilder) OutputSchema(schema *Schema) *ToolBuilder {
+	b.outputSchema = schema
+	return b
+}
+
+// Tags sets the tags used to group the tool.
+func (b *ToolBuilder) Tags(tags ...string) *ToolBuilder {
+	b.tags = tags
+	return b
+}
+
+// Examples adds example argument sets of the tool.
+func (b *ToolBuilder) Examples(examples ...map[string]any) *ToolBuilder {
+	b.examples = append(b.examples, examples...)
+	return b
+}
+
+// Handler sets the function executed by the tool and returns the built tool.
+// The function has the same form as the one given to NewFunctionTool.
+func (b *ToolBuilder) Handler(fn any) *Tool {
+	tool := NewFunctionTool(b.name, b.description, fn)
+	if b.inputSchema != nil {
+		tool.schema = b.inputSchema
+	}
+	tool.annotation = b.annotation
+	tool.outputSchema = b.outputSchema
+	tool.tags = b.tags
+	tool.examples = b.examples
+	return tool
+}
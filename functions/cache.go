@@ -0,0 +1,88 @@
+package functions
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	// schemaCache caches the input schema per function type.
+	schemaCache sync.Map // map[reflect.Type]*Schema
+	// functionCache caches the call metadata per function type.
+	functionCache sync.Map // map[reflect.Type]*functionInfo
+	// structFieldCache caches the parameter names of struct fields per struct type.
+	structFieldCache sync.Map // map[reflect.Type][]structField
+	// ogenWrapperCache caches whether the values of a type hold ogen wrappers per type.
+	ogenWrapperCache sync.Map // map[reflect.Type]bool
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+type functionInfo struct {
+	// hasContext reports whether the first parameter is a context.Context
+	hasContext bool
+}
+
+type structField struct {
+	index int
+	name  string
+	typ   reflect.Type
+}
+
+// cachedSchemaFromFunction returns the input schema of the function type.
+// The returned schema is shared between tools and must not be modified.
+func cachedSchemaFromFunction(fnType reflect.Type) *Schema {
+	if schema, ok := schemaCache.Load(fnType); ok {
+		return schema.(*Schema)
+	}
+	schema, _ := schemaCache.LoadOrStore(fnType, generateSchemaFromFunction(fnType))
+	return schema.(*Schema)
+}
+
+// cachedFunctionInfo returns the call metadata of the function type.
+func cachedFunctionInfo(fnType reflect.Type) *functionInfo {
+	if info, ok := functionCache.Load(fnType); ok {
+		return info.(*functionInfo)
+	}
+	info := &functionInfo{
+		hasContext: fnType.NumIn() > 0 && fnType.In(0).Implements(contextType),
+	}
+	cached, _ := functionCache.LoadOrStore(fnType, info)
+	return cached.(*functionInfo)
+}
+
+// cachedStructFields returns the fields of the struct type with their parameter names.
+func cachedStructFields(structType reflect.Type) []structField {
+	if fields, ok := structFieldCache.Load(structType); ok {
+		return fields.([]structField)
+	}
+	fields := make([]structField, 0, structType.NumField())
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+
+		// Get the JSON tag if available
+		// Handle json tag options like `json:"name,omitempty"`
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, structField{
+			index: i,
+			name:  name,
+			typ:   field.Type,
+		})
+	}
+	cached, _ := structFieldCache.LoadOrStore(structType, fields)
+	return cached.([]structField)
+}
+
+// cachedHasOgenWrapper reports whether values of the type hold ogen wrappers.
+func cachedHasOgenWrapper(t reflect.Type) bool {
+	if has, ok := ogenWrapperCache.Load(t); ok {
+		return has.(bool)
+	}
+	has, _ := ogenWrapperCache.LoadOrStore(t, hasOgenWrapper(t, map[reflect.Type]bool{}))
+	return has.(bool)
+}
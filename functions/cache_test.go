@@ -0,0 +1,102 @@
+package functions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type cacheInput struct {
+	PetID  int64    `json:"petId" mcprequired:"true"`
+	Name   string   `json:"name" mcpdescription:"Name of the pet"`
+	Tags   []string `json:"tags"`
+	Limit  int      `json:"limit,omitempty"`
+	Status string   `json:"status"`
+}
+
+func cacheFunction(ctx context.Context, input cacheInput) (any, error) {
+	return input.PetID, nil
+}
+
+var cacheArguments = map[string]any{
+	"petId":  42,
+	"name":   "tama",
+	"tags":   []any{"cat", "white"},
+	"limit":  10,
+	"status": "available",
+}
+
+// clearCaches drops the cached reflection results, as before they were cached.
+func clearCaches() {
+	schemaCache.Clear()
+	functionCache.Clear()
+	structFieldCache.Clear()
+	ogenWrapperCache.Clear()
+}
+
+func BenchmarkNewFunctionToolCache(b *testing.B) {
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			clearCaches()
+			NewFunctionTool("updatePet", "Update a pet", cacheFunction)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			NewFunctionTool("updatePet", "Update a pet", cacheFunction)
+		}
+	})
+}
+
+// BenchmarkSchemaCache compares deriving the input schema with getTypeSchema on
+// every tool with reading it from the cache of the function type.
+func BenchmarkSchemaCache(b *testing.B) {
+	fnType := reflect.TypeOf(cacheFunction)
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			generateSchemaFromFunction(fnType)
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			cachedSchemaFromFunction(fnType)
+		}
+	})
+}
+
+func BenchmarkExecuteCache(b *testing.B) {
+	tool := NewFunctionTool("updatePet", "Update a pet", cacheFunction)
+	ctx := context.Background()
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			clearCaches()
+			if _, err := tool.Execute(ctx, cacheArguments); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := tool.Execute(ctx, cacheArguments); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestCachedSchemaFromFunction(t *testing.T) {
+	fnType := reflect.TypeOf(cacheFunction)
+	cached := cachedSchemaFromFunction(fnType)
+	if cachedSchemaFromFunction(fnType) != cached {
+		t.Error("the schema of the function type is not cached")
+	}
+	if !reflect.DeepEqual(cached, generateSchemaFromFunction(fnType)) {
+		t.Error("the cached schema differs from the generated schema")
+	}
+}
@@ -0,0 +1,42 @@
+package functions
+
+import "github.com/mark3labs/mcp-go/server"
+
+// Capabilities selects the optional MCP capabilities declared by a server, so that a
+// deployment can turn off the features it does not want the clients to rely on. The
+// tools and resources capabilities themselves are always declared.
+type Capabilities struct {
+	// ToolsListChanged declares the notifications of the changes of the tool list.
+	ToolsListChanged bool
+	// ResourcesSubscribe declares resources/subscribe, see OperationResources.
+	ResourcesSubscribe bool
+	// ResourcesListChanged declares the notifications of the changes of the resource list.
+	ResourcesListChanged bool
+	// Prompts declares the prompts; the servers without it must not serve any.
+	Prompts bool
+	// PromptsListChanged declares the notifications of the changes of the prompt list.
+	PromptsListChanged bool
+	// Logging declares the MCP logging notifications, see MCPLogHandler.
+	Logging bool
+	// Completions declares completion/complete for the arguments of the prompts and
+	// resource templates.
+	Completions bool
+}
+
+// ServerOptions returns the options of the MCP server declaring the capabilities.
+func (c Capabilities) ServerOptions() []server.ServerOption {
+	opts := []server.ServerOption{
+		server.WithToolCapabilities(c.ToolsListChanged),
+		server.WithResourceCapabilities(c.ResourcesSubscribe, c.ResourcesListChanged),
+	}
+	if c.Prompts {
+		opts = append(opts, server.WithPromptCapabilities(c.PromptsListChanged))
+	}
+	if c.Logging {
+		opts = append(opts, server.WithLogging())
+	}
+	if c.Completions {
+		opts = append(opts, server.WithCompletions())
+	}
+	return opts
+}
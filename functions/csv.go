@@ -0,0 +1,105 @@
+package functions
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ConvertCSV returns a HTTPDoer converting the text/csv and text/tab-separated-values
+// response bodies to a JSON array of objects keyed by the header row.
+// At most maxRows rows are kept (no limit when maxRows <= 0); a truncated
+// result is returned as StreamedResult.
+// The Content-Type of the response is kept so that the clients still accept it.
+func ConvertCSV(doer HTTPDoer, maxRows int) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &csvDoer{doer: doer, maxRows: maxRows}
+}
+
+type csvDoer struct {
+	doer    HTTPDoer
+	maxRows int
+}
+
+func (d *csvDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		return res, nil
+	}
+	var comma rune
+	switch mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type")); mediaType {
+	case "text/csv":
+		comma = ','
+	case "text/tab-separated-values":
+		comma = '\t'
+	default:
+		return res, nil
+	}
+	defer res.Body.Close()
+	result, err := CSVToJSON(res.Body, comma, d.maxRows)
+	if err != nil {
+		return nil, fmt.Errorf("convert csv: %w", err)
+	}
+	buf, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("convert csv: %w", err)
+	}
+	res.Body = io.NopCloser(strings.NewReader(string(buf)))
+	res.ContentLength = int64(len(buf))
+	res.Header.Set("Content-Length", strconv.Itoa(len(buf)))
+	return res, nil
+}
+
+// CSVToJSON reads the CSV records separated by comma and returns them as objects
+// keyed by the header row. At most maxRows rows are read (no limit when maxRows <= 0);
+// when more rows follow, the rows are returned as StreamedResult.
+func CSVToJSON(r io.Reader, comma rune, maxRows int) (any, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = comma
+	// Rows may have fewer or more fields than the header
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return []map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	header = append([]string(nil), header...)
+	rows := []map[string]string{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if maxRows > 0 && len(rows) >= maxRows {
+			return &StreamedResult{Data: rows, Truncated: true, Reason: fmt.Sprintf("rows are limited to %d", maxRows)}, nil
+		}
+		row := make(map[string]string, len(header))
+		for i, value := range record {
+			key := "column" + strconv.Itoa(i+1)
+			if i < len(header) && header[i] != "" {
+				key = header[i]
+			}
+			row[key] = value
+		}
+		rows = append(rows, row)
+	}
+}
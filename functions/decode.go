@@ -0,0 +1,165 @@
+package functions
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	timeType            = reflect.TypeOf(time.Time{})
+)
+
+// decodesWithJSON reports whether values of the type are decoded with encoding/json.
+// Composite types and types with a custom UnmarshalJSON are decoded as JSON so that
+// nested structs are populated; primitives keep the lenient conversion of convertToType.
+func decodesWithJSON(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Ptr, reflect.Interface:
+		return true
+	}
+	return false
+}
+
+// decodeValue converts the decoded JSON value into a new value of the target type.
+func decodeValue(value any, targetType reflect.Type) (reflect.Value, error) {
+	if w, ok := ogenWrapperOf(targetType); ok {
+		return decodeOgenWrapper(value, targetType, w)
+	}
+	// Values holding ogen wrappers without their own JSON decoder are decoded element
+	// by element and structs field by field so that the wrappers are set; other values
+	// are decoded as JSON
+	if value != nil && cachedHasOgenWrapper(targetType) {
+		if decoded, ok, err := decodeOgenContainer(value, targetType); ok {
+			return decoded, err
+		}
+	}
+	if !decodesWithJSON(targetType) {
+		convertedValue, err := convertToType(value, targetType)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return valueOf(convertedValue, targetType), nil
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	ptr := reflect.New(targetType)
+	if err := json.Unmarshal(buf, ptr.Interface()); err != nil {
+		// Fall back to the lenient conversion, e.g. for numbers passed as strings
+		if convertedValue, convErr := convertToType(value, targetType); convErr == nil && convertedValue != nil {
+			return reflect.ValueOf(convertedValue), nil
+		}
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+// unmarshalString converts the string using the custom decoder of the target type.
+// It prefers encoding.TextUnmarshaler and falls back to json.Unmarshaler.
+// ok is false when the target type has no custom decoder.
+func unmarshalString(s string, targetType reflect.Type) (value any, ok bool, err error) {
+	ptrType := reflect.PointerTo(targetType)
+	switch {
+	case ptrType.Implements(textUnmarshalerType):
+		ptr := reflect.New(targetType)
+		if err := ptr.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return nil, true, err
+		}
+		return ptr.Elem().Interface(), true, nil
+	case ptrType.Implements(jsonUnmarshalerType):
+		buf, err := json.Marshal(s)
+		if err != nil {
+			return nil, true, err
+		}
+		ptr := reflect.New(targetType)
+		if err := ptr.Interface().(json.Unmarshaler).UnmarshalJSON(buf); err != nil {
+			return nil, true, err
+		}
+		return ptr.Elem().Interface(), true, nil
+	}
+	return nil, false, nil
+}
+
+// fieldErrors are the errors of the fields of a nested struct.
+type fieldErrors []FieldError
+
+func (errs fieldErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Field+": "+err.Message)
+	}
+	return strings.Join(messages, ", ")
+}
+
+// decodeOgenContainer decodes the value into the struct, pointer, slice, array or map
+// type holding ogen wrappers. It returns false when the value does not have the shape
+// of the type.
+func decodeOgenContainer(value any, targetType reflect.Type) (reflect.Value, bool, error) {
+	switch targetType.Kind() {
+	case reflect.Struct:
+		fields, ok := value.(map[string]any)
+		if !ok {
+			return reflect.Value{}, false, nil
+		}
+		structValue, errs := populateStruct(targetType, fields)
+		if len(errs) > 0 {
+			return reflect.Value{}, true, fieldErrors(errs)
+		}
+		return structValue, true, nil
+	case reflect.Ptr:
+		elemValue, err := decodeValue(value, targetType.Elem())
+		if err != nil {
+			return reflect.Value{}, true, err
+		}
+		ptr := reflect.New(targetType.Elem())
+		ptr.Elem().Set(elemValue)
+		return ptr, true, nil
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			return reflect.Value{}, false, nil
+		}
+		var list reflect.Value
+		if targetType.Kind() == reflect.Slice {
+			list = reflect.MakeSlice(targetType, len(items), len(items))
+		} else if len(items) > targetType.Len() {
+			return reflect.Value{}, true, fmt.Errorf("cannot convert %d elements to %v", len(items), targetType)
+		} else {
+			list = reflect.New(targetType).Elem()
+		}
+		for i, item := range items {
+			elemValue, err := decodeValue(item, targetType.Elem())
+			if err != nil {
+				return reflect.Value{}, true, fmt.Errorf("cannot convert slice element %d: %w", i, err)
+			}
+			list.Index(i).Set(elemValue)
+		}
+		return list, true, nil
+	case reflect.Map:
+		entries, ok := value.(map[string]any)
+		if !ok || targetType.Key().Kind() != reflect.String {
+			return reflect.Value{}, false, nil
+		}
+		mapValue := reflect.MakeMapWithSize(targetType, len(entries))
+		for key, entry := range entries {
+			elemValue, err := decodeValue(entry, targetType.Elem())
+			if err != nil {
+				return reflect.Value{}, true, fmt.Errorf("cannot convert map element %s: %w", key, err)
+			}
+			mapValue.SetMapIndex(reflect.ValueOf(key).Convert(targetType.Key()), elemValue)
+		}
+		return mapValue, true, nil
+	}
+	return reflect.Value{}, false, nil
+}
@@ -0,0 +1,89 @@
+package functions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type decodeBase struct {
+	ID int `json:"id"`
+}
+
+type decodePlain struct {
+	decodeBase
+	Name string `json:"name"`
+}
+
+// OptDecodeString mimics the OptString type generated by ogen, without its JSON decoder.
+type OptDecodeString struct {
+	Value string
+	Set   bool
+}
+
+func (o OptDecodeString) IsSet() bool { return o.Set }
+
+func (o OptDecodeString) Get() (string, bool) { return o.Value, o.Set }
+
+type decodeWrapped struct {
+	Name OptDecodeString `json:"name"`
+}
+
+// decodeLookalike has the fields of an ogen wrapper but is a plain struct.
+type decodeLookalike struct {
+	Value string `json:"Value"`
+	Set   bool   `json:"Set"`
+}
+
+func TestDecodeNestedStructs(t *testing.T) {
+	type input struct {
+		Plain     decodePlain                `json:"plain"`
+		Wrapped   *decodeWrapped             `json:"wrapped"`
+		List      []decodeWrapped            `json:"list"`
+		Map       map[string]*decodeWrapped  `json:"map"`
+		Lookalike decodeLookalike            `json:"lookalike"`
+		Nested    map[string][]decodeWrapped `json:"nested"`
+	}
+	tool := NewFunctionTool("decode", "Decode", func(ctx context.Context, in input) (any, error) {
+		return in, nil
+	})
+	res, err := tool.Execute(context.Background(), map[string]any{
+		"plain":     map[string]any{"id": 1, "name": "tama"},
+		"wrapped":   map[string]any{"name": "pochi"},
+		"list":      []any{map[string]any{"name": "mike"}, map[string]any{}},
+		"map":       map[string]any{"a": map[string]any{"name": "kuro"}},
+		"lookalike": map[string]any{"Value": "shiro", "Set": true},
+		"nested":    map[string]any{"b": []any{map[string]any{"name": "tora"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := res.(input)
+	// Plain nested structs are decoded as JSON, including the embedded fields
+	if got.Plain.ID != 1 || got.Plain.Name != "tama" {
+		t.Errorf("plain = %+v, want {ID:1 Name:tama}", got.Plain)
+	}
+	// Structs holding ogen wrappers are populated field by field, also within slices and maps
+	set := func(name string) decodeWrapped {
+		return decodeWrapped{Name: OptDecodeString{Value: name, Set: true}}
+	}
+	if got.Wrapped == nil || *got.Wrapped != set("pochi") {
+		t.Errorf("wrapped = %+v, want the name set", got.Wrapped)
+	}
+	if want := []decodeWrapped{set("mike"), {}}; !reflect.DeepEqual(got.List, want) {
+		t.Errorf("list = %+v, want %+v", got.List, want)
+	}
+	if a := got.Map["a"]; a == nil || *a != set("kuro") {
+		t.Errorf("map = %+v, want the name of a set", got.Map)
+	}
+	if want := map[string][]decodeWrapped{"b": {set("tora")}}; !reflect.DeepEqual(got.Nested, want) {
+		t.Errorf("nested = %+v, want %+v", got.Nested, want)
+	}
+	// A struct of the same shape which is not an ogen type is a plain object
+	if got.Lookalike != (decodeLookalike{Value: "shiro", Set: true}) {
+		t.Errorf("lookalike = %+v, want {Value:shiro Set:true}", got.Lookalike)
+	}
+	if _, ok := ogenWrapperOf(reflect.TypeFor[decodeLookalike]()); ok {
+		t.Error("a plain struct with Value and Set fields is taken for an ogen wrapper")
+	}
+}
@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// DefaultsPolicy decides how the defaults declared in the input schema are applied.
+type DefaultsPolicy int
+
+const (
+	// DefaultsLenient fills absent params with the defaults of the schema before Execute.
+	DefaultsLenient DefaultsPolicy = iota
+	// DefaultsStrict leaves absent params absent, so they must be given explicitly.
+	DefaultsStrict
+)
+
+// WithDefaults sets how the defaults of the input schema are applied.
+// The default policy is DefaultsLenient.
+func (tool *Tool) WithDefaults(policy DefaultsPolicy) *Tool {
+	tool.defaultsPolicy = policy
+	return tool
+}
+
+// WithPropertyDefaults sets the defaults of the properties of the input schema, e.g. the
+// defaults an OpenAPI spec declares for generated types without struct tags. defaults has
+// the shape of the arguments: a map given for an object property sets the defaults of its
+// properties, and an optional object without a default defaults to the map,
+// e.g. {"requestParameter": {"limit": 10}}.
+func (tool *Tool) WithPropertyDefaults(defaults map[string]any) *Tool {
+	tool.propertyDefaults = defaults
+	tool.schema = schemaWithDefaults(tool.schema, defaults)
+	return tool
+}
+
+// schemaWithDefaults returns a copy of the schema with the defaults set on its properties.
+// The schema may be shared with other tools, so it is not modified.
+func schemaWithDefaults(schema *Schema, defaults map[string]any) *Schema {
+	if schema == nil || len(defaults) == 0 {
+		return schema
+	}
+	s := *schema
+	s.Properties = propertiesWithDefaults(schema.Properties, schema.Required, defaults)
+	return &s
+}
+
+func propertiesWithDefaults(properties map[string]any, required []string, defaults map[string]any) map[string]any {
+	result := maps.Clone(properties)
+	for name, def := range defaults {
+		property := schemaMap(result[name])
+		if property == nil {
+			continue
+		}
+		property = maps.Clone(property)
+		result[name] = property
+		nested, _ := property["properties"].(map[string]any)
+		values, ok := def.(map[string]any)
+		if !ok || nested == nil {
+			property["default"] = def
+			continue
+		}
+		nestedRequired, _ := property["required"].([]string)
+		property["properties"] = propertiesWithDefaults(nested, nestedRequired, values)
+		if _, ok := property["default"]; !ok && !slices.Contains(required, name) {
+			property["default"] = values
+		}
+	}
+	return result
+}
+
+// applyDefaults returns params with the absent properties filled with the defaults of the schema.
+// The given params are not modified.
+func (tool *Tool) applyDefaults(params map[string]any) map[string]any {
+	if tool.defaultsPolicy == DefaultsStrict || tool.schema == nil {
+		return params
+	}
+	return fillDefaults(params, tool.schema.Map())
+}
+
+func fillDefaults(value map[string]any, schema map[string]any) map[string]any {
+	properties, _ := schema["properties"].(map[string]any)
+	if len(properties) == 0 {
+		return value
+	}
+	result := value
+	set := func(name string, v any) {
+		// Copy on first write so that the caller's params are kept intact
+		if sameMap(result, value) {
+			result = maps.Clone(value)
+			if result == nil {
+				result = map[string]any{}
+			}
+		}
+		result[name] = v
+	}
+	for name, property := range properties {
+		propertySchema := schemaMap(property)
+		if propertySchema == nil {
+			continue
+		}
+		current, ok := value[name]
+		if !ok || current == nil {
+			if def, ok := propertySchema["default"]; ok {
+				if v, ok := normalizeDefault(def); ok {
+					set(name, v)
+				}
+			}
+			continue
+		}
+		if obj, ok := current.(map[string]any); ok {
+			if filled := fillDefaults(obj, propertySchema); !sameMap(filled, obj) {
+				set(name, filled)
+			}
+		}
+	}
+	return result
+}
+
+// schemaMap returns the property schema as a map.
+func schemaMap(v any) map[string]any {
+	switch s := v.(type) {
+	case map[string]any:
+		return s
+	case *Schema:
+		if s != nil {
+			return s.Map()
+		}
+	case Schema:
+		return s.Map()
+	}
+	return nil
+}
+
+// normalizeDefault converts the default value into the form of decoded arguments,
+// e.g. numbers become json.Number, so it is validated and converted like a given value.
+func normalizeDefault(def any) (any, bool) {
+	buf, err := json.Marshal(def)
+	if err != nil {
+		return nil, false
+	}
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return nil, false
+	}
+	return v, true
+}
+
+// sameMap reports whether a and b are the same map instance.
+func sameMap(a, b map[string]any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return reflect.ValueOf(a).UnsafePointer() == reflect.ValueOf(b).UnsafePointer()
+}
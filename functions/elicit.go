@@ -0,0 +1,189 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// maxElicitDepth is the depth of the nested objects searched for missing arguments.
+const maxElicitDepth = 5
+
+// elicitKeywords are the keywords of the input schema kept in the requested schema.
+// Elicitation only supports flat objects of primitive properties.
+var elicitKeywords = []string{"type", "description", "enum", "format", "minimum", "maximum", "minLength", "maxLength", "default"}
+
+var (
+	// ErrElicitationUnavailable is returned when the client of the tool call does not
+	// support elicitation, e.g. because it did not declare the elicitation capability or
+	// its transport cannot receive requests from the server (SSE).
+	ErrElicitationUnavailable = errors.New("the client does not support elicitation")
+	// ErrElicitationDeclined is returned when the user declined or canceled the elicitation.
+	ErrElicitationDeclined = errors.New("the user declined to provide the information")
+)
+
+// Elicit asks the user of the client, through MCP elicitation, to fill the fields of
+// the schema, a flat object of primitive properties, and returns the answer.
+func Elicit(ctx context.Context, message string, schema map[string]any) (map[string]any, error) {
+	srv := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if srv == nil || session == nil {
+		return nil, ErrElicitationUnavailable
+	}
+	// Clients without the capability may never answer the request
+	if info, ok := session.(server.SessionWithClientInfo); ok && info.GetClientCapabilities().Elicitation == nil {
+		return nil, ErrElicitationUnavailable
+	}
+	res, err := srv.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message:         message,
+			RequestedSchema: schema,
+		},
+	})
+	if errors.Is(err, server.ErrElicitationNotSupported) {
+		return nil, ErrElicitationUnavailable
+	} else if err != nil {
+		return nil, fmt.Errorf("elicitation: %w", err)
+	}
+	if res.Action != mcp.ElicitationResponseActionAccept {
+		return nil, ErrElicitationDeclined
+	}
+	content, _ := res.Content.(map[string]any)
+	return content, nil
+}
+
+// elicitField is a required argument missing from a tool call.
+type elicitField struct {
+	path   []string
+	schema map[string]any
+}
+
+func (f elicitField) name() string {
+	return strings.Join(f.path, ".")
+}
+
+// ElicitMissing returns a middleware asking the user, through MCP elicitation, for the
+// required arguments the model omitted, instead of failing the call. Only primitive
+// arguments (strings, numbers, booleans and enums) can be elicited; the calls missing
+// other arguments, and the calls of clients without elicitation, are executed as is.
+// When the user declines, the call fails with a ValidationError listing the fields.
+func ElicitMissing() Middleware {
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			t, ok := tool.(*Tool)
+			if !ok || t.schema == nil {
+				return next(ctx, params)
+			}
+			fields, ok := missingFields(t.schema.Map(), params, nil, 0)
+			if !ok || len(fields) == 0 {
+				return next(ctx, params)
+			}
+			names := make([]string, 0, len(fields))
+			properties := map[string]any{}
+			for _, field := range fields {
+				names = append(names, field.name())
+				properties[field.name()] = elicitSchema(field)
+			}
+			message := fmt.Sprintf("The tool %q needs %s to continue.", tool.Name(), strings.Join(names, ", "))
+			content, err := Elicit(ctx, message, map[string]any{
+				"type":       "object",
+				"properties": properties,
+				"required":   names,
+			})
+			if errors.Is(err, ErrElicitationDeclined) {
+				validationErr := &ValidationError{Schema: t.schema}
+				for _, name := range names {
+					validationErr.Errors = append(validationErr.Errors, FieldError{Field: name, Message: fmt.Sprintf("is required (%v)", err)})
+				}
+				return nil, validationErr
+			} else if err != nil {
+				// Executed as without elicitation
+				return next(ctx, params)
+			}
+			if params == nil {
+				params = map[string]any{}
+			}
+			for _, field := range fields {
+				if value, ok := content[field.name()]; ok {
+					setPath(params, field.path, value)
+				}
+			}
+			return next(ctx, params)
+		}
+	}
+}
+
+// missingFields returns the required arguments missing from params. It reports false
+// when a missing argument cannot be elicited.
+func missingFields(schema, params map[string]any, path []string, depth int) ([]elicitField, bool) {
+	if depth > maxElicitDepth {
+		return nil, true
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	var fields []elicitField
+	for _, name := range slices.Sorted(maps.Keys(properties)) {
+		property, _ := properties[name].(map[string]any)
+		if property == nil {
+			continue
+		}
+		fieldPath := append(slices.Clone(path), name)
+		value, present := params[name]
+		if present && value != nil {
+			// Required arguments of the objects given by the model
+			if nested, ok := value.(map[string]any); ok {
+				nestedFields, ok := missingFields(property, nested, fieldPath, depth+1)
+				if !ok {
+					return nil, false
+				}
+				fields = append(fields, nestedFields...)
+			}
+			continue
+		}
+		if !slices.Contains(requiredNames(schema["required"]), name) {
+			continue
+		}
+		switch property["type"] {
+		case "string", "number", "integer", "boolean":
+			fields = append(fields, elicitField{path: fieldPath, schema: property})
+		case "object":
+			nestedFields, ok := missingFields(property, nil, fieldPath, depth+1)
+			if !ok {
+				return nil, false
+			}
+			fields = append(fields, nestedFields...)
+		default:
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+// elicitSchema returns the requested schema of the field.
+func elicitSchema(field elicitField) map[string]any {
+	schema := map[string]any{"title": field.path[len(field.path)-1]}
+	for _, keyword := range elicitKeywords {
+		if value, ok := field.schema[keyword]; ok {
+			schema[keyword] = value
+		}
+	}
+	return schema
+}
+
+// setPath sets the value at the path, creating the missing objects.
+func setPath(params map[string]any, path []string, value any) {
+	for _, name := range path[:len(path)-1] {
+		nested, ok := params[name].(map[string]any)
+		if !ok {
+			nested = map[string]any{}
+			params[name] = nested
+		}
+		params = nested
+	}
+	params[path[len(path)-1]] = value
+}
@@ -0,0 +1,80 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// maxKeyParameters is the maximum number of parameters listed in the text of an EmptyResponse.
+const maxKeyParameters = 5
+
+// EmptyResponse is the response of an operation without body, e.g. 204 No Content.
+// ProcessResponse turns it into a text telling the model that the action succeeded,
+// with the request parameters, instead of a useless {}.
+type EmptyResponse struct {
+	Method     string
+	StatusCode int
+}
+
+// Text returns the result text of the response, e.g.
+// "Deleted successfully (HTTP 204): petId=1".
+func (r EmptyResponse) Text(params map[string]any) string {
+	var text string
+	switch {
+	case r.StatusCode == http.StatusAccepted:
+		text = fmt.Sprintf("Accepted (HTTP %d); the action is processed asynchronously", r.StatusCode)
+	case strings.EqualFold(r.Method, http.MethodDelete):
+		text = fmt.Sprintf("Deleted successfully (HTTP %d)", r.StatusCode)
+	case r.StatusCode == http.StatusCreated:
+		text = fmt.Sprintf("Created successfully (HTTP %d)", r.StatusCode)
+	case strings.EqualFold(r.Method, http.MethodPut), strings.EqualFold(r.Method, http.MethodPatch):
+		text = fmt.Sprintf("Updated successfully (HTTP %d)", r.StatusCode)
+	default:
+		text = fmt.Sprintf("Succeeded (HTTP %d)", r.StatusCode)
+	}
+	if keys := keyParameters(params); len(keys) > 0 {
+		text += ": " + strings.Join(keys, ", ")
+	}
+	return text
+}
+
+func emptyResponse(res *http.Response) EmptyResponse {
+	empty := EmptyResponse{StatusCode: res.StatusCode}
+	if res.Request != nil {
+		empty.Method = res.Request.Method
+	}
+	return empty
+}
+
+type callParamsKey struct{}
+
+func withCallParams(ctx context.Context, params map[string]any) context.Context {
+	return context.WithValue(ctx, callParamsKey{}, params)
+}
+
+// keyParameters returns the scalar request parameters as name=value, looking into
+// the nested requestParameter object. The request body is left out.
+func keyParameters(params map[string]any) []string {
+	if nested, ok := params["requestParameter"].(map[string]any); ok {
+		params = nested
+	}
+	var keys []string
+	for name, value := range params {
+		if name == "requestBody" {
+			continue
+		}
+		switch value.(type) {
+		case map[string]any, []any, nil:
+			continue
+		}
+		keys = append(keys, fmt.Sprintf("%s=%v", name, value))
+	}
+	slices.Sort(keys)
+	if len(keys) > maxKeyParameters {
+		keys = keys[:maxKeyParameters]
+	}
+	return keys
+}
@@ -0,0 +1,45 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// Enumer is implemented by custom string types having a fixed set of values.
+// The values are published as the enum of the schema and validated on conversion.
+type Enumer interface {
+	EnumValues() []string
+}
+
+var enumerType = reflect.TypeOf((*Enumer)(nil)).Elem()
+
+// enumValues returns the allowed values of the type.
+// Besides Enumer, the AllValues method generated by ogen for enum types is supported.
+func enumValues(t reflect.Type) ([]string, bool) {
+	if t.Implements(enumerType) {
+		return reflect.Zero(t).Interface().(Enumer).EnumValues(), true
+	}
+	method, ok := t.MethodByName("AllValues")
+	if !ok || method.Type.NumIn() != 1 || method.Type.NumOut() != 1 {
+		return nil, false
+	}
+	if out := method.Type.Out(0); out.Kind() != reflect.Slice || out.Elem() != t {
+		return nil, false
+	}
+	all := method.Func.Call([]reflect.Value{reflect.Zero(t)})[0]
+	values := make([]string, 0, all.Len())
+	for i := range all.Len() {
+		values = append(values, fmt.Sprint(all.Index(i).Interface()))
+	}
+	return values, true
+}
+
+// checkEnum returns an error when the string is not an allowed value of the enum type.
+func checkEnum(s string, t reflect.Type) error {
+	values, ok := enumValues(t)
+	if !ok || slices.Contains(values, s) {
+		return nil
+	}
+	return fmt.Errorf("%q is not one of %v", s, values)
+}
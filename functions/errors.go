@@ -0,0 +1,77 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ogen-go/ogen/validate"
+)
+
+// UpstreamError is returned when the API called by the tool fails.
+// It is reported to the model as a tool error, so it can react to it.
+type UpstreamError struct {
+	// StatusCode is the HTTP status code of the response, or 0 when no response was received
+	StatusCode int
+	Err        error
+	// Body is the beginning of the error response body, if any
+	Body []byte
+}
+
+// NewUpstreamError wraps an error returned by an API client.
+// The status code is taken from the errors of ogen generated clients.
+func NewUpstreamError(err error) error {
+	if err == nil {
+		return nil
+	}
+	// Errors already classified by the client are kept as is
+	var upstreamErr *UpstreamError
+	if errors.As(err, &upstreamErr) {
+		return err
+	}
+	upstreamErr = &UpstreamError{Err: err}
+	var statusErr *validate.UnexpectedStatusCodeError
+	if errors.As(err, &statusErr) {
+		upstreamErr.StatusCode = statusErr.StatusCode
+	}
+	return upstreamErr
+}
+
+func (e *UpstreamError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("upstream error (status %d): %v", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("upstream error: %v", e.Err)
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+// InternalError is returned when the tool itself fails, e.g. because of a bug.
+// It is reported as a protocol error instead of a tool result.
+type InternalError struct {
+	Err error
+}
+
+// NewInternalError wraps err as an InternalError.
+func NewInternalError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &InternalError{Err: err}
+}
+
+func (e *InternalError) Error() string {
+	return fmt.Sprintf("internal error: %v", e.Err)
+}
+
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
+// isInternal reports whether err must be reported as a protocol error.
+func isInternal(err error) bool {
+	var internalErr *InternalError
+	var panicErr *PanicError
+	return errors.As(err, &internalErr) || errors.As(err, &panicErr)
+}
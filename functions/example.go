@@ -0,0 +1,61 @@
+package functions
+
+import (
+	"encoding/json"
+	"maps"
+	"strings"
+)
+
+// WithExamples adds example argument sets of the tool.
+// They are embedded as "examples" in the input schema and appended to the description,
+// which improves the accuracy of the model on complex operations.
+func (tool *Tool) WithExamples(examples ...map[string]any) *Tool {
+	tool.examples = append(tool.examples, examples...)
+	return tool
+}
+
+// Examples returns the example argument sets of the tool.
+func (tool *Tool) Examples() []map[string]any {
+	return tool.examples
+}
+
+// inputSchema returns the input schema including the examples.
+// The schema may be shared with other tools, so it is copied before modification.
+func (tool *Tool) inputSchema() *Schema {
+	if tool.schema == nil || len(tool.examples) == 0 {
+		return tool.schema
+	}
+	schema := *tool.schema
+	schema.Extra = maps.Clone(schema.Extra)
+	if schema.Extra == nil {
+		schema.Extra = map[string]any{}
+	}
+	examples := make([]any, 0, len(tool.examples))
+	for _, example := range tool.examples {
+		examples = append(examples, example)
+	}
+	schema.Extra["examples"] = examples
+	return &schema
+}
+
+// fullDescription returns the description followed by the example argument sets.
+func (tool *Tool) fullDescription() string {
+	if len(tool.examples) == 0 {
+		return tool.description
+	}
+	var b strings.Builder
+	b.WriteString(tool.description)
+	if tool.description != "" {
+		b.WriteString("\n\n")
+	}
+	b.WriteString("Example arguments:")
+	for _, example := range tool.examples {
+		buf, err := json.Marshal(example)
+		if err != nil {
+			continue
+		}
+		b.WriteString("\n")
+		b.Write(buf)
+	}
+	return b.String()
+}
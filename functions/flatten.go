@@ -0,0 +1,88 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONAPIFlatten is the flattening of JSON:API documents: the attributes of the
+// primary and included resources are lifted next to their id and type.
+var JSONAPIFlatten = []string{"data.attributes", "included.attributes"}
+
+// Flatten returns a middleware lifting nested objects of the JSON results into their
+// parent object. Each path is a dot separated list of fields, e.g. "data.attributes"
+// merges the fields of data.attributes into data; arrays on the way are traversed.
+// Lifted fields never overwrite the fields of the parent; conflicting fields stay nested.
+func Flatten(paths ...string) Middleware {
+	return func(_ MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil || len(paths) == 0 {
+				return res, err
+			}
+			return transformJSONResult(res, func(data []byte) ([]byte, error) {
+				return flattenJSON(data, paths)
+			})
+		}
+	}
+}
+
+// FlattenOperations returns a middleware flattening the results of the tools with the
+// paths given for their operation id, see Flatten. Used last with Registry.Use, it runs
+// before the size limit and the redaction of the registry see the result.
+func FlattenOperations(paths map[string][]string) Middleware {
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		t, ok := tool.(*Tool)
+		if !ok || len(paths[t.OperationID()]) == 0 {
+			return next
+		}
+		return Flatten(paths[t.OperationID()]...)(tool, next)
+	}
+}
+
+func flattenJSON(data []byte, paths []string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("flatten: %w", err)
+	}
+	for _, path := range paths {
+		if path = strings.Trim(path, "."); path != "" {
+			flattenPath(v, strings.Split(path, "."))
+		}
+	}
+	return json.Marshal(v)
+}
+
+// flattenPath lifts the object at the last field of path into its parent.
+func flattenPath(v any, path []string) {
+	switch node := v.(type) {
+	case []any:
+		for _, item := range node {
+			flattenPath(item, path)
+		}
+	case map[string]any:
+		if len(path) > 1 {
+			flattenPath(node[path[0]], path[1:])
+			return
+		}
+		nested, ok := node[path[0]].(map[string]any)
+		if !ok {
+			return
+		}
+		for key, value := range nested {
+			if _, exists := node[key]; exists {
+				continue
+			}
+			node[key] = value
+			delete(nested, key)
+		}
+		if len(nested) == 0 {
+			delete(node, path[0])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFlattenBeforeTruncationAndRedaction(t *testing.T) {
+	items := make([]any, 0, 100)
+	for i := range 100 {
+		items = append(items, map[string]any{
+			"id":         strconv.Itoa(i),
+			"type":       "pets",
+			"attributes": map[string]any{"name": "pet" + strconv.Itoa(i), "secret": "s3cret"},
+		})
+	}
+	document := map[string]any{"data": items}
+	newTool := func() *Tool {
+		return NewFunctionTool("listPets", "List the pets", func(ctx context.Context, params map[string]any) (any, error) {
+			return document, nil
+		}).WithOperationID("listPets")
+	}
+	redact, err := Redact("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		tool     *Tool
+		registry *Registry
+	}{
+		{
+			name:     "tool",
+			tool:     newTool().UseInner(Flatten("data.attributes")),
+			registry: NewRegistry().Use(MaxResultSize(2048), redact),
+		},
+		{
+			name: "registry",
+			tool: newTool(),
+			registry: NewRegistry().Use(MaxResultSize(2048), redact,
+				FlattenOperations(map[string][]string{"listPets": {"data.attributes"}})),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.registry.Add(tt.tool); err != nil {
+				t.Fatal(err)
+			}
+			res, err := tt.tool.Execute(context.Background(), map[string]any{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			truncated, ok := res.(*TruncatedResult)
+			if !ok {
+				t.Fatalf("Execute() = %T, want a TruncatedResult", res)
+			}
+			data, err := json.Marshal(truncated.Data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The truncated data is flattened and redacted
+			if strings.Contains(string(data), "attributes") || strings.Contains(string(data), "s3cret") {
+				t.Errorf("truncated data is not flattened and redacted: %s", data)
+			}
+			if !strings.Contains(string(data), `"name":"pet0"`) || !strings.Contains(string(data), RedactedValue) {
+				t.Errorf("truncated data misses the lifted fields: %s", data)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// OutputFormat is the format of the text of the tool results.
+type OutputFormat string
+
+const (
+	// OutputJSON renders the results as JSON, the default.
+	OutputJSON OutputFormat = "json"
+	// OutputYAML renders the results as YAML, keeping the order of the fields.
+	OutputYAML OutputFormat = "yaml"
+	// OutputCompact renders the results as one "path: value" line per scalar,
+	// e.g. "items[0].name: Rex", which is the cheapest in tokens for nested results.
+	OutputCompact OutputFormat = "compact"
+)
+
+// ParseOutputFormat returns the output format named s, case-insensitively.
+// An empty s is OutputJSON.
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(strings.TrimSpace(s))); format {
+	case "":
+		return OutputJSON, nil
+	case OutputJSON, OutputYAML, OutputCompact:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown output format %q: must be json, yaml or compact", s)
+}
+
+// WithOutputFormat sets the format of the text of the results of the tool.
+// Only JSON results are converted; the structuredContent stays JSON.
+func (tool *Tool) WithOutputFormat(format OutputFormat) *Tool {
+	tool.outputFormat = format
+	return tool
+}
+
+// WithOutputFormat sets the format of the results of the registered tools and of
+// the tools added later.
+func (r *Registry) WithOutputFormat(format OutputFormat) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.outputFormat = format
+	for _, tool := range r.tools {
+		tool.WithOutputFormat(format)
+	}
+	return r
+}
+
+// formatResult renders the JSON result in the format. It returns false when the
+// result is not JSON or is kept as JSON.
+func formatResult(res any, format OutputFormat) (string, bool) {
+	if format == "" || format == OutputJSON {
+		return "", false
+	}
+	var data []byte
+	switch v := res.(type) {
+	case nil, *ToolResult, ToolResult, *mcp.CallToolResult, mcp.CallToolResult, []mcp.Content, mcp.Content:
+		return "", false
+	case string:
+		data = []byte(v)
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(res); err != nil {
+			return "", false
+		}
+	}
+	if !json.Valid(data) {
+		return "", false
+	}
+	var text []byte
+	var err error
+	switch format {
+	case OutputYAML:
+		text, err = yaml.JSONToYAML(data)
+	case OutputCompact:
+		text, err = compactJSON(data)
+	default:
+		return "", false
+	}
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(string(text), "\n"), true
+}
+
+// compactJSON renders the JSON value as "path: value" lines in the order of the fields.
+func compactJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := compactValue(dec, &buf, ""); err != nil {
+		return nil, fmt.Errorf("compact output: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// compactValue writes the lines of the next value of dec under path.
+func compactValue(dec *json.Decoder, buf *bytes.Buffer, path string) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		writeCompactLine(buf, path, compactScalar(tok))
+		return nil
+	}
+	empty := true
+	for i := 0; dec.More(); i++ {
+		empty = false
+		var child string
+		if delim == '{' {
+			key, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			child = joinFieldPath(path, key.(string))
+		} else {
+			child = path + "[" + strconv.Itoa(i) + "]"
+		}
+		if err := compactValue(dec, buf, child); err != nil {
+			return err
+		}
+	}
+	// Closing delimiter
+	if _, err := dec.Token(); err != nil && err != io.EOF {
+		return err
+	}
+	if empty {
+		if delim == '{' {
+			writeCompactLine(buf, path, "{}")
+		} else {
+			writeCompactLine(buf, path, "[]")
+		}
+	}
+	return nil
+}
+
+func writeCompactLine(buf *bytes.Buffer, path, value string) {
+	if path != "" {
+		buf.WriteString(path)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// compactScalar returns the text of the JSON scalar. Strings are written as is
+// unless they could be read as another value or span several lines.
+func compactScalar(tok json.Token) string {
+	switch v := tok.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	case string:
+		if v == "" || v == "null" || v == "true" || v == "false" || v != strings.TrimSpace(v) ||
+			strings.ContainsAny(v, "\n\r\"") || json.Valid([]byte(v)) {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return fmt.Sprint(tok)
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,103 @@
+package functions
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Gzip returns a HTTPDoer compressing the request bodies of at least minRequestSize
+// bytes with gzip and transparently decompressing gzip encoded responses.
+// A negative minRequestSize only enables the response decompression.
+func Gzip(doer HTTPDoer, minRequestSize int) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &gzipDoer{doer: doer, minRequestSize: minRequestSize}
+}
+
+type gzipDoer struct {
+	doer           HTTPDoer
+	minRequestSize int
+}
+
+func (d *gzipDoer) Do(req *http.Request) (*http.Response, error) {
+	if d.minRequestSize >= 0 && req.Body != nil && req.Body != http.NoBody && req.Header.Get("Content-Encoding") == "" {
+		compressed, err := gzipRequest(req, d.minRequestSize)
+		if err != nil {
+			return nil, err
+		}
+		req = compressed
+	}
+	// Setting Accept-Encoding disables the transparent decompression of http.Transport,
+	// so the response is decompressed here
+	if req.Header.Get("Accept-Encoding") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	res, err := d.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		return res, nil
+	}
+	reader, err := gzip.NewReader(res.Body)
+	if err != nil {
+		res.Body.Close()
+		return nil, fmt.Errorf("decompress response: %w", err)
+	}
+	res.Body = &gzipBody{Reader: reader, body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return res, nil
+}
+
+// gzipRequest returns a copy of the request with the compressed body.
+func gzipRequest(req *http.Request, minSize int) (*http.Request, error) {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read request body: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	if len(body) < minSize {
+		clone.Body = io.NopCloser(bytes.NewReader(body))
+		clone.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		return clone, nil
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(body); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("compress request body: %w", err)
+	}
+	compressed := buf.Bytes()
+	clone.Body = io.NopCloser(bytes.NewReader(compressed))
+	clone.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	clone.ContentLength = int64(len(compressed))
+	clone.Header.Set("Content-Encoding", "gzip")
+	return clone, nil
+}
+
+// gzipBody closes both the gzip reader and the underlying body.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
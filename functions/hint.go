@@ -0,0 +1,39 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// correctionHint is shown to the model together with the input schema on bad input.
+const correctionHint = "The arguments do not match the input schema of this tool. Fix the arguments according to inputSchema and call the tool again."
+
+// isInvalidInput reports whether err was caused by arguments not matching the input schema.
+func isInvalidInput(err error) bool {
+	var validationErr *ValidationError
+	return errors.As(err, &validationErr) || errors.Is(err, ErrRequired)
+}
+
+// invalidInputResult returns a non-error result containing the input schema and a
+// correction hint, so the model can repair its arguments and retry.
+func (tool *Tool) invalidInputResult(err error) *mcp.CallToolResult {
+	body := map[string]any{
+		"error": err.Error(),
+		"hint":  correctionHint,
+	}
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		body["error"] = "invalid parameters"
+		body["errors"] = validationErr.Errors
+	}
+	if tool.schema != nil {
+		body["inputSchema"] = tool.schema
+	}
+	buf, marshalErr := json.Marshal(body)
+	if marshalErr != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultText(string(buf))
+}
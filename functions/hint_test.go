@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestInvalidInputResult(t *testing.T) {
+	type input struct {
+		Count int `json:"count"`
+	}
+	tool := NewFunctionTool("count", "Count", func(ctx context.Context, in input) (any, error) {
+		return in.Count, nil
+	})
+	var req mcp.CallToolRequest
+	req.Params.Name = "count"
+	req.Params.Arguments = map[string]any{"count": "many"}
+	res, err := tool.ServerTool().Handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError {
+		t.Error("the result of invalid input is an error result")
+	}
+	var body map[string]any
+	if err := json.Unmarshal([]byte(res.Content[0].(mcp.TextContent).Text), &body); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"error", "errors", "hint", "inputSchema"} {
+		if _, ok := body[key]; !ok {
+			t.Errorf("the result has no %s: %v", key, body)
+		}
+	}
+}
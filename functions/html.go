@@ -0,0 +1,277 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// HTMLFormat is the format HTML responses are converted to.
+type HTMLFormat int
+
+const (
+	// HTMLMarkdown converts HTML to markdown, keeping headings, links, lists,
+	// emphasis, code and tables.
+	HTMLMarkdown HTMLFormat = iota + 1
+	// HTMLText strips the markup and keeps the text only.
+	HTMLText
+)
+
+// ConvertHTML returns a HTTPDoer converting the text/html response bodies to
+// the format, since raw HTML wastes tokens and confuses models.
+// The Content-Type of the response is kept so that the clients still accept it.
+func ConvertHTML(doer HTTPDoer, format HTMLFormat) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &htmlDoer{doer: doer, format: format}
+}
+
+type htmlDoer struct {
+	doer   HTTPDoer
+	format HTMLFormat
+}
+
+func (d *htmlDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.doer.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return res, nil
+	}
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	converted, err := convertHTML(body, d.format)
+	if err != nil {
+		return nil, fmt.Errorf("convert html: %w", err)
+	}
+	res.Body = io.NopCloser(strings.NewReader(converted))
+	res.ContentLength = int64(len(converted))
+	res.Header.Set("Content-Length", strconv.Itoa(len(converted)))
+	return res, nil
+}
+
+func convertHTML(body []byte, format HTMLFormat) (string, error) {
+	switch format {
+	case HTMLMarkdown:
+		return HTMLToMarkdown(bytes.NewReader(body))
+	case HTMLText:
+		return HTMLToText(bytes.NewReader(body))
+	}
+	return string(body), nil
+}
+
+// HTMLToMarkdown converts the HTML document to markdown.
+func HTMLToMarkdown(r io.Reader) (string, error) {
+	return renderHTML(r, true)
+}
+
+// HTMLToText converts the HTML document to plain text.
+func HTMLToText(r io.Reader) (string, error) {
+	return renderHTML(r, false)
+}
+
+func renderHTML(r io.Reader, markdown bool) (string, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", err
+	}
+	w := &htmlWriter{markdown: markdown}
+	w.node(doc)
+	text := trailingSpaces.ReplaceAllString(w.buf.String(), "\n")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text), nil
+}
+
+var (
+	spaces         = regexp.MustCompile(`\s+`)
+	blankLines     = regexp.MustCompile(`\n([ \t]*\n)+`)
+	trailingSpaces = regexp.MustCompile(`[ \t]+\n`)
+)
+
+type htmlWriter struct {
+	buf      strings.Builder
+	markdown bool
+	// pre is set inside <pre>, where the whitespace is kept
+	pre bool
+	// lists holds the item counters of the enclosing lists; 0 for unordered lists
+	lists []int
+}
+
+func (w *htmlWriter) text(s string) {
+	w.buf.WriteString(s)
+}
+
+// mark writes the markup in markdown mode only.
+func (w *htmlWriter) mark(s string) {
+	if w.markdown {
+		w.buf.WriteString(s)
+	}
+}
+
+func (w *htmlWriter) block() {
+	w.text("\n\n")
+}
+
+func (w *htmlWriter) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		w.node(c)
+	}
+}
+
+func (w *htmlWriter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if w.pre {
+			w.text(n.Data)
+			return
+		}
+		w.text(spaces.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+	default:
+		w.children(n)
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Noscript, atom.Template, atom.Svg, atom.Iframe:
+		return
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		w.block()
+		w.mark(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		w.children(n)
+		w.block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Nav, atom.Table:
+		w.block()
+		w.children(n)
+		w.block()
+	case atom.Br:
+		w.text("\n")
+	case atom.Hr:
+		w.block()
+		w.mark("---")
+		w.block()
+	case atom.A:
+		href := attr(n, "href")
+		if !w.markdown || href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") {
+			w.children(n)
+			return
+		}
+		w.text("[")
+		w.children(n)
+		w.text("](" + href + ")")
+	case atom.Img:
+		alt := attr(n, "alt")
+		if w.markdown {
+			w.text("![" + alt + "](" + attr(n, "src") + ")")
+		} else {
+			w.text(alt)
+		}
+	case atom.Strong, atom.B:
+		w.mark("**")
+		w.children(n)
+		w.mark("**")
+	case atom.Em, atom.I:
+		w.mark("*")
+		w.children(n)
+		w.mark("*")
+	case atom.Code:
+		if w.pre {
+			w.children(n)
+			return
+		}
+		w.mark("`")
+		w.children(n)
+		w.mark("`")
+	case atom.Pre:
+		w.block()
+		w.mark("```\n")
+		w.pre = true
+		w.children(n)
+		w.pre = false
+		w.mark("\n```")
+		w.block()
+	case atom.Blockquote:
+		w.block()
+		w.mark("> ")
+		w.children(n)
+		w.block()
+	case atom.Ul, atom.Ol:
+		counter := 0
+		if n.DataAtom == atom.Ol {
+			counter = 1
+		}
+		// Nested lists continue the item of the enclosing list
+		if len(w.lists) == 0 {
+			w.text("\n")
+		}
+		w.lists = append(w.lists, counter)
+		w.children(n)
+		w.lists = w.lists[:len(w.lists)-1]
+		w.text("\n")
+	case atom.Li:
+		w.text("\n" + strings.Repeat("  ", max(len(w.lists)-1, 0)))
+		if depth := len(w.lists); depth > 0 && w.lists[depth-1] > 0 {
+			w.mark(strconv.Itoa(w.lists[depth-1]) + ". ")
+			w.lists[depth-1]++
+		} else {
+			w.mark("- ")
+		}
+		w.children(n)
+	case atom.Tr:
+		w.text("\n")
+		w.mark("|")
+		cells := 0
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || (c.DataAtom != atom.Td && c.DataAtom != atom.Th) {
+				continue
+			}
+			w.mark(" ")
+			w.children(c)
+			if w.markdown {
+				w.text(" |")
+			} else {
+				w.text("\t")
+			}
+			cells++
+		}
+		// A header row is followed by the separator of markdown tables
+		if w.markdown && isHeaderRow(n) {
+			w.text("\n|" + strings.Repeat(" --- |", cells))
+		}
+	default:
+		w.children(n)
+	}
+}
+
+func isHeaderRow(tr *html.Node) bool {
+	for c := tr.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Th {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,269 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HTTPDoer sends HTTP requests. *http.Client satisfies this interface.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// HTTPParameter describes a parameter of a HTTPOperation.
+type HTTPParameter struct {
+	Name string
+	// In is the location of the parameter: path, query, header or cookie
+	In string
+	// Argument is the name of the tool argument when it differs from Name
+	Argument string
+}
+
+// HTTPOperation calls an API operation with plain net/http.
+// It is used for operations that no client could be generated for.
+type HTTPOperation struct {
+	Method string
+	// Path is the templated path of the operation, e.g. /pets/{petId}
+	Path       string
+	Parameters []HTTPParameter
+	// ContentType is the content type of the request body
+	ContentType string
+	// Server is the URL declared in the servers of the path or operation.
+	// When set, it is used instead of the base URL.
+	Server string
+}
+
+// Do builds the request from params and body, sends it to baseURL and
+// returns the decoded response.
+func (op HTTPOperation) Do(ctx context.Context, doer HTTPDoer, baseURL string, params map[string]any, body any) (any, error) {
+	req, err := op.NewRequest(ctx, baseURL, params, body)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeHTTPResponse(doer.Do(req))
+}
+
+// NewRequest builds the request of the operation.
+func (op HTTPOperation) NewRequest(ctx context.Context, baseURL string, params map[string]any, body any) (*http.Request, error) {
+	path := op.Path
+	query := url.Values{}
+	header := http.Header{}
+	var cookies []*http.Cookie
+	for _, param := range op.Parameters {
+		argument := param.Argument
+		if argument == "" {
+			argument = param.Name
+		}
+		value, ok := params[argument]
+		if !ok || value == nil {
+			if param.In == "path" {
+				return nil, fmt.Errorf("%s: %w", param.Name, ErrRequired)
+			}
+			continue
+		}
+		values := parameterValues(value)
+		switch param.In {
+		case "path":
+			path = strings.ReplaceAll(path, "{"+param.Name+"}", url.PathEscape(strings.Join(values, ",")))
+		case "query":
+			for _, v := range values {
+				query.Add(param.Name, v)
+			}
+		case "header":
+			header.Set(param.Name, strings.Join(values, ","))
+		case "cookie":
+			cookies = append(cookies, &http.Cookie{Name: param.Name, Value: strings.Join(values, ",")})
+		}
+	}
+
+	if op.Server != "" {
+		baseURL = op.Server
+	}
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if len(query) > 0 {
+		u.RawQuery = query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		buf, err := encodeBody(op.ContentType, body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request body: %w", err)
+		}
+		reader = bytes.NewReader(buf)
+	}
+	req, err := http.NewRequestWithContext(ctx, op.Method, u.String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	if body != nil {
+		contentType := op.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// parameterValues formats a parameter value. Arrays are formatted per item.
+func parameterValues(value any) []string {
+	if items, ok := value.([]any); ok {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			values = append(values, formatParameter(item))
+		}
+		return values
+	}
+	return []string{formatParameter(value)}
+}
+
+func formatParameter(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]any:
+		buf, _ := json.Marshal(v)
+		return string(buf)
+	}
+	return fmt.Sprint(value)
+}
+
+// encodeBody encodes the request body according to the content type.
+func encodeBody(contentType string, body any) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		form := url.Values{}
+		if m, ok := body.(map[string]any); ok {
+			for key, value := range m {
+				for _, v := range parameterValues(value) {
+					form.Add(key, v)
+				}
+			}
+			return []byte(form.Encode()), nil
+		}
+	case contentType != "" && !strings.Contains(contentType, "json"):
+		// Non JSON bodies such as text or XML are sent as given
+		if s, ok := body.(string); ok {
+			return []byte(s), nil
+		}
+	}
+	return json.Marshal(body)
+}
+
+// RebaseURL moves the request from the server from to the server to,
+// keeping the part of the path below the server. It is used to send an operation
+// to the servers declared on it instead of the base URL of the client.
+func RebaseURL(req *http.Request, from, to string) error {
+	fromURL, err := url.Parse(from)
+	if err != nil {
+		return fmt.Errorf("invalid server url: %w", err)
+	}
+	toURL, err := url.Parse(to)
+	if err != nil {
+		return fmt.Errorf("invalid server url: %w", err)
+	}
+	// Keep the escaping of the path parameters
+	rawPath := strings.TrimSuffix(toURL.EscapedPath(), "/") +
+		strings.TrimPrefix(req.URL.EscapedPath(), strings.TrimSuffix(fromURL.EscapedPath(), "/"))
+	path, err := url.PathUnescape(rawPath)
+	if err != nil {
+		return fmt.Errorf("invalid path: %w", err)
+	}
+	req.URL.Scheme = toURL.Scheme
+	req.URL.Host = toURL.Host
+	req.URL.Path = path
+	req.URL.RawPath = rawPath
+	req.Host = toURL.Host
+	return nil
+}
+
+// DecodeHTTPResponse reads the response as the result of a tool.
+// JSON bodies are returned as json.RawMessage, other bodies as string and empty
+// bodies as EmptyResponse.
+// Responses with a status code of 300 or more are returned as UpstreamError.
+// When the request context has StreamOptions, the body is decoded incrementally instead.
+func DecodeHTTPResponse(res *http.Response, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNoContent {
+		return emptyResponse(res), nil
+	}
+	if res.Request != nil && res.StatusCode < 300 {
+		if opts, ok := streamOptionsFromContext(res.Request.Context()); ok {
+			return decodeStream(res.Body, res.Header.Get("Content-Type"), opts)
+		}
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		return nil, &UpstreamError{
+			StatusCode: res.StatusCode,
+			Err:        fmt.Errorf("unexpected status code: %d: %s", res.StatusCode, body),
+			Body:       body,
+		}
+	}
+	if len(body) == 0 {
+		return emptyResponse(res), nil
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body), nil
+	}
+	return string(body), nil
+}
+
+// RequestEditor mutates an outgoing request before it is sent,
+// e.g. to add headers, sign or log the request.
+type RequestEditor func(ctx context.Context, req *http.Request) error
+
+// EditRequests returns a HTTPDoer that applies the editors in order to every
+// request before sending it with doer. http.DefaultClient is used when doer is nil.
+func EditRequests(doer HTTPDoer, editors ...RequestEditor) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	if len(editors) == 0 {
+		return doer
+	}
+	return &editingDoer{doer: doer, editors: editors}
+}
+
+type editingDoer struct {
+	doer    HTTPDoer
+	editors []RequestEditor
+}
+
+func (d *editingDoer) Do(req *http.Request) (*http.Response, error) {
+	for _, edit := range d.editors {
+		if err := edit(req.Context(), req); err != nil {
+			return nil, fmt.Errorf("edit request: %w", err)
+		}
+	}
+	return d.doer.Do(req)
+}
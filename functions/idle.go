@@ -0,0 +1,107 @@
+package functions
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// IdleSessions returns a handler serving next and terminating the sessions of the
+// streamable HTTP transport which were idle, without requests nor open streams, for the
+// timeout, e.g. the sessions of the clients which lost their connection behind a proxy
+// and never deleted them. The session is deleted with a DELETE request to next, so that
+// the handlers on the way release it too. The transport must validate the sessions,
+// e.g. with SessionIDManager, to reject the next requests of the session with 404 Not
+// Found, which makes the clients initialize a new session. The sessions of the SSE
+// transport end with their connection. A timeout of zero or less is DefaultSessionTTL.
+func IdleSessions(next http.Handler, timeout time.Duration) http.Handler {
+	if timeout <= 0 {
+		timeout = DefaultSessionTTL
+	}
+	return &idleSessions{next: next, timeout: timeout, sessions: map[string]*idleSession{}}
+}
+
+type idleSessions struct {
+	next     http.Handler
+	timeout  time.Duration
+	mu       sync.Mutex
+	sessions map[string]*idleSession
+}
+
+// idleSession is the activity of a session.
+type idleSession struct {
+	// path is the endpoint of the session
+	path string
+	// active is the number of requests in flight, including the open streams
+	active int
+	// timer terminates the session once it is idle
+	timer *time.Timer
+}
+
+func (s *idleSessions) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	id := req.Header.Get(server.HeaderKeySessionID)
+	if id == "" {
+		s.next.ServeHTTP(w, req)
+		return
+	}
+	if req.Method == http.MethodDelete {
+		s.mu.Lock()
+		if session, ok := s.sessions[id]; ok && session.timer != nil {
+			session.timer.Stop()
+		}
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		s.next.ServeHTTP(w, req)
+		return
+	}
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	if !ok {
+		session = &idleSession{}
+		s.sessions[id] = session
+	}
+	session.path = req.URL.Path
+	session.active++
+	if session.timer != nil {
+		session.timer.Stop()
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if session.active--; session.active == 0 {
+			session.timer = time.AfterFunc(s.timeout, func() { s.expire(id, session) })
+		}
+	}()
+	s.next.ServeHTTP(w, req)
+}
+
+// expire terminates the session unless it was used since the timer was set.
+func (s *idleSessions) expire(id string, session *idleSession) {
+	s.mu.Lock()
+	if s.sessions[id] != session || session.active > 0 {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.sessions, id)
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodDelete, session.path, nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set(server.HeaderKeySessionID, id)
+	s.next.ServeHTTP(discardResponse{header: http.Header{}}, req)
+}
+
+// discardResponse is a http.ResponseWriter discarding the response.
+type discardResponse struct {
+	header http.Header
+}
+
+func (w discardResponse) Header() http.Header         { return w.header }
+func (w discardResponse) Write(b []byte) (int, error) { return len(b), nil }
+func (w discardResponse) WriteHeader(int)             {}
@@ -0,0 +1,98 @@
+package functions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// jsonschemaKeywords are the keywords accepted in the `jsonschema` struct tag.
+var jsonschemaKeywords = map[string]struct{}{
+	"title":            {},
+	"description":      {},
+	"format":           {},
+	"pattern":          {},
+	"enum":             {},
+	"default":          {},
+	"minimum":          {},
+	"maximum":          {},
+	"exclusiveMinimum": {},
+	"exclusiveMaximum": {},
+	"multipleOf":       {},
+	"minLength":        {},
+	"maxLength":        {},
+	"minItems":         {},
+	"maxItems":         {},
+	"uniqueItems":      {},
+}
+
+// applyJSONSchemaTag sets the constraints described by a tag like
+// `jsonschema:"minimum=1,maximum=100,pattern=^[a-z]+$,enum=a|b|c"` to the schema.
+func applyJSONSchemaTag(schema map[string]any, tag string) {
+	for key, value := range parseJSONSchemaTag(tag) {
+		typ, _ := schema["type"].(string)
+		switch key {
+		case "title", "description", "format", "pattern":
+			schema[key] = value
+		case "enum":
+			values := strings.Split(value, "|")
+			enum := make([]any, 0, len(values))
+			for _, v := range values {
+				enum = append(enum, parseJSONSchemaValue(typ, v))
+			}
+			schema[key] = enum
+		case "default":
+			schema[key] = parseJSONSchemaValue(typ, value)
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				schema[key] = f
+			}
+		case "minLength", "maxLength", "minItems", "maxItems":
+			if i, err := strconv.Atoi(value); err == nil {
+				schema[key] = i
+			}
+		case "uniqueItems":
+			if b, err := strconv.ParseBool(value); err == nil {
+				schema[key] = b
+			}
+		}
+	}
+}
+
+// parseJSONSchemaTag splits the tag into keyword/value pairs.
+// Commas that are not followed by a known keyword are treated as part of the value,
+// so patterns such as `pattern=^[a-z]{1,3}$` are kept intact.
+func parseJSONSchemaTag(tag string) map[string]string {
+	result := make(map[string]string)
+	lastKey := ""
+	for _, part := range strings.Split(tag, ",") {
+		key, value, found := strings.Cut(part, "=")
+		if _, known := jsonschemaKeywords[key]; found && known {
+			result[key] = value
+			lastKey = key
+			continue
+		}
+		if lastKey != "" {
+			result[lastKey] += "," + part
+		}
+	}
+	return result
+}
+
+// parseJSONSchemaValue converts the tag value according to the schema type.
+func parseJSONSchemaValue(typ, value string) any {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
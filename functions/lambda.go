@@ -0,0 +1,150 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// LambdaRequest is the event of an AWS Lambda function invoked by API Gateway, in
+// either the REST API (payload format 1.0) or the HTTP API and function URL (2.0) format.
+// It has the JSON encoding of the events of github.com/aws/aws-lambda-go, so that
+// the handler can be passed to lambda.Start without depending on it.
+type LambdaRequest struct {
+	Version string `json:"version,omitempty"`
+
+	// Payload format 1.0
+	HTTPMethod                      string              `json:"httpMethod,omitempty"`
+	Path                            string              `json:"path,omitempty"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders,omitempty"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters,omitempty"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters,omitempty"`
+
+	// Payload format 2.0
+	RawPath        string   `json:"rawPath,omitempty"`
+	RawQueryString string   `json:"rawQueryString,omitempty"`
+	Cookies        []string `json:"cookies,omitempty"`
+
+	Headers         map[string]string    `json:"headers,omitempty"`
+	RequestContext  LambdaRequestContext `json:"requestContext"`
+	Body            string               `json:"body,omitempty"`
+	IsBase64Encoded bool                 `json:"isBase64Encoded,omitempty"`
+}
+
+// LambdaRequestContext is the part of the request context of the API Gateway events
+// used to build the HTTP request.
+type LambdaRequestContext struct {
+	DomainName string `json:"domainName,omitempty"`
+	// HTTP is the method of the payload format 2.0.
+	HTTP struct {
+		Method string `json:"method,omitempty"`
+	} `json:"http"`
+}
+
+// LambdaResponse is the response of the AWS Lambda function to API Gateway.
+type LambdaResponse struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"`
+	Cookies           []string            `json:"cookies,omitempty"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded,omitempty"`
+}
+
+// LambdaHandler returns an AWS Lambda handler serving the API Gateway events with the
+// HTTP handler, e.g. a stateless streamable HTTP MCP server:
+//
+//	lambda.Start(functions.LambdaHandler(handler))
+//
+// The response is buffered, so streamed responses are returned once complete.
+func LambdaHandler(handler http.Handler) func(context.Context, LambdaRequest) (LambdaResponse, error) {
+	return func(ctx context.Context, event LambdaRequest) (LambdaResponse, error) {
+		req, err := event.httpRequest(ctx)
+		if err != nil {
+			return LambdaResponse{}, err
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return newLambdaResponse(rec.Result().Header, rec.Code, rec.Body.Bytes(), event.isV2()), nil
+	}
+}
+
+func (event LambdaRequest) isV2() bool {
+	return event.Version == "2.0"
+}
+
+// httpRequest converts the event to the HTTP request.
+func (event LambdaRequest) httpRequest(ctx context.Context) (*http.Request, error) {
+	method, path, rawQuery := event.HTTPMethod, event.Path, ""
+	if event.isV2() {
+		method, path, rawQuery = event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString
+	} else {
+		query := url.Values{}
+		for name, value := range event.QueryStringParameters {
+			query.Set(name, value)
+		}
+		for name, values := range event.MultiValueQueryStringParameters {
+			query[name] = values
+		}
+		rawQuery = query.Encode()
+	}
+	if path == "" {
+		path = "/"
+	}
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(event.Body); err != nil {
+			return nil, fmt.Errorf("decode lambda request body: %w", err)
+		}
+	}
+	u := &url.URL{Scheme: "https", Host: event.RequestContext.DomainName, Path: path, RawQuery: rawQuery}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build lambda request: %w", err)
+	}
+	for name, value := range event.Headers {
+		req.Header.Set(name, value)
+	}
+	for name, values := range event.MultiValueHeaders {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	for _, cookie := range event.Cookies {
+		req.Header.Add("Cookie", cookie)
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+	return req, nil
+}
+
+// newLambdaResponse converts the HTTP response. Bodies that are not UTF-8 text are
+// encoded in base64.
+func newLambdaResponse(header http.Header, status int, body []byte, v2 bool) LambdaResponse {
+	res := LambdaResponse{StatusCode: status, Body: string(body)}
+	if !utf8.Valid(body) {
+		res.Body, res.IsBase64Encoded = base64.StdEncoding.EncodeToString(body), true
+	}
+	if v2 {
+		res.Headers = map[string]string{}
+		for name, values := range header {
+			if http.CanonicalHeaderKey(name) == "Set-Cookie" {
+				res.Cookies = append(res.Cookies, values...)
+				continue
+			}
+			res.Headers[name] = strings.Join(values, ",")
+		}
+		return res
+	}
+	res.MultiValueHeaders = header
+	return res
+}
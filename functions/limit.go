@@ -0,0 +1,53 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRateLimited is returned when the limiter rejects a call of the tool.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Limiter limits the rate of the tool calls.
+// *rate.Limiter of golang.org/x/time/rate satisfies this interface.
+type Limiter interface {
+	// Allow reports whether a call may happen now.
+	Allow() bool
+	// Wait blocks until a call may happen or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// LimitPolicy decides what happens when the limiter has no capacity left.
+type LimitPolicy int
+
+const (
+	// LimitWait waits until the limiter allows the call.
+	LimitWait LimitPolicy = iota
+	// LimitReject fails the call immediately with ErrRateLimited.
+	LimitReject
+)
+
+// WithLimiter makes the tool consult the limiter before every Execute.
+func (tool *Tool) WithLimiter(limiter Limiter, policy LimitPolicy) *Tool {
+	tool.limiter = limiter
+	tool.limitPolicy = policy
+	return tool
+}
+
+// acquire consults the limiter of the tool according to its policy.
+func (tool *Tool) acquire(ctx context.Context) error {
+	if tool.limiter == nil {
+		return nil
+	}
+	if tool.limitPolicy == LimitReject {
+		if !tool.limiter.Allow() {
+			return ErrRateLimited
+		}
+		return nil
+	}
+	if err := tool.limiter.Wait(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLongRunningInterval is the interval at which the status of the long-running
+// operations is polled by default, unless the API sends Retry-After.
+const DefaultLongRunningInterval = 2 * time.Second
+
+// DefaultLongRunningTimeout is the time the long-running operations are waited for by default.
+const DefaultLongRunningTimeout = 10 * time.Minute
+
+// statusURLHeaders are the headers of the 202 responses giving the status URL, in order
+// of preference.
+var statusURLHeaders = []string{"Operation-Location", "Azure-AsyncOperation", "Location", "Content-Location"}
+
+// statusURLFields are the fields of the 202 response bodies giving the status URL.
+var statusURLFields = []string{"statusUrl", "status_url", "statusURL"}
+
+// statusFields are the fields of the status giving the state of the operation.
+var statusFields = []string{"status", "state"}
+
+// progressFields are the fields of the status giving the percentage of completion.
+var progressFields = []string{"progress", "percentComplete", "percent_complete", "percentage"}
+
+// resultURLFields are the fields of the final status giving the URL of the result.
+var resultURLFields = []string{"resourceLocation", "resultUrl", "result_url"}
+
+// The states of the operations, in lower case. The other states, e.g. succeeded, end
+// the polling with the status as the result.
+var (
+	runningStates = []string{"pending", "queued", "accepted", "submitted", "scheduled", "waiting", "notstarted", "not_started", "started", "running", "inprogress", "in_progress", "processing", "executing", "provisioning"}
+	failedStates  = []string{"failed", "failure", "error", "errored", "canceled", "cancelled", "aborted", "rejected"}
+)
+
+// LongRunningOptions configures LongRunning. The zero value uses the defaults.
+type LongRunningOptions struct {
+	// Interval is the interval between the polls when the API does not send
+	// Retry-After. It defaults to DefaultLongRunningInterval.
+	Interval time.Duration
+	// Timeout is the time the operation is waited for. It defaults to
+	// DefaultLongRunningTimeout.
+	Timeout time.Duration
+	// StatusURLField is the dot separated path of the status URL in the 202 response
+	// body, when the API does not send it in a header, e.g. "links.status".
+	StatusURLField string
+	// StatusField is the dot separated path of the state in the status response body.
+	// It defaults to status, then state.
+	StatusField string
+}
+
+// LongRunning returns a middleware completing the operations answered with 202
+// Accepted and a status URL, given by the Operation-Location or Location header or by
+// the body: the status URL is polled with the HTTP client of the call, reporting the
+// progress to the client, until the operation succeeds or fails, and the final status
+// or result replaces the 202 response. The operation is done when the status responds
+// with a status other than 202 and its status or state field, if any, is not in
+// progress, e.g. "running", nor failed; a resourceLocation of the final status is read
+// as the result. The HTTP client must be wrapped with CaptureAccepted; the other calls
+// are returned as they are.
+func LongRunning(opts LongRunningOptions) Middleware {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultLongRunningInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultLongRunningTimeout
+	}
+	return func(_ MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			recorder := &acceptedResponse{}
+			res, err := next(context.WithValue(ctx, acceptedKey{}, recorder), params)
+			if recorder.doer == nil {
+				return res, err
+			}
+			// The 202 response is replaced even when the API client failed to decode it
+			statusURL := recorder.statusURL(opts.StatusURLField)
+			if statusURL == nil {
+				return res, err
+			}
+			return opts.poll(ctx, recorder, statusURL)
+		}
+	}
+}
+
+// CaptureAccepted returns a HTTPDoer keeping the 202 Accepted responses of the calls of
+// the tools using LongRunning, along with doer to poll their status. The response body
+// is left readable.
+func CaptureAccepted(doer HTTPDoer) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &acceptedDoer{doer: doer}
+}
+
+type acceptedDoer struct {
+	doer HTTPDoer
+}
+
+func (d *acceptedDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.doer.Do(req)
+	if err != nil || res.StatusCode != http.StatusAccepted {
+		return res, err
+	}
+	recorder, ok := req.Context().Value(acceptedKey{}).(*acceptedResponse)
+	if !ok {
+		return res, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		return res, nil
+	}
+	recorder.doer = d.doer
+	recorder.url = req.URL
+	recorder.requestHeader = req.Header.Clone()
+	recorder.header = res.Header.Clone()
+	recorder.body = body
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	return res, nil
+}
+
+type acceptedKey struct{}
+
+// acceptedResponse records the 202 response of the tool call.
+type acceptedResponse struct {
+	doer HTTPDoer
+	url  *url.URL
+	// requestHeader holds the headers of the request, e.g. the credentials set by the client
+	requestHeader http.Header
+	header        http.Header
+	body          []byte
+}
+
+// contentHeaders are the headers of the request body, which are not sent with the polls.
+var contentHeaders = []string{"Content-Type", "Content-Length", "Content-Encoding", "Idempotency-Key"}
+
+// statusURL returns the status URL of the operation, resolved against the request URL,
+// or nil when the response has none.
+func (r *acceptedResponse) statusURL(field string) *url.URL {
+	var location string
+	if field != "" {
+		location, _ = jsonField(r.body, field).(string)
+	}
+	for _, name := range statusURLHeaders {
+		if location != "" {
+			break
+		}
+		location = r.header.Get(name)
+	}
+	for _, name := range statusURLFields {
+		if location != "" {
+			break
+		}
+		location, _ = jsonField(r.body, name).(string)
+	}
+	if location == "" {
+		return nil
+	}
+	u, err := r.url.Parse(location)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// poll reads the status until the operation completes.
+func (opts LongRunningOptions) poll(ctx context.Context, accepted *acceptedResponse, statusURL *url.URL) (any, error) {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	progress := ProgressFromContext(ctx)
+	wait := retryAfter(accepted.header, opts.Interval)
+	for polls := 1; ; polls++ {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("the operation did not complete within %s, its status is at %s", opts.Timeout, statusURL)
+			}
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		res, err := accepted.get(ctx, statusURL)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if res.StatusCode >= 300 {
+			return nil, &UpstreamError{
+				StatusCode: res.StatusCode,
+				Err:        fmt.Errorf("poll the status of the operation: unexpected status code: %d: %s", res.StatusCode, body),
+				Body:       body,
+			}
+		}
+		state := opts.state(body)
+		switch {
+		case slices.Contains(failedStates, state):
+			return nil, fmt.Errorf("the operation %s: %s", state, body)
+		case res.StatusCode != http.StatusAccepted && !slices.Contains(runningStates, state):
+			if location := resultURL(body); location != "" {
+				if result, err := statusURL.Parse(location); err == nil {
+					return DecodeHTTPResponse(accepted.get(ctx, result))
+				}
+			}
+			res.Body = io.NopCloser(bytes.NewReader(body))
+			return DecodeHTTPResponse(res, nil)
+		}
+		message := "Waiting for the operation to complete"
+		if state != "" {
+			message += ": " + state
+		}
+		if percent, ok := jsonNumber(body, progressFields...); ok {
+			_ = progress.Report(percent, 100, message)
+		} else {
+			_ = progress.Report(float64(polls), 0, message)
+		}
+		wait = retryAfter(res.Header, opts.Interval)
+	}
+}
+
+// get sends a GET request to u with the HTTP client of the call. The headers of the
+// request, e.g. the credentials set by the API client, are sent to the same host only.
+func (r *acceptedResponse) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == r.url.Host && r.requestHeader != nil {
+		req.Header = r.requestHeader.Clone()
+		for _, name := range contentHeaders {
+			req.Header.Del(name)
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	return r.doer.Do(req)
+}
+
+// state returns the state of the operation in the status body, or an empty string.
+func (opts LongRunningOptions) state(body []byte) string {
+	fields := statusFields
+	if opts.StatusField != "" {
+		fields = []string{opts.StatusField}
+	}
+	for _, field := range fields {
+		if state, ok := jsonField(body, field).(string); ok {
+			return strings.ToLower(state)
+		}
+	}
+	return ""
+}
+
+// resultURL returns the URL of the result in the final status body, or an empty string.
+func resultURL(body []byte) string {
+	for _, field := range resultURLFields {
+		if location, ok := jsonField(body, field).(string); ok && location != "" {
+			return location
+		}
+	}
+	return ""
+}
+
+// retryAfter returns the delay of the Retry-After header in seconds, or interval.
+func retryAfter(header http.Header, interval time.Duration) time.Duration {
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return interval
+}
+
+// jsonField returns the value at the dot separated path of the JSON body, or nil.
+// Numbers are returned as json.Number.
+func jsonField(body []byte, path string) any {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v any
+	if decoder.Decode(&v) != nil {
+		return nil
+	}
+	for _, name := range strings.Split(strings.Trim(path, "."), ".") {
+		object, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = object[name]
+	}
+	return v
+}
+
+// jsonNumber returns the first number at the paths of the JSON body.
+func jsonNumber(body []byte, paths ...string) (float64, bool) {
+	for _, path := range paths {
+		if number, ok := jsonField(body, path).(json.Number); ok {
+			if n, err := number.Float64(); err == nil {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,35 @@
+package functions
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONField(t *testing.T) {
+	body := []byte(`{"status": "running", "progress": {"percent": 42.5}, "id": 9007199254740993}`)
+	tests := []struct {
+		path string
+		want any
+	}{
+		{path: "status", want: "running"},
+		{path: "progress.percent", want: json.Number("42.5")},
+		{path: "id", want: json.Number("9007199254740993")},
+		{path: "status.value", want: nil},
+		{path: "missing", want: nil},
+	}
+	for _, tt := range tests {
+		if got := jsonField(body, tt.path); got != tt.want {
+			t.Errorf("jsonField(%q) = %v (%T), want %v", tt.path, got, got, tt.want)
+		}
+	}
+	if got := jsonField([]byte(`not json`), "status"); got != nil {
+		t.Errorf("jsonField of invalid JSON = %v, want nil", got)
+	}
+
+	if n, ok := jsonNumber(body, "missing", "progress.percent"); !ok || n != 42.5 {
+		t.Errorf("jsonNumber = %v, %t, want 42.5, true", n, ok)
+	}
+	if _, ok := jsonNumber(body, "status"); ok {
+		t.Error("jsonNumber of a string field succeeded")
+	}
+}
@@ -0,0 +1,164 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ServerLoggerName is the logger name of the MCP log messages sent outside of tool calls.
+const ServerLoggerName = "server"
+
+// mcpLogHandler is a slog.Handler sending the records to the MCP client of their context.
+type mcpLogHandler struct {
+	srv  *server.MCPServer
+	next slog.Handler
+	// attrs are the attributes of WithAttrs, nested in the groups open at the time
+	attrs  []slog.Attr
+	groups []string
+}
+
+// MCPLogHandler returns a slog.Handler passing the records to next and sending them,
+// as MCP logging notifications, to the client of the session of their context, e.g.
+// the logs of the tool calls written with slog.InfoContext(ctx, ...). Each client
+// receives the records at or above the level it set with logging/setLevel (error by
+// default), so it can see the logs without access to the host. The server must have
+// the logging capability (server.WithLogging). The logger name is the called tool.
+func MCPLogHandler(srv *server.MCPServer, next slog.Handler) slog.Handler {
+	return &mcpLogHandler{srv: srv, next: next}
+}
+
+func (h *mcpLogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next.Enabled(ctx, level) {
+		return true
+	}
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithLogging)
+	return ok && mcpLogLevel(level).ShouldSendTo(session.GetLogLevel())
+}
+
+func (h *mcpLogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if h.next.Enabled(ctx, r.Level) {
+		err = h.next.Handle(ctx, r)
+	}
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithLogging); ok &&
+		mcpLogLevel(r.Level).ShouldSendTo(session.GetLogLevel()) {
+		logger := ToolNameFromContext(ctx)
+		if logger == "" {
+			logger = ServerLoggerName
+		}
+		// Failing to notify the client must not fail the log of the host
+		_ = h.srv.SendLogMessageToClient(ctx, mcp.NewLoggingMessageNotification(mcpLogLevel(r.Level), logger, h.data(r)))
+	}
+	return err
+}
+
+func (h *mcpLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	clone.attrs = append(slices.Clip(h.attrs), nestInGroups(h.groups, attrs)...)
+	return &clone
+}
+
+func (h *mcpLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.groups = append(slices.Clip(h.groups), name)
+	return &clone
+}
+
+// data returns the message and the attributes of the record as a JSON object.
+func (h *mcpLogHandler) data(r slog.Record) map[string]any {
+	data := map[string]any{"message": r.Message}
+	for _, attr := range h.attrs {
+		addLogAttr(data, attr)
+	}
+	var attrs []slog.Attr
+	r.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	for _, attr := range nestInGroups(h.groups, attrs) {
+		addLogAttr(data, attr)
+	}
+	return data
+}
+
+// nestInGroups wraps the attributes in the groups, the first group being the outermost.
+func nestInGroups(groups []string, attrs []slog.Attr) []slog.Attr {
+	if len(groups) == 0 || len(attrs) == 0 {
+		return attrs
+	}
+	values := make([]any, len(attrs))
+	for i, attr := range attrs {
+		values[i] = attr
+	}
+	attr := slog.Group(groups[len(groups)-1], values...)
+	return nestInGroups(groups[:len(groups)-1], []slog.Attr{attr})
+}
+
+// addLogAttr adds the attribute to the object, merging the groups of the same name.
+func addLogAttr(data map[string]any, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+	if attr.Value.Kind() != slog.KindGroup {
+		data[attr.Key] = logValue(attr.Value)
+		return
+	}
+	group := data
+	// Attributes of groups without a key are inlined
+	if attr.Key != "" {
+		var ok bool
+		if group, ok = data[attr.Key].(map[string]any); !ok {
+			group = map[string]any{}
+			data[attr.Key] = group
+		}
+	}
+	for _, nested := range attr.Value.Group() {
+		addLogAttr(group, nested)
+	}
+}
+
+// logValue returns the value encoded in JSON as it is printed by the text handlers.
+func logValue(v slog.Value) any {
+	switch v.Kind() {
+	case slog.KindDuration:
+		return v.Duration().String()
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		switch value := v.Any().(type) {
+		case error:
+			return value.Error()
+		case fmt.Stringer:
+			return value.String()
+		}
+	}
+	return v.Any()
+}
+
+// mcpLogLevel maps the slog level to the MCP logging level.
+func mcpLogLevel(level slog.Level) mcp.LoggingLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return mcp.LoggingLevelDebug
+	case level < slog.LevelWarn:
+		return mcp.LoggingLevelInfo
+	case level < slog.LevelError:
+		return mcp.LoggingLevelWarning
+	}
+	return mcp.LoggingLevelError
+}
@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"context"
+	"slices"
+)
+
+// ExecuteFunc executes a tool with the given parameters.
+// The numbers of the arguments of a tool call are json.Number in params; the function
+// of the tool receives them as float64 when it takes untyped (any) values.
+type ExecuteFunc func(ctx context.Context, params map[string]any) (any, error)
+
+// Middleware wraps the execution of a tool.
+// It can inspect the tool, the params and the result, e.g. for logging, rate limiting,
+// metrics or result shaping.
+type Middleware func(tool MCPTool, next ExecuteFunc) ExecuteFunc
+
+// Use appends the middlewares to the tool.
+// Middlewares are applied in order, so the first one is the outermost.
+func (tool *Tool) Use(mw ...Middleware) *Tool {
+	tool.middlewares = append(tool.middlewares, mw...)
+	return tool
+}
+
+// UseInner appends middlewares running next to the function of the tool, inside the
+// middlewares of the registry the tool is added to. They shape the result before the
+// registry limits its size or redacts it, e.g. Flatten or StripEmpty.
+func (tool *Tool) UseInner(mw ...Middleware) *Tool {
+	tool.innerMiddlewares = append(tool.innerMiddlewares, mw...)
+	return tool
+}
+
+// chain builds the execution chain of the middlewares around fn.
+// The middlewares of the registry the tool belongs to run inside those given to Use
+// and outside those given to UseInner.
+func (tool *Tool) chain(fn ExecuteFunc) ExecuteFunc {
+	middlewares := slices.Clip(tool.middlewares)
+	if r := tool.registry; r != nil {
+		middlewares = append(middlewares, r.registryMiddlewares()...)
+	}
+	middlewares = append(middlewares, tool.innerMiddlewares...)
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](tool, fn)
+	}
+	return fn
+}
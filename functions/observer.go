@@ -0,0 +1,121 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrorClass classifies the error of a tool call for metrics.
+type ErrorClass string
+
+const (
+	ErrorClassNone        ErrorClass = ""
+	ErrorClassValidation  ErrorClass = "validation"
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	ErrorClassCanceled    ErrorClass = "canceled"
+	ErrorClassTimeout     ErrorClass = "timeout"
+	ErrorClassPanic       ErrorClass = "panic"
+	ErrorClassUpstream    ErrorClass = "upstream"
+	ErrorClassInternal    ErrorClass = "internal"
+	ErrorClassTool        ErrorClass = "tool"
+)
+
+// CallStats describes a finished tool call.
+type CallStats struct {
+	Tool     string
+	Start    time.Time
+	Duration time.Duration
+	// InputSize is the size of the JSON encoded params in bytes
+	InputSize int
+	// ResultSize is the size of the JSON encoded result in bytes
+	ResultSize int
+	Err        error
+	ErrorClass ErrorClass
+}
+
+// Observer receives the events of tool calls, e.g. to record metrics.
+type Observer interface {
+	// CallStarted is called before the tool is executed.
+	CallStarted(ctx context.Context, tool string)
+	// CallFinished is called after the tool is executed.
+	CallFinished(ctx context.Context, stats CallStats)
+}
+
+// WithObserver adds observers notified of every Execute of the tool.
+func (tool *Tool) WithObserver(observers ...Observer) *Tool {
+	tool.observers = append(tool.observers, observers...)
+	return tool
+}
+
+// observe notifies the observers of the start of a call and returns
+// a function to notify them of its end.
+func (tool *Tool) observe(ctx context.Context, params map[string]any) func(res any, err error) {
+	observers := tool.observers
+	if r := tool.registry; r != nil {
+		observers = append(slices.Clip(observers), r.registryObservers()...)
+	}
+	if len(observers) == 0 {
+		return func(any, error) {}
+	}
+	start := time.Now()
+	inputSize := encodedSize(params)
+	for _, observer := range observers {
+		observer.CallStarted(ctx, tool.name)
+	}
+	return func(res any, err error) {
+		stats := CallStats{
+			Tool:       tool.name,
+			Start:      start,
+			Duration:   time.Since(start),
+			InputSize:  inputSize,
+			Err:        err,
+			ErrorClass: classifyError(err),
+		}
+		if err == nil {
+			stats.ResultSize = encodedSize(res)
+		}
+		for _, observer := range observers {
+			observer.CallFinished(ctx, stats)
+		}
+	}
+}
+
+// classifyError returns the class of the error returned by Execute.
+func classifyError(err error) ErrorClass {
+	var panicErr *PanicError
+	var upstreamErr *UpstreamError
+	var internalErr *InternalError
+	switch {
+	case err == nil:
+		return ErrorClassNone
+	case isInvalidInput(err):
+		return ErrorClassValidation
+	case errors.Is(err, ErrRateLimited):
+		return ErrorClassRateLimited
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.As(err, &panicErr):
+		return ErrorClassPanic
+	case errors.As(err, &upstreamErr):
+		return ErrorClassUpstream
+	case errors.As(err, &internalErr):
+		return ErrorClassInternal
+	}
+	return ErrorClassTool
+}
+
+func encodedSize(v any) int {
+	if s, ok := v.(string); ok {
+		return len(s)
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return len(buf)
+}
@@ -0,0 +1,47 @@
+package functions
+
+import (
+	"context"
+	"testing"
+)
+
+type countingObserver struct {
+	started, finished int
+}
+
+func (o *countingObserver) CallStarted(ctx context.Context, tool string) { o.started++ }
+
+func (o *countingObserver) CallFinished(ctx context.Context, stats CallStats) { o.finished++ }
+
+func TestRegistryObserversNotifiedOnce(t *testing.T) {
+	observer := &countingObserver{}
+	tool := NewFunctionTool("echo", "Echo the input", func(ctx context.Context, params map[string]any) (any, error) {
+		return params, nil
+	})
+
+	r := NewRegistry().WithObserver(observer)
+	if err := r.Add(tool); err != nil {
+		t.Fatal(err)
+	}
+	// Adding the tool again after removing it must not notify the observer twice
+	r.Remove(tool.Name())
+	if err := r.Add(tool); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if observer.started != 1 || observer.finished != 1 {
+		t.Errorf("observer notified %d/%d times, want 1/1", observer.started, observer.finished)
+	}
+
+	// A removed tool no longer notifies the observers of the registry
+	r.Remove(tool.Name())
+	if _, err := tool.Execute(context.Background(), map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if observer.started != 1 || observer.finished != 1 {
+		t.Errorf("observer notified %d/%d times after Remove, want 1/1", observer.started, observer.finished)
+	}
+}
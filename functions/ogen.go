@@ -0,0 +1,141 @@
+package functions
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ogenWrapper describes the optional/nilable wrapper types generated by ogen:
+// OptT{Value, Set}, NilT{Value, Null} and OptNilT{Value, Set, Null}.
+type ogenWrapper struct {
+	value reflect.StructField
+	set   *reflect.StructField
+	null  *reflect.StructField
+}
+
+// optional reports whether the value may be omitted.
+func (w ogenWrapper) optional() bool {
+	return w.set != nil
+}
+
+// nullable reports whether the value may be null.
+func (w ogenWrapper) nullable() bool {
+	return w.null != nil
+}
+
+// ogenWrapperOf returns the wrapper description when t is an ogen Opt/Nil type.
+// Besides the fields, the type must be named like the ogen types and have their
+// Get, IsSet and IsNull methods, so that user structs of the same shape are not mistaken.
+func ogenWrapperOf(t reflect.Type) (ogenWrapper, bool) {
+	var w ogenWrapper
+	if t.Kind() != reflect.Struct || t.NumField() < 2 || t.NumField() > 3 {
+		return w, false
+	}
+	hasValue := false
+	for i := range t.NumField() {
+		field := t.Field(i)
+		switch {
+		case field.Name == "Value":
+			w.value = field
+			hasValue = true
+		case field.Name == "Set" && field.Type.Kind() == reflect.Bool:
+			w.set = &field
+		case field.Name == "Null" && field.Type.Kind() == reflect.Bool:
+			w.null = &field
+		default:
+			return w, false
+		}
+	}
+	if !hasValue || (w.set == nil && w.null == nil) {
+		return w, false
+	}
+	// OptT and OptNilT have Set, NilT has only Null
+	prefix := "Opt"
+	if w.set == nil {
+		prefix = "Nil"
+	}
+	if !strings.HasPrefix(t.Name(), prefix) {
+		return w, false
+	}
+	boolType := reflect.TypeFor[bool]()
+	if !hasMethod(t, "Get", w.value.Type, boolType) ||
+		(w.set != nil && !hasMethod(t, "IsSet", boolType)) ||
+		(w.null != nil && !hasMethod(t, "IsNull", boolType)) {
+		return w, false
+	}
+	return w, true
+}
+
+// hasMethod reports whether t has the method without parameters returning the results.
+func hasMethod(t reflect.Type, name string, results ...reflect.Type) bool {
+	method, ok := t.MethodByName(name)
+	// The receiver is the first parameter of the method type
+	if !ok || method.Type.NumIn() != 1 || method.Type.NumOut() != len(results) {
+		return false
+	}
+	for i, result := range results {
+		if method.Type.Out(i) != result {
+			return false
+		}
+	}
+	return true
+}
+
+// hasOgenWrapper reports whether values of the type hold an ogen wrapper without its
+// own JSON decoder, which encoding/json cannot set: the type is a wrapper, or a struct
+// field, a pointer, or a slice, array or map element holds one.
+func hasOgenWrapper(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return hasOgenWrapper(t.Elem(), seen)
+	case reflect.Struct:
+		if _, ok := ogenWrapperOf(t); ok {
+			return true
+		}
+		if seen[t] {
+			return false
+		}
+		seen[t] = true
+		for _, field := range cachedStructFields(t) {
+			if hasOgenWrapper(field.typ, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// decodeOgenWrapper sets the value to the wrapper, marking it as set or null.
+func decodeOgenWrapper(value any, targetType reflect.Type, w ogenWrapper) (reflect.Value, error) {
+	wrapper := reflect.New(targetType).Elem()
+	if w.set != nil {
+		wrapper.FieldByIndex(w.set.Index).SetBool(true)
+	}
+	if value == nil {
+		if w.null != nil {
+			wrapper.FieldByIndex(w.null.Index).SetBool(true)
+		} else if w.set != nil {
+			// null for a non-nullable optional value is treated as omitted
+			wrapper.FieldByIndex(w.set.Index).SetBool(false)
+		}
+		return wrapper, nil
+	}
+	decodedValue, err := decodeValue(value, w.value.Type)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	wrapper.FieldByIndex(w.value.Index).Set(decodedValue)
+	return wrapper, nil
+}
+
+// ogenWrapperSchema returns the schema of the wrapped value, marking it as nullable if needed.
+func ogenWrapperSchema(w ogenWrapper, seen map[reflect.Type]bool) map[string]any {
+	schema := typeSchema(w.value.Type, seen)
+	if typ, ok := schema["type"].(string); ok && w.nullable() {
+		schema["type"] = []any{typ, "null"}
+	}
+	return schema
+}
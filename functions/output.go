@@ -0,0 +1,113 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// WithOutputSchema sets the schema of the tool result.
+// The result is then also returned as structuredContent. As structuredContent must be
+// an object, a schema of another type (e.g. an array) is advertised wrapped in an object
+// whose "result" property holds the result.
+func (tool *Tool) WithOutputSchema(schema *Schema) *Tool {
+	tool.outputSchema = schema
+	return tool
+}
+
+// SchemaOf returns the object schema of the struct (or pointer to struct) value v.
+func SchemaOf(v any) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: map[string]any{},
+		Required:   []string{},
+	}
+	if structType := derefStruct(reflect.TypeOf(v)); structType != nil {
+		appendStructProperties(schema, structType)
+	}
+	return schema
+}
+
+// MCPOutputSchema returns the schema as the output schema of a MCP tool.
+func (s *Schema) MCPOutputSchema() mcp.ToolOutputSchema {
+	return mcp.ToolOutputSchema(s.MCPTool())
+}
+
+// resultProperty is the property of the structuredContent holding a result which is not an object.
+const resultProperty = "result"
+
+// wrapsResult reports whether the output schema describes a value other than an object,
+// so that the result is wrapped in an object.
+func wrapsResult(schema *Schema) bool {
+	return schema.Type != "object"
+}
+
+// structuredOutputSchema returns the output schema advertised to the clients.
+func (tool *Tool) structuredOutputSchema() *Schema {
+	schema := tool.outputSchema
+	if schema == nil || !wrapsResult(schema) {
+		return schema
+	}
+	// Keep the definitions at the root, where the references point to
+	result := *schema
+	result.Defs = nil
+	return &Schema{
+		Type:       "object",
+		Properties: map[string]any{resultProperty: result.Map()},
+		Required:   []string{resultProperty},
+		Defs:       schema.Defs,
+	}
+}
+
+// structuredContent returns the result as the structuredContent of the tool with the output schema.
+func structuredContent(res any, schema *Schema) any {
+	if !wrapsResult(schema) {
+		return structuredObject(res)
+	}
+	v, ok := decodeResult(res)
+	if !ok {
+		// A plain text result of a string schema
+		text, isText := res.(string)
+		if !isText {
+			return nil
+		}
+		v = text
+	}
+	return map[string]any{resultProperty: v}
+}
+
+// structuredObject returns the result as a JSON object, or nil if it is not an object.
+// A string result holding a JSON object is decoded as well.
+func structuredObject(res any) any {
+	v, ok := decodeResult(res)
+	if !ok {
+		return nil
+	}
+	if obj, ok := v.(map[string]any); ok {
+		return obj
+	}
+	return nil
+}
+
+// decodeResult returns the result as a JSON value. A string result holding JSON is decoded.
+func decodeResult(res any) (any, bool) {
+	var buf []byte
+	if s, ok := res.(string); ok {
+		buf = []byte(s)
+	} else {
+		var err error
+		if buf, err = json.Marshal(res); err != nil {
+			return nil, false
+		}
+	}
+	// Keep numbers as json.Number so that large int64 IDs are not rounded through float64
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil || decoder.More() {
+		return nil, false
+	}
+	return v, true
+}
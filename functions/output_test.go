@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestStructuredObjectKeepsLargeNumbers(t *testing.T) {
+	for _, res := range []any{
+		`{"id": 9007199254740993}`,
+		map[string]any{"id": int64(9007199254740993)},
+	} {
+		obj, ok := structuredObject(res).(map[string]any)
+		if !ok {
+			t.Fatalf("structuredObject(%v) is not an object", res)
+		}
+		if got := obj["id"]; got != json.Number("9007199254740993") {
+			t.Errorf("structuredObject(%v)[id] = %v (%T), want 9007199254740993", res, got, got)
+		}
+	}
+	if obj := structuredObject(`{"id": 1} {"id": 2}`); obj != nil {
+		t.Errorf("structuredObject of several values = %v, want nil", obj)
+	}
+}
+
+func TestOutputSchemaWrapsNonObjectResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		schema     *Schema
+		res        any
+		wantSchema string
+		want       map[string]any
+	}{
+		{
+			name:       "object",
+			schema:     &Schema{Type: "object", Properties: map[string]any{"id": map[string]any{"type": "integer"}}},
+			res:        map[string]any{"id": 1},
+			wantSchema: `{"properties":{"id":{"type":"integer"}},"type":"object"}`,
+			want:       map[string]any{"id": json.Number("1")},
+		},
+		{
+			name: "array",
+			schema: &Schema{
+				Type:  "array",
+				Items: map[string]any{"$ref": "#/$defs/Pet"},
+				Defs:  map[string]any{"Pet": map[string]any{"type": "object"}},
+			},
+			res:        []map[string]any{{"id": 1}},
+			wantSchema: `{"$defs":{"Pet":{"type":"object"}},"properties":{"result":{"items":{"$ref":"#/$defs/Pet"},"type":"array"}},"required":["result"],"type":"object"}`,
+			want:       map[string]any{"result": []any{map[string]any{"id": json.Number("1")}}},
+		},
+		{
+			name:       "string",
+			schema:     &Schema{Type: "string"},
+			res:        "done",
+			wantSchema: `{"properties":{"result":{"type":"string"}},"required":["result"],"type":"object"}`,
+			want:       map[string]any{"result": "done"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewFunctionTool("output", "Output", func(ctx context.Context, params map[string]any) (any, error) {
+				return tt.res, nil
+			}).WithOutputSchema(tt.schema)
+			serverTool := tool.ServerTool()
+			if got := string(serverTool.Tool.RawOutputSchema); got != tt.wantSchema {
+				t.Errorf("output schema = %s, want %s", got, tt.wantSchema)
+			}
+
+			var req mcp.CallToolRequest
+			req.Params.Name = tool.Name()
+			result, err := serverTool.Handler(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(result.StructuredContent, tt.want) {
+				t.Errorf("structuredContent = %#v, want %#v", result.StructuredContent, tt.want)
+			}
+		})
+	}
+}
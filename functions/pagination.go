@@ -0,0 +1,287 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// PageResourcePrefix is the prefix of the URIs of the pages of a stored result.
+const PageResourcePrefix = "oas-mcp://results/"
+
+// maxStoredResults is the number of results kept per session.
+// The oldest result is dropped when a session stores more.
+const maxStoredResults = 32
+
+// DefaultPageTTL is how long a stored result is kept by default.
+const DefaultPageTTL = 30 * time.Minute
+
+// ErrInvalidCursor is returned when a cursor is malformed or its result is no longer stored.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PagedResult is returned instead of a list result having more items than the page size.
+type PagedResult struct {
+	Items []any `json:"items"`
+	// Total is the number of items of the whole result
+	Total int `json:"total"`
+	// NextCursor reads the next page with the page tool. Empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+	// NextResource is the URI of the MCP resource holding the next page. Empty on the last page.
+	NextResource string `json:"nextResource,omitempty"`
+}
+
+// PageStore keeps the items of oversized list results per MCP session so that
+// the model can read them page by page instead of receiving one giant payload.
+// The results are dropped after the TTL, so that the results of the sessions
+// closed without calling Release do not stay in memory.
+type PageStore struct {
+	mu       sync.Mutex
+	pageSize int
+	ttl      time.Duration
+	// results holds the items of the results by session id and result id
+	results map[string]map[string][]any
+	// order holds the results by session id in storing order
+	order map[string][]storedResult
+}
+
+// storedResult is the id of a stored result with the time it was stored.
+type storedResult struct {
+	id     string
+	stored time.Time
+}
+
+// NewPageStore returns a store returning list results in pages of pageSize items.
+func NewPageStore(pageSize int) *PageStore {
+	if pageSize <= 0 {
+		pageSize = 1
+	}
+	return &PageStore{
+		pageSize: pageSize,
+		ttl:      DefaultPageTTL,
+		results:  map[string]map[string][]any{},
+		order:    map[string][]storedResult{},
+	}
+}
+
+// WithTTL sets how long a stored result is kept. A ttl of 0 keeps the results until
+// Release or until the session stores more than maxStoredResults.
+func (s *PageStore) WithTTL(ttl time.Duration) *PageStore {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ttl = ttl
+	return s
+}
+
+// Paginate returns a middleware storing the list results having more items than
+// the page size and returning their first page as PagedResult.
+func (s *PageStore) Paginate() Middleware {
+	return func(_ MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil {
+				return res, err
+			}
+			items, ok := listItems(res)
+			if !ok || len(items) <= s.pageSize {
+				return res, nil
+			}
+			id, err := s.store(sessionID(ctx), items)
+			if err != nil {
+				return nil, NewInternalError(err)
+			}
+			return s.page(items, id, 0), nil
+		}
+	}
+}
+
+// Page returns the page of the cursor stored in the session of ctx.
+func (s *PageStore) Page(ctx context.Context, cursor string) (*PagedResult, error) {
+	id, offset, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.evict(time.Now())
+	items, ok := s.results[sessionID(ctx)][id]
+	s.mu.Unlock()
+	if !ok || offset >= len(items) {
+		return nil, ErrInvalidCursor
+	}
+	return s.page(items, id, offset), nil
+}
+
+// Release drops the results stored for the session, e.g. when the session is closed.
+func (s *PageStore) Release(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.results, sessionID)
+	delete(s.order, sessionID)
+}
+
+// Tool returns a tool reading the page of a cursor returned in a PagedResult.
+func (s *PageStore) Tool() *Tool {
+	return NewFunctionTool("get_result_page", "Read the next page of a paginated result using its nextCursor.",
+		func(ctx context.Context, input struct {
+			Cursor string `json:"cursor" mcpdescription:"The nextCursor of the previous page" mcprequired:"true"`
+		}) (any, error) {
+			return s.Page(ctx, input.Cursor)
+		},
+	).WithReadOnlyHint(true).WithIdempotentHint(true)
+}
+
+// Bind registers the page tool and the resource template of the pages to the MCP server.
+func (s *PageStore) Bind(srv *server.MCPServer) {
+	srv.AddTools(s.Tool().ServerTool())
+	template := mcp.NewResourceTemplate(PageResourcePrefix+"{cursor}", "Result page",
+		mcp.WithTemplateDescription("A page of a paginated tool result"),
+		mcp.WithTemplateMIMEType("application/json"),
+	)
+	srv.AddResourceTemplate(template, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		page, err := s.Page(ctx, strings.TrimPrefix(request.Params.URI, PageResourcePrefix))
+		if err != nil {
+			return nil, err
+		}
+		buf, err := json.Marshal(page)
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: request.Params.URI, MIMEType: "application/json", Text: string(buf)},
+		}, nil
+	})
+}
+
+// store keeps the items in the session and returns the id of the result.
+func (s *PageStore) store(session string, items []any) (string, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	id := hex.EncodeToString(buf[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.evict(now)
+	if s.results[session] == nil {
+		s.results[session] = map[string][]any{}
+	}
+	s.results[session][id] = items
+	s.order[session] = append(s.order[session], storedResult{id: id, stored: now})
+	if len(s.order[session]) > maxStoredResults {
+		delete(s.results[session], s.order[session][0].id)
+		s.order[session] = s.order[session][1:]
+	}
+	return id, nil
+}
+
+// evict drops the results stored before the TTL, and the sessions left without results.
+// The caller must hold s.mu.
+func (s *PageStore) evict(now time.Time) {
+	if s.ttl <= 0 {
+		return
+	}
+	for session, order := range s.order {
+		expired := 0
+		for expired < len(order) && now.Sub(order[expired].stored) > s.ttl {
+			delete(s.results[session], order[expired].id)
+			expired++
+		}
+		if expired == len(order) {
+			delete(s.results, session)
+			delete(s.order, session)
+			continue
+		}
+		s.order[session] = order[expired:]
+	}
+}
+
+func (s *PageStore) page(items []any, id string, offset int) *PagedResult {
+	end := min(offset+s.pageSize, len(items))
+	page := &PagedResult{
+		Items: items[offset:end],
+		Total: len(items),
+	}
+	if end < len(items) {
+		page.NextCursor = encodeCursor(id, end)
+		page.NextResource = PageResourcePrefix + page.NextCursor
+	}
+	return page
+}
+
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
+	}
+	return ""
+}
+
+func encodeCursor(id string, offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(id + ":" + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (string, int, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	id, offset, ok := strings.Cut(string(buf), ":")
+	if !ok {
+		return "", 0, ErrInvalidCursor
+	}
+	n, err := strconv.Atoi(offset)
+	if err != nil || n < 0 {
+		return "", 0, ErrInvalidCursor
+	}
+	return id, n, nil
+}
+
+// listItems returns the items of a list result. JSON encoded results and
+// slices of the generated client are decoded as well.
+func listItems(res any) ([]any, bool) {
+	var buf []byte
+	switch v := res.(type) {
+	case []any:
+		return v, true
+	case json.RawMessage:
+		buf = v
+	case string:
+		if !strings.HasPrefix(strings.TrimSpace(v), "[") {
+			return nil, false
+		}
+		buf = []byte(v)
+	default:
+		rv := reflect.ValueOf(res)
+		if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, false
+		}
+		var err error
+		if buf, err = json.Marshal(res); err != nil {
+			return nil, false
+		}
+	}
+	// Keep numbers as json.Number so that large int64 IDs are not rounded through float64
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	decoder.UseNumber()
+	var items []any
+	if err := decoder.Decode(&items); err != nil || decoder.More() {
+		return nil, false
+	}
+	return items, true
+}
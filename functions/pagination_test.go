@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPageStoreEvictsExpiredResults(t *testing.T) {
+	store := NewPageStore(1).WithTTL(time.Minute)
+	first, err := store.store("closed", []any{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make the result of the closed session older than the TTL
+	store.order["closed"][0].stored = time.Now().Add(-2 * time.Minute)
+	if _, err := store.store("open", []any{3, 4}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.results["closed"]; ok {
+		t.Error("the expired session is still stored")
+	}
+	if _, ok := store.order["closed"]; ok {
+		t.Error("the order of the expired session is still stored")
+	}
+	if _, err := store.Page(context.Background(), encodeCursor(first, 1)); err != ErrInvalidCursor {
+		t.Errorf("Page() of an expired result error = %v, want ErrInvalidCursor", err)
+	}
+	if len(store.results["open"]) != 1 {
+		t.Errorf("the result of the open session is dropped")
+	}
+}
+
+func TestListItemsKeepsLargeNumbers(t *testing.T) {
+	items, ok := listItems(`[{"id": 9007199254740993}]`)
+	if !ok || len(items) != 1 {
+		t.Fatalf("listItems() = %v, %t", items, ok)
+	}
+	if id := items[0].(map[string]any)["id"]; id != json.Number("9007199254740993") {
+		t.Errorf("id = %v (%T), want 9007199254740993", id, id)
+	}
+	if _, ok := listItems(`[1] [2]`); ok {
+		t.Error("listItems() of several values succeeded")
+	}
+}
@@ -0,0 +1,90 @@
+package functions
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// ResponseProcessor post-processes the response of the API before the tool
+// encodes it as its result, e.g. to summarize, enrich or filter it.
+// The response is the value returned by the generated client, e.g. *client.Pet.
+type ResponseProcessor interface {
+	ProcessResponse(ctx context.Context, resp any) (any, error)
+}
+
+// ResponseProcessorFunc is a function implementing ResponseProcessor.
+type ResponseProcessorFunc func(ctx context.Context, resp any) (any, error)
+
+func (f ResponseProcessorFunc) ProcessResponse(ctx context.Context, resp any) (any, error) {
+	return f(ctx, resp)
+}
+
+// WithResponseProcessor appends processors applied in order to the responses of the tool.
+func (tool *Tool) WithResponseProcessor(processors ...ResponseProcessor) *Tool {
+	tool.processors = append(tool.processors, processors...)
+	return tool
+}
+
+// ProcessResponse applies the response processors of the called tool to resp.
+// It is called by the generated tools between the API call and the encoding of the result.
+// Responses read from an io.Reader, i.e. the non JSON responses of the ogen client,
+// are read as string first. An EmptyResponse left by the processors is returned as its text.
+func ProcessResponse(ctx context.Context, resp any) (any, error) {
+	if r, ok := resp.(io.Reader); ok {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return nil, NewUpstreamError(fmt.Errorf("read response: %w", err))
+		}
+		resp = string(body)
+	}
+	if tool, ok := ctx.Value(toolKey{}).(*Tool); ok {
+		for _, processor := range tool.processors {
+			var err error
+			if resp, err = processor.ProcessResponse(ctx, resp); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if empty, ok := resp.(EmptyResponse); ok {
+		params, _ := ctx.Value(callParamsKey{}).(map[string]any)
+		return empty.Text(params), nil
+	}
+	return resp, nil
+}
+
+// taggedProcessor is a response processor applied to the tools having one of the tags.
+type taggedProcessor struct {
+	processor ResponseProcessor
+	// tags selects the tools. Empty selects every tool.
+	tags []string
+}
+
+func (p taggedProcessor) applies(tool *Tool) bool {
+	if len(p.tags) == 0 {
+		return true
+	}
+	for _, tag := range p.tags {
+		if slices.Contains(tool.tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithResponseProcessor adds the processor to the registered tools and to the tools
+// added later having one of the tags, or to every tool when no tag is given.
+func (r *Registry) WithResponseProcessor(processor ResponseProcessor, tags ...string) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := taggedProcessor{processor: processor, tags: tags}
+	r.processors = append(r.processors, p)
+	for _, tool := range r.tools {
+		if p.applies(tool) {
+			tool.WithResponseProcessor(processor)
+		}
+	}
+	return r
+}
@@ -0,0 +1,90 @@
+package functions
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ProgressReporter reports the progress of a long-running tool call to the client.
+type ProgressReporter interface {
+	// Report sends the progress so far. total is omitted when it is 0 (unknown).
+	// Reports without progress, total nor message are not sent.
+	Report(progress, total float64, message string) error
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context carrying the progress reporter.
+func WithProgress(ctx context.Context, progress ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// ProgressFromContext returns the progress reporter of the tool call.
+// When the client did not send a progressToken, the returned reporter discards every report.
+func ProgressFromContext(ctx context.Context) ProgressReporter {
+	if progress, ok := ctx.Value(progressKey{}).(ProgressReporter); ok && progress != nil {
+		return progress
+	}
+	return nopProgress{}
+}
+
+type nopProgress struct{}
+
+func (nopProgress) Report(float64, float64, string) error {
+	return nil
+}
+
+// progressNotifier sends progress notifications for a single tool call.
+type progressNotifier struct {
+	ctx      context.Context
+	token    mcp.ProgressToken
+	mu       sync.Mutex
+	progress float64
+}
+
+// newProgressNotifier returns a notifier for the request, or nil when the client
+// did not ask for progress notifications.
+func newProgressNotifier(ctx context.Context, req mcp.CallToolRequest) *progressNotifier {
+	if req.Params.Meta == nil || req.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	return &progressNotifier{
+		ctx:   ctx,
+		token: req.Params.Meta.ProgressToken,
+	}
+}
+
+// Report sends a progress notification. The progress is kept increasing
+// as required by the protocol. A report without progress, total nor message
+// is skipped, as it would only advance the progress shown to the client.
+func (n *progressNotifier) Report(progress, total float64, message string) error {
+	if progress <= 0 && total <= 0 && message == "" {
+		return nil
+	}
+	srv := server.ServerFromContext(n.ctx)
+	if srv == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if progress <= n.progress {
+		progress = n.progress + 1
+	}
+	n.progress = progress
+	params := map[string]any{
+		"progressToken": n.token,
+		"progress":      progress,
+	}
+	if total > 0 {
+		params["total"] = total
+	}
+	if message != "" {
+		params["message"] = message
+	}
+	return srv.SendNotificationToClient(n.ctx, "notifications/progress", params)
+}
+
+var _ ProgressReporter = (*progressNotifier)(nil)
@@ -0,0 +1,49 @@
+package functions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestProgressNotifierSkipsEmptyReports(t *testing.T) {
+	tool := NewFunctionTool("work", "Report the progress", func(ctx context.Context, params map[string]any) (any, error) {
+		progress := ProgressFromContext(ctx)
+		if err := progress.Report(0, 0, ""); err != nil {
+			return nil, err
+		}
+		return "done", progress.Report(1, 2, "half")
+	})
+	srv := server.NewMCPServer("test", "1.0.0")
+	srv.AddTools(tool.ServerTool())
+
+	notifications := make(chan mcp.JSONRPCNotification, 10)
+	ctx := srv.WithContext(context.Background(), &testSession{notifications: notifications})
+	srv.HandleMessage(ctx, []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"work","arguments":{},"_meta":{"progressToken":"token"}}}`))
+	close(notifications)
+
+	var got []map[string]any
+	for n := range notifications {
+		got = append(got, n.Params.AdditionalFields)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d notifications, want 1: %v", len(got), got)
+	}
+	if got[0]["progress"] != 1.0 || got[0]["total"] != 2.0 || got[0]["message"] != "half" {
+		t.Errorf("unexpected notification: %v", got[0])
+	}
+}
+
+// testSession is a client session collecting the notifications sent to it.
+type testSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *testSession) Initialize()       {}
+func (s *testSession) Initialized() bool { return true }
+func (s *testSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+func (s *testSession) SessionID() string { return "test" }
@@ -0,0 +1,90 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// PromptGoalArgument is the optional argument of the tool prompts describing the task
+// of the user.
+const PromptGoalArgument = "goal"
+
+// ToolPrompt is an MCP prompt introducing a group of tools, e.g. the operations of an
+// OpenAPI tag, to give the clients curated entry points into the tool set.
+type ToolPrompt struct {
+	// Name is the name of the prompt, e.g. manage_invoices.
+	Name        string
+	Description string
+	Tools       []PromptTool
+}
+
+// PromptTool is a tool introduced by a ToolPrompt.
+type PromptTool struct {
+	Name    string
+	Summary string
+	// Example holds example arguments of the tool, or nil.
+	Example map[string]any
+}
+
+// Registered returns the prompt without the tools which are not registered in registry.
+func (p ToolPrompt) Registered(registry *Registry) ToolPrompt {
+	tools := make([]PromptTool, 0, len(p.Tools))
+	for _, tool := range p.Tools {
+		if _, ok := registry.Get(tool.Name); ok {
+			tools = append(tools, tool)
+		}
+	}
+	p.Tools = tools
+	return p
+}
+
+// ServerPrompt returns the MCP prompt, a user message listing the tools with their
+// summaries and examples, followed by the task given in the goal argument.
+func (p ToolPrompt) ServerPrompt() server.ServerPrompt {
+	prompt := mcp.NewPrompt(p.Name,
+		mcp.WithPromptDescription(p.Description),
+		mcp.WithArgument(PromptGoalArgument,
+			mcp.ArgumentDescription("What you want to do, e.g. the question to answer"),
+		),
+	)
+	return server.ServerPrompt{
+		Prompt: prompt,
+		Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+			return mcp.NewGetPromptResult(p.Description, []mcp.PromptMessage{
+				mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(p.Text(request.Params.Arguments[PromptGoalArgument]))),
+			}), nil
+		},
+	}
+}
+
+// Text returns the message of the prompt for the goal, which may be empty.
+func (p ToolPrompt) Text(goal string) string {
+	var b strings.Builder
+	if p.Description != "" {
+		b.WriteString(p.Description)
+		b.WriteString("\n\n")
+	}
+	b.WriteString("The following tools are available for this:\n")
+	for _, tool := range p.Tools {
+		fmt.Fprintf(&b, "- %s", tool.Name)
+		if tool.Summary != "" {
+			fmt.Fprintf(&b, ": %s", tool.Summary)
+		}
+		b.WriteString("\n")
+		if len(tool.Example) > 0 {
+			if example, err := json.Marshal(tool.Example); err == nil {
+				fmt.Fprintf(&b, "  Example arguments: %s\n", example)
+			}
+		}
+	}
+	b.WriteString("\nPick the tools fitting the task, call them with arguments following their input schemas, and ask me for the required values you cannot find.")
+	if goal = strings.TrimSpace(goal); goal != "" {
+		fmt.Fprintf(&b, "\n\nTask: %s", goal)
+	}
+	return b.String()
+}
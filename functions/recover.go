@@ -0,0 +1,28 @@
+package functions
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError is returned when a tool panics during execution.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value any
+	// Stack is the stack trace captured when the panic was recovered
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("tool panicked: %v", e.Value)
+}
+
+// recoverPanic converts a recovered panic into a PanicError and stores it in err.
+func recoverPanic(err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{
+			Value: r,
+			Stack: debug.Stack(),
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestExecuteRecoversPanics(t *testing.T) {
+	type input struct {
+		Count int `json:"count"`
+	}
+	tests := []struct {
+		name   string
+		tool   *Tool
+		params map[string]any
+		// wantPanic is set when Execute must return a PanicError, otherwise an input error
+		wantPanic bool
+	}{
+		{
+			name:      "nil handler",
+			tool:      NewFunctionTool("nil", "Nil handler", (func(context.Context, input) (any, error))(nil)),
+			params:    map[string]any{"count": 1},
+			wantPanic: true,
+		},
+		{
+			name: "wrong argument types",
+			tool: NewFunctionTool("count", "Count", func(ctx context.Context, in input) (any, error) {
+				return in.Count, nil
+			}),
+			params: map[string]any{"count": map[string]any{"not": "a number"}},
+		},
+		{
+			name: "wrong result types",
+			tool: NewFunctionTool("result", "Non-error last result", func(ctx context.Context, in input) (any, string) {
+				return in.Count, "not an error"
+			}),
+			params:    map[string]any{"count": 1},
+			wantPanic: true,
+		},
+		{
+			name: "panicking handler",
+			tool: NewFunctionTool("panic", "Panic", func(ctx context.Context, in input) (any, error) {
+				panic("boom")
+			}),
+			params:    map[string]any{"count": 1},
+			wantPanic: true,
+		},
+		{
+			name: "panicking middleware",
+			tool: NewFunctionTool("middleware", "Panicking middleware", func(ctx context.Context, in input) (any, error) {
+				return in.Count, nil
+			}).Use(func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+				return func(ctx context.Context, params map[string]any) (any, error) {
+					var m map[string]any
+					m["boom"] = true
+					return next(ctx, params)
+				}
+			}),
+			params:    map[string]any{"count": 1},
+			wantPanic: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.tool.Execute(context.Background(), tt.params)
+			if err == nil {
+				t.Fatalf("Execute() = %v, want an error", res)
+			}
+			var panicErr *PanicError
+			if !tt.wantPanic {
+				if errors.As(err, &panicErr) || !isInvalidInput(err) {
+					t.Errorf("Execute() error = %v, want an invalid input error", err)
+				}
+				return
+			}
+			if !errors.As(err, &panicErr) {
+				t.Fatalf("Execute() error = %v, want a PanicError", err)
+			}
+			if !isInternal(err) {
+				t.Error("a PanicError is not an internal error")
+			}
+			if len(panicErr.Stack) == 0 || !strings.Contains(string(panicErr.Stack), "panic") {
+				t.Errorf("the stack was not captured: %s", panicErr.Stack)
+			}
+
+			// The server reports the panic as an internal error instead of crashing
+			var req mcp.CallToolRequest
+			req.Params.Name = tt.tool.Name()
+			req.Params.Arguments = tt.params
+			if _, err := tt.tool.ServerTool().Handler(context.Background(), req); !errors.As(err, &panicErr) {
+				t.Errorf("Handler() error = %v, want a PanicError", err)
+			}
+		})
+	}
+}
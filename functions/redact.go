@@ -0,0 +1,243 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces the values of the redacted fields.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactPatterns are patterns of fields commonly holding personal data or credentials.
+var DefaultRedactPatterns = []string{
+	"$..email",
+	"$..*_email",
+	"$..phone*",
+	"$..*password*",
+	"$..*token*",
+	"$..*secret*",
+	"$..api_key",
+	"$..apikey",
+	"$..authorization",
+}
+
+// redactStep is a step of a redaction pattern.
+type redactStep struct {
+	// descendant matches at any depth below the current value (..)
+	descendant bool
+	// name is a glob matching the field names; "*" also matches every array item
+	name string
+	// index selects an array item; -1 selects by name
+	index int
+}
+
+// Redact returns a middleware masking the fields of the JSON results, and of the
+// JSON error bodies of the API, matching the patterns with RedactedValue, so that
+// personal data and credentials never reach the model.
+//
+// A pattern is a JSONPath such as "$.users[*].email" or "$..token". Field names
+// are matched case-insensitively and may contain the globs of path.Match, e.g.
+// "$..*_token"; arrays are traversed by field names. A pattern without the leading
+// "$" matches at any depth, i.e. "email" is "$..email".
+func Redact(patterns ...string) (Middleware, error) {
+	paths := make([][]redactStep, 0, len(patterns))
+	for _, pattern := range patterns {
+		steps, err := parseRedactPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, steps)
+	}
+	redact := func(data []byte) ([]byte, error) {
+		return redactJSON(data, paths)
+	}
+	return func(_ MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if len(paths) == 0 {
+				return res, err
+			}
+			if err != nil {
+				return res, redactUpstreamError(err, redact)
+			}
+			return transformJSONResult(res, redact)
+		}
+	}, nil
+}
+
+func parseRedactPattern(pattern string) ([]redactStep, error) {
+	p := strings.TrimSpace(pattern)
+	if !strings.HasPrefix(p, "$") {
+		p = "$.." + strings.TrimPrefix(p, ".")
+	}
+	p = p[1:]
+	var steps []redactStep
+	for p != "" {
+		var step redactStep
+		step.index = -1
+		switch {
+		case strings.HasPrefix(p, ".."):
+			step.descendant = true
+			p = p[2:]
+			if strings.HasPrefix(p, "[") {
+				break
+			}
+			step.name, p = cutName(p)
+		case strings.HasPrefix(p, "."):
+			step.name, p = cutName(p[1:])
+		case !strings.HasPrefix(p, "["):
+			return nil, fmt.Errorf("invalid redact pattern %q: unexpected %q", pattern, p)
+		}
+		if strings.HasPrefix(p, "[") && step.name == "" {
+			end := strings.Index(p, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid redact pattern %q: missing ]", pattern)
+			}
+			selector := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			switch {
+			case selector == "*":
+				step.name = "*"
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				step.name = selector[1 : len(selector)-1]
+			default:
+				index, err := strconv.Atoi(selector)
+				if err != nil || index < 0 {
+					return nil, fmt.Errorf("invalid redact pattern %q: unsupported selector [%s]", pattern, selector)
+				}
+				step.index = index
+			}
+		}
+		if step.name == "" && step.index < 0 {
+			return nil, fmt.Errorf("invalid redact pattern %q: empty field name", pattern)
+		}
+		if _, err := path.Match(step.name, ""); err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("invalid redact pattern %q: no field", pattern)
+	}
+	return steps, nil
+}
+
+// cutName splits the field name at the start of p from the rest of the pattern.
+func cutName(p string) (string, string) {
+	end := strings.IndexAny(p, ".[")
+	if end < 0 {
+		return p, ""
+	}
+	return p[:end], p[end:]
+}
+
+func redactJSON(data []byte, paths [][]redactStep) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("redact: %w", err)
+	}
+	for _, steps := range paths {
+		v = redactPath(v, steps)
+	}
+	return json.Marshal(v)
+}
+
+// redactPath replaces the values matching the steps below v and returns v.
+func redactPath(v any, steps []redactStep) any {
+	if len(steps) == 0 {
+		return RedactedValue
+	}
+	step := steps[0]
+	if step.descendant {
+		// Match at this level, then at any depth below
+		here := append([]redactStep{{name: step.name, index: step.index}}, steps[1:]...)
+		v = redactPath(v, here)
+		switch node := v.(type) {
+		case map[string]any:
+			for key, child := range node {
+				node[key] = redactPath(child, steps)
+			}
+		case []any:
+			for i, child := range node {
+				node[i] = redactPath(child, steps)
+			}
+		}
+		return v
+	}
+	switch node := v.(type) {
+	case map[string]any:
+		if step.index >= 0 {
+			return v
+		}
+		for key, child := range node {
+			if matchFieldName(step.name, key) {
+				node[key] = redactPath(child, steps[1:])
+			}
+		}
+	case []any:
+		for i, child := range node {
+			switch {
+			case step.index >= 0:
+				if i == step.index {
+					node[i] = redactPath(child, steps[1:])
+				}
+			case step.name == "*":
+				node[i] = redactPath(child, steps[1:])
+			default:
+				// Field names select the fields of the items
+				node[i] = redactPath(child, steps)
+			}
+		}
+	}
+	return v
+}
+
+func matchFieldName(pattern, name string) bool {
+	matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return matched
+}
+
+// redactUpstreamError redacts the JSON error body of the UpstreamError wrapped by
+// err, including its copy in the error message.
+func redactUpstreamError(err error, redact func([]byte) ([]byte, error)) error {
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) || len(upstreamErr.Body) == 0 || !json.Valid(upstreamErr.Body) {
+		return err
+	}
+	redacted, redactErr := redact(upstreamErr.Body)
+	if redactErr != nil {
+		return err
+	}
+	if upstreamErr.Err != nil {
+		if message := upstreamErr.Err.Error(); strings.Contains(message, string(upstreamErr.Body)) {
+			upstreamErr.Err = &redactedError{
+				message: strings.ReplaceAll(message, string(upstreamErr.Body), string(redacted)),
+				err:     upstreamErr.Err,
+			}
+		}
+	}
+	upstreamErr.Body = redacted
+	return err
+}
+
+// redactedError hides the message of the wrapped error.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,81 @@
+// Package redisstore keeps the MCP sessions of the generated servers in Redis, so that
+// several replicas can serve the streamable HTTP transport behind a load balancer.
+// It requires Redis 6.2 or later.
+//
+//	rdb := redis.NewClient(&redis.Options{Addr: "localhost:6379"})
+//	server.StartServer(ctx, name, version, addr, server.WithSessionStore(redisstore.New(rdb)))
+package redisstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nonchan7720/oas-mcp/functions"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultKeyPrefix is the prefix of the keys of the sessions by default.
+const DefaultKeyPrefix = "oas-mcp:session:"
+
+// Values of the keys of the sessions
+const (
+	active     = "active"
+	terminated = "terminated"
+)
+
+// Store is a functions.SessionStore keeping the sessions in Redis. The sessions expire
+// after the TTL without requests.
+type Store struct {
+	client    redis.UniversalClient
+	keyPrefix string
+	ttl       time.Duration
+}
+
+var _ functions.SessionStore = (*Store)(nil)
+
+// New returns the store of the sessions in Redis, expiring after
+// functions.DefaultSessionTTL without requests.
+func New(client redis.UniversalClient) *Store {
+	return &Store{client: client, keyPrefix: DefaultKeyPrefix, ttl: functions.DefaultSessionTTL}
+}
+
+// WithKeyPrefix sets the prefix of the keys, e.g. to share the Redis between servers.
+func (s *Store) WithKeyPrefix(prefix string) *Store {
+	s.keyPrefix = prefix
+	return s
+}
+
+// WithTTL sets the time the sessions are kept without requests.
+func (s *Store) WithTTL(ttl time.Duration) *Store {
+	s.ttl = ttl
+	return s
+}
+
+func (s *Store) Create(ctx context.Context, id string) error {
+	if err := s.client.Set(ctx, s.keyPrefix+id, active, s.ttl).Err(); err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) Touch(ctx context.Context, id string) (bool, error) {
+	value, err := s.client.GetEx(ctx, s.keyPrefix+id, s.ttl).Result()
+	if errors.Is(err, redis.Nil) {
+		return false, functions.ErrSessionNotFound
+	} else if err != nil {
+		return false, fmt.Errorf("get session: %w", err)
+	}
+	return value == terminated, nil
+}
+
+func (s *Store) Terminate(ctx context.Context, id string) error {
+	// Keep the terminated session until it expires, so that it is answered as terminated
+	if err := s.client.SetArgs(ctx, s.keyPrefix+id, terminated, redis.SetArgs{Mode: "XX", KeepTTL: true}).Err(); errors.Is(err, redis.Nil) {
+		return functions.ErrSessionNotFound
+	} else if err != nil {
+		return fmt.Errorf("terminate session: %w", err)
+	}
+	return nil
+}
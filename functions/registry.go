@@ -0,0 +1,197 @@
+package functions
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Registry manages a set of tools by name.
+// When bound to a MCP server, changes to the registry are reflected to the server at runtime.
+type Registry struct {
+	mu          sync.RWMutex
+	tools       map[string]*Tool
+	order       []string
+	server      *server.MCPServer
+	observers   []Observer
+	middlewares []Middleware
+	processors  []taggedProcessor
+	// outputFormat is the format of the results, set when not empty
+	outputFormat OutputFormat
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		tools: map[string]*Tool{},
+	}
+}
+
+// Add registers the tools. It fails without registering anything when a tool
+// name is already registered or duplicated within tools.
+func (r *Registry) Add(tools ...*Tool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(tools))
+	for _, tool := range tools {
+		if _, ok := r.tools[tool.name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateTool, tool.name)
+		}
+		if _, ok := seen[tool.name]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateTool, tool.name)
+		}
+		seen[tool.name] = struct{}{}
+	}
+	for _, tool := range tools {
+		// The observers and middlewares of the registry are looked up on every call
+		// instead of being copied, so that adding a tool again does not apply them twice.
+		tool.registry = r
+		if r.outputFormat != "" {
+			tool.WithOutputFormat(r.outputFormat)
+		}
+		for _, p := range r.processors {
+			if p.applies(tool) {
+				tool.WithResponseProcessor(p.processor)
+			}
+		}
+		r.tools[tool.name] = tool
+		r.order = append(r.order, tool.name)
+	}
+	if r.server != nil {
+		r.server.AddTools(toServerTools(tools)...)
+	}
+	return nil
+}
+
+// WithObserver adds observers to the registered tools and to the tools added later.
+func (r *Registry) WithObserver(observers ...Observer) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.observers = append(r.observers, observers...)
+	return r
+}
+
+// Use appends the middlewares to the registered tools and to the tools added later.
+// They run inside the middlewares given to Tool.Use and outside those given to Tool.UseInner.
+func (r *Registry) Use(mw ...Middleware) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.middlewares = append(r.middlewares, mw...)
+	return r
+}
+
+// Remove unregisters the tools with the given names.
+func (r *Registry) Remove(names ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, name := range names {
+		if tool, ok := r.tools[name]; ok && tool.registry == r {
+			tool.registry = nil
+		}
+		delete(r.tools, name)
+	}
+	r.order = slices.DeleteFunc(r.order, func(name string) bool {
+		return slices.Contains(names, name)
+	})
+	if r.server != nil {
+		r.server.DeleteTools(names...)
+	}
+}
+
+// Get returns the tool with the given name.
+func (r *Registry) Get(name string) (*Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List returns the registered tools in registration order.
+func (r *Registry) List() []*Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list()
+}
+
+// list returns the registered tools in registration order. r.mu must be held.
+func (r *Registry) list() []*Tool {
+	tools := make([]*Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// ListByTag returns the registered tools having the tag in registration order.
+func (r *Registry) ListByTag(tag string) []*Tool {
+	var tools []*Tool
+	for _, tool := range r.List() {
+		if slices.Contains(tool.tags, tag) {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
+
+// Tags returns the tags of the registered tools in sorted order.
+func (r *Registry) Tags() []string {
+	var tags []string
+	for _, tool := range r.List() {
+		for _, tag := range tool.tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+	return tags
+}
+
+// ServerTools converts the registered tools to MCP server tools.
+func (r *Registry) ServerTools() []server.ServerTool {
+	return toServerTools(r.List())
+}
+
+// Bind registers every tool to the MCP server and keeps the server in sync
+// with later changes to the registry.
+func (r *Registry) Bind(s *server.MCPServer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Register the tools under the same lock as Add and Remove, so that a concurrent
+	// change is neither applied twice nor undone by a stale list of tools.
+	r.server = s
+	s.AddTools(toServerTools(r.list())...)
+}
+
+func toServerTools(tools []*Tool) []server.ServerTool {
+	serverTools := make([]server.ServerTool, 0, len(tools))
+	for _, tool := range tools {
+		serverTools = append(serverTools, tool.ServerTool())
+	}
+	return serverTools
+}
+
+// registryMiddlewares returns the middlewares of the registry.
+func (r *Registry) registryMiddlewares() []Middleware {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.middlewares)
+}
+
+// registryObservers returns the observers of the registry.
+func (r *Registry) registryObservers() []Observer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.observers)
+}
@@ -0,0 +1,48 @@
+package functions
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegistryMiddlewaresAppliedOnce(t *testing.T) {
+	var calls int
+	counter := func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			calls++
+			return next(ctx, params)
+		}
+	}
+	tool := NewFunctionTool("echo", "Echo the input", func(ctx context.Context, params map[string]any) (any, error) {
+		return params, nil
+	})
+
+	r := NewRegistry().Use(counter)
+	if err := r.Add(tool); err != nil {
+		t.Fatal(err)
+	}
+	// Adding the tool again after removing it must not wrap it twice
+	r.Remove(tool.Name())
+	if err := r.Add(tool); err != nil {
+		t.Fatal(err)
+	}
+	// Middlewares added after the tool apply as well
+	r.Use(counter)
+
+	if _, err := tool.Execute(context.Background(), map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("middlewares called %d times, want 2", calls)
+	}
+
+	// A removed tool no longer runs the middlewares of the registry
+	calls = 0
+	r.Remove(tool.Name())
+	if _, err := tool.Execute(context.Background(), map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 0 {
+		t.Errorf("middlewares called %d times after Remove, want 0", calls)
+	}
+}
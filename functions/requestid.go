@@ -0,0 +1,136 @@
+package functions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// RequestIDHeader is the header carrying the request id to the upstream API by default.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDMetaKey is the _meta field of the tool call requests and results
+// carrying the request id.
+const RequestIDMetaKey = "requestId"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request id of the tool call.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id of the tool call, or "" when none is set.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a random request id.
+func NewRequestID() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// RequestID returns a middleware assigning a request id to every tool call, unless
+// the client sent one in the _meta of the request, and logging the call with it.
+// The id is returned in the _meta of the result, so that the actions of an agent can
+// be traced into the logs of the upstream API with PropagateRequestID.
+// slog.Default() is used when logger is nil.
+func RequestID(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			id := RequestIDFromContext(ctx)
+			if id == "" {
+				id = NewRequestID()
+				ctx = WithRequestID(ctx, id)
+			}
+			SetResultMeta(ctx, RequestIDMetaKey, id)
+			start := time.Now()
+			res, err := next(ctx, params)
+			attrs := []any{"tool", tool.Name(), "request_id", id, "duration", time.Since(start)}
+			if err != nil {
+				logger.WarnContext(ctx, "Tool call failed", append(attrs, "error", err)...)
+			} else {
+				logger.InfoContext(ctx, "Tool call", attrs...)
+			}
+			return res, err
+		}
+	}
+}
+
+// PropagateRequestID returns a RequestEditor sending the request id of the tool call
+// in the header, unless the request already has it.
+func PropagateRequestID(header string) RequestEditor {
+	return func(ctx context.Context, req *http.Request) error {
+		if id := RequestIDFromContext(ctx); id != "" && req.Header.Get(header) == "" {
+			req.Header.Set(header, id)
+		}
+		return nil
+	}
+}
+
+// requestIDFromMeta returns the request id sent by the client in the _meta of the request.
+func requestIDFromMeta(req mcp.CallToolRequest) string {
+	if req.Params.Meta == nil {
+		return ""
+	}
+	id, _ := req.Params.Meta.AdditionalFields[RequestIDMetaKey].(string)
+	return id
+}
+
+type resultMetaKey struct{}
+
+// resultMeta collects the _meta fields of the result of a tool call.
+type resultMeta struct {
+	mu     sync.Mutex
+	fields map[string]any
+}
+
+func withResultMeta(ctx context.Context) (context.Context, *resultMeta) {
+	meta := &resultMeta{}
+	return context.WithValue(ctx, resultMetaKey{}, meta), meta
+}
+
+// SetResultMeta sets a field of the _meta of the result of the tool call in ctx.
+// It does nothing when the tool is not called through the MCP server.
+func SetResultMeta(ctx context.Context, key string, value any) {
+	meta, ok := ctx.Value(resultMetaKey{}).(*resultMeta)
+	if !ok {
+		return
+	}
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	if meta.fields == nil {
+		meta.fields = map[string]any{}
+	}
+	meta.fields[key] = value
+}
+
+// apply adds the collected fields to the _meta of the result.
+func (m *resultMeta) apply(result *mcp.CallToolResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.fields) == 0 {
+		return
+	}
+	if result.Meta == nil {
+		result.Meta = &mcp.Meta{}
+	}
+	if result.Meta.AdditionalFields == nil {
+		result.Meta.AdditionalFields = map[string]any{}
+	}
+	for key, value := range m.fields {
+		result.Meta.AdditionalFields[key] = value
+	}
+}
@@ -0,0 +1,357 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// OperationResourcePrefix is the prefix of the URIs of the operations exposed as resources.
+const OperationResourcePrefix = "oas-mcp://operations/"
+
+// DefaultPollInterval is the interval at which the subscribed resources are polled by default.
+const DefaultPollInterval = time.Minute
+
+// The methods of the subscription requests, which are not defined by mcp-go.
+const (
+	methodResourcesSubscribe   mcp.MCPMethod = "resources/subscribe"
+	methodResourcesUnsubscribe mcp.MCPMethod = "resources/unsubscribe"
+)
+
+// operationResources holds the OperationResources of the MCP servers, for the
+// transports implemented by this package.
+var operationResources sync.Map // *server.MCPServer -> *OperationResources
+
+// OperationResources exposes the read-only tools without required arguments, i.e. the
+// GET operations without required parameters, as MCP resources read by calling the
+// tool. It implements resources/subscribe for them, which the MCP server does not, by
+// polling the API while a session is subscribed and notifying the subscribed sessions
+// with notifications/resources/updated when the payload changes.
+type OperationResources struct {
+	srv      *server.MCPServer
+	interval time.Duration
+	// subscriptions is false when the server does not declare resources/subscribe
+	subscriptions bool
+	mu            sync.Mutex
+	tools         map[string]*Tool           // by URI
+	pollers       map[string]*resourcePoller // by URI
+}
+
+// resourcePoller polls a resource for the subscribed sessions.
+type resourcePoller struct {
+	sessions map[string]struct{}
+	cancel   context.CancelFunc
+}
+
+// NewOperationResources returns the resources of srv polled every DefaultPollInterval.
+// The server must declare the subscribe capability of the resources
+// (server.WithResourceCapabilities), unless the subscriptions are turned off with
+// WithSubscribe.
+func NewOperationResources(srv *server.MCPServer) *OperationResources {
+	r := &OperationResources{
+		srv:           srv,
+		interval:      DefaultPollInterval,
+		subscriptions: true,
+		tools:         map[string]*Tool{},
+		pollers:       map[string]*resourcePoller{},
+	}
+	operationResources.Store(srv, r)
+	return r
+}
+
+// WithPollInterval sets the interval at which the subscribed resources are polled.
+func (r *OperationResources) WithPollInterval(interval time.Duration) *OperationResources {
+	if interval > 0 {
+		r.interval = interval
+	}
+	return r
+}
+
+// WithSubscribe sets whether resources/subscribe is supported. The resources of a server
+// which does not declare the subscriptions are only read, and the subscription
+// requests are left to the MCP server, which rejects them.
+func (r *OperationResources) WithSubscribe(subscribe bool) *OperationResources {
+	r.subscriptions = subscribe
+	return r
+}
+
+// Add registers the read-only tools without required arguments as the resources
+// OperationResourcePrefix + tool name. The other tools are skipped.
+func (r *OperationResources) Add(tools ...*Tool) {
+	for _, tool := range tools {
+		if hint := tool.Annotation().ReadOnlyHint; hint == nil || !*hint {
+			continue
+		}
+		if tool.schema != nil && len(tool.schema.Required) > 0 {
+			continue
+		}
+		uri := OperationResourcePrefix + tool.name
+		r.mu.Lock()
+		r.tools[uri] = tool
+		r.mu.Unlock()
+		resource := mcp.NewResource(uri, tool.name,
+			mcp.WithResourceDescription(tool.description),
+			mcp.WithMIMEType("application/json"),
+		)
+		r.srv.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			res, err := tool.Execute(ctx, map[string]any{})
+			if err != nil {
+				return nil, err
+			}
+			text, mimeType, err := resourceText(res)
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{URI: uri, MIMEType: mimeType, Text: text},
+			}, nil
+		})
+	}
+}
+
+// Handler returns a handler answering the resources/subscribe and resources/unsubscribe
+// requests sent to the SSE and streamable HTTP transports served by next. The
+// subscriptions of a streamable HTTP session are released when it is deleted.
+func (r *OperationResources) Handler(next http.Handler) http.Handler {
+	if !r.subscriptions {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		session := req.Header.Get(server.HeaderKeySessionID)
+		if session == "" {
+			// The message endpoint of the SSE transport
+			session = req.URL.Query().Get("sessionId")
+		}
+		if session == "" || (req.Method != http.MethodPost && req.Method != http.MethodDelete) {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if req.Method == http.MethodDelete {
+			r.release(session)
+			next.ServeHTTP(w, req)
+			return
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		message, apply := r.rewrite(body)
+		req.Body = io.NopCloser(bytes.NewReader(message))
+		req.ContentLength = int64(len(message))
+		if apply == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		// The session is only known to be valid once the transport accepted the message
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, req)
+		if rec.status < http.StatusMultipleChoices {
+			apply(session)
+		}
+	})
+}
+
+// rewrite returns the message to pass to the MCP server and, for the subscription
+// requests, the function applying them to the session. The subscription requests are
+// replaced by a ping of the same id, so that the client receives an empty result.
+func (r *OperationResources) rewrite(message []byte) ([]byte, func(session string)) {
+	var request struct {
+		ID     json.RawMessage `json:"id"`
+		Method mcp.MCPMethod   `json:"method"`
+		Params struct {
+			URI string `json:"uri"`
+		} `json:"params"`
+	}
+	if !r.subscriptions || json.Unmarshal(message, &request) != nil || request.ID == nil {
+		return message, nil
+	}
+	var apply func(session string)
+	switch request.Method {
+	case methodResourcesSubscribe:
+		apply = func(session string) { r.subscribe(session, request.Params.URI) }
+	case methodResourcesUnsubscribe:
+		apply = func(session string) { r.unsubscribe(session, request.Params.URI) }
+	default:
+		return message, nil
+	}
+	ping, err := json.Marshal(map[string]any{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      request.ID,
+		"method":  mcp.MethodPing,
+	})
+	if err != nil {
+		return message, nil
+	}
+	return ping, apply
+}
+
+// subscribe starts polling the resource for the session. The subscriptions to the
+// other resources are accepted without polling, e.g. the webhook events which are
+// notified as they are received.
+func (r *OperationResources) subscribe(session, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tool, ok := r.tools[uri]
+	if !ok {
+		return
+	}
+	poller, ok := r.pollers[uri]
+	if !ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		poller = &resourcePoller{sessions: map[string]struct{}{}, cancel: cancel}
+		r.pollers[uri] = poller
+		go r.poll(ctx, uri, tool)
+	}
+	poller.sessions[session] = struct{}{}
+}
+
+// unsubscribe stops polling the resource for the session.
+func (r *OperationResources) unsubscribe(session, uri string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unsubscribeLocked(session, uri)
+}
+
+func (r *OperationResources) unsubscribeLocked(session, uri string) {
+	poller, ok := r.pollers[uri]
+	if !ok {
+		return
+	}
+	delete(poller.sessions, session)
+	if len(poller.sessions) == 0 {
+		poller.cancel()
+		delete(r.pollers, uri)
+	}
+}
+
+// release cancels the subscriptions of the closed session.
+func (r *OperationResources) release(session string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for uri := range r.pollers {
+		r.unsubscribeLocked(session, uri)
+	}
+}
+
+// poll reads the resource every interval until ctx is canceled and notifies the
+// subscribed sessions when its payload changed since the previous read.
+func (r *OperationResources) poll(ctx context.Context, uri string, tool *Tool) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	last, _ := r.digest(ctx, tool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		digest, err := r.digest(ctx, tool)
+		// Failures of the API are not changes of the resource
+		if err != nil || digest == last {
+			continue
+		}
+		last = digest
+		r.notify(uri)
+	}
+}
+
+// digest returns the hash of the payload of the tool. The function of the tool is
+// called without the middlewares, whose results may differ on every call, e.g. the
+// ids of the stored pages.
+func (r *OperationResources) digest(ctx context.Context, tool *Tool) (_ [sha256.Size]byte, err error) {
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+	defer recoverPanic(&err)
+	ctx = WithToolName(ctx, tool.name)
+	ctx = withTool(ctx, tool)
+	if err := tool.acquire(ctx); err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	res, err := tool.execute(ctx, map[string]any{})
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	text, _, err := resourceText(res)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256([]byte(text)), nil
+}
+
+// notify sends the update of the resource to the subscribed sessions. The sessions
+// which cannot be notified, because they were closed, are unsubscribed.
+func (r *OperationResources) notify(uri string) {
+	r.mu.Lock()
+	var sessions []string
+	if poller, ok := r.pollers[uri]; ok {
+		for session := range poller.sessions {
+			sessions = append(sessions, session)
+		}
+	}
+	r.mu.Unlock()
+	for _, session := range sessions {
+		err := r.srv.SendNotificationToSpecificClient(session, string(mcp.MethodNotificationResourceUpdated), map[string]any{
+			"uri": uri,
+		})
+		if err != nil {
+			r.unsubscribe(session, uri)
+		}
+	}
+}
+
+// resourceText returns the text and MIME type of the result of a tool.
+func resourceText(res any) (string, string, error) {
+	switch v := res.(type) {
+	case string:
+		if json.Valid([]byte(v)) {
+			return v, "application/json", nil
+		}
+		return v, "text/plain", nil
+	case *ToolResult, ToolResult, *mcp.CallToolResult, mcp.CallToolResult, []mcp.Content, mcp.Content:
+		result, err := toCallToolResult(res)
+		if err != nil {
+			return "", "", err
+		}
+		var texts []string
+		for _, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				texts = append(texts, text.Text)
+			}
+		}
+		return strings.Join(texts, "\n"), "text/plain", nil
+	}
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return "", "", err
+	}
+	return string(buf), "application/json", nil
+}
+
+// subscriptionsOf returns the OperationResources of srv, or nil.
+func subscriptionsOf(srv *server.MCPServer) *OperationResources {
+	if r, ok := operationResources.Load(srv); ok {
+		return r.(*OperationResources)
+	}
+	return nil
+}
+
+// statusRecorder records the status code of a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
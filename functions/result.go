@@ -0,0 +1,79 @@
+package functions
+
+import (
+	"encoding/json"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ToolResult is a result with explicit content blocks.
+// Return it from a tool function to send images, embedded resources or
+// multiple content blocks instead of the JSON encoded result.
+type ToolResult struct {
+	Content []mcp.Content
+	IsError bool
+}
+
+// NewToolResult returns a result consisting of the given content blocks.
+func NewToolResult(content ...mcp.Content) *ToolResult {
+	return &ToolResult{
+		Content: content,
+	}
+}
+
+// toCallToolResult converts the value returned by a tool function into a CallToolResult.
+// Well-known types (*mcp.CallToolResult, ToolResult, mcp.Content and []mcp.Content)
+// are passed through, anything else is encoded as JSON text.
+func toCallToolResult(res any) (*mcp.CallToolResult, error) {
+	switch v := res.(type) {
+	case *mcp.CallToolResult:
+		return v, nil
+	case mcp.CallToolResult:
+		return &v, nil
+	case *ToolResult:
+		return &mcp.CallToolResult{Content: v.Content, IsError: v.IsError}, nil
+	case ToolResult:
+		return &mcp.CallToolResult{Content: v.Content, IsError: v.IsError}, nil
+	case []mcp.Content:
+		return &mcp.CallToolResult{Content: v}, nil
+	case mcp.Content:
+		return &mcp.CallToolResult{Content: []mcp.Content{v}}, nil
+	}
+	buf, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(buf)), nil
+}
+
+// transformJSONResult applies fn to the JSON encoding of the result. String results
+// holding JSON stay strings, other results are returned as json.RawMessage.
+// Text results that are not JSON and explicit contents are returned as is.
+func transformJSONResult(res any, fn func(data []byte) ([]byte, error)) (any, error) {
+	var data []byte
+	switch v := res.(type) {
+	case nil, *ToolResult, ToolResult, *mcp.CallToolResult, mcp.CallToolResult, []mcp.Content, mcp.Content:
+		return res, nil
+	case string:
+		if !json.Valid([]byte(v)) {
+			return res, nil
+		}
+		transformed, err := fn([]byte(v))
+		if err != nil {
+			return nil, NewInternalError(err)
+		}
+		return string(transformed), nil
+	case json.RawMessage:
+		data = v
+	default:
+		var err error
+		if data, err = json.Marshal(res); err != nil {
+			return res, nil
+		}
+	}
+	transformed, err := fn(data)
+	if err != nil {
+		return nil, NewInternalError(err)
+	}
+	return json.RawMessage(transformed), nil
+}
@@ -0,0 +1,239 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxResultSize is the maximum size of the results of the generated server by default.
+const DefaultMaxResultSize = 512 * 1024
+
+// truncationReserve is the part of the maximum size kept for the truncation notice.
+const truncationReserve = 512
+
+// narrowingParameter matches the names of the parameters commonly used to narrow a query.
+var narrowingParameter = regexp.MustCompile(`(?i)^(limit|per_?page|page_?size|size|max|max_?results|page|offset|cursor|fields|select|filter.*|q|query|search|since|until|from|to)$`)
+
+// TruncatedResult is returned instead of a result exceeding the maximum size.
+type TruncatedResult struct {
+	// Data is the part of the result fitting in the maximum size. JSON results are
+	// cut between array items or object fields, so Data is always valid JSON.
+	Data      any  `json:"data"`
+	Truncated bool `json:"truncated"`
+	// OriginalSize is the size of the whole result in bytes
+	OriginalSize int `json:"originalSize"`
+	// Notice explains how to get the rest of the result
+	Notice string `json:"notice"`
+}
+
+// MaxResultSize returns a middleware truncating the results larger than maxBytes
+// bytes once encoded, instead of sending megabytes to the model. The truncated result
+// is returned as TruncatedResult with a notice telling how to narrow the query.
+func MaxResultSize(maxBytes int) Middleware {
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil || maxBytes <= 0 {
+				return res, err
+			}
+			var data []byte
+			switch v := res.(type) {
+			case nil, *ToolResult, ToolResult, *TruncatedResult:
+				return res, nil
+			case string:
+				data = []byte(v)
+			default:
+				if data, err = json.Marshal(res); err != nil {
+					return res, nil
+				}
+			}
+			if len(data) <= maxBytes {
+				return res, nil
+			}
+			budget := max(maxBytes-truncationReserve, 0)
+			result := &TruncatedResult{
+				Truncated:    true,
+				OriginalSize: len(data),
+				Notice:       truncationNotice(tool, len(data), maxBytes),
+			}
+			if json.Valid(data) {
+				result.Data = json.RawMessage(truncateJSON(data, budget))
+			} else {
+				result.Data = truncateString(string(data), budget)
+			}
+			return result, nil
+		}
+	}
+}
+
+func truncationNotice(tool MCPTool, size, maxBytes int) string {
+	notice := fmt.Sprintf("The result of %d bytes exceeds the limit of %d bytes and was truncated.", size, maxBytes)
+	if params := narrowingParameters(tool); len(params) > 0 {
+		return notice + " Narrow the query with " + strings.Join(params, ", ") + " to get the rest."
+	}
+	return notice + " Narrow the query, e.g. with more specific parameters, to get the rest."
+}
+
+// narrowingParameters returns the parameters of the tool that narrow the query,
+// looking into the nested requestParameter object as well.
+func narrowingParameters(tool MCPTool) []string {
+	t, ok := tool.(*Tool)
+	if !ok || t.schema == nil {
+		return nil
+	}
+	var names []string
+	var collect func(properties map[string]any, depth int)
+	collect = func(properties map[string]any, depth int) {
+		for name, prop := range properties {
+			if narrowingParameter.MatchString(name) {
+				names = append(names, name)
+			}
+			if schema, ok := prop.(map[string]any); ok && depth == 0 {
+				if nested, ok := schema["properties"].(map[string]any); ok {
+					collect(nested, depth+1)
+				}
+			}
+		}
+	}
+	collect(t.schema.Properties, 0)
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// truncateString cuts s to at most n bytes at a rune boundary.
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// truncateJSON returns the valid JSON encoding of the beginning of data fitting in
+// budget bytes, dropping the array items and object fields that do not fit.
+func truncateJSON(data []byte, budget int) []byte {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := decodeJSONNode(dec)
+	if err != nil {
+		return []byte("null")
+	}
+	if out, _ := node.fit(budget); out != nil {
+		return out
+	}
+	return []byte("null")
+}
+
+// jsonNode is a decoded JSON value keeping the order of the object fields.
+type jsonNode struct {
+	// raw is the encoding of scalar values
+	raw      []byte
+	str      *string
+	array    bool
+	object   bool
+	keys     [][]byte
+	children []*jsonNode
+}
+
+func decodeJSONNode(dec *json.Decoder) (*jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		raw, err := json.Marshal(tok)
+		if err != nil {
+			return nil, err
+		}
+		node := &jsonNode{raw: raw}
+		if s, ok := tok.(string); ok {
+			node.str = &s
+		}
+		return node, nil
+	}
+	node := &jsonNode{array: delim == '[', object: delim == '{'}
+	for dec.More() {
+		if node.object {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			encoded, _ := json.Marshal(key)
+			node.keys = append(node.keys, encoded)
+		}
+		child, err := decodeJSONNode(dec)
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, child)
+	}
+	// Consume the closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// fit encodes the part of the value fitting in budget bytes,
+// or returns nil when nothing fits. It reports whether the whole value was encoded.
+func (n *jsonNode) fit(budget int) ([]byte, bool) {
+	if !n.array && !n.object {
+		if len(n.raw) <= budget {
+			return n.raw, true
+		}
+		// A long string is cut instead of dropped
+		if n.str != nil && budget > 2 {
+			// The encoded length grows with the prefix, so search the longest prefix
+			// fitting with O(log n) encodings instead of cutting a byte at a time
+			str := *n.str
+			cut := sort.Search(min(len(str), budget-2)+1, func(i int) bool {
+				out, _ := json.Marshal(truncateString(str, i))
+				return len(out) > budget
+			}) - 1
+			out, _ := json.Marshal(truncateString(str, cut))
+			return out, false
+		}
+		return nil, false
+	}
+	open, closing := byte('['), byte(']')
+	if n.object {
+		open, closing = '{', '}'
+	}
+	if budget < 2 {
+		return nil, false
+	}
+	buf := []byte{open}
+	for i, child := range n.children {
+		var prefix []byte
+		if i > 0 {
+			prefix = append(prefix, ',')
+		}
+		if n.object {
+			prefix = append(prefix, n.keys[i]...)
+			prefix = append(prefix, ':')
+		}
+		remaining := budget - len(buf) - len(prefix) - 1
+		if remaining < 0 {
+			return append(buf, closing), false
+		}
+		out, complete := child.fit(remaining)
+		if out == nil {
+			return append(buf, closing), false
+		}
+		buf = append(append(buf, prefix...), out...)
+		if !complete {
+			return append(buf, closing), false
+		}
+	}
+	return append(buf, closing), true
+}
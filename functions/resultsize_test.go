@@ -0,0 +1,49 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTruncateJSONString(t *testing.T) {
+	for _, s := range []string{
+		strings.Repeat("a", 10000),
+		strings.Repeat("<\"é\n", 3000),
+		strings.Repeat("日本語", 3000),
+	} {
+		data, _ := json.Marshal(map[string]any{"text": s})
+		for _, budget := range []int{5, 11, 12, 100, 1001, 4096} {
+			out := truncateJSON(data, budget)
+			if len(out) > budget && string(out) != "null" {
+				t.Errorf("truncateJSON(%.10q, %d) has %d bytes", s, budget, len(out))
+			}
+			var got map[string]string
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatalf("truncateJSON(%.10q, %d) = %s: %v", s, budget, out, err)
+			}
+			text, ok := got["text"]
+			if !ok {
+				continue
+			}
+			if !strings.HasPrefix(s, text) {
+				t.Errorf("truncateJSON(%.10q, %d) is not a prefix: %q", s, budget, text)
+			}
+			// The cut keeps the longest prefix fitting in the budget
+			if longer := truncateString(s, len(text)+4); longer != text {
+				encoded, _ := json.Marshal(map[string]any{"text": longer})
+				if len(encoded) <= budget {
+					t.Errorf("truncateJSON(%.10q, %d) kept %d bytes, %q fits as well", s, budget, len(text), longer)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkTruncateJSONString(b *testing.B) {
+	data, _ := json.Marshal(map[string]any{"text": strings.Repeat("<\"é\n", 1<<16)})
+	b.ReportAllocs()
+	for b.Loop() {
+		truncateJSON(data, DefaultMaxResultSize/4)
+	}
+}
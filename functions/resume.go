@@ -0,0 +1,158 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultStreamHistory is the number of events kept per session for the resumption of
+// the streams by default.
+const DefaultStreamHistory = 100
+
+// ResumableStreams returns a handler serving next and making the GET streams of the
+// streamable HTTP transport resumable: the events get an id, increasing per session,
+// and the last history events of each session are kept, so that a client reconnecting
+// with the Last-Event-ID header receives the events it missed, e.g. the ones written
+// to a connection closed by a proxy, before the new ones. The keep-alive pings are
+// neither numbered nor kept. The events of a session are released when it is deleted.
+// A history of zero or less is DefaultStreamHistory.
+func ResumableStreams(next http.Handler, history int) http.Handler {
+	if history <= 0 {
+		history = DefaultStreamHistory
+	}
+	return &resumableStreams{next: next, history: history, streams: map[string]*eventHistory{}}
+}
+
+type resumableStreams struct {
+	next    http.Handler
+	history int
+	mu      sync.Mutex
+	streams map[string]*eventHistory // by session
+}
+
+// eventHistory holds the last events sent to a session.
+type eventHistory struct {
+	mu     sync.Mutex
+	lastID int64
+	events []sentEvent
+}
+
+type sentEvent struct {
+	id    int64
+	event []byte
+}
+
+func (s *resumableStreams) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	id := req.Header.Get(server.HeaderKeySessionID)
+	if id == "" || (req.Method != http.MethodGet && req.Method != http.MethodDelete) {
+		s.next.ServeHTTP(w, req)
+		return
+	}
+	if req.Method == http.MethodDelete {
+		s.mu.Lock()
+		delete(s.streams, id)
+		s.mu.Unlock()
+		s.next.ServeHTTP(w, req)
+		return
+	}
+	if !strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+		s.next.ServeHTTP(w, req)
+		return
+	}
+	s.mu.Lock()
+	stream, ok := s.streams[id]
+	if !ok {
+		stream = &eventHistory{}
+		s.streams[id] = stream
+	}
+	s.mu.Unlock()
+	// An invalid id resumes nothing, as a new stream
+	lastEventID, err := strconv.ParseInt(req.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil {
+		lastEventID = -1
+	}
+	rw := &resumableWriter{
+		ResponseWriter: w,
+		stream:         stream,
+		history:        s.history,
+		lastEventID:    lastEventID,
+	}
+	s.next.ServeHTTP(rw, req)
+	if len(rw.pending) > 0 {
+		// The rest of a response which is not a stream, e.g. an error
+		_, _ = w.Write(rw.pending)
+	}
+}
+
+// resumableWriter numbers the events written to a stream and replays the missed ones
+// when the stream starts.
+type resumableWriter struct {
+	http.ResponseWriter
+	stream      *eventHistory
+	history     int
+	lastEventID int64
+	// pending is the start of an event not written entirely yet
+	pending []byte
+}
+
+func (w *resumableWriter) WriteHeader(status int) {
+	w.ResponseWriter.WriteHeader(status)
+	if status != http.StatusOK || w.lastEventID < 0 {
+		return
+	}
+	w.stream.mu.Lock()
+	var missed []byte
+	for _, event := range w.stream.events {
+		if event.id > w.lastEventID {
+			missed = append(missed, event.event...)
+		}
+	}
+	w.stream.mu.Unlock()
+	// A failed write ends the stream on the next event
+	_, _ = w.ResponseWriter.Write(missed)
+}
+
+func (w *resumableWriter) Write(b []byte) (int, error) {
+	w.pending = append(w.pending, b...)
+	for {
+		end := bytes.Index(w.pending, []byte("\n\n"))
+		if end < 0 {
+			return len(b), nil
+		}
+		event := w.pending[:end+2]
+		w.pending = w.pending[end+2:]
+		if _, err := w.ResponseWriter.Write(w.number(event)); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// number returns the event with its id, and keeps it in the history of the session.
+// The events are kept before they are written, so that the ones lost with the
+// connection are replayed.
+func (w *resumableWriter) number(event []byte) []byte {
+	if bytes.Contains(event, []byte(`"method":"ping"`)) {
+		return event
+	}
+	w.stream.mu.Lock()
+	defer w.stream.mu.Unlock()
+	w.stream.lastID++
+	numbered := fmt.Appendf(nil, "id: %d\n%s", w.stream.lastID, event)
+	w.stream.events = append(w.stream.events, sentEvent{id: w.stream.lastID, event: numbered})
+	if len(w.stream.events) > w.history {
+		w.stream.events = w.stream.events[len(w.stream.events)-w.history:]
+	}
+	return numbered
+}
+
+func (w *resumableWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,162 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ErrRootsUnavailable is returned when the client of the tool call does not provide
+// roots, e.g. because it did not declare the roots capability or its transport cannot
+// receive requests from the server (SSE).
+var ErrRootsUnavailable = errors.New("the client does not provide roots")
+
+// PathError is returned by ResolvePath for paths outside of the roots of the client.
+// It is reported to the model with the allowed roots, so it can pick another path.
+type PathError struct {
+	Path  string
+	Roots []string
+}
+
+func (e *PathError) Error() string {
+	if len(e.Roots) == 0 {
+		return fmt.Sprintf("path %q is not allowed: the client provides no file roots", e.Path)
+	}
+	return fmt.Sprintf("path %q is outside of the roots of the client: %s", e.Path, strings.Join(e.Roots, ", "))
+}
+
+type rootsKey struct{}
+
+// rootsCache keeps the roots of the client for a single tool call.
+type rootsCache struct {
+	once  sync.Once
+	roots []mcp.Root
+	err   error
+}
+
+// withRoots returns a context requesting the roots from the client at most once.
+func withRoots(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rootsKey{}, &rootsCache{})
+}
+
+// Roots returns the roots of the client of the tool call, i.e. the directories and
+// files the client allows the server to access. Within a tool call the roots are
+// requested once, so the changes notified by the client apply to the next calls.
+func Roots(ctx context.Context) ([]mcp.Root, error) {
+	if cache, ok := ctx.Value(rootsKey{}).(*rootsCache); ok {
+		cache.once.Do(func() {
+			cache.roots, cache.err = requestRoots(ctx)
+		})
+		return cache.roots, cache.err
+	}
+	return requestRoots(ctx)
+}
+
+func requestRoots(ctx context.Context) ([]mcp.Root, error) {
+	srv := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if srv == nil || session == nil {
+		return nil, ErrRootsUnavailable
+	}
+	// Clients without the capability may never answer the request
+	if info, ok := session.(server.SessionWithClientInfo); ok && info.GetClientCapabilities().Roots == nil {
+		return nil, ErrRootsUnavailable
+	}
+	res, err := srv.RequestRoots(ctx, mcp.ListRootsRequest{})
+	if errors.Is(err, server.ErrRootsNotSupported) {
+		return nil, ErrRootsUnavailable
+	} else if err != nil {
+		return nil, fmt.Errorf("list roots: %w", err)
+	}
+	return res.Roots, nil
+}
+
+// RootPaths returns the local paths of the file:// roots of the client.
+func RootPaths(ctx context.Context) ([]string, error) {
+	roots, err := Roots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, root := range roots {
+		if path, ok := fileURIPath(root.URI); ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
+// ResolvePath resolves a path argument of a tool against the roots of the client.
+// Relative paths are resolved against the first root, file:// URIs are accepted.
+// A *PathError is returned when the path, or the file it links to, is outside of
+// every root.
+func ResolvePath(ctx context.Context, path string) (string, error) {
+	roots, err := RootPaths(ctx)
+	if err != nil {
+		return "", err
+	}
+	resolved := path
+	if p, ok := fileURIPath(path); ok {
+		resolved = p
+	}
+	if !filepath.IsAbs(resolved) {
+		if len(roots) == 0 {
+			return "", &PathError{Path: path}
+		}
+		resolved = filepath.Join(roots[0], resolved)
+	}
+	resolved = filepath.Clean(resolved)
+	if !withinRoots(roots, resolved) {
+		return "", &PathError{Path: path, Roots: roots}
+	}
+	// Symbolic links must not lead out of the roots either
+	if target, err := filepath.EvalSymlinks(resolved); err == nil && !withinRoots(evalRoots(roots), target) {
+		return "", &PathError{Path: path, Roots: roots}
+	}
+	return resolved, nil
+}
+
+// withinRoots reports whether the clean absolute path is one of the roots or inside one.
+func withinRoots(roots []string, path string) bool {
+	for _, root := range roots {
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// evalRoots returns the roots with their symbolic links evaluated.
+func evalRoots(roots []string) []string {
+	evaluated := make([]string, 0, len(roots))
+	for _, root := range roots {
+		if target, err := filepath.EvalSymlinks(root); err == nil {
+			root = target
+		}
+		evaluated = append(evaluated, root)
+	}
+	return evaluated
+}
+
+// fileURIPath returns the local path of a file:// URI.
+func fileURIPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" || (u.Host != "" && u.Host != "localhost") {
+		return "", false
+	}
+	path := u.Path
+	// file:///C:/dir on Windows
+	if runtime.GOOS == "windows" {
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.Clean(filepath.FromSlash(path)), true
+}
@@ -0,0 +1,188 @@
+package functions
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// maxSampleDepth bounds the nesting of the sampled values, e.g. for recursive schemas.
+const maxSampleDepth = 8
+
+// sampleFormats are the values of the string formats.
+var sampleFormats = map[string]string{
+	"date-time": "2024-01-01T00:00:00Z",
+	"date":      "2024-01-01",
+	"time":      "00:00:00Z",
+	"email":     "user@example.com",
+	"uuid":      "00000000-0000-0000-0000-000000000000",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "127.0.0.1",
+	"ipv6":      "::1",
+}
+
+// SampleArguments returns arguments valid against the input schema, with only the
+// required properties. Their values are taken from the const, default, examples and
+// enum of the schema, or made up from the type and format.
+// The schema may be a *Schema, a map, JSON or anything encoded as a JSON object,
+// e.g. the mcp.ToolInputSchema of a listed tool.
+// It is meant to call the tools in tests and smoke checks.
+func SampleArguments(schema any) map[string]any {
+	// Round trip through JSON so that nested *Schema values are maps as well
+	var data []byte
+	switch s := schema.(type) {
+	case json.RawMessage:
+		data = s
+	case []byte:
+		data = s
+	default:
+		var err error
+		if data, err = json.Marshal(schema); err != nil {
+			return map[string]any{}
+		}
+	}
+	var root map[string]any
+	_ = json.Unmarshal(data, &root)
+	args, _ := sampleValue(root, root, 0).(map[string]any)
+	if args == nil {
+		args = map[string]any{}
+	}
+	return args
+}
+
+func sampleValue(schema, root map[string]any, depth int) any {
+	if schema == nil || depth > maxSampleDepth {
+		return nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return sampleValue(resolveLocalRef(root, ref), root, depth+1)
+	}
+	if v, ok := schema["const"]; ok {
+		return v
+	}
+	if v, ok := schema["default"]; ok {
+		return v
+	}
+	if examples, ok := schema["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+	if v, ok := schema["example"]; ok {
+		return v
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if schemas, ok := schema[key].([]any); ok && len(schemas) > 0 {
+			sub, _ := schemas[0].(map[string]any)
+			return sampleValue(sub, root, depth+1)
+		}
+	}
+	if schemas, ok := schema["allOf"].([]any); ok && len(schemas) > 0 {
+		merged := map[string]any{}
+		for _, s := range schemas {
+			sub, _ := s.(map[string]any)
+			obj, ok := sampleValue(sub, root, depth+1).(map[string]any)
+			if !ok {
+				return sampleValue(sub, root, depth+1)
+			}
+			for k, v := range obj {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		obj := map[string]any{}
+		properties, _ := schema["properties"].(map[string]any)
+		for _, name := range requiredNames(schema["required"]) {
+			prop, _ := properties[name].(map[string]any)
+			obj[name] = sampleValue(prop, root, depth+1)
+		}
+		return obj
+	case "array":
+		items := []any{}
+		if minItems, ok := toFloat(schema["minItems"]); ok {
+			item, _ := schema["items"].(map[string]any)
+			for range int(minItems) {
+				items = append(items, sampleValue(item, root, depth+1))
+			}
+		}
+		return items
+	case "integer", "number":
+		if v, ok := schema["minimum"]; ok {
+			return v
+		}
+		return 1
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	}
+	format, _ := schema["format"].(string)
+	if v, ok := sampleFormats[format]; ok {
+		return v
+	}
+	s := "string"
+	if minLength, ok := toFloat(schema["minLength"]); ok && int(minLength) > len(s) {
+		s += strings.Repeat("x", int(minLength)-len(s))
+	}
+	return s
+}
+
+// schemaType returns the first non-null type of the schema, guessing object from
+// the properties.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// requiredNames returns the names of the required keyword, decoded from JSON or
+// built by the schema generator.
+func requiredNames(v any) []string {
+	if names, ok := v.([]string); ok {
+		return names
+	}
+	required, _ := v.([]any)
+	names := make([]string, 0, len(required))
+	for _, name := range required {
+		if s, ok := name.(string); ok {
+			names = append(names, s)
+		}
+	}
+	return names
+}
+
+// resolveLocalRef returns the schema referenced by "#/..." in root.
+func resolveLocalRef(root map[string]any, ref string) map[string]any {
+	path, ok := strings.CutPrefix(ref, "#/")
+	if !ok {
+		return nil
+	}
+	var node any = root
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = m[segment]
+	}
+	schema, _ := node.(map[string]any)
+	return schema
+}
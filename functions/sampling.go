@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// DefaultSummarizeMinBytes is the size above which Summarize summarizes the results by default.
+const DefaultSummarizeMinBytes = 16 * 1024
+
+// DefaultSamplingMaxTokens is the maximum number of tokens of the sampled answers by default.
+const DefaultSamplingMaxTokens = 1024
+
+// summarySystemPrompt is the instructions given to the model of the client by default.
+const summarySystemPrompt = "You summarize API responses for another assistant. Keep the identifiers, names, counts and values needed to answer questions about the response, and say what was left out. Answer with the summary only."
+
+// summaryNotice tells the model the result is not the response itself.
+const summaryNotice = "The response was summarized by the model of the client. Call the tool again with narrower parameters to get exact values."
+
+// ErrSamplingUnavailable is returned when the client of the tool call does not support
+// sampling, e.g. because it did not declare the sampling capability or its transport
+// cannot receive requests from the server (SSE).
+var ErrSamplingUnavailable = errors.New("the client does not support sampling")
+
+// Sample asks the model of the client of the tool call to answer the prompt, and
+// returns the text of the answer. The client may ask the user to approve the request.
+// maxTokens of 0 or less is DefaultSamplingMaxTokens.
+func Sample(ctx context.Context, systemPrompt, prompt string, maxTokens int) (string, error) {
+	srv := server.ServerFromContext(ctx)
+	session := server.ClientSessionFromContext(ctx)
+	if srv == nil || session == nil {
+		return "", ErrSamplingUnavailable
+	}
+	// Clients without the capability may never answer the request
+	if info, ok := session.(server.SessionWithClientInfo); ok && info.GetClientCapabilities().Sampling == nil {
+		return "", ErrSamplingUnavailable
+	}
+	if maxTokens <= 0 {
+		maxTokens = DefaultSamplingMaxTokens
+	}
+	res, err := srv.RequestSampling(ctx, mcp.CreateMessageRequest{
+		CreateMessageParams: mcp.CreateMessageParams{
+			Messages: []mcp.SamplingMessage{
+				{Role: mcp.RoleUser, Content: mcp.NewTextContent(prompt)},
+			},
+			SystemPrompt: systemPrompt,
+			MaxTokens:    maxTokens,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("sampling: %w", err)
+	}
+	text, ok := samplingText(res.Content)
+	if !ok {
+		return "", fmt.Errorf("sampling: the client returned %T instead of text", res.Content)
+	}
+	return text, nil
+}
+
+// samplingText returns the text of the content of a sampling result, which is
+// decoded as a map by the transports.
+func samplingText(content any) (string, bool) {
+	switch v := content.(type) {
+	case mcp.TextContent:
+		return v.Text, true
+	case *mcp.TextContent:
+		return v.Text, true
+	case map[string]any:
+		if parsed, err := mcp.ParseContent(v); err == nil {
+			return samplingText(parsed)
+		}
+	}
+	return "", false
+}
+
+// SummarizeOptions configures the summaries of Summarize.
+type SummarizeOptions struct {
+	// MinBytes is the size of the encoded result above which it is summarized.
+	// 0 means DefaultSummarizeMinBytes.
+	MinBytes int
+	// MaxInputBytes truncates the result sent to the client before summarizing it.
+	// 0 means DefaultMaxResultSize.
+	MaxInputBytes int
+	// MaxTokens is the maximum number of tokens of the summary. 0 means DefaultSamplingMaxTokens.
+	MaxTokens int
+	// Instructions are added to the prompt, e.g. the fields that matter.
+	Instructions string
+}
+
+// SummarizedResult is returned instead of a result summarized by the model of the client.
+type SummarizedResult struct {
+	Summary    string `json:"summary"`
+	Summarized bool   `json:"summarized"`
+	// OriginalSize is the size of the whole result in bytes
+	OriginalSize int    `json:"originalSize"`
+	Notice       string `json:"notice"`
+}
+
+// Summarize returns a middleware asking the model of the client, through MCP sampling,
+// to summarize the results larger than opts.MinBytes before returning them. Results are
+// returned as is when the client does not support sampling or the sampling fails.
+func Summarize(opts SummarizeOptions) Middleware {
+	if opts.MinBytes <= 0 {
+		opts.MinBytes = DefaultSummarizeMinBytes
+	}
+	if opts.MaxInputBytes <= 0 {
+		opts.MaxInputBytes = DefaultMaxResultSize
+	}
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil {
+				return res, err
+			}
+			var data []byte
+			switch v := res.(type) {
+			case nil, *ToolResult, ToolResult, *mcp.CallToolResult, mcp.CallToolResult, []mcp.Content, mcp.Content:
+				return res, nil
+			case string:
+				data = []byte(v)
+			default:
+				if data, err = json.Marshal(res); err != nil {
+					return res, nil
+				}
+			}
+			if len(data) <= opts.MinBytes {
+				return res, nil
+			}
+			input := data
+			if len(input) > opts.MaxInputBytes {
+				if json.Valid(input) {
+					input = truncateJSON(input, opts.MaxInputBytes)
+				} else {
+					input = []byte(truncateString(string(input), opts.MaxInputBytes))
+				}
+			}
+			prompt := fmt.Sprintf("Summarize the response of the tool %q.", tool.Name())
+			if opts.Instructions != "" {
+				prompt += " " + opts.Instructions
+			}
+			prompt += "\n\nResponse:\n" + string(input)
+			summary, sampleErr := Sample(ctx, summarySystemPrompt, prompt, opts.MaxTokens)
+			if sampleErr != nil {
+				return res, nil
+			}
+			return &SummarizedResult{
+				Summary:      summary,
+				Summarized:   true,
+				OriginalSize: len(data),
+				Notice:       summaryNotice,
+			}, nil
+		}
+	}
+}
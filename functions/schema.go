@@ -0,0 +1,147 @@
+package functions
+
+import (
+	"encoding/json"
+	"maps"
+)
+
+// Map returns the schema as a JSON Schema (2020-12) object, omitting unset keywords.
+func (s *Schema) Map() map[string]any {
+	m := make(map[string]any)
+	maps.Copy(m, s.Extra)
+	if s.Ref != "" {
+		m["$ref"] = s.Ref
+	}
+	if len(s.Defs) > 0 {
+		m["$defs"] = s.Defs
+	}
+	if s.Type != "" {
+		m["type"] = s.Type
+	}
+	if s.Title != "" {
+		m["title"] = s.Title
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if s.Properties != nil {
+		m["properties"] = s.Properties
+	}
+	if len(s.Required) > 0 {
+		m["required"] = s.Required
+	}
+	if s.Items != nil {
+		m["items"] = s.Items
+	}
+	if s.AdditionalProperties != nil {
+		m["additionalProperties"] = s.AdditionalProperties
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = s.Enum
+	}
+	if s.Default != nil {
+		m["default"] = s.Default
+	}
+	if len(s.OneOf) > 0 {
+		m["oneOf"] = s.OneOf
+	}
+	if len(s.AnyOf) > 0 {
+		m["anyOf"] = s.AnyOf
+	}
+	if len(s.AllOf) > 0 {
+		m["allOf"] = s.AllOf
+	}
+	return m
+}
+
+// MarshalJSON encodes the schema as a JSON Schema (2020-12) document.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Map())
+}
+
+// UnmarshalJSON decodes a JSON Schema document. Unknown keywords are kept in Extra.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	*s = schemaFromMap(m)
+	return nil
+}
+
+// MarshalYAML encodes the schema as a YAML mapping.
+func (s Schema) MarshalYAML() (any, error) {
+	return s.Map(), nil
+}
+
+// UnmarshalYAML decodes a YAML mapping. Unknown keywords are kept in Extra.
+func (s *Schema) UnmarshalYAML(unmarshal func(any) error) error {
+	var m map[string]any
+	if err := unmarshal(&m); err != nil {
+		return err
+	}
+	*s = schemaFromMap(m)
+	return nil
+}
+
+func schemaFromMap(m map[string]any) Schema {
+	var s Schema
+	for key, value := range m {
+		switch key {
+		case "$ref":
+			s.Ref, _ = value.(string)
+		case "$defs":
+			s.Defs, _ = value.(map[string]any)
+		case "type":
+			s.Type, _ = value.(string)
+		case "title":
+			s.Title, _ = value.(string)
+		case "description":
+			s.Description, _ = value.(string)
+		case "format":
+			s.Format, _ = value.(string)
+		case "properties":
+			s.Properties, _ = value.(map[string]any)
+		case "required":
+			values, _ := value.([]any)
+			for _, v := range values {
+				if name, ok := v.(string); ok {
+					s.Required = append(s.Required, name)
+				}
+			}
+		case "items":
+			s.Items = value
+		case "additionalProperties":
+			s.AdditionalProperties = value
+		case "enum":
+			s.Enum, _ = value.([]any)
+		case "default":
+			s.Default = value
+		case "oneOf":
+			s.OneOf, _ = value.([]any)
+		case "anyOf":
+			s.AnyOf, _ = value.([]any)
+		case "allOf":
+			s.AllOf, _ = value.([]any)
+		default:
+			if s.Extra == nil {
+				s.Extra = map[string]any{}
+			}
+			s.Extra[key] = value
+		}
+	}
+	return s
+}
+
+// MustParseSchema decodes a JSON Schema document and panics when it is invalid.
+// It is intended for schemas embedded in generated code.
+func MustParseSchema(data string) *Schema {
+	var s Schema
+	if err := json.Unmarshal([]byte(data), &s); err != nil {
+		panic("functions: invalid schema: " + err.Error())
+	}
+	return &s
+}
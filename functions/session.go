@@ -0,0 +1,128 @@
+package functions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// sessionIDPrefix is the prefix of the generated MCP session ids.
+const sessionIDPrefix = "mcp-session-"
+
+// DefaultSessionTTL is the time the sessions are kept without requests by default.
+const DefaultSessionTTL = 24 * time.Hour
+
+// ErrSessionNotFound is returned when a session is unknown or expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore keeps the MCP sessions of the streamable HTTP transport outside of the
+// process, so that the requests of a session can be served by any replica of the server.
+type SessionStore interface {
+	// Create registers the new session.
+	Create(ctx context.Context, id string) error
+	// Touch reports whether the session was terminated and extends its lifetime.
+	// It returns ErrSessionNotFound for unknown or expired sessions.
+	Touch(ctx context.Context, id string) (terminated bool, err error)
+	// Terminate marks the session as terminated.
+	Terminate(ctx context.Context, id string) error
+}
+
+// SessionIDManager returns the session id manager of the streamable HTTP transport
+// keeping the sessions in the store.
+func SessionIDManager(store SessionStore) server.SessionIdManager {
+	return &sessionIDManager{store: store}
+}
+
+type sessionIDManager struct {
+	store SessionStore
+}
+
+func (m *sessionIDManager) Generate() string {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	id := sessionIDPrefix + hex.EncodeToString(buf[:])
+	// A session failing to be stored is rejected by Validate on the next request
+	_ = m.store.Create(context.Background(), id)
+	return id
+}
+
+func (m *sessionIDManager) Validate(sessionID string) (bool, error) {
+	if !strings.HasPrefix(sessionID, sessionIDPrefix) {
+		return false, fmt.Errorf("invalid session id: %q", sessionID)
+	}
+	return m.store.Touch(context.Background(), sessionID)
+}
+
+func (m *sessionIDManager) Terminate(sessionID string) (bool, error) {
+	if _, err := m.Validate(sessionID); err != nil {
+		return false, err
+	}
+	return false, m.store.Terminate(context.Background(), sessionID)
+}
+
+// MemorySessionStore keeps the sessions in memory. It is meant for a single replica
+// and for tests.
+type MemorySessionStore struct {
+	ttl      time.Duration
+	mu       sync.Mutex
+	sessions map[string]*memorySession
+}
+
+type memorySession struct {
+	terminated bool
+	expires    time.Time
+}
+
+// NewMemorySessionStore returns a store forgetting the sessions after ttl without
+// requests. A ttl of zero or less is DefaultSessionTTL.
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &MemorySessionStore{ttl: ttl, sessions: map[string]*memorySession{}}
+}
+
+func (s *MemorySessionStore) Create(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, session := range s.sessions {
+		if now.After(session.expires) {
+			delete(s.sessions, id)
+		}
+	}
+	s.sessions[id] = &memorySession{expires: now.Add(s.ttl)}
+	return nil
+}
+
+func (s *MemorySessionStore) Touch(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || time.Now().After(session.expires) {
+		return false, ErrSessionNotFound
+	}
+	session.expires = time.Now().Add(s.ttl)
+	return session.terminated, nil
+}
+
+func (s *MemorySessionStore) Terminate(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	session.terminated = true
+	return nil
+}
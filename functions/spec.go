@@ -0,0 +1,44 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// SpecResourceURI is the URI of the MCP resource of the OpenAPI document.
+const SpecResourceURI = "oas-mcp://openapi.json"
+
+// SpecPath is the HTTP path serving the OpenAPI document.
+const SpecPath = "/openapi.json"
+
+// SpecHandler serves the OpenAPI document, encoded as JSON, to GET and HEAD requests.
+func SpecHandler(spec []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeContent(w, r, SpecPath, time.Time{}, bytes.NewReader(spec))
+	})
+}
+
+// BindSpec registers the OpenAPI document as the MCP resource SpecResourceURI, so
+// that the clients can inspect the spec the server implements.
+func BindSpec(srv *server.MCPServer, spec []byte) {
+	resource := mcp.NewResource(SpecResourceURI, "OpenAPI document",
+		mcp.WithResourceDescription("The OpenAPI document the tools were generated from"),
+		mcp.WithMIMEType("application/json"),
+	)
+	srv.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: SpecResourceURI, MIMEType: "application/json", Text: string(spec)},
+		}, nil
+	})
+}
@@ -0,0 +1,44 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+)
+
+// Emitter sends a partial result of a long-running tool.
+// A function receives it by declaring a parameter of type Emitter, e.g.
+// func(ctx context.Context, input Input, emit functions.Emitter) (any, error).
+type Emitter func(chunk any)
+
+var emitterType = reflect.TypeOf(Emitter(nil))
+
+type emitterKey struct{}
+
+// WithEmitter returns a context carrying the emitter passed to tool functions.
+func WithEmitter(ctx context.Context, emit Emitter) context.Context {
+	return context.WithValue(ctx, emitterKey{}, emit)
+}
+
+// emitterFromContext returns the emitter of the context, or one discarding every chunk.
+func emitterFromContext(ctx context.Context) Emitter {
+	if emit, ok := ctx.Value(emitterKey{}).(Emitter); ok && emit != nil {
+		return emit
+	}
+	return func(any) {}
+}
+
+// emitter returns an emitter sending every chunk as the message of a progress notification.
+func (n *progressNotifier) emitter() Emitter {
+	return func(chunk any) {
+		message, ok := chunk.(string)
+		if !ok {
+			buf, err := json.Marshal(chunk)
+			if err != nil {
+				return
+			}
+			message = string(buf)
+		}
+		_ = n.Report(0, 0, message)
+	}
+}
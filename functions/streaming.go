@@ -0,0 +1,279 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// StreamOptions bounds the memory used to decode the responses of the upstream API.
+// The response is decoded incrementally, so items and fields that are dropped are
+// never held in memory.
+type StreamOptions struct {
+	// MaxBytes stops reading the response body after this many bytes. 0 means no limit.
+	MaxBytes int64
+	// MaxItems keeps at most this many items of every array. 0 means no limit.
+	MaxItems int
+	// Fields keeps only these fields of the top-level object, or of the objects
+	// in a top-level array. Empty keeps every field.
+	Fields []string
+}
+
+// ErrResponseTooLarge is returned when a response body is read beyond the MaxBytes
+// of the StreamOptions.
+var ErrResponseTooLarge = errors.New("response too large")
+
+// StreamedResult is returned instead of the decoded response when it was truncated.
+type StreamedResult struct {
+	Data      any    `json:"data"`
+	Truncated bool   `json:"truncated"`
+	Reason    string `json:"reason"`
+}
+
+type streamOptionsKey struct{}
+
+// WithStreamOptions returns a context decoding the responses with the options.
+func WithStreamOptions(ctx context.Context, opts StreamOptions) context.Context {
+	return context.WithValue(ctx, streamOptionsKey{}, opts)
+}
+
+func streamOptionsFromContext(ctx context.Context) (StreamOptions, bool) {
+	opts, ok := ctx.Value(streamOptionsKey{}).(StreamOptions)
+	return opts, ok
+}
+
+// StreamResponses returns a middleware decoding the responses of the tool
+// incrementally with the options. It applies to the responses decoded with
+// DecodeHTTPResponse, i.e. HTTPOperation and the oapi-codegen client.
+// JSON results decoded otherwise, e.g. by the ogen client, are truncated and
+// projected the same way after the call. As such clients read the whole body
+// first, MaxBytes bounds them only through LimitResponses, failing the call
+// with ErrResponseTooLarge instead of returning a truncated result.
+func StreamResponses(opts StreamOptions) Middleware {
+	return func(_ MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(WithStreamOptions(ctx, opts), params)
+			if err != nil {
+				return res, err
+			}
+			switch v := res.(type) {
+			case string:
+				if json.Valid([]byte(v)) {
+					return decodeStream(strings.NewReader(v), "application/json", opts)
+				}
+			case json.RawMessage:
+				return decodeStream(bytes.NewReader(v), "application/json", opts)
+			}
+			return res, nil
+		}
+	}
+}
+
+// decodeStream decodes the body incrementally with the options.
+func decodeStream(body io.Reader, contentType string, opts StreamOptions) (any, error) {
+	limited := &limitedReader{r: body, n: opts.MaxBytes}
+	if !strings.Contains(contentType, "json") {
+		buf, err := io.ReadAll(limited)
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) == 0 {
+			return nil, nil
+		}
+		if limited.exceeded {
+			return &StreamedResult{Data: string(buf), Truncated: true, Reason: fmt.Sprintf("response exceeds %d bytes", opts.MaxBytes)}, nil
+		}
+		return string(buf), nil
+	}
+
+	d := &streamDecoder{dec: json.NewDecoder(limited), opts: opts}
+	d.dec.UseNumber()
+	v, err := d.value(0, true)
+	if errors.Is(err, io.EOF) && v == nil && !limited.exceeded {
+		// Empty body
+		return nil, nil
+	}
+	if err != nil && !limited.exceeded {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	switch {
+	case limited.exceeded:
+		return &StreamedResult{Data: v, Truncated: true, Reason: fmt.Sprintf("response exceeds %d bytes", opts.MaxBytes)}, nil
+	case d.truncated:
+		return &StreamedResult{Data: v, Truncated: true, Reason: fmt.Sprintf("arrays are limited to %d items", opts.MaxItems)}, nil
+	}
+	return v, nil
+}
+
+type streamDecoder struct {
+	dec       *json.Decoder
+	opts      StreamOptions
+	truncated bool
+}
+
+// value decodes the next value. project filters the fields of an object value.
+// On error, the part decoded so far is returned.
+func (d *streamDecoder) value(depth int, project bool) (any, error) {
+	tok, err := d.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '[':
+		items := []any{}
+		for d.dec.More() {
+			if d.opts.MaxItems > 0 && len(items) >= d.opts.MaxItems {
+				d.truncated = true
+				if err := d.skip(); err != nil {
+					return items, err
+				}
+				continue
+			}
+			// The items of a top-level array are projected
+			item, err := d.value(depth+1, project && depth == 0)
+			if item != nil || err == nil {
+				items = append(items, item)
+			}
+			if err != nil {
+				return items, err
+			}
+		}
+		_, err := d.dec.Token()
+		return items, err
+	case '{':
+		obj := map[string]any{}
+		for d.dec.More() {
+			tok, err := d.dec.Token()
+			if err != nil {
+				return obj, err
+			}
+			key, _ := tok.(string)
+			if project && len(d.opts.Fields) > 0 && !slices.Contains(d.opts.Fields, key) {
+				if err := d.skip(); err != nil {
+					return obj, err
+				}
+				continue
+			}
+			v, err := d.value(depth+1, false)
+			if v != nil || err == nil {
+				obj[key] = v
+			}
+			if err != nil {
+				return obj, err
+			}
+		}
+		_, err := d.dec.Token()
+		return obj, err
+	}
+	return nil, fmt.Errorf("unexpected delimiter %v", delim)
+}
+
+// skip discards the next value without keeping it in memory.
+func (d *streamDecoder) skip() error {
+	depth := 0
+	for {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// limitedReader reads at most n bytes (no limit when n <= 0) and records
+// whether the underlying reader had more.
+// LimitResponses returns a HTTPDoer enforcing the MaxBytes of the StreamOptions of
+// the request context on the bodies of the successful responses, whichever client
+// decodes them. Reading the body beyond the limit fails with ErrResponseTooLarge;
+// DecodeHTTPResponse returns the part read so far as a StreamedResult instead.
+func LimitResponses(doer HTTPDoer) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &limitDoer{doer: doer}
+}
+
+type limitDoer struct {
+	doer HTTPDoer
+}
+
+func (d *limitDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.doer.Do(req)
+	if err != nil || res.StatusCode >= 300 {
+		return res, err
+	}
+	if opts, ok := streamOptionsFromContext(req.Context()); ok && opts.MaxBytes > 0 {
+		res.Body = &maxBytesBody{ReadCloser: res.Body, limit: opts.MaxBytes, remaining: opts.MaxBytes}
+	}
+	return res, nil
+}
+
+// maxBytesBody fails with ErrResponseTooLarge when the body continues beyond the limit.
+type maxBytesBody struct {
+	io.ReadCloser
+	limit     int64
+	remaining int64
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		var one [1]byte
+		if n, err := b.ReadCloser.Read(one[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: the response exceeds %d bytes", ErrResponseTooLarge, b.limit)
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, err
+}
+
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	read     int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		return l.r.Read(p)
+	}
+	if l.read >= l.n {
+		// Check whether the body continues beyond the limit
+		var one [1]byte
+		if n, err := l.r.Read(one[:]); n > 0 || errors.Is(err, ErrResponseTooLarge) {
+			l.exceeded = true
+		}
+		return 0, io.EOF
+	}
+	if remaining := l.n - l.read; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	return n, err
+}
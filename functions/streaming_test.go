@@ -0,0 +1,73 @@
+package functions
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitResponses(t *testing.T) {
+	body := `[` + strings.Repeat(`{"id":1},`, 100) + `{"id":1}]`
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	defer upstream.Close()
+	doer := LimitResponses(upstream.Client())
+
+	request := func(opts *StreamOptions) *http.Request {
+		ctx := context.Background()
+		if opts != nil {
+			ctx = WithStreamOptions(ctx, *opts)
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	t.Run("buffering client fails", func(t *testing.T) {
+		res, err := doer.Do(request(&StreamOptions{MaxBytes: 64}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		buf, err := io.ReadAll(res.Body)
+		if !errors.Is(err, ErrResponseTooLarge) {
+			t.Errorf("ReadAll() error = %v, want ErrResponseTooLarge", err)
+		}
+		if len(buf) != 64 {
+			t.Errorf("read %d bytes, want 64", len(buf))
+		}
+	})
+
+	t.Run("streaming decoder truncates", func(t *testing.T) {
+		res, err := DecodeHTTPResponse(doer.Do(request(&StreamOptions{MaxBytes: 64})))
+		if err != nil {
+			t.Fatal(err)
+		}
+		streamed, ok := res.(*StreamedResult)
+		if !ok || !streamed.Truncated {
+			t.Fatalf("DecodeHTTPResponse() = %#v, want a truncated StreamedResult", res)
+		}
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		for _, opts := range []*StreamOptions{nil, {MaxBytes: int64(len(body))}} {
+			res, err := doer.Do(request(opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf, err := io.ReadAll(res.Body)
+			res.Body.Close()
+			if err != nil || string(buf) != body {
+				t.Errorf("ReadAll() = %d bytes, %v, want the whole body", len(buf), err)
+			}
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// StripEmpty returns a middleware removing the fields whose value is null, an empty
+// string or an empty array from the JSON results of the tool, at any depth.
+// The order of the remaining fields is kept. Non JSON results are returned as is.
+func StripEmpty() Middleware {
+	return func(_ MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil {
+				return res, err
+			}
+			return transformJSONResult(res, stripEmptyJSON)
+		}
+	}
+}
+
+func stripEmptyJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := stripValue(dec, &buf); err != nil {
+		return nil, fmt.Errorf("strip empty fields: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// stripValue re-encodes the next value of dec into buf without the empty fields.
+func stripValue(dec *json.Decoder, buf *bytes.Buffer) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		encoded, err := json.Marshal(tok)
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+		return nil
+	}
+	switch delim {
+	case '{':
+		buf.WriteByte('{')
+		first := true
+		var value bytes.Buffer
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			value.Reset()
+			if err := stripValue(dec, &value); err != nil {
+				return err
+			}
+			if isEmptyJSON(value.Bytes()) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			key, _ := json.Marshal(tok)
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value.Bytes())
+		}
+		buf.WriteByte('}')
+	case '[':
+		buf.WriteByte('[')
+		for i := 0; dec.More(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := stripValue(dec, buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	}
+	// Consume the closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+func isEmptyJSON(value []byte) bool {
+	switch string(value) {
+	case "null", `""`, "[]":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,157 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts tried, in order, to parse the date-time strings.
+// Values without a time zone are taken as UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05Z0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+	time.RFC850,
+	time.ANSIC,
+	time.UnixDate,
+	time.RFC822Z,
+	time.RFC822,
+	"20060102T150405Z0700",
+	"20060102",
+	"2006-01-02",
+}
+
+// WithTimeFields sets the date-time fields of the results of the tool, normalized
+// by NormalizeTimes. Each path is a dot separated list of fields; arrays on the way
+// are traversed, e.g. "items.createdAt".
+func (tool *Tool) WithTimeFields(paths ...string) *Tool {
+	tool.timeFields = append(tool.timeFields, paths...)
+	return tool
+}
+
+// TimeFields returns the date-time fields of the results of the tool.
+func (tool *Tool) TimeFields() []string {
+	return tool.timeFields
+}
+
+// NormalizeTimes returns a middleware converting the date-time fields of the JSON
+// results, set with WithTimeFields, to RFC 3339 strings in UTC. Epoch seconds,
+// milliseconds, microseconds and nanoseconds, common date-time strings and the
+// given layouts are recognized; unrecognized values are kept as is.
+func NormalizeTimes(layouts ...string) Middleware {
+	layouts = append(append([]string(nil), layouts...), timeLayouts...)
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		var paths []string
+		if t, ok := tool.(*Tool); ok {
+			paths = t.timeFields
+		}
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			res, err := next(ctx, params)
+			if err != nil || len(paths) == 0 {
+				return res, err
+			}
+			return transformJSONResult(res, func(data []byte) ([]byte, error) {
+				return normalizeTimesJSON(data, paths, layouts)
+			})
+		}
+	}
+}
+
+func normalizeTimesJSON(data []byte, paths, layouts []string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("normalize times: %w", err)
+	}
+	for _, path := range paths {
+		if path = strings.Trim(path, "."); path != "" {
+			normalizeTimePath(v, strings.Split(path, "."), layouts)
+		}
+	}
+	return json.Marshal(v)
+}
+
+// normalizeTimePath normalizes the value at the last field of path.
+func normalizeTimePath(v any, path []string, layouts []string) {
+	switch node := v.(type) {
+	case []any:
+		for _, item := range node {
+			normalizeTimePath(item, path, layouts)
+		}
+	case map[string]any:
+		value, ok := node[path[0]]
+		if !ok {
+			return
+		}
+		if len(path) > 1 {
+			normalizeTimePath(value, path[1:], layouts)
+			return
+		}
+		if items, ok := value.([]any); ok {
+			for i, item := range items {
+				if t, ok := parseTime(item, layouts); ok {
+					items[i] = t.Format(time.RFC3339Nano)
+				}
+			}
+			return
+		}
+		if t, ok := parseTime(value, layouts); ok {
+			node[path[0]] = t.Format(time.RFC3339Nano)
+		}
+	}
+}
+
+// parseTime parses an epoch number or a date-time string.
+func parseTime(v any, layouts []string) (time.Time, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		return parseEpoch(string(v))
+	case string:
+		s := strings.TrimSpace(v)
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC(), true
+			}
+		}
+		return parseEpoch(s)
+	}
+	return time.Time{}, false
+}
+
+// parseEpoch parses a Unix time, guessing the unit from its magnitude: values below
+// 1e11 are seconds (until year 5138), then milliseconds, microseconds and nanoseconds.
+func parseEpoch(s string) (time.Time, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// Integers are converted exactly
+		switch {
+		case n < 0:
+			return time.Time{}, false
+		case n < 1e11:
+			return time.Unix(n, 0).UTC(), true
+		case n < 1e14:
+			return time.UnixMilli(n).UTC(), true
+		case n < 1e17:
+			return time.UnixMicro(n).UTC(), true
+		}
+		return time.Unix(0, n).UTC(), true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || f < 0 || f >= 1e11 {
+		return time.Time{}, false
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+}
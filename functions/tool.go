@@ -1,9 +1,12 @@
 package functions
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"slices"
 	"strconv"
@@ -19,7 +22,7 @@ func NewFunctionTool(name, description string, fn any) *Tool {
 		panic("function tool must be a function")
 	}
 
-	schema := generateSchemaFromFunction(fnType)
+	schema := cachedSchemaFromFunction(fnType)
 
 	return &Tool{
 		name:        name,
@@ -37,17 +40,62 @@ func (tool *Tool) Description() string {
 	return tool.description
 }
 
+// Tags returns the tags used to group the tool.
+func (tool *Tool) Tags() []string {
+	return tool.tags
+}
+
+// WithTags sets the tags used to group the tool, e.g. the OpenAPI operation tags.
+func (tool *Tool) WithTags(tags ...string) *Tool {
+	tool.tags = tags
+	return tool
+}
+
+// OperationID returns the id of the API operation called by the tool, or "".
+func (tool *Tool) OperationID() string {
+	return tool.operationID
+}
+
+// WithOperationID sets the id of the API operation called by the tool.
+func (tool *Tool) WithOperationID(id string) *Tool {
+	tool.operationID = id
+	return tool
+}
+
 func (tool *Tool) SetFunction(fn Function) *Tool {
 	tool.function = fn
 	return tool
 }
 
-func (t *Tool) Execute(ctx context.Context, params map[string]any) (any, error) {
+func (t *Tool) Execute(ctx context.Context, params map[string]any) (res any, err error) {
+	ctx = WithToolName(ctx, t.name)
+	ctx = withTool(ctx, t)
+	finish := t.observe(ctx, params)
+	defer func() { finish(res, err) }()
+	// Do not crash the server when the function or a middleware panics
+	defer recoverPanic(&err)
+	if err := t.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return t.chain(t.execute)(ctx, params)
+}
+
+func (t *Tool) execute(ctx context.Context, params map[string]any) (any, error) {
+	// Keep the error response body for the hints of the upstream validation errors
+	ctx, errBody := withErrorBody(ctx)
+	params = t.applyDefaults(params)
+	ctx = withCallParams(ctx, params)
+	if t.validate {
+		if err := t.Validate(params); err != nil {
+			return nil, err
+		}
+	}
+
 	fnType := reflect.TypeOf(t.function)
 	fnValue := reflect.ValueOf(t.function)
 
 	// Check if the function accepts a context as the first parameter
-	hasContext := fnType.NumIn() > 0 && fnType.In(0).Implements(reflect.TypeOf((*context.Context)(nil)).Elem())
+	hasContext := cachedFunctionInfo(fnType).hasContext
 
 	// Prepare arguments
 	args := make([]reflect.Value, fnType.NumIn())
@@ -59,56 +107,53 @@ func (t *Tool) Execute(ctx context.Context, params map[string]any) (any, error)
 		argIndex = 1
 	}
 
+	// Collect every conversion error so that all invalid arguments are reported at once
+	var fieldErrs []FieldError
+
 	// Set parameters based on function signature
 	for i := argIndex; i < fnType.NumIn(); i++ {
 		paramType := fnType.In(i)
 
+		// Inject the emitter for partial results
+		if paramType == emitterType {
+			args[i] = reflect.ValueOf(emitterFromContext(ctx))
+			continue
+		}
+
 		// If the function expects a map[string]any directly
 		if i == argIndex && paramType.Kind() == reflect.Map &&
 			paramType.Key().Kind() == reflect.String &&
 			paramType.Elem().Kind() == reflect.Interface {
-			args[i] = reflect.ValueOf(params)
+			args[i] = reflect.ValueOf(untypedNumbers(params))
+			continue
+		}
+
+		// Bind the parameter to the named top-level argument
+		if idx := i - argIndex; idx < len(t.parameterNames) && t.parameterNames[idx] != "" {
+			value, errs := namedArgument(paramType, t.parameterNames[idx], params)
+			fieldErrs = append(fieldErrs, errs...)
+			args[i] = value
 			continue
 		}
 
 		// Handle struct parameter - map params to struct fields
 		if paramType.Kind() == reflect.Struct {
-			structValue := reflect.New(paramType).Elem()
-
-			// For each field in the struct, check if we have a corresponding parameter
-			for j := 0; j < paramType.NumField(); j++ {
-				field := paramType.Field(j)
-
-				// Get the JSON tag if available
-				jsonTag := field.Tag.Get("json")
-				if jsonTag == "" {
-					jsonTag = field.Name
-				} else {
-					// Handle json tag options like `json:"name,omitempty"`
-					parts := strings.Split(jsonTag, ",")
-					jsonTag = parts[0]
-				}
-
-				// Check if we have a parameter with this name
-				if paramValue, ok := params[jsonTag]; ok {
-					// Try to set the field
-					fieldValue := structValue.Field(j)
-					if fieldValue.CanSet() {
-						// Convert the parameter value to the field type
-						convertedValue, err := convertToType(paramValue, field.Type)
-						if err != nil {
-							return nil, fmt.Errorf("failed to convert parameter %s: %w", jsonTag, err)
-						}
-
-						fieldValue.Set(reflect.ValueOf(convertedValue))
-					}
-				}
-			}
-
+			structValue, errs := populateStruct(paramType, params)
+			fieldErrs = append(fieldErrs, errs...)
 			args[i] = structValue
 			continue
 		}
 
+		// Handle pointer to struct parameter - allocate the struct and pass its address
+		if paramType.Kind() == reflect.Ptr && paramType.Elem().Kind() == reflect.Struct {
+			structValue, errs := populateStruct(paramType.Elem(), params)
+			fieldErrs = append(fieldErrs, errs...)
+			ptrValue := reflect.New(paramType.Elem())
+			ptrValue.Elem().Set(structValue)
+			args[i] = ptrValue
+			continue
+		}
+
 		// For a single parameter function with a primitive type, try to use the first parameter or a parameter with the same name
 		paramName := ""
 		// Only try to access struct fields if the parameter type is a struct
@@ -140,10 +185,14 @@ func (t *Tool) Execute(ctx context.Context, params map[string]any) (any, error)
 				// Try to convert the parameter value to the expected type
 				convertedValue, err := convertToType(paramValue, paramType)
 				if err != nil {
-					return nil, fmt.Errorf("failed to convert parameter %s: %w", paramName, err)
+					fieldErrs = append(fieldErrs, FieldError{
+						Field:   paramName,
+						Message: fmt.Sprintf("failed to convert parameter: %v", err),
+					})
+					continue
 				}
 
-				args[i] = reflect.ValueOf(convertedValue)
+				args[i] = valueOf(convertedValue, paramType)
 				continue
 			}
 		}
@@ -152,6 +201,13 @@ func (t *Tool) Execute(ctx context.Context, params map[string]any) (any, error)
 		args[i] = reflect.Zero(paramType)
 	}
 
+	if len(fieldErrs) > 0 {
+		return nil, &ValidationError{
+			Errors: fieldErrs,
+			Schema: t.schema,
+		}
+	}
+
 	// Call the function
 	results := fnValue.Call(args)
 
@@ -166,46 +222,182 @@ func (t *Tool) Execute(ctx context.Context, params map[string]any) (any, error)
 		if errVal.IsNil() {
 			return results[0].Interface(), nil
 		}
-		return results[0].Interface(), errVal.Interface().(error)
+		return results[0].Interface(), errBody.attach(errVal.Interface().(error))
 	}
 }
 
+// WithParameterNames binds the function parameters after the context to the given
+// top-level argument names, e.g. func(ctx, params PathParams, body Body) with
+// WithParameterNames("params", "body"). An empty name flattens the struct parameter
+// so that its fields are read from the top-level arguments.
+func (tool *Tool) WithParameterNames(names ...string) *Tool {
+	tool.parameterNames = names
+	tool.schema = schemaWithDefaults(generateSchemaFromParameters(reflect.TypeOf(tool.function), names), tool.propertyDefaults)
+	return tool
+}
+
+// namedArgument converts the argument with the given name to the parameter type.
+func namedArgument(paramType reflect.Type, name string, params map[string]any) (reflect.Value, []FieldError) {
+	paramValue, ok := params[name]
+	if !ok {
+		return reflect.Zero(paramType), nil
+	}
+	if structType := derefStruct(paramType); structType != nil {
+		if fields, ok := paramValue.(map[string]any); ok {
+			structValue, errs := populateStruct(structType, fields)
+			for i := range errs {
+				errs[i].Field = name + "." + errs[i].Field
+				errs[i].SchemaPointer = "#/properties/" + escapePointer(name) + strings.TrimPrefix(errs[i].SchemaPointer, "#")
+			}
+			if paramType.Kind() == reflect.Ptr {
+				return structValue.Addr(), errs
+			}
+			return structValue, errs
+		}
+	}
+	decodedValue, err := decodeValue(paramValue, paramType)
+	if err != nil {
+		return reflect.Zero(paramType), []FieldError{{
+			Field:         name,
+			Message:       fmt.Sprintf("failed to convert parameter: %v", err),
+			SchemaPointer: "#/properties/" + escapePointer(name),
+		}}
+	}
+	return decodedValue, nil
+}
+
+// populateStruct maps the params to the fields of a new struct value by their JSON names.
+func populateStruct(structType reflect.Type, params map[string]any) (reflect.Value, []FieldError) {
+	var fieldErrs []FieldError
+	structValue := reflect.New(structType).Elem()
+
+	// For each field in the struct, check if we have a corresponding parameter
+	for _, field := range cachedStructFields(structType) {
+		// Check if we have a parameter with this name
+		paramValue, ok := params[field.name]
+		if !ok {
+			continue
+		}
+		// Try to set the field
+		fieldValue := structValue.Field(field.index)
+		if !fieldValue.CanSet() {
+			continue
+		}
+		// Decode the parameter value into the field type
+		decodedValue, err := decodeValue(paramValue, field.typ)
+		if nested := (fieldErrors)(nil); errors.As(err, &nested) {
+			// Report the errors of the nested fields individually
+			for _, nestedErr := range nested {
+				nestedErr.Field = field.name + "." + nestedErr.Field
+				nestedErr.SchemaPointer = "#/properties/" + escapePointer(field.name) + strings.TrimPrefix(nestedErr.SchemaPointer, "#")
+				fieldErrs = append(fieldErrs, nestedErr)
+			}
+			continue
+		}
+		if err != nil {
+			fieldErrs = append(fieldErrs, FieldError{
+				Field:         field.name,
+				Message:       fmt.Sprintf("failed to convert parameter: %v", err),
+				SchemaPointer: "#/properties/" + escapePointer(field.name),
+			})
+			continue
+		}
+
+		fieldValue.Set(decodedValue)
+	}
+	return structValue, fieldErrs
+}
+
 func (tool *Tool) ServerTool() server.ServerTool {
 	t := mcp.Tool{
 		Name:        tool.name,
-		Description: tool.description,
+		Description: tool.fullDescription(),
 		InputSchema: mcp.ToolInputSchema{},
 	}
-	if tool.schema != nil {
-		t.InputSchema = tool.schema.MCPTool()
+	// Use the raw schemas so that no keyword is lost
+	if schema := tool.inputSchema(); schema != nil {
+		if buf, err := json.Marshal(schema); err == nil {
+			t.InputSchema = mcp.ToolInputSchema{}
+			t.RawInputSchema = buf
+		} else {
+			t.InputSchema = schema.MCPTool()
+		}
+	}
+	if schema := tool.structuredOutputSchema(); schema != nil {
+		if buf, err := json.Marshal(schema); err == nil {
+			t.RawOutputSchema = buf
+		} else {
+			t.OutputSchema = schema.MCPOutputSchema()
+		}
+	}
+	if tool.annotation != nil {
+		t.Annotations = *tool.annotation
 	}
 	return server.ServerTool{
 		Tool: t,
-		Handler: func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		Handler: func(ctx context.Context, req mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
+			ctx, meta := withResultMeta(ctx)
+			defer func() {
+				if result != nil {
+					meta.apply(result)
+				}
+			}()
+			if id := requestIDFromMeta(req); id != "" {
+				ctx = WithRequestID(ctx, id)
+			}
+			ctx = withRoots(ctx)
 			params := map[string]any{}
 			if req.Params.Arguments != nil {
 				buf, err := json.Marshal(&req.Params.Arguments)
 				if err != nil {
 					return mcp.NewToolResultError(err.Error()), nil
 				}
-				if err := json.Unmarshal(buf, &params); err != nil {
-					return mcp.NewToolResultError(err.Error()), nil
+				// Keep numbers as json.Number so that large int64 IDs are not rounded through float64
+				decoder := json.NewDecoder(bytes.NewReader(buf))
+				decoder.UseNumber()
+				if err := decoder.Decode(&params); err != nil {
+					return tool.invalidInputResult(err), nil
 				}
 			}
+			if notifier := newProgressNotifier(ctx, req); notifier != nil {
+				ctx = WithProgress(ctx, notifier)
+				ctx = WithEmitter(ctx, notifier.emitter())
+			}
 			res, err := tool.Execute(ctx, params)
 			if err != nil {
+				switch {
+				case isInvalidInput(err):
+					return tool.invalidInputResult(err), nil
+				case isUpstreamValidation(err):
+					return tool.upstreamValidationResult(err), nil
+				case isInternal(err):
+					// Failures of the server itself are not actionable for the model
+					return nil, err
+				}
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			buf, err := json.Marshal(res)
-			if err != nil {
+			if text, ok := formatResult(res, tool.outputFormat); ok {
+				result = mcp.NewToolResultText(text)
+			} else if result, err = toCallToolResult(res); err != nil {
 				return mcp.NewToolResultError(err.Error()), nil
 			}
-			return mcp.NewToolResultText(string(buf)), nil
+			if tool.outputSchema != nil && result.StructuredContent == nil && !result.IsError {
+				result.StructuredContent = structuredContent(res, tool.outputSchema)
+			}
+			return result, nil
 		},
 	}
 }
 
 func generateSchemaFromFunction(fnType reflect.Type) *Schema {
+	return generateSchemaFromParameters(fnType, nil)
+}
+
+// generateSchemaFromParameters creates the input schema of the function.
+// When names are given, each parameter after the context is exposed as the named
+// top-level property; an empty name flattens the struct fields into the top level.
+// Otherwise the fields of every struct parameter are flattened into the top level.
+func generateSchemaFromParameters(fnType reflect.Type, names []string) *Schema {
 	// Initialize schema
 	schema := &Schema{
 		Type:       "object",
@@ -214,7 +406,7 @@ func generateSchemaFromFunction(fnType reflect.Type) *Schema {
 	}
 
 	// Check if the function accepts a context as the first parameter
-	hasContext := fnType.NumIn() > 0 && fnType.In(0).Implements(reflect.TypeOf((*context.Context)(nil)).Elem())
+	hasContext := cachedFunctionInfo(fnType).hasContext
 
 	// Start from the first non-context parameter
 	startIndex := 0
@@ -227,82 +419,159 @@ func generateSchemaFromFunction(fnType reflect.Type) *Schema {
 		return schema
 	}
 
-	// Get the first parameter type after context (if any)
-	paramType := fnType.In(startIndex)
+	for i := startIndex; i < fnType.NumIn(); i++ {
+		paramType := fnType.In(i)
 
-	// If the parameter is a map[string]any, we can't infer the schema
-	if paramType.Kind() == reflect.Map &&
-		paramType.Key().Kind() == reflect.String &&
-		paramType.Elem().Kind() == reflect.Interface {
-		// Generic map, can't infer schema
-		return schema
-	}
+		// The emitter is injected by Execute and is not an input
+		if paramType == emitterType {
+			continue
+		}
 
-	// If the parameter is a struct, create a schema from its fields
-	if paramType.Kind() == reflect.Struct {
-		for i := range paramType.NumField() {
-			field := paramType.Field(i)
+		// Expose the parameter as a named property
+		if idx := i - startIndex; idx < len(names) && names[idx] != "" {
+			propSchema := getTypeSchema(paramType)
+			if structType := derefStruct(paramType); structType != nil {
+				structSchema := &Schema{
+					Type:       "object",
+					Properties: map[string]any{},
+					Required:   []string{},
+				}
+				appendStructProperties(structSchema, structType)
+				propSchema = map[string]any{
+					"type":       structSchema.Type,
+					"properties": structSchema.Properties,
+					"required":   structSchema.Required,
+				}
+			}
+			schema.Properties[names[idx]] = propSchema
+			if paramType.Kind() != reflect.Ptr {
+				schema.Required = append(schema.Required, names[idx])
+			}
+			continue
+		}
 
-			// Skip unexported fields
-			if field.PkgPath != "" {
-				continue
+		// If the parameter is a map[string]any, we can't infer the schema
+		if paramType.Kind() == reflect.Map &&
+			paramType.Key().Kind() == reflect.String &&
+			paramType.Elem().Kind() == reflect.Interface {
+			// Generic map, can't infer schema
+			if i == startIndex {
+				return schema
 			}
+			continue
+		}
 
-			// Get the field name from JSON tag or fallback to field name
-			fieldName := field.Name
-			jsonTag := field.Tag.Get("json")
-			if jsonTag != "" {
-				// Handle json tag options like `json:"name,omitempty"`
-				parts := strings.Split(jsonTag, ",")
-				fieldName = parts[0]
+		// If the parameter is a struct, create a schema from its fields
+		if structType := derefStruct(paramType); structType != nil {
+			appendStructProperties(schema, structType)
+			continue
+		}
 
-				// Skip if the field is explicitly omitted with "-"
-				if fieldName == "-" {
-					continue
-				}
+		if i == startIndex {
+			// For other parameter types, create a single property schema
+			propName := "value"
+			propSchema := getTypeSchema(paramType)
+			schema.Properties[propName] = propSchema
+			schema.Required = append(schema.Required, propName)
+		}
+	}
 
-				// Check if the field is required (not marked as omitempty)
-				isRequired := true
-				if slices.Contains(parts[1:], "omitempty") {
-					isRequired = false
-				}
+	return schema
+}
 
-				if isRequired {
-					schema.Required = append(schema.Required, fieldName)
-				}
-			} else {
-				// If no JSON tag, assume it's required
-				schema.Required = append(schema.Required, fieldName)
-			}
+// derefStruct returns the struct type of a struct or pointer to struct type, otherwise nil.
+func derefStruct(t reflect.Type) reflect.Type {
+	// A pointer to struct is described by the struct itself
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
 
-			// Get the field schema
-			fieldSchema := getTypeSchema(field.Type)
+// appendStructProperties adds the fields of the struct to the schema properties.
+func appendStructProperties(schema *Schema, structType reflect.Type) {
+	for i := range structType.NumField() {
+		field := structType.Field(i)
 
-			// Add description from doc tag if available
-			if docTag := field.Tag.Get("mcpdescription"); docTag != "" {
-				fieldSchema["description"] = docTag
+		// Skip unexported fields
+		if field.PkgPath != "" {
+			continue
+		}
+
+		// Get the field name from JSON tag or fallback to field name
+		fieldName := field.Name
+		jsonTag := field.Tag.Get("json")
+		if jsonTag != "" {
+			// Handle json tag options like `json:"name,omitempty"`
+			parts := strings.Split(jsonTag, ",")
+			if parts[0] != "" {
+				fieldName = parts[0]
 			}
 
-			// Add the field to properties
-			schema.Properties[fieldName] = fieldSchema
+			// Skip if the field is explicitly omitted with "-"
+			if fieldName == "-" {
+				continue
+			}
 		}
-	} else {
-		// For other parameter types, create a single property schema
-		propName := "value"
-		propSchema := getTypeSchema(paramType)
-		schema.Properties[propName] = propSchema
-		schema.Required = append(schema.Required, propName)
+
+		if isRequiredField(field) {
+			schema.Required = append(schema.Required, fieldName)
+		}
+
+		// Get the field schema
+		fieldSchema := getTypeSchema(field.Type)
+
+		// Add description from doc tag if available
+		if docTag := field.Tag.Get("mcpdescription"); docTag != "" {
+			fieldSchema["description"] = docTag
+		}
+
+		// Add constraints from jsonschema tag if available
+		if schemaTag := field.Tag.Get("jsonschema"); schemaTag != "" {
+			applyJSONSchemaTag(fieldSchema, schemaTag)
+		}
+
+		// Add the field to properties
+		schema.Properties[fieldName] = fieldSchema
 	}
+}
 
-	return schema
+// isRequiredField reports whether the field is required.
+// The `mcprequired:"true|false"` tag takes precedence; otherwise fields without
+// a JSON tag or not marked as omitempty are treated as required.
+func isRequiredField(field reflect.StructField) bool {
+	if tag := field.Tag.Get("mcprequired"); tag != "" {
+		if required, err := strconv.ParseBool(tag); err == nil {
+			return required
+		}
+	}
+	// ogen Opt wrappers may be omitted
+	if w, ok := ogenWrapperOf(field.Type); ok && w.optional() {
+		return false
+	}
+	jsonTag := field.Tag.Get("json")
+	if jsonTag == "" {
+		return true
+	}
+	parts := strings.Split(jsonTag, ",")
+	return !slices.Contains(parts[1:], "omitempty")
 }
 
 func getTypeSchema(t reflect.Type) map[string]any {
+	return typeSchema(t, map[reflect.Type]bool{})
+}
+
+// typeSchema returns the schema of t. Types already being described in seen are
+// recursive and described as any object instead of recursing forever.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool) map[string]any {
 	schema := make(map[string]any)
 
 	// Handle pointers
 	if t.Kind() == reflect.Ptr {
-		elemSchema := getTypeSchema(t.Elem())
+		elemSchema := typeSchema(t.Elem(), seen)
 
 		// For pointers, the field is nullable
 		if enum, ok := elemSchema["enum"]; ok {
@@ -315,6 +584,31 @@ func getTypeSchema(t reflect.Type) map[string]any {
 		return elemSchema
 	}
 
+	// ogen Opt/Nil wrappers are described by the wrapped value
+	if w, ok := ogenWrapperOf(t); ok {
+		return ogenWrapperSchema(w, seen)
+	}
+
+	// Enum types publish their allowed values
+	if values, ok := enumValues(t); ok {
+		enum := make([]any, 0, len(values))
+		for _, v := range values {
+			enum = append(enum, v)
+		}
+		schema["type"] = "string"
+		schema["enum"] = enum
+		return schema
+	}
+
+	// Types decoded from text (dates, IDs, enums) are passed as strings
+	if t.Kind() != reflect.String && reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		schema["type"] = "string"
+		if t == timeType {
+			schema["format"] = "date-time"
+		}
+		return schema
+	}
+
 	// Handle different types
 	switch t.Kind() {
 	case reflect.Bool:
@@ -332,12 +626,12 @@ func getTypeSchema(t reflect.Type) map[string]any {
 
 	case reflect.Slice, reflect.Array:
 		schema["type"] = "array"
-		schema["items"] = getTypeSchema(t.Elem())
+		schema["items"] = typeSchema(t.Elem(), seen)
 
 	case reflect.Map:
 		schema["type"] = "object"
 		if t.Key().Kind() == reflect.String {
-			schema["additionalProperties"] = getTypeSchema(t.Elem())
+			schema["additionalProperties"] = typeSchema(t.Elem(), seen)
 		} else {
 			// Non-string keyed maps are not well represented in JSON Schema
 			schema["additionalProperties"] = true
@@ -345,6 +639,11 @@ func getTypeSchema(t reflect.Type) map[string]any {
 
 	case reflect.Struct:
 		schema["type"] = "object"
+		if seen[t] {
+			return schema
+		}
+		seen[t] = true
+		defer delete(seen, t)
 		schema["properties"] = make(map[string]any)
 		schema["required"] = []string{}
 
@@ -362,35 +661,33 @@ func getTypeSchema(t reflect.Type) map[string]any {
 			if jsonTag != "" {
 				// Handle json tag options like `json:"name,omitempty"`
 				parts := strings.Split(jsonTag, ",")
-				fieldName = parts[0]
+				if parts[0] != "" {
+					fieldName = parts[0]
+				}
 
 				// Skip if the field is explicitly omitted with "-"
 				if fieldName == "-" {
 					continue
 				}
+			}
 
-				// Check if the field is required (not marked as omitempty)
-				isRequired := true
-				if slices.Contains(parts[1:], "omitempty") {
-					isRequired = false
-				}
-
-				if isRequired {
-					schema["required"] = append(schema["required"].([]string), fieldName)
-				}
-			} else {
-				// If no JSON tag, assume it's required
+			if isRequiredField(field) {
 				schema["required"] = append(schema["required"].([]string), fieldName)
 			}
 
 			// Get the field schema
-			fieldSchema := getTypeSchema(field.Type)
+			fieldSchema := typeSchema(field.Type, seen)
 
 			// Add description from doc tag if available
 			if docTag := field.Tag.Get("doc"); docTag != "" {
 				fieldSchema["description"] = docTag
 			}
 
+			// Add constraints from jsonschema tag if available
+			if schemaTag := field.Tag.Get("jsonschema"); schemaTag != "" {
+				applyJSONSchemaTag(fieldSchema, schemaTag)
+			}
+
 			// Add the field to properties
 			schema["properties"].(map[string]any)[fieldName] = fieldSchema
 		}
@@ -403,6 +700,43 @@ func getTypeSchema(t reflect.Type) map[string]any {
 	return schema
 }
 
+// isUntyped reports whether values of the type are not converted to a Go type,
+// i.e. it is an interface or a map or slice of interfaces.
+func isUntyped(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Map, reflect.Slice:
+		return t.Elem().Kind() == reflect.Interface
+	}
+	return false
+}
+
+// untypedNumbers returns the value with its json.Number values converted to float64.
+// The arguments are decoded with json.Number so that large integers convert exactly to
+// typed fields, but untyped parameters receive float64 as encoding/json decodes them.
+func untypedNumbers(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, elem := range v {
+			m[key] = untypedNumbers(elem)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, elem := range v {
+			s[i] = untypedNumbers(elem)
+		}
+		return s
+	}
+	return value
+}
+
 func convertToType(value any, targetType reflect.Type) (any, error) {
 	// Handle nil special case
 	if value == nil {
@@ -414,60 +748,146 @@ func convertToType(value any, targetType reflect.Type) (any, error) {
 
 	// If the value is already assignable to the target type, return it
 	if valueType.AssignableTo(targetType) {
+		if isUntyped(targetType) {
+			return untypedNumbers(value), nil
+		}
 		return value, nil
 	}
 
+	// Use the custom decoder of the target type for string inputs (enums, dates, custom IDs)
+	if s, ok := value.(string); ok {
+		if err := checkEnum(s, targetType); err != nil {
+			return nil, fmt.Errorf("cannot convert %v to %v: %w", value, targetType, err)
+		}
+		if converted, ok, err := unmarshalString(s, targetType); ok {
+			if err != nil {
+				return nil, fmt.Errorf("cannot convert %v to %v: %w", value, targetType, err)
+			}
+			return converted, nil
+		}
+	}
+
+	// Convert to the element type and take its address for pointer targets
+	if targetType.Kind() == reflect.Ptr {
+		converted, err := convertToType(value, targetType.Elem())
+		if err != nil {
+			return nil, err
+		}
+		ptr := reflect.New(targetType.Elem())
+		if converted != nil {
+			ptr.Elem().Set(reflect.ValueOf(converted))
+		}
+		return ptr.Interface(), nil
+	}
+
 	// Handle some common conversions
 	switch targetType.Kind() {
 	case reflect.String:
 		// Convert to string
-		return fmt.Sprintf("%v", value), nil
+		return reflect.ValueOf(fmt.Sprintf("%v", value)).Convert(targetType).Interface(), nil
 
 	case reflect.Bool:
 		// Try to convert to bool
+		var b bool
 		switch v := value.(type) {
 		case bool:
-			return v, nil
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
-			return reflect.ValueOf(v).Int() != 0, nil
+			b = v
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+			f, ok := toFloat(v)
+			if !ok {
+				return false, fmt.Errorf("cannot convert %v to bool", value)
+			}
+			b = f != 0
 		case string:
-			b, err := strconv.ParseBool(v)
+			parsed, err := strconv.ParseBool(v)
 			if err != nil {
 				return false, fmt.Errorf("cannot convert %v to bool: %w", value, err)
 			}
-			return b, nil
+			b = parsed
 		default:
 			return false, fmt.Errorf("cannot convert %v to bool", value)
 		}
+		return reflect.ValueOf(b).Convert(targetType).Interface(), nil
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		// Try to convert to int
 		switch v := value.(type) {
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-			intVal := reflect.ValueOf(v).Int()
-			return reflect.ValueOf(intVal).Convert(targetType).Interface(), nil
+		case int, int8, int16, int32, int64:
+			return convertInt(reflect.ValueOf(v).Int(), targetType)
+		case uint, uint8, uint16, uint32, uint64:
+			u := reflect.ValueOf(v).Uint()
+			if u > math.MaxInt64 {
+				return 0, fmt.Errorf("cannot convert %v to %v: out of range", value, targetType)
+			}
+			return convertInt(int64(u), targetType)
 		case float32, float64:
-			floatVal := reflect.ValueOf(v).Float()
-			return reflect.ValueOf(int64(floatVal)).Convert(targetType).Interface(), nil
+			return convertFloatToInt(reflect.ValueOf(v).Float(), targetType)
+		case json.Number:
+			// Parse the literal directly so that large IDs do not lose precision through float64
+			i, err := strconv.ParseInt(v.String(), 10, 64)
+			if err != nil {
+				f, ferr := v.Float64()
+				if ferr != nil {
+					return 0, fmt.Errorf("cannot convert %v to int: %w", value, err)
+				}
+				return convertFloatToInt(f, targetType)
+			}
+			return convertInt(i, targetType)
 		case string:
 			i, err := strconv.ParseInt(v, 10, 64)
 			if err != nil {
 				return 0, fmt.Errorf("cannot convert %v to int: %w", value, err)
 			}
-			return reflect.ValueOf(i).Convert(targetType).Interface(), nil
+			return convertInt(i, targetType)
 		default:
 			return 0, fmt.Errorf("cannot convert %v to int", value)
 		}
 
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// Try to convert to uint
+		switch v := value.(type) {
+		case int, int8, int16, int32, int64:
+			i := reflect.ValueOf(v).Int()
+			if i < 0 {
+				return 0, fmt.Errorf("cannot convert %v to uint", value)
+			}
+			return convertUint(uint64(i), targetType)
+		case uint, uint8, uint16, uint32, uint64:
+			return convertUint(reflect.ValueOf(v).Uint(), targetType)
+		case json.Number:
+			u, err := strconv.ParseUint(v.String(), 10, 64)
+			if err != nil {
+				f, ferr := v.Float64()
+				if ferr != nil || f < 0 {
+					return 0, fmt.Errorf("cannot convert %v to uint: %w", value, err)
+				}
+				return convertFloatToUint(f, targetType)
+			}
+			return convertUint(u, targetType)
+		case float32, float64:
+			return convertFloatToUint(reflect.ValueOf(v).Float(), targetType)
+		case string:
+			u, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("cannot convert %v to uint: %w", value, err)
+			}
+			return convertUint(u, targetType)
+		default:
+			return 0, fmt.Errorf("cannot convert %v to uint", value)
+		}
+
 	case reflect.Float32, reflect.Float64:
 		// Try to convert to float
 		switch v := value.(type) {
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-			intVal := reflect.ValueOf(v).Int()
-			return reflect.ValueOf(float64(intVal)).Convert(targetType).Interface(), nil
-		case float32, float64:
-			floatVal := reflect.ValueOf(v).Float()
-			return reflect.ValueOf(floatVal).Convert(targetType).Interface(), nil
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			f, _ := toFloat(v)
+			return reflect.ValueOf(f).Convert(targetType).Interface(), nil
+		case json.Number:
+			f, err := v.Float64()
+			if err != nil {
+				return 0.0, fmt.Errorf("cannot convert %v to float: %w", value, err)
+			}
+			return reflect.ValueOf(f).Convert(targetType).Interface(), nil
 		case string:
 			f, err := strconv.ParseFloat(v, 64)
 			if err != nil {
@@ -490,7 +910,7 @@ func convertToType(value any, targetType reflect.Type) (any, error) {
 				if err != nil {
 					return nil, fmt.Errorf("cannot convert slice element %d: %w", i, err)
 				}
-				sliceValue.Index(i).Set(reflect.ValueOf(convertedElem))
+				sliceValue.Index(i).Set(valueOf(convertedElem, elemType))
 			}
 
 			return sliceValue.Interface(), nil
@@ -511,7 +931,7 @@ func convertToType(value any, targetType reflect.Type) (any, error) {
 					if err != nil {
 						return nil, fmt.Errorf("cannot convert map element %s: %w", key, err)
 					}
-					mapValue.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(convertedElem))
+					mapValue.SetMapIndex(reflect.ValueOf(key).Convert(targetType.Key()), valueOf(convertedElem, elemType))
 				}
 
 				return mapValue.Interface(), nil
@@ -524,3 +944,49 @@ func convertToType(value any, targetType reflect.Type) (any, error) {
 	// If we couldn't convert, return an error
 	return nil, fmt.Errorf("cannot convert %v (type %T) to %v", value, value, targetType)
 }
+
+// valueOf returns v as a reflect.Value, the zero value of t when v is nil.
+func valueOf(v any, t reflect.Type) reflect.Value {
+	if v == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(v)
+}
+
+// convertInt converts i to the integer type t, failing when it overflows t.
+func convertInt(i int64, t reflect.Type) (any, error) {
+	v := reflect.New(t).Elem()
+	if v.OverflowInt(i) {
+		return nil, fmt.Errorf("cannot convert %d to %v: out of range", i, t)
+	}
+	v.SetInt(i)
+	return v.Interface(), nil
+}
+
+// convertUint converts u to the unsigned integer type t, failing when it overflows t.
+func convertUint(u uint64, t reflect.Type) (any, error) {
+	v := reflect.New(t).Elem()
+	if v.OverflowUint(u) {
+		return nil, fmt.Errorf("cannot convert %d to %v: out of range", u, t)
+	}
+	v.SetUint(u)
+	return v.Interface(), nil
+}
+
+// convertFloatToInt truncates f to the integer type t, failing for NaN, infinities
+// and values out of the range of int64.
+func convertFloatToInt(f float64, t reflect.Type) (any, error) {
+	if math.IsNaN(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return nil, fmt.Errorf("cannot convert %v to %v: out of range", f, t)
+	}
+	return convertInt(int64(f), t)
+}
+
+// convertFloatToUint truncates f to the unsigned integer type t, failing for NaN,
+// infinities and negative values.
+func convertFloatToUint(f float64, t reflect.Type) (any, error) {
+	if math.IsNaN(f) || f < 0 || f >= math.MaxUint64 {
+		return nil, fmt.Errorf("cannot convert %v to %v: out of range", f, t)
+	}
+	return convertUint(uint64(f), t)
+}
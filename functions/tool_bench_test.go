@@ -0,0 +1,116 @@
+package functions
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// OptBenchString mimics the OptString type generated by ogen.
+type OptBenchString struct {
+	Value string
+	Set   bool
+}
+
+func (o OptBenchString) IsSet() bool { return o.Set }
+
+func (o OptBenchString) Get() (string, bool) { return o.Value, o.Set }
+
+type benchTag struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name" mcpdescription:"Name of the tag"`
+}
+
+type benchParams struct {
+	PetID  int64          `json:"petId" mcprequired:"true"`
+	Status OptBenchString `json:"status"`
+	Limit  *int           `json:"limit,omitempty"`
+}
+
+type benchBody struct {
+	Name      string            `json:"name" mcprequired:"true"`
+	Tags      []benchTag        `json:"tags"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	BirthDate time.Time         `json:"birthDate"`
+	Owner     *benchTag         `json:"owner,omitempty"`
+}
+
+// benchFunction has the shape of the functions of the generated tools.
+func benchFunction(ctx context.Context, input struct {
+	RequestParameter benchParams `json:"requestParameter"`
+	RequestBody      *benchBody  `json:"requestBody"`
+}) (any, error) {
+	return input.RequestParameter.PetID, nil
+}
+
+// benchArguments is a small input with a few nested values.
+var benchArguments = map[string]any{
+	"requestParameter": map[string]any{"petId": 42, "status": "available", "limit": 10},
+	"requestBody": map[string]any{
+		"name":      "tama",
+		"tags":      []any{map[string]any{"id": 1, "name": "cat"}, map[string]any{"id": 2, "name": "white"}},
+		"labels":    map[string]any{"color": "white"},
+		"birthDate": "2020-01-02T03:04:05Z",
+		"owner":     map[string]any{"id": 3, "name": "hanako"},
+	},
+}
+
+// benchLargeArguments is a large input with many nested values.
+var benchLargeArguments = func() map[string]any {
+	tags := make([]any, 0, 1000)
+	labels := make(map[string]any, 100)
+	for i := range 1000 {
+		tags = append(tags, map[string]any{"id": i, "name": "tag-" + strconv.Itoa(i)})
+	}
+	for i := range 100 {
+		labels["label-"+strconv.Itoa(i)] = "value-" + strconv.Itoa(i)
+	}
+	return map[string]any{
+		"requestParameter": map[string]any{"petId": 42, "status": "available", "limit": 1000},
+		"requestBody": map[string]any{
+			"name":      "tama",
+			"tags":      tags,
+			"labels":    labels,
+			"birthDate": "2020-01-02T03:04:05Z",
+			"owner":     map[string]any{"id": 3, "name": "hanako"},
+		},
+	}
+}()
+
+func BenchmarkNewFunctionTool(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		NewFunctionTool("updatePet", "Update a pet", benchFunction)
+	}
+}
+
+func BenchmarkGetTypeSchema(b *testing.B) {
+	t := reflect.TypeOf(benchBody{})
+	b.ReportAllocs()
+	for b.Loop() {
+		getTypeSchema(t)
+	}
+}
+
+func BenchmarkExecute(b *testing.B) {
+	tool := NewFunctionTool("updatePet", "Update a pet", benchFunction)
+	ctx := context.Background()
+	for _, bb := range []struct {
+		name   string
+		params map[string]any
+	}{
+		{name: "small", params: benchArguments},
+		{name: "large", params: benchLargeArguments},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := tool.Execute(ctx, bb.params); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
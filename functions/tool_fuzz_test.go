@@ -0,0 +1,176 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type fuzzEnum string
+
+type fuzzRecursive struct {
+	Name     string           `json:"name"`
+	Children []*fuzzRecursive `json:"children"`
+	Parent   *fuzzRecursive   `json:"parent"`
+}
+
+type fuzzTags struct {
+	Empty   string `json:",omitempty"`
+	Skipped string `json:"-"`
+	Dash    string `json:"-,"`
+	unused  string
+}
+
+// fuzzTypes are the target types of the fuzzed values.
+var fuzzTypes = []reflect.Type{
+	reflect.TypeFor[string](),
+	reflect.TypeFor[bool](),
+	reflect.TypeFor[int](),
+	reflect.TypeFor[int8](),
+	reflect.TypeFor[int64](),
+	reflect.TypeFor[uint](),
+	reflect.TypeFor[uint8](),
+	reflect.TypeFor[uint64](),
+	reflect.TypeFor[float32](),
+	reflect.TypeFor[float64](),
+	reflect.TypeFor[fuzzEnum](),
+	reflect.TypeFor[*int](),
+	reflect.TypeFor[[]int](),
+	reflect.TypeFor[[2]string](),
+	reflect.TypeFor[map[string]any](),
+	reflect.TypeFor[map[string]int](),
+	reflect.TypeFor[any](),
+	reflect.TypeFor[time.Time](),
+	reflect.TypeFor[time.Duration](),
+	reflect.TypeFor[json.Number](),
+	reflect.TypeFor[json.RawMessage](),
+	reflect.TypeFor[OptBenchString](),
+	reflect.TypeFor[benchBody](),
+	reflect.TypeFor[*benchBody](),
+	reflect.TypeFor[fuzzRecursive](),
+	reflect.TypeFor[fuzzTags](),
+}
+
+// fuzzDecode decodes the JSON value the way the tool arguments are decoded,
+// with and without json.Number.
+func fuzzDecode(data []byte) []any {
+	var values []any
+	for _, useNumber := range []bool{true, false} {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		if useNumber {
+			decoder.UseNumber()
+		}
+		var v any
+		if decoder.Decode(&v) == nil {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func FuzzConvertToType(f *testing.F) {
+	for _, seed := range []string{
+		`300`, `-129`, `-1`, `18446744073709551616`, `9223372036854775808`, `1e400`, `-1e400`, `1.5`, `1e-400`,
+		`"NaN"`, `"+Inf"`, `"-Inf"`, `"300"`, `"0x10"`, `"true"`, `"2020-01-02T03:04:05Z"`, `"1h"`,
+		`null`, `[null]`, `[1, "2", null, {}]`, `{"Value": null, "Set": "yes"}`, `{"a": {"b": {"c": []}}}`,
+		`{"name": 1, "children": [null, {"children": [{"parent": {}}]}]}`,
+		`{"tags": [{"id": "1"}, null], "owner": null, "birthDate": 0}`,
+		`[[[[[[[[[[[[[[[[[[[[1]]]]]]]]]]]]]]]]]]]]`,
+	} {
+		for i := range fuzzTypes {
+			f.Add([]byte(seed), uint8(i))
+		}
+	}
+	f.Fuzz(func(t *testing.T, data []byte, typeIndex uint8) {
+		targetType := fuzzTypes[int(typeIndex)%len(fuzzTypes)]
+		for _, value := range fuzzDecode(data) {
+			// Invalid values fail with an error instead of panicking
+			converted, err := convertToType(value, targetType)
+			if err == nil && converted != nil && !reflect.TypeOf(converted).AssignableTo(targetType) &&
+				!reflect.TypeOf(converted).ConvertibleTo(targetType) {
+				t.Errorf("convertToType(%v, %v) = %T", value, targetType, converted)
+			}
+			_, _ = decodeValue(value, targetType)
+		}
+	})
+}
+
+// fuzzFieldTypes are the types of the fields of the fuzzed struct types.
+var fuzzFieldTypes = []reflect.Type{
+	reflect.TypeFor[string](),
+	reflect.TypeFor[int](),
+	reflect.TypeFor[*uint8](),
+	reflect.TypeFor[[]float64](),
+	reflect.TypeFor[map[string]fuzzEnum](),
+	reflect.TypeFor[any](),
+	reflect.TypeFor[OptBenchString](),
+	reflect.TypeFor[*benchTag](),
+	reflect.TypeFor[[]fuzzRecursive](),
+	reflect.TypeFor[fuzzTags](),
+	reflect.TypeFor[time.Time](),
+}
+
+func FuzzGetTypeSchema(f *testing.F) {
+	for _, seed := range []struct {
+		tags  string
+		types []byte
+		args  string
+	}{
+		{tags: `json:"id"`, types: []byte{1}, args: `{"id": 1}`},
+		{tags: `json:"a,omitempty" mcprequired:"true"` + "\n" + `json:"a"`, types: []byte{0, 1}, args: `{"a": "x"}`},
+		{tags: `json:",omitempty"` + "\n" + `json:"-"` + "\n" + `json:"-,"`, types: []byte{2, 3, 4}, args: `{"F0": 300, "-": [1e400]}`},
+		{tags: `mcpdescription:"x" mcpenum:"a,b"` + "\n" + `json:"opt"`, types: []byte{5, 6}, args: `{"F0": null, "opt": {"Value": 1}}`},
+		{tags: `json:"tag"` + "\n" + `json:"tree"` + "\n" + `json:"tags"`, types: []byte{7, 8, 9}, args: `{"tree": [{"children": [null]}], "tag": {"id": "x"}}`},
+		{tags: "json:\"\x00\xff\"\n`", types: []byte{10, 10}, args: `[]`},
+	} {
+		f.Add(seed.tags, seed.types, []byte(seed.args))
+	}
+	f.Fuzz(func(t *testing.T, tags string, types []byte, args []byte) {
+		if len(types) > 16 {
+			types = types[:16]
+		}
+		tagList := bytes.Split([]byte(tags), []byte("\n"))
+		fields := make([]reflect.StructField, 0, len(types))
+		for i, typeIndex := range types {
+			field := reflect.StructField{
+				Name: "F" + strconv.Itoa(i),
+				Type: fuzzFieldTypes[int(typeIndex)%len(fuzzFieldTypes)],
+			}
+			if i < len(tagList) {
+				field.Tag = reflect.StructTag(tagList[i])
+			}
+			fields = append(fields, field)
+		}
+		structType := reflect.StructOf(fields)
+		getTypeSchema(structType)
+		getTypeSchema(reflect.PointerTo(structType))
+		getTypeSchema(reflect.SliceOf(structType))
+
+		// Build a tool function taking the struct and call it with the fuzzed arguments
+		fnType := reflect.FuncOf(
+			[]reflect.Type{reflect.TypeFor[context.Context](), structType},
+			[]reflect.Type{reflect.TypeFor[any](), reflect.TypeFor[error]()},
+			false,
+		)
+		fn := reflect.MakeFunc(fnType, func(in []reflect.Value) []reflect.Value {
+			return []reflect.Value{reflect.Zero(reflect.TypeFor[any]()), reflect.Zero(reflect.TypeFor[error]())}
+		})
+		tool := NewFunctionTool("fuzz", "Fuzzed struct", fn.Interface())
+		for _, value := range fuzzDecode(args) {
+			params, _ := value.(map[string]any)
+			if params == nil {
+				params = map[string]any{"value": value}
+			}
+			// Invalid arguments fail with an error, but must not panic
+			var panicErr *PanicError
+			if _, err := tool.Execute(context.Background(), params); errors.As(err, &panicErr) {
+				t.Fatalf("Execute(%v) panicked: %v\n%s", params, err, panicErr.Stack)
+			}
+		}
+	})
+}
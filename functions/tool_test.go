@@ -0,0 +1,161 @@
+package functions
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestPointerStructParameter(t *testing.T) {
+	type input struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	tool := NewFunctionTool("pointer", "Pointer to struct", func(ctx context.Context, in *input) (any, error) {
+		return in, nil
+	})
+
+	// The schema describes the struct the pointer refers to
+	wantProperties := map[string]any{
+		"name":  map[string]any{"type": "string"},
+		"count": map[string]any{"type": "integer"},
+	}
+	if !reflect.DeepEqual(tool.schema.Properties, wantProperties) {
+		t.Errorf("schema properties = %v, want %v", tool.schema.Properties, wantProperties)
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   input
+	}{
+		{name: "arguments", params: map[string]any{"name": "tama", "count": 2}, want: input{Name: "tama", Count: 2}},
+		{name: "no arguments", params: map[string]any{}, want: input{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tool.Execute(context.Background(), tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, ok := res.(*input)
+			if !ok || got == nil {
+				t.Fatalf("Execute() = %#v, want a non-nil *input", res)
+			}
+			if *got != tt.want {
+				t.Errorf("Execute() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUntypedParametersReceiveFloat64(t *testing.T) {
+	type input struct {
+		ID    int64          `json:"id"`
+		Value any            `json:"value"`
+		Extra map[string]any `json:"extra"`
+		List  []any          `json:"list"`
+	}
+	var gotMap map[string]any
+	var gotStruct input
+	mapTool := NewFunctionTool("map", "Untyped arguments", func(ctx context.Context, params map[string]any) (any, error) {
+		gotMap = params
+		return nil, nil
+	})
+	structTool := NewFunctionTool("struct", "Typed arguments", func(ctx context.Context, in input) (any, error) {
+		gotStruct = in
+		return nil, nil
+	})
+
+	var req mcp.CallToolRequest
+	req.Params.Arguments = json.RawMessage(`{"id": 9007199254740993, "value": 1.5, "extra": {"n": 2}, "list": [3]}`)
+	for _, tool := range []*Tool{mapTool, structTool} {
+		if _, err := tool.ServerTool().Handler(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Handlers taking untyped values get float64 as with encoding/json
+	wantMap := map[string]any{"id": 9007199254740993.0, "value": 1.5, "extra": map[string]any{"n": 2.0}, "list": []any{3.0}}
+	if !reflect.DeepEqual(gotMap, wantMap) {
+		t.Errorf("map parameter = %#v, want %#v", gotMap, wantMap)
+	}
+	// Typed fields keep the exact value of large integers
+	wantStruct := input{ID: 9007199254740993, Value: 1.5, Extra: map[string]any{"n": 2.0}, List: []any{3.0}}
+	if !reflect.DeepEqual(gotStruct, wantStruct) {
+		t.Errorf("struct parameter = %#v, want %#v", gotStruct, wantStruct)
+	}
+}
+
+func TestPropertyDefaults(t *testing.T) {
+	type params struct {
+		Limit int    `json:"limit"`
+		Sort  string `json:"sort"`
+	}
+	type input struct {
+		RequestParameter params `json:"requestParameter" mcprequired:"false"`
+	}
+	handler := func(ctx context.Context, in input) (any, error) {
+		return in.RequestParameter, nil
+	}
+	tool := NewFunctionTool("list", "List", handler).WithPropertyDefaults(map[string]any{
+		"requestParameter": map[string]any{"limit": 10},
+	})
+
+	// The advertised schema declares the default of the nested property
+	var req mcp.CallToolRequest
+	st := tool.ServerTool()
+	buf, err := json.Marshal(st.Tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var listed struct {
+		InputSchema struct {
+			Properties map[string]struct {
+				Properties map[string]struct {
+					Default any `json:"default"`
+				} `json:"properties"`
+			} `json:"properties"`
+		} `json:"inputSchema"`
+	}
+	if err := json.Unmarshal(buf, &listed); err != nil {
+		t.Fatal(err)
+	}
+	if got := listed.InputSchema.Properties["requestParameter"].Properties["limit"].Default; got != 10.0 {
+		t.Errorf("default of limit = %v, want 10 in %s", got, buf)
+	}
+
+	tests := []struct {
+		name string
+		args map[string]any
+		want params
+	}{
+		{name: "absent object", args: map[string]any{}, want: params{Limit: 10}},
+		{name: "absent property", args: map[string]any{"requestParameter": map[string]any{"sort": "name"}}, want: params{Limit: 10, Sort: "name"}},
+		{name: "given property", args: map[string]any{"requestParameter": map[string]any{"limit": 3}}, want: params{Limit: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tool.Execute(context.Background(), tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != tt.want {
+				t.Errorf("Execute(%v) = %+v, want %+v", tt.args, res, tt.want)
+			}
+		})
+	}
+
+	// The schema shared with other tools of the same function is kept intact
+	req.Params.Arguments = map[string]any{}
+	res, err := NewFunctionTool("list", "List", handler).ServerTool().Handler(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; text != `{"limit":0,"sort":""}` {
+		t.Errorf("tool without defaults returned %s", text)
+	}
+}
@@ -0,0 +1,92 @@
+package functions
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = modulePath + "/functions"
+
+// Attribute keys describing the tool call on the spans.
+const (
+	AttributeToolName      = attribute.Key("mcp.tool.name")
+	AttributeSessionID     = attribute.Key("mcp.session.id")
+	AttributeOperationID   = attribute.Key("oas.operation.id")
+	AttributeOperationTags = attribute.Key("oas.operation.tags")
+	AttributeRequestID     = attribute.Key("mcp.request.id")
+)
+
+type toolKey struct{}
+
+func withTool(ctx context.Context, tool *Tool) context.Context {
+	return context.WithValue(ctx, toolKey{}, tool)
+}
+
+// ToolAttributes returns the attributes of the tool call in ctx: the tool name,
+// the id and tags of the operation, the id of the MCP session and the request id.
+// It returns nil outside a tool call.
+func ToolAttributes(ctx context.Context) []attribute.KeyValue {
+	tool, ok := ctx.Value(toolKey{}).(*Tool)
+	if !ok {
+		return nil
+	}
+	attrs := []attribute.KeyValue{AttributeToolName.String(tool.name)}
+	if tool.operationID != "" {
+		attrs = append(attrs, AttributeOperationID.String(tool.operationID))
+	}
+	if len(tool.tags) > 0 {
+		attrs = append(attrs, AttributeOperationTags.StringSlice(tool.tags))
+	}
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		attrs = append(attrs, AttributeSessionID.String(session.SessionID()))
+	}
+	if id := RequestIDFromContext(ctx); id != "" {
+		attrs = append(attrs, AttributeRequestID.String(id))
+	}
+	return attrs
+}
+
+// Trace returns a middleware recording a span named "tools/call <tool>" for every
+// call of the tool, with the attributes of ToolAttributes.
+// The global tracer provider is used when tp is nil.
+func Trace(tp trace.TracerProvider) Middleware {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(tracerName)
+	return func(tool MCPTool, next ExecuteFunc) ExecuteFunc {
+		return func(ctx context.Context, params map[string]any) (any, error) {
+			ctx, span := tracer.Start(ctx, "tools/call "+tool.Name(),
+				trace.WithSpanKind(trace.SpanKindInternal),
+				trace.WithAttributes(ToolAttributes(ctx)...),
+			)
+			defer span.End()
+			res, err := next(ctx, params)
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			return res, err
+		}
+	}
+}
+
+// TraceRequests returns a RequestEditor adding the attributes of ToolAttributes
+// to the span of the request, i.e. the client span of the ogen client, so that
+// the traces of the API can be filtered by tool rather than by HTTP route.
+func TraceRequests() RequestEditor {
+	return func(ctx context.Context, req *http.Request) error {
+		span := trace.SpanFromContext(ctx)
+		if !span.IsRecording() {
+			return nil
+		}
+		span.SetAttributes(ToolAttributes(ctx)...)
+		return nil
+	}
+}
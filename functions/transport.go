@@ -0,0 +1,164 @@
+package functions
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// HTTP2Mode selects the HTTP versions used for the upstream API.
+type HTTP2Mode int
+
+const (
+	// HTTP2Auto negotiates HTTP/2 over TLS and falls back to HTTP/1.1.
+	HTTP2Auto HTTP2Mode = iota
+	// HTTP2Force uses only HTTP/2, including unencrypted HTTP/2 (h2c) for http URLs.
+	HTTP2Force
+	// HTTP2Disable uses only HTTP/1.1.
+	HTTP2Disable
+)
+
+// TransportOptions tunes the connection pool of the HTTP client sending the
+// requests to the upstream API. Zero values keep the settings of http.DefaultTransport.
+type TransportOptions struct {
+	// MaxIdleConns limits the idle connections across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the idle connections kept per host.
+	// http.DefaultTransport keeps only 2, which bottlenecks concurrent tool calls.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections per host, including those in use.
+	MaxConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer than this.
+	IdleConnTimeout time.Duration
+	// TLSHandshakeTimeout limits the time spent on the TLS handshake.
+	TLSHandshakeTimeout time.Duration
+	// HTTP2 forces or disables HTTP/2.
+	HTTP2 HTTP2Mode
+	// KeepAlive is the interval of the TCP keep-alive probes. A negative value disables them.
+	KeepAlive time.Duration
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+}
+
+// NewTransport returns a clone of http.DefaultTransport with the options applied.
+func NewTransport(opts TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.MaxIdleConns > 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = opts.TLSHandshakeTimeout
+	}
+	switch opts.HTTP2 {
+	case HTTP2Force:
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	case HTTP2Disable:
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	}
+	if opts.KeepAlive != 0 {
+		// Same as the dialer of http.DefaultTransport except for the keep-alive
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: opts.KeepAlive,
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	return transport
+}
+
+// NewHTTPClient returns a HTTP client using NewTransport.
+func NewHTTPClient(opts TransportOptions) *http.Client {
+	return &http.Client{Transport: NewTransport(opts)}
+}
+
+// ConnMetrics counts the connections used for the requests to the upstream API,
+// e.g. to check that connections are reused under load.
+type ConnMetrics struct {
+	requests atomic.Int64
+	reused   atomic.Int64
+	idle     atomic.Int64
+}
+
+// ConnStats is a snapshot of ConnMetrics.
+type ConnStats struct {
+	// Requests is the number of requests that got a connection.
+	Requests int64
+	// Reused is the number of requests sent on a previously used connection.
+	Reused int64
+	// Idle is the number of requests sent on a connection taken from the idle pool.
+	Idle int64
+}
+
+// Stats returns the counts so far.
+func (m *ConnMetrics) Stats() ConnStats {
+	return ConnStats{
+		Requests: m.requests.Load(),
+		Reused:   m.reused.Load(),
+		Idle:     m.idle.Load(),
+	}
+}
+
+// TraceConnections returns a HTTPDoer recording the connection of every request to metrics.
+func TraceConnections(doer HTTPDoer, metrics *ConnMetrics) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &tracingDoer{doer: doer, metrics: metrics}
+}
+
+type tracingDoer struct {
+	doer    HTTPDoer
+	metrics *ConnMetrics
+}
+
+func (d *tracingDoer) Do(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			d.metrics.requests.Add(1)
+			if info.Reused {
+				d.metrics.reused.Add(1)
+			}
+			if info.WasIdle {
+				d.metrics.idle.Add(1)
+			}
+		},
+	}
+	return d.doer.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// HandlerDoer returns a HTTPDoer serving the requests with handler in process instead
+// of sending them, e.g. to answer with a mock of the upstream API.
+func HandlerDoer(handler http.Handler) HTTPDoer {
+	return handlerDoer{handler: handler}
+}
+
+type handlerDoer struct {
+	handler http.Handler
+}
+
+func (d handlerDoer) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	rec := httptest.NewRecorder()
+	d.handler.ServeHTTP(rec, req)
+	res := rec.Result()
+	res.Request = req
+	return res, nil
+}
@@ -20,23 +20,66 @@ type Tool struct {
 	description string
 	function    any
 	schema      *Schema
+	validate    bool
+	// parameterNames binds the function parameters to top-level argument names
+	parameterNames []string
+	middlewares    []Middleware
+	// innerMiddlewares run inside the middlewares of the registry
+	innerMiddlewares []Middleware
+	outputSchema     *Schema
+	tags             []string
+	operationID      string
+	timeFields       []string
+	outputFormat     OutputFormat
+	processors       []ResponseProcessor
+	annotation       *mcp.ToolAnnotation
+	examples         []map[string]any
+	limiter          Limiter
+	limitPolicy      LimitPolicy
+	observers        []Observer
+	defaultsPolicy   DefaultsPolicy
+	// propertyDefaults are the defaults set on the input schema with WithPropertyDefaults
+	propertyDefaults map[string]any
+	// registry is the registry the tool is added to, whose middlewares and observers also apply
+	registry *Registry
 }
 
+// Schema is a JSON Schema (2020-12) describing the input or output of a tool.
 type Schema struct {
-	Type       string
-	Properties map[string]any
-	Required   []string
+	Type        string
+	Title       string
+	Description string
+	Format      string
+	Properties  map[string]any
+	Required    []string
+	// Items is the schema of the array elements
+	Items any
+	// AdditionalProperties is a schema or a boolean
+	AdditionalProperties any
+	Enum                 []any
+	Default              any
+	// Ref references a schema, e.g. "#/$defs/User"
+	Ref   string
+	Defs  map[string]any
+	OneOf []any
+	AnyOf []any
+	AllOf []any
+	// Extra holds any other keywords
+	Extra map[string]any
 }
 
 func (s *Schema) MCPTool() mcp.ToolInputSchema {
 	return mcp.ToolInputSchema{
-		Type:       s.Type,
-		Properties: s.Properties,
-		Required:   s.Required,
+		Defs:                 s.Defs,
+		Type:                 s.Type,
+		Properties:           s.Properties,
+		Required:             s.Required,
+		AdditionalProperties: s.AdditionalProperties,
 	}
 }
 
 var (
-	_           MCPTool = (*Tool)(nil)
-	ErrRequired         = errors.New("Required.")
+	_                MCPTool = (*Tool)(nil)
+	ErrRequired              = errors.New("Required.")
+	ErrDuplicateTool         = errors.New("duplicate tool name")
 )
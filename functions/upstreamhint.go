@@ -0,0 +1,331 @@
+package functions
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxErrorBodySize is the maximum size of the error response bodies kept for the hints.
+const maxErrorBodySize = 64 * 1024
+
+// upstreamHint is shown to the model when the upstream API rejects the arguments.
+const upstreamHint = "The upstream API rejected the arguments. Fix the arguments listed in errors according to their message and inputSchema and call the tool again."
+
+// fieldErrorKeys are the members of the error bodies listing the invalid fields,
+// e.g. invalid-params of RFC 9457 problem details.
+var fieldErrorKeys = []string{"invalid-params", "invalid_params", "errors", "violations", "fieldErrors", "validationErrors", "detail"}
+
+// UpstreamFieldError is an invalid field reported by the upstream API, matched
+// against the input schema of the tool.
+type UpstreamFieldError struct {
+	// Field is the field as named by the upstream API
+	Field string `json:"field"`
+	// Argument is the path of the matching argument of the tool, if any
+	Argument string `json:"argument,omitempty"`
+	Message  string `json:"message,omitempty"`
+	// Schema is the schema of the matching argument
+	Schema map[string]any `json:"schema,omitempty"`
+}
+
+// CaptureErrorBodies returns a HTTPDoer keeping the bodies of the 4xx responses, so
+// that the validation errors of the upstream API are reported to the model even when
+// the API client discards them. The response body is left readable.
+func CaptureErrorBodies(doer HTTPDoer) HTTPDoer {
+	if doer == nil {
+		doer = http.DefaultClient
+	}
+	return &errorBodyDoer{doer: doer}
+}
+
+type errorBodyDoer struct {
+	doer HTTPDoer
+}
+
+func (d *errorBodyDoer) Do(req *http.Request) (*http.Response, error) {
+	res, err := d.doer.Do(req)
+	if err != nil || res.StatusCode < 400 || res.StatusCode >= 500 {
+		return res, err
+	}
+	recorder, ok := req.Context().Value(errorBodyKey{}).(*errorBody)
+	if !ok {
+		return res, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxErrorBodySize))
+	if err != nil {
+		return res, nil
+	}
+	recorder.body = body
+	res.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), res.Body), res.Body}
+	return res, nil
+}
+
+type errorBodyKey struct{}
+
+// errorBody records the error response body of the tool call.
+type errorBody struct {
+	body []byte
+}
+
+func withErrorBody(ctx context.Context) (context.Context, *errorBody) {
+	recorder := &errorBody{}
+	return context.WithValue(ctx, errorBodyKey{}, recorder), recorder
+}
+
+// attach sets the recorded body to the UpstreamError wrapped by err.
+func (b *errorBody) attach(err error) error {
+	var upstreamErr *UpstreamError
+	if b.body != nil && errors.As(err, &upstreamErr) && upstreamErr.Body == nil {
+		upstreamErr.Body = b.body
+	}
+	return err
+}
+
+// isUpstreamValidation reports whether err is a rejection of the arguments by the upstream API.
+func isUpstreamValidation(err error) bool {
+	var upstreamErr *UpstreamError
+	return errors.As(err, &upstreamErr) &&
+		(upstreamErr.StatusCode == http.StatusBadRequest || upstreamErr.StatusCode == http.StatusUnprocessableEntity)
+}
+
+// upstreamValidationResult returns an error result telling the model which arguments
+// the upstream API rejected, parsed from the error body (e.g. RFC 9457 problem details).
+func (tool *Tool) upstreamValidationResult(err error) *mcp.CallToolResult {
+	var upstreamErr *UpstreamError
+	if !errors.As(err, &upstreamErr) {
+		return mcp.NewToolResultError(err.Error())
+	}
+	body := map[string]any{
+		"error":  fmt.Sprintf("the upstream API rejected the arguments (status %d)", upstreamErr.StatusCode),
+		"status": upstreamErr.StatusCode,
+		"hint":   upstreamHint,
+	}
+	problem, ok := parseProblem(upstreamErr.Body)
+	switch {
+	case ok:
+		if problem.title != "" {
+			body["title"] = problem.title
+		}
+		if problem.detail != "" {
+			body["detail"] = problem.detail
+		}
+		if len(problem.fields) > 0 {
+			tool.matchArguments(problem.fields)
+			body["errors"] = problem.fields
+		}
+	case len(upstreamErr.Body) > 0:
+		body["response"] = truncateString(string(upstreamErr.Body), truncationReserve)
+	default:
+		body["detail"] = upstreamErr.Err.Error()
+	}
+	if tool.schema != nil {
+		body["inputSchema"] = tool.schema
+	}
+	buf, marshalErr := json.Marshal(body)
+	if marshalErr != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultError(string(buf))
+}
+
+type problemDetails struct {
+	title  string
+	detail string
+	fields []UpstreamFieldError
+}
+
+// parseProblem reads the title, detail and invalid fields of a JSON error body.
+func parseProblem(data []byte) (problemDetails, bool) {
+	var problem problemDetails
+	var doc any
+	if len(data) == 0 || json.Unmarshal(data, &doc) != nil {
+		return problem, false
+	}
+	switch v := doc.(type) {
+	case map[string]any:
+		problem.title, _ = v["title"].(string)
+		problem.detail, _ = v["detail"].(string)
+		if message, ok := v["message"].(string); ok && problem.detail == "" {
+			problem.detail = message
+		}
+		for _, key := range fieldErrorKeys {
+			problem.fields = append(problem.fields, upstreamFieldErrors(v[key])...)
+		}
+	case []any:
+		problem.fields = upstreamFieldErrors(v)
+	}
+	return problem, true
+}
+
+// upstreamFieldErrors reads the invalid fields from a list of error objects, e.g.
+// [{"name": "age", "reason": "must be positive"}], or from an object mapping the
+// fields to their messages, e.g. {"age": ["must be positive"]}.
+func upstreamFieldErrors(v any) []UpstreamFieldError {
+	var fields []UpstreamFieldError
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if item, ok := item.(map[string]any); ok {
+				if field := fieldName(item); field != "" {
+					fields = append(fields, UpstreamFieldError{Field: field, Message: firstString(item, "message", "msg", "reason", "detail", "description", "error", "title")})
+				}
+			}
+		}
+	case map[string]any:
+		for field, value := range v {
+			var messages []string
+			switch value := value.(type) {
+			case string:
+				messages = append(messages, value)
+			case []any:
+				for _, message := range value {
+					if message, ok := message.(string); ok {
+						messages = append(messages, message)
+					}
+				}
+			}
+			if len(messages) > 0 {
+				fields = append(fields, UpstreamFieldError{Field: field, Message: strings.Join(messages, "; ")})
+			}
+		}
+		slices.SortFunc(fields, func(a, b UpstreamFieldError) int { return strings.Compare(a.Field, b.Field) })
+	}
+	return fields
+}
+
+// fieldName returns the field an error object refers to.
+func fieldName(item map[string]any) string {
+	if source, ok := item["source"].(map[string]any); ok {
+		// JSON:API errors
+		if field := firstString(source, "pointer", "parameter"); field != "" {
+			return field
+		}
+	}
+	if loc, ok := item["loc"].([]any); ok {
+		// FastAPI errors
+		segments := make([]string, 0, len(loc))
+		for _, segment := range loc {
+			segments = append(segments, fmt.Sprint(segment))
+		}
+		return strings.Join(segments, ".")
+	}
+	return firstString(item, "pointer", "field", "name", "param", "parameter", "path", "property", "propertyPath")
+}
+
+func firstString(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := m[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// fieldSegments splits a field name, a dotted path or a JSON pointer into its
+// segments, dropping the array indexes and the locations of the parameters.
+func fieldSegments(field string) []string {
+	var segments []string
+	if strings.HasPrefix(field, "/") || strings.HasPrefix(field, "#/") {
+		for _, segment := range strings.Split(strings.TrimPrefix(field, "#"), "/") {
+			segments = append(segments, strings.NewReplacer("~1", "/", "~0", "~").Replace(segment))
+		}
+	} else {
+		segments = strings.FieldsFunc(field, func(r rune) bool { return r == '.' || r == '[' || r == ']' })
+	}
+	var result []string
+	for i, segment := range segments {
+		if segment == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(segment); err == nil {
+			continue
+		}
+		if i == 0 && slices.Contains([]string{"body", "query", "path", "header", "cookie"}, segment) && len(segments) > 1 {
+			continue
+		}
+		result = append(result, strings.ToLower(segment))
+	}
+	return result
+}
+
+// schemaArgument is an argument of the input schema of a tool.
+type schemaArgument struct {
+	segments []string
+	path     string
+	schema   map[string]any
+}
+
+// schemaArguments returns the arguments of the input schema, including the nested fields.
+func (tool *Tool) schemaArguments() []schemaArgument {
+	if tool.schema == nil {
+		return nil
+	}
+	var args []schemaArgument
+	var collect func(properties map[string]any, segments []string, path string, depth int)
+	collect = func(properties map[string]any, segments []string, path string, depth int) {
+		for name, prop := range properties {
+			schema, _ := prop.(map[string]any)
+			argPath := name
+			if path != "" {
+				argPath = path + "." + name
+			}
+			argSegments := append(slices.Clone(segments), strings.ToLower(name))
+			args = append(args, schemaArgument{segments: argSegments, path: argPath, schema: schema})
+			if depth >= 8 {
+				continue
+			}
+			// Array items are traversed without a segment
+			for items, ok := schema["items"].(map[string]any); ok; items, ok = items["items"].(map[string]any) {
+				schema = items
+				argPath += "[]"
+			}
+			if nested, ok := schema["properties"].(map[string]any); ok {
+				collect(nested, argSegments, argPath, depth+1)
+			}
+		}
+	}
+	collect(tool.schema.Properties, nil, "", 0)
+	// Shallow arguments are preferred on ambiguous matches
+	slices.SortStableFunc(args, func(a, b schemaArgument) int {
+		if c := len(a.segments) - len(b.segments); c != 0 {
+			return c
+		}
+		return strings.Compare(a.path, b.path)
+	})
+	return args
+}
+
+// matchArguments sets the tool arguments matching the fields reported by the upstream API.
+// A field matches the arguments whose path ends with it, e.g. "/owner/name" matches
+// requestBody.owner.name; the last segment alone is tried when no path matches.
+func (tool *Tool) matchArguments(fields []UpstreamFieldError) {
+	args := tool.schemaArguments()
+	for i := range fields {
+		segments := fieldSegments(fields[i].Field)
+		if len(segments) == 0 {
+			continue
+		}
+		for _, candidate := range [][]string{segments, segments[len(segments)-1:]} {
+			index := slices.IndexFunc(args, func(arg schemaArgument) bool {
+				return len(arg.segments) >= len(candidate) && slices.Equal(arg.segments[len(arg.segments)-len(candidate):], candidate)
+			})
+			if index >= 0 {
+				fields[i].Argument = args[index].path
+				fields[i].Schema = args[index].schema
+				break
+			}
+		}
+	}
+}
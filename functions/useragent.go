@@ -0,0 +1,54 @@
+package functions
+
+import (
+	"context"
+	"net/http"
+	"runtime/debug"
+)
+
+const modulePath = "github.com/nonchan7720/oas-mcp"
+
+type toolNameKey struct{}
+
+// WithToolName returns a context carrying the name of the called tool.
+func WithToolName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, toolNameKey{}, name)
+}
+
+// ToolNameFromContext returns the name of the called tool, or "" outside a tool call.
+func ToolNameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(toolNameKey{}).(string)
+	return name
+}
+
+// DefaultUserAgent returns the product sent as User-Agent by default,
+// e.g. oas-mcp/v1.2.0. The version is read from the build info.
+func DefaultUserAgent() string {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+				break
+			}
+		}
+		if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+	}
+	return "oas-mcp/" + version
+}
+
+// UserAgent returns a RequestEditor setting the User-Agent header to the product
+// followed by the name of the called tool, e.g. oas-mcp/v1.2.0 (GetPet),
+// so that API owners can attribute the traffic to agents and tools.
+func UserAgent(product string) RequestEditor {
+	return func(ctx context.Context, req *http.Request) error {
+		userAgent := product
+		if name := ToolNameFromContext(ctx); name != "" {
+			userAgent += " (" + name + ")"
+		}
+		req.Header.Set("User-Agent", userAgent)
+		return nil
+	}
+}
@@ -0,0 +1,247 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FieldError describes a single parameter that does not satisfy the schema.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	// SchemaPointer is a JSON pointer to the schema of the field within the input schema.
+	SchemaPointer string `json:"schemaPointer,omitempty"`
+}
+
+// ValidationError is returned when the parameters do not satisfy the tool schema.
+// It lists every violating field together with the expected schema.
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+	Schema *Schema      `json:"schema,omitempty"`
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid parameters:")
+	for _, fieldErr := range e.Errors {
+		fmt.Fprintf(&b, "\n- %s: %s", fieldErr.Field, fieldErr.Message)
+		if fieldErr.SchemaPointer != "" {
+			fmt.Fprintf(&b, " (schema: %s)", fieldErr.SchemaPointer)
+		}
+	}
+	if e.Schema != nil {
+		if buf, err := json.Marshal(e.Schema); err == nil {
+			b.WriteString("\nexpected schema: ")
+			b.Write(buf)
+		}
+	}
+	return b.String()
+}
+
+// WithValidation enables validating the parameters against the input schema before Execute.
+func (tool *Tool) WithValidation() *Tool {
+	tool.validate = true
+	return tool
+}
+
+// Validate checks the parameters against the input schema of the tool.
+func (tool *Tool) Validate(params map[string]any) error {
+	if tool.schema == nil {
+		return nil
+	}
+	errs := validateValue("", "#", params, tool.schema.Map())
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ValidationError{
+		Errors: errs,
+		Schema: tool.schema,
+	}
+}
+
+func validateValue(path, pointer string, value any, schema map[string]any) []FieldError {
+	if value == nil {
+		return nil
+	}
+	// Numeric keywords are checked as float64
+	if n, ok := value.(json.Number); ok {
+		if f, err := n.Float64(); err == nil {
+			value = f
+		}
+	}
+	fieldName := path
+	if fieldName == "" {
+		fieldName = "(root)"
+	}
+	invalid := func(format string, args ...any) FieldError {
+		return FieldError{Field: fieldName, Message: fmt.Sprintf(format, args...), SchemaPointer: pointer}
+	}
+
+	var errs []FieldError
+	if typ, ok := schema["type"].(string); ok && !matchesType(value, typ) {
+		return []FieldError{invalid("expected %s but got %T", typ, value)}
+	}
+	if types, ok := schema["type"].([]any); ok && !slices.ContainsFunc(types, func(typ any) bool {
+		s, _ := typ.(string)
+		return matchesType(value, s)
+	}) {
+		return []FieldError{invalid("expected one of %v but got %T", types, value)}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !containsValue(enum, value) {
+		errs = append(errs, invalid("must be one of %v", enum))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if minimum, ok := toFloat(schema["minimum"]); ok && v < minimum {
+			errs = append(errs, invalid("must be >= %v", minimum))
+		}
+		if maximum, ok := toFloat(schema["maximum"]); ok && v > maximum {
+			errs = append(errs, invalid("must be <= %v", maximum))
+		}
+		if minimum, ok := toFloat(schema["exclusiveMinimum"]); ok && v <= minimum {
+			errs = append(errs, invalid("must be > %v", minimum))
+		}
+		if maximum, ok := toFloat(schema["exclusiveMaximum"]); ok && v >= maximum {
+			errs = append(errs, invalid("must be < %v", maximum))
+		}
+		if multipleOf, ok := toFloat(schema["multipleOf"]); ok && multipleOf != 0 && math.Mod(v, multipleOf) != 0 {
+			errs = append(errs, invalid("must be a multiple of %v", multipleOf))
+		}
+
+	case string:
+		length := len([]rune(v))
+		if minLength, ok := toFloat(schema["minLength"]); ok && float64(length) < minLength {
+			errs = append(errs, invalid("length must be >= %v", minLength))
+		}
+		if maxLength, ok := toFloat(schema["maxLength"]); ok && float64(length) > maxLength {
+			errs = append(errs, invalid("length must be <= %v", maxLength))
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(pattern); err == nil && !re.MatchString(v) {
+				errs = append(errs, invalid("must match pattern %s", pattern))
+			}
+		}
+
+	case []any:
+		if minItems, ok := toFloat(schema["minItems"]); ok && float64(len(v)) < minItems {
+			errs = append(errs, invalid("must have at least %v items", minItems))
+		}
+		if maxItems, ok := toFloat(schema["maxItems"]); ok && float64(len(v)) > maxItems {
+			errs = append(errs, invalid("must have at most %v items", maxItems))
+		}
+		if unique, ok := schema["uniqueItems"].(bool); ok && unique {
+			for i := range v {
+				if containsValue(v[:i], v[i]) {
+					errs = append(errs, invalid("items must be unique"))
+					break
+				}
+			}
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				errs = append(errs, validateValue(fmt.Sprintf("%s[%d]", path, i), pointer+"/items", item, items)...)
+			}
+		}
+
+	case map[string]any:
+		if required, ok := schema["required"].([]string); ok {
+			for _, name := range required {
+				if v[name] == nil {
+					errs = append(errs, FieldError{
+						Field:         joinPath(path, name),
+						Message:       "is required",
+						SchemaPointer: pointer + "/properties/" + escapePointer(name),
+					})
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range v {
+			propertyPointer := pointer + "/properties/" + escapePointer(name)
+			propertySchema, ok := properties[name].(map[string]any)
+			if !ok {
+				propertyPointer = pointer + "/additionalProperties"
+				propertySchema, _ = schema["additionalProperties"].(map[string]any)
+			}
+			if propertySchema != nil {
+				errs = append(errs, validateValue(joinPath(path, name), propertyPointer, property, propertySchema)...)
+			}
+		}
+		slices.SortStableFunc(errs, func(a, b FieldError) int {
+			return strings.Compare(a.Field, b.Field)
+		})
+	}
+	return errs
+}
+
+func matchesType(value any, typ string) bool {
+	switch typ {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return true
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+		// Enum values declared in Go may be typed numbers while decoded JSON numbers are float64
+		if a, ok := toFloat(v); ok {
+			if b, ok := toFloat(value); ok && a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(v).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(v).Uint()), true
+	case float32, float64:
+		return reflect.ValueOf(v).Float(), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// escapePointer escapes the name as a JSON pointer reference token.
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
@@ -0,0 +1,180 @@
+package functions
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WebhookResourceURI is the URI of the MCP resource listing the last webhook events.
+const WebhookResourceURI = "oas-mcp://webhooks/events"
+
+// WebhookNotificationMethod is the method of the notifications sent to the clients for
+// every webhook event.
+const WebhookNotificationMethod = "notifications/oas-mcp/webhook"
+
+// DefaultWebhookEvents is the number of events kept by a WebhookReceiver by default.
+const DefaultWebhookEvents = 100
+
+// maxWebhookBody is the maximum size of the webhook requests.
+const maxWebhookBody = 1 << 20
+
+// ErrInvalidSignature is returned by the verifiers when the request is not signed by the sender.
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// WebhookVerifier checks that a webhook request was sent by the upstream API, e.g.
+// from a signature header. The body has already been read from the request.
+type WebhookVerifier func(r *http.Request, body []byte) error
+
+// HMACVerifier returns a verifier checking the HMAC-SHA256 of the body with the secret
+// in the header, encoded in hex or base64 and optionally prefixed with "sha256=",
+// e.g. X-Hub-Signature-256 of GitHub.
+func HMACVerifier(header, secret string) WebhookVerifier {
+	return func(r *http.Request, body []byte) error {
+		signature := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(header)), "sha256=")
+		if signature == "" {
+			return ErrInvalidSignature
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := mac.Sum(nil)
+		if got, err := hex.DecodeString(signature); err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+		if got, err := base64.StdEncoding.DecodeString(signature); err == nil && hmac.Equal(got, expected) {
+			return nil
+		}
+		return ErrInvalidSignature
+	}
+}
+
+// WebhookEvent is a webhook request received from the upstream API.
+type WebhookEvent struct {
+	ID string `json:"id"`
+	// Event is the path of the request below the endpoint, e.g. invoice.paid for
+	// /webhooks/invoice.paid, or the X-Event-Type header.
+	Event      string    `json:"event,omitempty"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	// Payload is the JSON body of the request, or the body as a string.
+	Payload any `json:"payload,omitempty"`
+}
+
+// WebhookReceiver is an HTTP handler accepting the webhooks of the upstream API and
+// forwarding them to the connected MCP sessions, as a WebhookNotificationMethod
+// notification and an update of the WebhookResourceURI resource, so that agents
+// can react to the events of the API.
+type WebhookReceiver struct {
+	srv       *server.MCPServer
+	verify    WebhookVerifier
+	maxEvents int
+	mu        sync.RWMutex
+	events    []WebhookEvent
+}
+
+// NewWebhookReceiver returns a receiver forwarding the events to the sessions of srv,
+// and registers the WebhookResourceURI resource. Requests are not verified unless a
+// verifier is set.
+func NewWebhookReceiver(srv *server.MCPServer) *WebhookReceiver {
+	r := &WebhookReceiver{srv: srv, maxEvents: DefaultWebhookEvents}
+	resource := mcp.NewResource(WebhookResourceURI, "Webhook events",
+		mcp.WithResourceDescription("The last events received from the webhooks of the API, newest last"),
+		mcp.WithMIMEType("application/json"),
+	)
+	srv.AddResource(resource, func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		data, err := json.Marshal(r.Events())
+		if err != nil {
+			return nil, err
+		}
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{URI: WebhookResourceURI, MIMEType: "application/json", Text: string(data)},
+		}, nil
+	})
+	return r
+}
+
+// WithVerifier sets the verifier of the requests. Unverified requests are rejected
+// with 401 Unauthorized.
+func (r *WebhookReceiver) WithVerifier(verify WebhookVerifier) *WebhookReceiver {
+	r.verify = verify
+	return r
+}
+
+// WithMaxEvents sets the number of events kept for the resource.
+func (r *WebhookReceiver) WithMaxEvents(n int) *WebhookReceiver {
+	if n > 0 {
+		r.maxEvents = n
+	}
+	return r
+}
+
+// Events returns the last events, newest last.
+func (r *WebhookReceiver) Events() []WebhookEvent {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]WebhookEvent{}, r.events...)
+}
+
+func (r *WebhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if r.verify != nil {
+		if err := r.verify(req, body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+	event := WebhookEvent{
+		ID:         NewRequestID(),
+		Event:      strings.Trim(req.URL.Path, "/"),
+		ReceivedAt: time.Now().UTC(),
+		Payload:    string(body),
+	}
+	if event.Event == "" {
+		event.Event = req.Header.Get("X-Event-Type")
+	}
+	if json.Valid(body) {
+		event.Payload = json.RawMessage(body)
+	}
+	r.add(event)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// add keeps the event and notifies the sessions.
+func (r *WebhookReceiver) add(event WebhookEvent) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	if len(r.events) > r.maxEvents {
+		r.events = r.events[len(r.events)-r.maxEvents:]
+	}
+	r.mu.Unlock()
+
+	r.srv.SendNotificationToAllClients(WebhookNotificationMethod, map[string]any{
+		"id":         event.ID,
+		"event":      event.Event,
+		"receivedAt": event.ReceivedAt,
+		"payload":    event.Payload,
+	})
+	r.srv.SendNotificationToAllClients(mcp.MethodNotificationResourceUpdated, map[string]any{
+		"uri": WebhookResourceURI,
+	})
+}
@@ -0,0 +1,270 @@
+package functions
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// WebSocketSubprotocol is the subprotocol of the WebSocket transport, accepted when
+// requested by the client.
+const WebSocketSubprotocol = "mcp"
+
+// maxWebSocketMessage is the maximum size of the messages read from the clients.
+const maxWebSocketMessage = 4 << 20
+
+// WebSocketHandler serves the MCP server over WebSocket, for clients behind
+// intermediaries blocking SSE. Each connection is a session exchanging one JSON-RPC
+// message per frame. The connections from browsers are accepted from the same
+// host or from the origins matching the patterns, e.g. "*.example.com".
+func WebSocketHandler(srv *server.MCPServer, originPatterns ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			Subprotocols:   []string{WebSocketSubprotocol},
+			OriginPatterns: originPatterns,
+		})
+		if err != nil {
+			// Accept has written the error response
+			return
+		}
+		defer conn.CloseNow()
+		conn.SetReadLimit(maxWebSocketMessage)
+		if err := serveWebSocket(r.Context(), srv, conn); err != nil {
+			conn.Close(websocket.StatusInternalError, err.Error())
+			return
+		}
+		conn.Close(websocket.StatusNormalClosure, "")
+	})
+}
+
+// serveWebSocket handles the messages of the connection until it is closed.
+func serveWebSocket(ctx context.Context, srv *server.MCPServer, conn *websocket.Conn) error {
+	session := newWebSocketSession(conn)
+	if err := srv.RegisterSession(ctx, session); err != nil {
+		return err
+	}
+	defer srv.UnregisterSession(ctx, session.SessionID())
+	subscriptions := subscriptionsOf(srv)
+	if subscriptions != nil {
+		defer subscriptions.release(session.SessionID())
+	}
+
+	// The pending messages are canceled before waiting for them
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(srv.WithContext(ctx, session))
+	defer cancel()
+
+	go func() {
+		for {
+			select {
+			case notification := <-session.notifications:
+				_ = wsjson.Write(ctx, conn, notification)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	for {
+		_, message, err := conn.Read(ctx)
+		if err != nil {
+			// The client closed the connection
+			return nil
+		}
+		handle := func() {
+			if res := srv.HandleMessage(ctx, message); res != nil {
+				_ = wsjson.Write(ctx, conn, res)
+			}
+		}
+		var base struct {
+			Method string          `json:"method"`
+			ID     json.RawMessage `json:"id"`
+		}
+		valid := json.Unmarshal(message, &base) == nil
+		// Responses to the requests of the server, e.g. roots/list
+		if valid && base.Method == "" && base.ID != nil {
+			session.deliver(message)
+			continue
+		}
+		if subscriptions != nil {
+			var apply func(string)
+			if message, apply = subscriptions.rewrite(message); apply != nil {
+				apply(session.SessionID())
+			}
+		}
+		// Tool calls are handled concurrently so that slow calls do not block the others,
+		// the other messages in order, e.g. initialize before the requests
+		if !valid || base.Method != string(mcp.MethodToolsCall) {
+			handle()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handle()
+		}()
+	}
+}
+
+// webSocketSession is the MCP session of a WebSocket connection.
+type webSocketSession struct {
+	id                 string
+	conn               *websocket.Conn
+	notifications      chan mcp.JSONRPCNotification
+	lastRequestID      atomic.Int64
+	pending            sync.Map // request id -> chan webSocketResponse
+	initialized        atomic.Bool
+	logLevel           atomic.Value
+	clientInfo         atomic.Value
+	clientCapabilities atomic.Value
+}
+
+var (
+	_ server.SessionWithLogging     = (*webSocketSession)(nil)
+	_ server.SessionWithClientInfo  = (*webSocketSession)(nil)
+	_ server.SessionWithRoots       = (*webSocketSession)(nil)
+	_ server.SessionWithSampling    = (*webSocketSession)(nil)
+	_ server.SessionWithElicitation = (*webSocketSession)(nil)
+)
+
+// webSocketResponse is the response of the client to a request of the server.
+type webSocketResponse struct {
+	ID     int64                    `json:"id"`
+	Result json.RawMessage          `json:"result"`
+	Error  *mcp.JSONRPCErrorDetails `json:"error"`
+}
+
+func newWebSocketSession(conn *websocket.Conn) *webSocketSession {
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	return &webSocketSession{
+		id:            "ws-" + hex.EncodeToString(buf[:]),
+		conn:          conn,
+		notifications: make(chan mcp.JSONRPCNotification, 100),
+	}
+}
+
+// request sends a request to the client and decodes the result of its response.
+func (s *webSocketSession) request(ctx context.Context, method mcp.MCPMethod, params, result any) error {
+	id := s.lastRequestID.Add(1)
+	responses := make(chan webSocketResponse, 1)
+	s.pending.Store(id, responses)
+	defer s.pending.Delete(id)
+
+	req := mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(id),
+		Params:  params,
+		Request: mcp.Request{Method: string(method)},
+	}
+	if err := wsjson.Write(ctx, s.conn, req); err != nil {
+		return fmt.Errorf("send %s request: %w", method, err)
+	}
+	select {
+	case res := <-responses:
+		if res.Error != nil {
+			return fmt.Errorf("%s request failed: %s (code %d)", method, res.Error.Message, res.Error.Code)
+		}
+		if err := json.Unmarshal(res.Result, result); err != nil {
+			return fmt.Errorf("decode %s response: %w", method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver passes the response to the pending request with the same id.
+// Responses to unknown requests are dropped.
+func (s *webSocketSession) deliver(message []byte) {
+	var res webSocketResponse
+	if json.Unmarshal(message, &res) != nil {
+		return
+	}
+	if responses, ok := s.pending.Load(res.ID); ok {
+		select {
+		case responses.(chan webSocketResponse) <- res:
+		default:
+			// A duplicated response
+		}
+	}
+}
+
+func (s *webSocketSession) ListRoots(ctx context.Context, request mcp.ListRootsRequest) (*mcp.ListRootsResult, error) {
+	var result mcp.ListRootsResult
+	if err := s.request(ctx, mcp.MethodListRoots, request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *webSocketSession) SessionID() string {
+	return s.id
+}
+
+func (s *webSocketSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+func (s *webSocketSession) Initialize() {
+	s.initialized.Store(true)
+}
+
+func (s *webSocketSession) Initialized() bool {
+	return s.initialized.Load()
+}
+
+func (s *webSocketSession) SetLogLevel(level mcp.LoggingLevel) {
+	s.logLevel.Store(level)
+}
+
+func (s *webSocketSession) GetLogLevel() mcp.LoggingLevel {
+	if level, ok := s.logLevel.Load().(mcp.LoggingLevel); ok {
+		return level
+	}
+	return mcp.LoggingLevelError
+}
+
+func (s *webSocketSession) GetClientInfo() mcp.Implementation {
+	info, _ := s.clientInfo.Load().(mcp.Implementation)
+	return info
+}
+
+func (s *webSocketSession) SetClientInfo(clientInfo mcp.Implementation) {
+	s.clientInfo.Store(clientInfo)
+}
+
+func (s *webSocketSession) GetClientCapabilities() mcp.ClientCapabilities {
+	capabilities, _ := s.clientCapabilities.Load().(mcp.ClientCapabilities)
+	return capabilities
+}
+
+func (s *webSocketSession) SetClientCapabilities(clientCapabilities mcp.ClientCapabilities) {
+	s.clientCapabilities.Store(clientCapabilities)
+}
+
+func (s *webSocketSession) RequestSampling(ctx context.Context, request mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	var result mcp.CreateMessageResult
+	if err := s.request(ctx, mcp.MethodSamplingCreateMessage, request.CreateMessageParams, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *webSocketSession) RequestElicitation(ctx context.Context, request mcp.ElicitationRequest) (*mcp.ElicitationResult, error) {
+	var result mcp.ElicitationResult
+	if err := s.request(ctx, mcp.MethodElicitationCreate, request.Params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}